@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/tapcraft-io/purr/internal/config"
+)
+
+// runConfigCommand implements `purr config <subcommand>` and returns the
+// process exit code
+func runConfigCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: purr config view [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "view":
+		return runConfigView(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown config subcommand %q\n", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: purr config view [flags]")
+		return 2
+	}
+}
+
+// runConfigView prints the effective configuration and where each value
+// came from. It accepts the same overrides as purr itself so the output
+// reflects exactly what a run with those flags would use.
+func runConfigView(args []string) int {
+	fs := flag.NewFlagSet("purr config view", flag.ContinueOnError)
+	configFlags := config.BindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(config.LoadOptions{
+		ConfigFile: configFlags.ConfigFile(),
+		Flags:      configFlags.Overrides(),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config:\n%v\n", err)
+		return 1
+	}
+
+	fmt.Printf("# config file: %s\n", cfg.ConfigFile)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, s := range cfg.Settings() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, s.Value, s.Source)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
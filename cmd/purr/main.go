@@ -6,13 +6,18 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/tapcraft-io/purr/internal/config"
+	"github.com/tapcraft-io/purr/internal/debuglog"
 	"github.com/tapcraft-io/purr/internal/history"
 	"github.com/tapcraft-io/purr/internal/k8s"
 	"github.com/tapcraft-io/purr/internal/kubecomplete"
+	"github.com/tapcraft-io/purr/internal/policy"
+	"github.com/tapcraft-io/purr/internal/redact"
 	"github.com/tapcraft-io/purr/internal/tui"
 )
 
@@ -20,9 +25,22 @@ import (
 var Version = "dev"
 
 func main() {
+	// Subcommands take their own flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			os.Exit(runConfigCommand(os.Args[2:]))
+		case "run":
+			os.Exit(runRunCommand(os.Args[2:]))
+		case "spec":
+			os.Exit(runSpecCommand(os.Args[2:]))
+		}
+	}
+
 	// Parse command-line flags
 	demoMode := flag.Bool("demo", false, "Run in demo mode with mock Kubernetes data (no cluster required)")
 	showVersion := flag.Bool("version", false, "Print version and exit")
+	configFlags := config.BindFlags(flag.CommandLine)
 	flag.Parse()
 
 	if *showVersion {
@@ -42,14 +60,37 @@ func main() {
 	}()
 
 	// Load configuration
-	cfg, err := config.NewConfig()
+	cfg, err := config.Load(config.LoadOptions{
+		ConfigFile: configFlags.ConfigFile(),
+		Flags:      configFlags.Overrides(),
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
 
+	pol, err := policy.Load(cfg.PolicyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading policy: %v\n", err)
+		os.Exit(1)
+	}
+
+	redactor, err := redact.Load(cfg.RedactFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading redaction patterns: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.DebugLog != "" {
+		if err := debuglog.Open(cfg.DebugLog, redactor); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		defer debuglog.Close()
+	}
+
 	var cache k8s.Cache
 	var currentContext string
+	schemaDir := filepath.Join(cfg.ConfigDir, "openapi")
 
 	if *demoMode {
 		// Demo mode: use mock cache
@@ -79,8 +120,11 @@ func main() {
 			currentContext = "unknown"
 		}
 
-		// Initialize resource cache
-		cache = k8s.NewResourceCache(client.Clientset)
+		// Initialize resource cache, keeping OpenAPI schemas between runs
+		rc := k8s.NewResourceCache(client.Clientset, client.Metadata, time.Duration(cfg.CacheTTL)*time.Second)
+		rc.SetSchemaCacheDir(schemaDir)
+		rc.SetMetricsClient(client.Metrics, time.Duration(cfg.MetricsInterval)*time.Second)
+		cache = rc
 
 		// Start cache refresh in background
 		go func() {
@@ -92,24 +136,40 @@ func main() {
 
 	// Initialize history
 	hist, err := history.NewHistory(cfg.HistorySize, cfg.HistoryFile)
+	if err == nil {
+		err = hist.SetRedactor(redactor)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Could not load history: %v\n", err)
 		// Continue without history
+		hist = nil
 	}
 
-	// Load kubectl command specifications (embedded in binary)
-	root, err := kubecomplete.LoadRootSpec()
+	// Load kubectl command specifications (generated, or embedded in binary)
+	root, err := loadSpec(cfg.SpecFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading kubectl commands spec: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Create registry and completer
+	// Create registry and completer, with the kubectl plugins on PATH
 	registry := kubecomplete.NewRegistry(root)
+	registry.AddPlugins(kubecomplete.DiscoverPlugins(os.Getenv("PATH"), kubecomplete.KrewBinDir()))
 	completer := kubecomplete.NewCompleter(registry, cache)
+	if hist != nil {
+		completer.History = hist
+	}
+	completer.Schema = cache
 
 	// Create and run the TUI
-	model := tui.NewModel(cache, hist, currentContext, cfg.KubeconfigPath, completer)
+	model := tui.NewModel(cfg, cache, hist, currentContext, completer).WithPolicy(pol).WithRedactor(redactor)
+	if !*demoMode {
+		resync := time.Duration(cfg.CacheTTL) * time.Second
+		metrics := time.Duration(cfg.MetricsInterval) * time.Second
+		model = model.WithConnector(func(name string) (k8s.Cache, error) {
+			return k8s.Connect(ctx, cfg.KubeconfigPath, name, schemaDir, resync, metrics, 30*time.Second)
+		})
+	}
 
 	p := tea.NewProgram(
 		model,
@@ -119,6 +179,8 @@ func main() {
 
 	// Run the program
 	finalModel, err := p.Run()
+	// Every model shares the forwards; end their kubectl processes with purr
+	model.StopForwards()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
@@ -130,9 +192,9 @@ func main() {
 			_ = hist.Save()
 		}
 
-		// Stop cache
-		if cache != nil {
-			cache.Stop()
+		// Stop cache (the model's, which differs after a context switch)
+		if c := m.Cache(); c != nil {
+			c.Stop()
 		}
 
 		// Check if there was an error
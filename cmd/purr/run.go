@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/tapcraft-io/purr/internal/config"
+	"github.com/tapcraft-io/purr/internal/exec"
+	"github.com/tapcraft-io/purr/internal/history"
+	"github.com/tapcraft-io/purr/internal/k8s"
+	"github.com/tapcraft-io/purr/internal/kubecomplete"
+	"github.com/tapcraft-io/purr/internal/policy"
+	"github.com/tapcraft-io/purr/internal/redact"
+	"github.com/tapcraft-io/purr/internal/shlex"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+const runUsage = `Usage:
+  purr run [flags] -- <command>
+  purr run [flags] -f <script.purr>
+
+Runs kubectl commands without the TUI. Commands are written as at the purr
+prompt: "kubectl" is optional and "!" runs a shell command. Every command is
+checked against the kubectl spec before any runs, and the safety policy
+applies. Scripts hold one command per line; blank lines and lines starting
+with # are skipped, and a trailing \ continues a line. A script stops at the
+first command that fails, and purr exits with that command's exit code.
+
+Flags:
+`
+
+// runStep is a command of a run, ready to execute
+type runStep struct {
+	line    int // in the script, 0 for a command given as arguments
+	command string
+	isShell bool
+}
+
+// runOptions are the settings of a run
+type runOptions struct {
+	yes         bool // run what the policy asks to confirm
+	interactive bool // confirmations can be asked on the terminal
+	stdin       io.Reader
+	answers     *bufio.Reader // confirmations read stdin through one reader, so none read ahead is lost
+	context     string
+	namespace   string
+	cfg         *config.Config
+	policy      *policy.Policy
+	redactor    *redact.Redactor
+	history     *history.History
+}
+
+// runRunCommand implements `purr run` and returns the process exit code
+func runRunCommand(args []string) int {
+	fs := flag.NewFlagSet("purr run", flag.ContinueOnError)
+	script := fs.String("f", "", "Run the commands in a script file, - for stdin")
+	yes := fs.Bool("yes", false, "Run commands the safety policy asks to confirm (deny rules still apply)")
+	fs.BoolVar(yes, "y", false, "Shorthand for --yes")
+	kubeContext := fs.String("context", "", "Kubeconfig context to run against (default: the current one)")
+	namespace := fs.String("namespace", "", "Namespace to run in (default: the context's)")
+	fs.StringVar(namespace, "n", "", "Shorthand for --namespace")
+	configFlags := config.BindFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), runUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if (*script == "") == (fs.NArg() == 0) {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := config.Load(config.LoadOptions{
+		ConfigFile: configFlags.ConfigFile(),
+		Flags:      configFlags.Overrides(),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	pol, err := policy.Load(cfg.PolicyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading policy: %v\n", err)
+		return 1
+	}
+	redactor, err := redact.Load(cfg.RedactFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading redaction patterns: %v\n", err)
+		return 1
+	}
+	root, err := loadSpec(cfg.SpecFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading kubectl commands spec: %v\n", err)
+		return 1
+	}
+	registry := kubecomplete.NewRegistry(root)
+	registry.AddPlugins(kubecomplete.DiscoverPlugins(os.Getenv("PATH"), kubecomplete.KrewBinDir()))
+
+	// Validate everything before anything runs
+	var steps []runStep
+	var errs []error
+	stdin := io.Reader(os.Stdin)
+	if *script != "" {
+		lines, err := readScript(*script)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading script: %v\n", err)
+			return 1
+		}
+		if *script == "-" {
+			stdin = nil
+		}
+		for _, l := range lines {
+			step, err := prepareRunStep(registry, l.text)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s:%d: %w", *script, l.number, err))
+				continue
+			}
+			step.line = l.number
+			steps = append(steps, step)
+		}
+	} else {
+		step, err := prepareRunStep(registry, strings.Join(quoteArgs(fs.Args()), " "))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		steps = append(steps, step)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintln(os.Stderr, errors.Join(errs...))
+		return 2
+	}
+
+	opts := runOptions{
+		yes:         *yes,
+		interactive: stdin != nil && term.IsTerminal(os.Stdin.Fd()),
+		stdin:       stdin,
+		context:     *kubeContext,
+		namespace:   *namespace,
+		cfg:         cfg,
+		policy:      pol,
+		redactor:    redactor,
+	}
+	if opts.interactive {
+		opts.answers = bufio.NewReader(stdin)
+	}
+	if opts.context == "" {
+		opts.context, _ = k8s.GetCurrentContext(cfg.KubeconfigPath)
+	}
+	if opts.namespace == "" {
+		opts.namespace = cfg.DefaultNamespace
+		if cfg.Source("default_namespace") == config.SourceDefault {
+			if ns, err := k8s.ContextNamespace(cfg.KubeconfigPath, opts.context); err == nil {
+				opts.namespace = ns
+			}
+		}
+	}
+
+	hist, err := history.NewHistory(cfg.HistorySize, cfg.HistoryFile)
+	if err == nil {
+		err = hist.SetRedactor(redactor)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not load history: %v\n", err)
+		hist = nil
+	}
+	opts.history = hist
+
+	executor, err := exec.NewExecutor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	executor.SetKubeconfig(cfg.KubeconfigPath)
+	if *kubeContext != "" {
+		executor.SetContext(*kubeContext)
+	}
+	executor.SetNamespace(opts.namespace)
+	executor.SetPlugins(registry.PluginNames())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	code := 0
+	for _, step := range steps {
+		if *script != "" {
+			fmt.Fprintf(os.Stderr, "+ %s\n", redactor.String(step.command))
+		}
+		code = runCommandStep(ctx, executor, step, opts)
+		if code != 0 {
+			if step.line > 0 {
+				fmt.Fprintf(os.Stderr, "purr: %s:%d: exited with code %d\n", *script, step.line, code)
+			}
+			break
+		}
+	}
+
+	if hist != nil {
+		if err := hist.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not save history: %v\n", err)
+		}
+	}
+	return code
+}
+
+// scriptLine is a command of a script and the line it starts on
+type scriptLine struct {
+	number int
+	text   string
+}
+
+// readScript returns the commands of a script, without blank lines and
+// comments, joining lines continued with a trailing backslash
+func readScript(path string) ([]scriptLine, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []scriptLine
+	var pending *scriptLine
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimSpace(scanner.Text())
+		if pending == nil && (text == "" || strings.HasPrefix(text, "#")) {
+			continue
+		}
+		if pending == nil {
+			pending = &scriptLine{number: number}
+		}
+		if cont, ok := strings.CutSuffix(text, `\`); ok {
+			pending.text += strings.TrimSpace(cont) + " "
+			continue
+		}
+		pending.text += text
+		lines = append(lines, *pending)
+		pending = nil
+	}
+	if pending != nil {
+		lines = append(lines, *pending)
+	}
+	return lines, scanner.Err()
+}
+
+// quoteArgs quotes command-line arguments so that joining them gives back
+// the same words, as the command was split by the user's shell already
+func quoteArgs(args []string) []string {
+	if len(args) > 0 && strings.HasPrefix(args[0], "!") {
+		// A shell command is passed on as written
+		return args
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shlex.Quote(arg)
+	}
+	return quoted
+}
+
+// prepareRunStep turns a line as typed at the purr prompt into a command,
+// checking kubectl commands against the spec
+func prepareRunStep(registry *kubecomplete.Registry, raw string) (runStep, error) {
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "!") {
+		shell := strings.TrimSpace(strings.TrimPrefix(trimmed, "!"))
+		if shell == "" {
+			return runStep{}, fmt.Errorf("shell command cannot be empty")
+		}
+		return runStep{command: "!" + shell, isShell: true}, nil
+	}
+
+	args, err := shlex.Fields(trimmed)
+	if err != nil {
+		return runStep{}, fmt.Errorf("invalid command %q: %w", trimmed, err)
+	}
+	if len(args) > 0 && args[0] == "kubectl" {
+		args = args[1:]
+		trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "kubectl"))
+	}
+	if err := registry.Validate(args); err != nil {
+		return runStep{}, fmt.Errorf("%s: %w", trimmed, err)
+	}
+	return runStep{command: "kubectl " + trimmed}, nil
+}
+
+// runCommandStep runs one command once the policy allows it, records it in
+// history and returns its exit code
+func runCommandStep(ctx context.Context, executor *exec.Executor, step runStep, opts runOptions) int {
+	// Shell commands and plugins get no session flags, so kubectl takes
+	// them to the kubeconfig's current context
+	session := policy.Session{Context: opts.context, Namespace: opts.namespace}
+	sessionFlags := !step.isShell && !executor.RunsPlugin(step.command)
+	if !sessionFlags {
+		session.CurrentContext, _ = k8s.GetCurrentContext(opts.cfg.KubeconfigPath)
+		session.CurrentNamespace, _ = k8s.ContextNamespace(opts.cfg.KubeconfigPath, "")
+	}
+	req, d := opts.policy.Check(session, exec.Assess(step.command), sessionFlags, opts.cfg.ConfirmDestructive)
+	switch d.Action {
+	case policy.ActionDeny:
+		fmt.Fprintf(os.Stderr, "purr: blocked: %s%s\n", d.Reason, ruleSuffix(d))
+		return 1
+	case policy.ActionConfirm, policy.ActionTypeNamespace:
+		if !opts.yes && !confirmRun(step.command, d, opts) {
+			return 1
+		}
+	}
+
+	cmd, err := executor.Command(ctx, step.command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "purr: %v\n", err)
+		return 1
+	}
+	// History keeps a snapshot and digest of the output, recorded as it
+	// streams so commands like logs -f don't pile it up in memory
+	var output *history.OutputRecorder
+	cmd.Stdin = opts.stdin
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if opts.history != nil {
+		output = opts.history.NewOutputRecorder()
+		cmd.Stdout = io.MultiWriter(os.Stdout, output)
+		cmd.Stderr = io.MultiWriter(os.Stderr, output)
+	}
+
+	start := time.Now()
+	err = cmd.Run()
+	duration := time.Since(start)
+
+	code := 0
+	var exitErr *osexec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+		if code < 0 {
+			// Killed by a signal
+			code = 1
+		}
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "purr: %v\n", err)
+		code = 1
+	}
+
+	if opts.history != nil {
+		entry := types.HistoryEntry{
+			Command:   step.command,
+			Success:   code == 0,
+			ExitCode:  code,
+			Duration:  duration,
+			Context:   req.Context,
+			Namespace: req.Namespace,
+		}
+		output.Fill(&entry)
+		opts.history.AddEntry(entry)
+	}
+	return code
+}
+
+// confirmRun asks on the terminal whether to run a command the policy
+// wants confirmed. Without a terminal the answer is no.
+func confirmRun(command string, d policy.Decision, opts runOptions) bool {
+	if !opts.interactive {
+		fmt.Fprintf(os.Stderr, "purr: %s needs confirmation (%s%s); pass --yes to run it\n",
+			opts.redactor.String(command), d.Reason, ruleSuffix(d))
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "%s\n  %s\n", d.Reason, opts.redactor.String(command))
+	if d.Action == policy.ActionTypeNamespace {
+		fmt.Fprintf(os.Stderr, "Type %s to continue: ", d.Expect)
+	} else {
+		fmt.Fprint(os.Stderr, "Continue? [y/N] ")
+	}
+	answer, _ := opts.answers.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if d.Action == policy.ActionTypeNamespace {
+		return answer == d.Expect
+	}
+	return strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes")
+}
+
+// ruleSuffix names the policy rule behind a decision, if any
+func ruleSuffix(d policy.Decision) string {
+	if d.Rule == "" {
+		return ""
+	}
+	return fmt.Sprintf(", policy rule %s", d.Rule)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/tapcraft-io/purr/internal/config"
+	"github.com/tapcraft-io/purr/internal/kubecomplete"
+	"github.com/tapcraft-io/purr/internal/specgen"
+)
+
+// runSpecCommand implements `purr spec <subcommand>` and returns the
+// process exit code
+func runSpecCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: purr spec generate [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "generate":
+		return runSpecGenerate(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown spec subcommand %q\n", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: purr spec generate [flags]")
+		return 2
+	}
+}
+
+// runSpecGenerate writes the commands spec of the local kubectl where purr
+// loads it from, in place of the built-in one
+func runSpecGenerate(args []string) int {
+	fs := flag.NewFlagSet("purr spec generate", flag.ContinueOnError)
+	output := fs.String("o", "", "Where to write the spec, - for stdout (default: spec_file)")
+	kubectl := fs.String("kubectl", "kubectl", "The kubectl binary to generate the spec from")
+	configFlags := config.BindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(config.LoadOptions{
+		ConfigFile: configFlags.ConfigFile(),
+		Flags:      configFlags.Overrides(),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config:\n%v\n", err)
+		return 1
+	}
+	path := *output
+	if path == "" {
+		path = cfg.SpecFile
+	}
+
+	bin, err := osexec.LookPath(*kubectl)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	// The built-in spec knows positionals and roles that help output does not
+	base, err := kubecomplete.LoadRootSpec()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading kubectl commands spec: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "Reading commands from %s...\n", bin)
+	root, err := specgen.Generate(ctx, specgen.Kubectl(bin), specgen.Options{Base: base})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+	} else if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing spec: %v\n", err)
+		return 1
+	}
+
+	plugins := 0
+	for _, c := range root.Commands {
+		if c.Plugin {
+			plugins++
+		}
+	}
+	if path != "-" {
+		fmt.Fprintf(os.Stderr, "Wrote the spec of kubectl %s (%d commands, %d plugins) to %s\n",
+			root.KubectlVersion, len(root.Commands)-plugins, plugins, path)
+	}
+	return 0
+}
+
+// loadSpec loads the kubectl commands spec purr generated, or the built-in
+// one. A generated spec of another kubectl release than the installed one
+// is set aside with a warning, so it can't complete or reject flags the
+// installed kubectl does not have.
+func loadSpec(path string) (*kubecomplete.RootSpec, error) {
+	version := ""
+	if _, err := os.Stat(path); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		version, _ = specgen.Version(ctx, specgen.Kubectl("kubectl"))
+		cancel()
+	}
+	root, err := kubecomplete.LoadSpec(path, version)
+	if errors.Is(err, kubecomplete.ErrStaleSpec) {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using the built-in spec\n", err)
+		return kubecomplete.LoadRootSpec()
+	}
+	return root, err
+}
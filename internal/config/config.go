@@ -1,29 +1,90 @@
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
 )
 
 // Config holds the application configuration
 type Config struct {
 	// Preferences
-	DefaultNamespace    string
-	HistorySize         int
-	CacheTTL            int
-	ConfirmDestructive  bool
+	DefaultNamespace   string `yaml:"default_namespace"`
+	HistorySize        int    `yaml:"history_size"`
+	CacheTTL           int    `yaml:"cache_ttl"`        // seconds between full cache resyncs
+	MetricsInterval    int    `yaml:"metrics_interval"` // seconds between metrics-server polls, 0 to not poll
+	ConfirmDestructive bool   `yaml:"confirm_destructive"`
+	DiffBeforeApply    bool   `yaml:"diff_before_apply"` // review apply/patch/replace/edit as a diff first
+	RedactOutput       bool   `yaml:"redact_output"`     // mask secrets in output shown, not only in what is stored
+	RestoreForwards    bool   `yaml:"restore_forwards"`  // start the port-forwards left running last time
 
 	// UI
-	Theme               string
-	ShowHelp            bool
-	CompactMode         bool
+	Theme        string `yaml:"theme"`
+	ShowHelp     bool   `yaml:"show_help"`
+	CompactMode  bool   `yaml:"compact_mode"`
+	ExplainPanel bool   `yaml:"explain_panel"` // document the resource, field or flag being typed beside the input
 
 	// Paths
-	ConfigDir           string
-	HistoryFile         string
+	ConfigDir    string
+	ConfigFile   string
+	HistoryFile  string `yaml:"history_file"`
+	PolicyFile   string `yaml:"policy_file"`
+	RedactFile   string `yaml:"redact_file"`
+	SpecFile     string `yaml:"spec_file"`     // kubectl commands spec written by `purr spec generate`
+	ForwardsFile string `yaml:"forwards_file"` // port-forwards saved for restore_forwards
+	DebugLog     string `yaml:"debug_log"`     // where to write a debug log of completion, empty for none
 
 	// Kubernetes
-	KubeconfigPath      string
+	KubeconfigPath string `yaml:"kubeconfig"`
+
+	// sources records where each setting's effective value came from,
+	// keyed by its config file key
+	sources map[string]string
+}
+
+// Sources a setting can be loaded from, in increasing order of precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// EnvPrefix is prepended to the upper-cased config key to form the
+// environment variable that overrides it (e.g. PURR_HISTORY_SIZE)
+const EnvPrefix = "PURR_"
+
+// DefaultConfigFileName is the name of the config file inside ConfigDir
+const DefaultConfigFileName = "config.yaml"
+
+// Themes lists the supported UI themes
+var Themes = []string{"dark", "light"}
+
+// Setting is a single effective configuration value and its origin
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// LoadOptions controls how Load layers configuration sources
+type LoadOptions struct {
+	// ConfigFile overrides the config file location. When empty, $PURR_CONFIG
+	// is consulted and then ConfigDir/config.yaml. An explicitly requested
+	// file must exist; the default one is optional.
+	ConfigFile string
+
+	// Flags holds command-line overrides keyed by config key
+	Flags map[string]string
 }
 
 // NewConfig creates a new configuration with defaults
@@ -40,21 +101,391 @@ func NewConfig() (*Config, error) {
 		return nil, err
 	}
 
-	kubeconfigPath := os.Getenv("KUBECONFIG")
-	if kubeconfigPath == "" {
-		kubeconfigPath = filepath.Join(homeDir, ".kube", "config")
-	}
-
-	return &Config{
+	cfg := &Config{
 		DefaultNamespace:   "default",
 		HistorySize:        1000,
 		CacheTTL:           30,
+		MetricsInterval:    15,
 		ConfirmDestructive: true,
+		DiffBeforeApply:    false,
+		RedactOutput:       true,
 		Theme:              "dark",
 		ShowHelp:           true,
 		CompactMode:        false,
+		ExplainPanel:       true,
 		ConfigDir:          configDir,
-		HistoryFile:        filepath.Join(configDir, "history.json"),
-		KubeconfigPath:     kubeconfigPath,
-	}, nil
+		ConfigFile:         filepath.Join(configDir, DefaultConfigFileName),
+		HistoryFile:        filepath.Join(configDir, "history.jsonl"),
+		PolicyFile:         filepath.Join(configDir, "policy.yaml"),
+		RedactFile:         filepath.Join(configDir, "redact.yaml"),
+		SpecFile:           filepath.Join(configDir, "kubectl_commands.json"),
+		ForwardsFile:       filepath.Join(configDir, "forwards.json"),
+		KubeconfigPath:     filepath.Join(homeDir, ".kube", "config"),
+		sources:            make(map[string]string),
+	}
+	for _, f := range fields() {
+		cfg.sources[f.key] = SourceDefault
+	}
+
+	// KUBECONFIG is honoured as a default, the same way kubectl does
+	if kubeconfigPath := os.Getenv("KUBECONFIG"); kubeconfigPath != "" {
+		cfg.KubeconfigPath = kubeconfigPath
+		cfg.sources["kubeconfig"] = SourceEnv + " (KUBECONFIG)"
+	}
+
+	return cfg, nil
+}
+
+// Load builds the effective configuration by layering, in order, the
+// defaults, the config file, PURR_* environment variables and command-line
+// flags. The result is validated before it is returned.
+func Load(opts LoadOptions) (*Config, error) {
+	cfg, err := NewConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	path := opts.ConfigFile
+	explicit := path != ""
+	if !explicit {
+		if env := os.Getenv(EnvPrefix + "CONFIG"); env != "" {
+			path = env
+			explicit = true
+		} else {
+			path = cfg.ConfigFile
+		}
+	}
+	cfg.ConfigFile = path
+
+	if err := cfg.loadFile(path); err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			// No config file is fine - defaults apply
+		} else {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	for _, f := range fields() {
+		envName := f.envName()
+		if raw, ok := os.LookupEnv(envName); ok {
+			if err := cfg.set(f, raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", envName, err))
+				continue
+			}
+			cfg.sources[f.key] = SourceEnv + " (" + envName + ")"
+		}
+	}
+
+	for _, f := range fields() {
+		if raw, ok := opts.Flags[f.key]; ok {
+			if err := cfg.set(f, raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.flagName(), err))
+				continue
+			}
+			cfg.sources[f.key] = SourceFlag + " (" + f.flagName() + ")"
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	cfg.HistoryFile = expandHome(cfg.HistoryFile)
+	cfg.PolicyFile = expandHome(cfg.PolicyFile)
+	cfg.RedactFile = expandHome(cfg.RedactFile)
+	cfg.SpecFile = expandHome(cfg.SpecFile)
+	cfg.ForwardsFile = expandHome(cfg.ForwardsFile)
+	cfg.DebugLog = expandHome(cfg.DebugLog)
+	cfg.KubeconfigPath = expandHome(cfg.KubeconfigPath)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile merges settings from a YAML config file into the config
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	raw := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("%s: invalid YAML: %w", path, err)
+	}
+
+	byKey := make(map[string]field)
+	for _, f := range fields() {
+		byKey[f.key] = f
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		f, ok := byKey[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown key %q (valid keys: %s)", path, key, strings.Join(Keys(), ", ")))
+			continue
+		}
+		if err := c.setTyped(f, raw[key]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", path, key, err))
+			continue
+		}
+		c.sources[key] = SourceFile + " (" + path + ")"
+	}
+
+	return errors.Join(errs...)
+}
+
+// expandHome replaces a leading ~/ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
+
+// dnsLabel matches a valid Kubernetes namespace name
+var dnsLabel = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Validate checks the configuration for values purr cannot work with
+func (c *Config) Validate() error {
+	var errs []error
+
+	if len(c.DefaultNamespace) > 63 || !dnsLabel.MatchString(c.DefaultNamespace) {
+		errs = append(errs, c.invalid("default_namespace", "%q is not a valid namespace name", c.DefaultNamespace))
+	}
+	if c.HistorySize <= 0 {
+		errs = append(errs, c.invalid("history_size", "must be greater than 0, got %d", c.HistorySize))
+	}
+	if c.CacheTTL <= 0 {
+		errs = append(errs, c.invalid("cache_ttl", "must be a positive number of seconds, got %d", c.CacheTTL))
+	}
+	if c.MetricsInterval < 0 {
+		errs = append(errs, c.invalid("metrics_interval", "must be 0 or a positive number of seconds, got %d", c.MetricsInterval))
+	}
+	validTheme := false
+	for _, t := range Themes {
+		if c.Theme == t {
+			validTheme = true
+			break
+		}
+	}
+	if !validTheme {
+		errs = append(errs, c.invalid("theme", "must be one of %s, got %q", strings.Join(Themes, ", "), c.Theme))
+	}
+	if c.HistoryFile == "" {
+		errs = append(errs, c.invalid("history_file", "must not be empty"))
+	}
+	if c.PolicyFile == "" {
+		errs = append(errs, c.invalid("policy_file", "must not be empty"))
+	}
+	if c.RedactFile == "" {
+		errs = append(errs, c.invalid("redact_file", "must not be empty"))
+	}
+	if c.SpecFile == "" {
+		errs = append(errs, c.invalid("spec_file", "must not be empty"))
+	}
+	if c.ForwardsFile == "" {
+		errs = append(errs, c.invalid("forwards_file", "must not be empty"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// invalid formats a validation error naming the setting and where it came from
+func (c *Config) invalid(key, format string, args ...interface{}) error {
+	return fmt.Errorf("invalid %s (from %s): %s", key, c.Source(key), fmt.Sprintf(format, args...))
+}
+
+// Source returns where the effective value of a setting came from
+func (c *Config) Source(key string) string {
+	if src, ok := c.sources[key]; ok {
+		return src
+	}
+	return SourceDefault
+}
+
+// Settings returns every configurable setting with its effective value and
+// source, in declaration order
+func (c *Config) Settings() []Setting {
+	v := reflect.ValueOf(c).Elem()
+	settings := make([]Setting, 0, len(fields()))
+	for _, f := range fields() {
+		settings = append(settings, Setting{
+			Key:    f.key,
+			Value:  fmt.Sprint(v.Field(f.index).Interface()),
+			Source: c.Source(f.key),
+		})
+	}
+	return settings
+}
+
+// Keys returns the config file keys of all configurable settings
+func Keys() []string {
+	keys := make([]string, 0, len(fields()))
+	for _, f := range fields() {
+		keys = append(keys, f.key)
+	}
+	return keys
+}
+
+// Flags holds the command-line overrides bound to a flag.FlagSet
+type Flags struct {
+	fs         *flag.FlagSet
+	configFile *string
+	names      map[string]string // flag name -> config key
+}
+
+// BindFlags registers a flag for every configurable setting, plus --config
+// for the config file location
+func BindFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{
+		fs:         fs,
+		configFile: fs.String("config", "", "Path to the purr config file (default ~/.purr/"+DefaultConfigFileName+")"),
+		names:      make(map[string]string),
+	}
+
+	for _, fd := range fields() {
+		name := strings.TrimPrefix(fd.flagName(), "--")
+		usage := fmt.Sprintf("Override %s from the config file", fd.key)
+		switch fd.kind {
+		case reflect.Int:
+			fs.Int(name, 0, usage)
+		case reflect.Bool:
+			fs.Bool(name, false, usage)
+		default:
+			fs.String(name, "", usage)
+		}
+		f.names[name] = fd.key
+	}
+
+	return f
+}
+
+// ConfigFile returns the value of --config
+func (f *Flags) ConfigFile() string {
+	return *f.configFile
+}
+
+// Overrides returns the settings that were explicitly set on the command
+// line, keyed by config key
+func (f *Flags) Overrides() map[string]string {
+	overrides := make(map[string]string)
+	f.fs.Visit(func(fl *flag.Flag) {
+		if key, ok := f.names[fl.Name]; ok {
+			overrides[key] = fl.Value.String()
+		}
+	})
+	return overrides
+}
+
+// field describes a Config field that can be set from the file, env or flags
+type field struct {
+	key   string
+	index int
+	kind  reflect.Kind
+}
+
+func (f field) envName() string {
+	return EnvPrefix + strings.ToUpper(f.key)
+}
+
+func (f field) flagName() string {
+	return "--" + strings.ReplaceAll(f.key, "_", "-")
+}
+
+// fields returns the configurable fields, derived from the yaml struct tags
+func fields() []field {
+	t := reflect.TypeOf(Config{})
+	out := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("yaml")
+		if key == "" {
+			continue
+		}
+		out = append(out, field{key: key, index: i, kind: t.Field(i).Type.Kind()})
+	}
+	return out
+}
+
+// set parses a string value (from env or flags) into the field
+func (c *Config) set(f field, raw string) error {
+	fv := reflect.ValueOf(c).Elem().Field(f.index)
+	switch f.kind {
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", raw)
+		}
+		fv.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", raw)
+		}
+		fv.SetBool(b)
+	default:
+		fv.SetString(raw)
+	}
+	return nil
+}
+
+// setTyped assigns a decoded YAML value to the field, rejecting values of
+// the wrong type rather than coercing them
+func (c *Config) setTyped(f field, value interface{}) error {
+	fv := reflect.ValueOf(c).Elem().Field(f.index)
+	switch f.kind {
+	case reflect.Int:
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("expected an integer, got %s", describeValue(value))
+		}
+		fv.SetInt(int64(n))
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected true or false, got %s", describeValue(value))
+		}
+		fv.SetBool(b)
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a string, got %s", describeValue(value))
+		}
+		fv.SetString(s)
+	}
+	return nil
+}
+
+// describeValue renders a decoded YAML value for error messages
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case float64:
+		return fmt.Sprintf("number %v", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "a map"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
 }
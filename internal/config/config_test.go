@@ -0,0 +1,186 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupHome points the home directory at a temp dir and clears any PURR_*
+// variables so tests start from the defaults
+func setupHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("KUBECONFIG", "")
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, EnvPrefix) {
+			name := strings.SplitN(env, "=", 2)[0]
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+	return home
+}
+
+func writeConfig(t *testing.T, home, content string) string {
+	t.Helper()
+	path := filepath.Join(home, ".purr", DefaultConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	home := setupHome(t)
+
+	cfg, err := Load(LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.DefaultNamespace != "default" {
+		t.Errorf("DefaultNamespace = %s, want default", cfg.DefaultNamespace)
+	}
+	if cfg.HistorySize != 1000 {
+		t.Errorf("HistorySize = %d, want 1000", cfg.HistorySize)
+	}
+	if cfg.KubeconfigPath != filepath.Join(home, ".kube", "config") {
+		t.Errorf("KubeconfigPath = %s", cfg.KubeconfigPath)
+	}
+	for _, s := range cfg.Settings() {
+		if s.Source != SourceDefault {
+			t.Errorf("Source(%s) = %s, want default", s.Key, s.Source)
+		}
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	home := setupHome(t)
+	path := writeConfig(t, home, "theme: light\nhistory_size: 50\ncache_ttl: 60\ncompact_mode: true\n")
+
+	t.Setenv("PURR_HISTORY_SIZE", "75")
+	t.Setenv("PURR_CACHE_TTL", "90")
+
+	cfg, err := Load(LoadOptions{Flags: map[string]string{"cache_ttl": "120"}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		key    string
+		got    interface{}
+		want   interface{}
+		source string
+	}{
+		{"theme", cfg.Theme, "light", SourceFile + " (" + path + ")"},
+		{"compact_mode", cfg.CompactMode, true, SourceFile + " (" + path + ")"},
+		{"history_size", cfg.HistorySize, 75, SourceEnv + " (PURR_HISTORY_SIZE)"},
+		{"cache_ttl", cfg.CacheTTL, 120, SourceFlag + " (--cache-ttl)"},
+		{"default_namespace", cfg.DefaultNamespace, "default", SourceDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.key, tt.got, tt.want)
+			}
+			if src := cfg.Source(tt.key); src != tt.source {
+				t.Errorf("Source(%s) = %q, want %q", tt.key, src, tt.source)
+			}
+		})
+	}
+}
+
+func TestLoad_ExpandsHome(t *testing.T) {
+	home := setupHome(t)
+	writeConfig(t, home, "history_file: ~/hist.json\n")
+
+	cfg, err := Load(LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.HistoryFile != filepath.Join(home, "hist.json") {
+		t.Errorf("HistoryFile = %s, want %s", cfg.HistoryFile, filepath.Join(home, "hist.json"))
+	}
+}
+
+func TestLoad_FileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown key", "histroy_size: 10\n", `unknown key "histroy_size"`},
+		{"wrong type", "history_size: lots\n", `history_size: expected an integer, got string "lots"`},
+		{"wrong bool", "compact_mode: 1\n", "compact_mode: expected true or false, got number 1"},
+		{"invalid yaml", "theme: [dark\n", "invalid YAML"},
+		{"invalid theme", "theme: solarized\n", "invalid theme"},
+		{"invalid namespace", "default_namespace: Not_Valid\n", "invalid default_namespace"},
+		{"zero history", "history_size: 0\n", "invalid history_size"},
+		{"negative metrics interval", "metrics_interval: -5\n", "invalid metrics_interval"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := setupHome(t)
+			writeConfig(t, home, tt.content)
+
+			_, err := Load(LoadOptions{})
+			if err == nil {
+				t.Fatalf("Load() expected error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %q, want it to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_EnvErrors(t *testing.T) {
+	setupHome(t)
+	t.Setenv("PURR_CONFIRM_DESTRUCTIVE", "maybe")
+
+	_, err := Load(LoadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "PURR_CONFIRM_DESTRUCTIVE") {
+		t.Errorf("Load() error = %v, want it to name PURR_CONFIRM_DESTRUCTIVE", err)
+	}
+}
+
+func TestLoad_ExplicitFileMustExist(t *testing.T) {
+	home := setupHome(t)
+
+	_, err := Load(LoadOptions{ConfigFile: filepath.Join(home, "missing.yaml")})
+	if err == nil {
+		t.Error("Load() expected error for missing explicit config file")
+	}
+}
+
+func TestBindFlags_Overrides(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := BindFlags(fs)
+
+	if err := fs.Parse([]string{"--theme", "light", "--compact-mode", "--history-size=10", "--config", "/tmp/x.yaml"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	overrides := flags.Overrides()
+	want := map[string]string{"theme": "light", "compact_mode": "true", "history_size": "10"}
+	if len(overrides) != len(want) {
+		t.Errorf("Overrides() = %v, want %v", overrides, want)
+	}
+	for k, v := range want {
+		if overrides[k] != v {
+			t.Errorf("Overrides()[%s] = %q, want %q", k, overrides[k], v)
+		}
+	}
+	if flags.ConfigFile() != "/tmp/x.yaml" {
+		t.Errorf("ConfigFile() = %q, want /tmp/x.yaml", flags.ConfigFile())
+	}
+}
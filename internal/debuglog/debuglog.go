@@ -0,0 +1,62 @@
+// Package debuglog writes purr's debug log. It is off unless a file is
+// opened, and everything written is redacted first, since what is logged
+// includes the commands being typed.
+package debuglog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/tapcraft-io/purr/internal/redact"
+)
+
+var (
+	mu       sync.Mutex
+	out      *os.File // nil while the log is off
+	redactor *redact.Redactor
+)
+
+// Open starts logging to path, readable only by the user, masking secrets
+// with r
+func Open(path string, r *redact.Redactor) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open debug log: %w", err)
+	}
+	// An older log may have been created readable by others
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open debug log: %w", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if out != nil {
+		out.Close()
+	}
+	out, redactor = f, r
+	return nil
+}
+
+// Close stops logging
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if out == nil {
+		return nil
+	}
+	err := out.Close()
+	out = nil
+	return err
+}
+
+// Printf writes a line to the log, if it is on
+func Printf(format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if out == nil {
+		return
+	}
+	fmt.Fprintln(out, redactor.String(fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,48 @@
+package debuglog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tapcraft-io/purr/internal/redact"
+)
+
+func TestPrintf(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.log")
+
+	// Nothing is written while the log is off
+	Printf("input=%q", "get pods")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("log exists before Open: %v", err)
+	}
+
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := redact.New([]string{`acme_[a-z0-9]+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Open(path, r); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	Printf("input=%q", "create secret generic x --from-literal=key=acme_s3cr3t")
+	if err := Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	Printf("after close")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("log mode = %o, want 600", perm)
+	}
+	data, _ := os.ReadFile(path)
+	if got := string(data); strings.Contains(got, "acme_s3cr3t") || strings.Contains(got, "after close") || !strings.Contains(got, "input=") {
+		t.Errorf("log = %q, want one redacted line", got)
+	}
+}
@@ -0,0 +1,235 @@
+// Package diff produces line-based unified diffs
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+// maxEdits bounds the edit distance searched for. Inputs that differ more
+// than this are shown as one removal and one insertion, which keeps the
+// search cheap on wholesale rewrites.
+const maxEdits = 2000
+
+// op is one line of an edit script: ' ' kept, '-' removed, '+' inserted
+type op struct {
+	kind byte
+	text string
+}
+
+// Unified returns the unified diff turning a into b, with "---" and "+++"
+// headers naming them. It returns "" when they are equal.
+func Unified(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := lineDiff(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for _, h := range hunks(ops) {
+		writeHunk(&out, ops, h)
+	}
+	return out.String()
+}
+
+// splitLines splits text into lines without their newlines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// lineDiff returns an edit script turning a into b. Common leading and
+// trailing lines are peeled off before the Myers search on the rest.
+func lineDiff(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{' ', line})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{' ', line})
+	}
+	return ops
+}
+
+// myers finds a shortest edit script with Myers' O(ND) algorithm. Each
+// round's frontier is kept, trimmed to the diagonals it reached, so the
+// path can be walked back.
+func myers(a, b []string) []op {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+// backtrack walks the recorded frontiers back from the end of both inputs
+func backtrack(a, b []string, trace [][]int) []op {
+	var ops []op
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] holds diagonals -d..d of the frontier before round d
+		at := func(k int) int {
+			if k < -d || k > d {
+				return 0
+			}
+			return trace[d][k+d]
+		}
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, op{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			ops = append(ops, op{'+', b[y-1]})
+		} else {
+			ops = append(ops, op{'-', a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// replaceAll is the edit script removing all of a and inserting all of b
+func replaceAll(a, b []string) []op {
+	ops := make([]op, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, op{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, op{'+', line})
+	}
+	return ops
+}
+
+// hunk is a range of the edit script shown together
+type hunk struct{ start, end int }
+
+// hunks groups changes that are within twice contextLines of each other,
+// padding each group with context
+func hunks(ops []op) []hunk {
+	var out []hunk
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == ' ' {
+			continue
+		}
+		start := max(i-contextLines, 0)
+		end := i + 1
+		for end < len(ops) {
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*contextLines {
+				break
+			}
+			end = next + 1
+		}
+		end = min(end+contextLines, len(ops))
+		if n := len(out); n > 0 && start <= out[n-1].end {
+			out[n-1].end = end
+		} else {
+			out = append(out, hunk{start, end})
+		}
+		i = end - 1
+	}
+	return out
+}
+
+// writeHunk writes a hunk with its "@@ -a,n +b,m @@" header
+func writeHunk(out *strings.Builder, ops []op, h hunk) {
+	aLine, bLine := 1, 1
+	for _, o := range ops[:h.start] {
+		if o.kind != '+' {
+			aLine++
+		}
+		if o.kind != '-' {
+			bLine++
+		}
+	}
+	aCount, bCount := 0, 0
+	for _, o := range ops[h.start:h.end] {
+		if o.kind != '+' {
+			aCount++
+		}
+		if o.kind != '-' {
+			bCount++
+		}
+	}
+	// An empty range is numbered by the line before it
+	if aCount == 0 {
+		aLine--
+	}
+	if bCount == 0 {
+		bLine--
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+	for _, o := range ops[h.start:h.end] {
+		out.WriteByte(o.kind)
+		out.WriteString(o.text)
+		out.WriteByte('\n')
+	}
+}
+
+// hunkRange formats a line range, leaving out a count of one
+func hunkRange(line, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
@@ -0,0 +1,128 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{
+			"one change",
+			"spec:\n  replicas: 2\n  template:\n",
+			"spec:\n  replicas: 3\n  template:\n",
+			"--- live\n+++ edited\n@@ -1,3 +1,3 @@\n spec:\n-  replicas: 2\n+  replicas: 3\n   template:\n",
+		},
+		{
+			"insert into empty",
+			"",
+			"a\nb\n",
+			"--- live\n+++ edited\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			"delete everything",
+			"a\n",
+			"",
+			"--- live\n+++ edited\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"1\nX\n3\n4\n5\n6\n7\n8\n9\n10\n11\nY\n",
+			"--- live\n+++ edited\n@@ -1,5 +1,5 @@\n 1\n-2\n+X\n 3\n 4\n 5\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+Y\n",
+		},
+		{
+			"nearby changes share a hunk",
+			"1\n2\n3\n4\n5\n6\n7\n8\n",
+			"1\nX\n3\n4\n5\n6\nY\n8\n",
+			"--- live\n+++ edited\n@@ -1,8 +1,8 @@\n 1\n-2\n+X\n 3\n 4\n 5\n 6\n-7\n+Y\n 8\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := Unified("live", "edited", tt.a, tt.b); got != tt.want {
+			t.Errorf("Unified(%s) =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// apply rebuilds both sides of an edit script
+func apply(ops []op) (a, b []string) {
+	for _, o := range ops {
+		if o.kind != '+' {
+			a = append(a, o.text)
+		}
+		if o.kind != '-' {
+			b = append(b, o.text)
+		}
+	}
+	return a, b
+}
+
+func TestLineDiff_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	words := []string{"a", "b", "c", "d"}
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = words[rng.Intn(len(words))]
+		}
+		return lines
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b := randomLines(), randomLines()
+		ops := lineDiff(a, b)
+		gotA, gotB := apply(ops)
+		if strings.Join(gotA, "\n") != strings.Join(a, "\n") || strings.Join(gotB, "\n") != strings.Join(b, "\n") {
+			t.Fatalf("lineDiff(%q, %q) = %v, which does not rebuild its inputs", a, b, ops)
+		}
+
+		edits := 0
+		for _, o := range ops {
+			if o.kind != ' ' {
+				edits++
+			}
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); edits != want {
+			t.Fatalf("lineDiff(%q, %q) made %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+// lcs is the length of the longest common subsequence, for checking that
+// edit scripts are minimal
+func lcs(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func TestUnified_TooDifferent(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < maxEdits; i++ {
+		a.WriteString("old\n")
+		b.WriteString("new\n")
+	}
+	got := Unified("a", "b", a.String(), b.String())
+	if !strings.HasPrefix(got, "--- a\n+++ b\n@@ -1,2000 +1,2000 @@\n-old\n") || strings.Count(got, "\n+new") != maxEdits {
+		t.Errorf("Unified() of rewritten input starts %q, want every line replaced", got[:40])
+	}
+}
@@ -0,0 +1,244 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/tapcraft-io/purr/internal/diff"
+	"github.com/tapcraft-io/purr/internal/shlex"
+)
+
+// DiffPlan is how the changes a command would make are worked out before
+// it runs. Manifests (apply, replace) go through kubectl diff; patches are
+// dry run and compared with the live object; edits compare the live object
+// with the user's edited copy.
+type DiffPlan struct {
+	Verb   string   // apply, replace, patch or edit
+	Target string   // the object patched or edited, e.g. "deploy/web"
+	Diff   []string // kubectl diff arguments, for apply and replace
+	Live   []string // arguments printing the live object as YAML, for patch and edit
+	DryRun []string // arguments printing the patched object as YAML, for patch
+	Flags  []string // target flags carried over to the replace applying an edit
+}
+
+// targetFlags choose the cluster and namespace a command talks to, and are
+// carried over to the commands that work out its diff
+var targetFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true, "--cluster": true,
+	"--user": true, "--kubeconfig": true, "-s": true, "--server": true,
+	"--token": true, "--as": true, "--as-group": true, "--as-uid": true,
+}
+
+// manifestDiffFlags are the apply flags kubectl diff understands
+var manifestDiffFlags = map[string]bool{
+	"-f": true, "--filename": true, "-k": true, "--kustomize": true,
+	"-R": true, "--recursive": true, "-l": true, "--selector": true,
+	"--server-side": true, "--field-manager": true, "--force-conflicts": true,
+	"--prune": true, "--prune-allowlist": true,
+}
+
+// PlanDiff works out how to show what a command would change. Only apply,
+// replace, patch and edit of named objects can be diffed; manifests read
+// from stdin cannot.
+func PlanDiff(command string) (DiffPlan, bool) {
+	trimmed := strings.TrimSpace(command)
+	if strings.HasPrefix(trimmed, "!") {
+		return DiffPlan{}, false
+	}
+	args := commandArgs(trimmed)
+	positional, flags := splitArgs(args)
+	if len(positional) == 0 {
+		return DiffPlan{}, false
+	}
+	plan := DiffPlan{Verb: positional[0]}
+	operands := positional[1:]
+	manifest, hasManifest := flags["-f"]
+	if f, ok := flags["--filename"]; ok {
+		manifest, hasManifest = f, true
+	}
+	_, hasKustomize := flags["-k"]
+	if _, ok := flags["--kustomize"]; ok {
+		hasKustomize = true
+	}
+	if _, ok := flags["--dry-run"]; ok {
+		return DiffPlan{}, false
+	}
+
+	switch plan.Verb {
+	case "apply", "replace":
+		// apply's subcommands (view-last-applied, ...) change nothing to diff
+		if len(operands) > 0 || (!hasManifest && !hasKustomize) || manifest == "-" {
+			return DiffPlan{}, false
+		}
+		plan.Diff = append([]string{"diff"}, pickFlags(args, targetFlags, manifestDiffFlags)...)
+	case "patch":
+		if len(operands) == 0 || hasManifest {
+			return DiffPlan{}, false
+		}
+		plan.Target = strings.Join(operands, "/")
+		plan.Live = liveArgs(args, operands)
+		plan.DryRun = append(withoutFlags(args, "--dry-run", "-o", "--output"), "--dry-run=server", "-o", "yaml")
+	case "edit":
+		if len(operands) == 0 || hasManifest {
+			return DiffPlan{}, false
+		}
+		plan.Target = strings.Join(operands, "/")
+		plan.Live = liveArgs(args, operands)
+		plan.Flags = pickFlags(args, targetFlags)
+	default:
+		return DiffPlan{}, false
+	}
+	return plan, true
+}
+
+// liveArgs returns the kubectl get arguments printing objects as YAML
+func liveArgs(args, operands []string) []string {
+	live := append([]string{"get"}, operands...)
+	live = append(live, pickFlags(args, targetFlags)...)
+	return append(live, "-o", "yaml")
+}
+
+// pickFlags returns the flags in any of the sets, with their values
+func pickFlags(args []string, sets ...map[string]bool) []string {
+	var picked []string
+	eachFlag(args, func(name string, tokens []string) {
+		for _, set := range sets {
+			if set[name] {
+				picked = append(picked, tokens...)
+				return
+			}
+		}
+	})
+	return picked
+}
+
+// withoutFlags returns the arguments with the named flags and their
+// values removed
+func withoutFlags(args []string, names ...string) []string {
+	drop := make(map[string]bool)
+	for _, name := range names {
+		drop[name] = true
+	}
+	var kept []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(kept, args[i:]...)
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			kept = append(kept, arg)
+			continue
+		}
+		name, _, hasValue := shlex.SplitFlag(arg)
+		if !hasValue {
+			name = arg
+			if strings.HasPrefix(arg, "-o") && len(arg) > 2 && !strings.HasPrefix(arg, "--") {
+				name, hasValue = "-o", true
+			}
+		}
+		if !drop[name] {
+			kept = append(kept, arg)
+			continue
+		}
+		if !hasValue && globalValueFlags[name] && i+1 < len(args) {
+			i++
+		}
+	}
+	return kept
+}
+
+// eachFlag calls fn with every flag before "--" and the tokens spelling it
+// out, its value included
+func eachFlag(args []string, fn func(name string, tokens []string)) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+		name, _, hasValue := shlex.SplitFlag(arg)
+		switch {
+		case hasValue:
+			fn(name, args[i:i+1])
+		case strings.HasPrefix(arg, "-n") && len(arg) > 2 && !strings.HasPrefix(arg, "--"):
+			fn("-n", args[i:i+1])
+		case globalValueFlags[arg] && i+1 < len(args):
+			fn(arg, args[i:i+2])
+			i++
+		default:
+			fn(arg, args[i:i+1])
+		}
+	}
+}
+
+// Diff returns the unified diff of what a plan's command would change, or
+// "" if it would change nothing. Edits are diffed by the caller, once the
+// user has made them.
+func (e *Executor) Diff(ctx context.Context, plan DiffPlan) (string, error) {
+	switch plan.Verb {
+	case "apply", "replace":
+		// kubectl diff exits 1 when there are differences
+		result := e.Execute(ctx, plan.Diff)
+		if result.Error != nil && result.ExitCode != 1 {
+			return "", kubectlError(result)
+		}
+		return result.Stdout, nil
+	case "patch":
+		live, err := e.Live(ctx, plan)
+		if err != nil {
+			return "", err
+		}
+		result := e.Execute(ctx, plan.DryRun)
+		if result.Error != nil {
+			return "", kubectlError(result)
+		}
+		return diff.Unified(plan.Target+" (live)", plan.Target+" (patched)", live, result.Stdout), nil
+	}
+	return "", fmt.Errorf("cannot diff %s", plan.Verb)
+}
+
+// Live returns the YAML of the object a patch or edit acts on
+func (e *Executor) Live(ctx context.Context, plan DiffPlan) (string, error) {
+	result := e.Execute(ctx, plan.Live)
+	if result.Error != nil {
+		return "", kubectlError(result)
+	}
+	return result.Stdout, nil
+}
+
+// ReplaceArgs returns the arguments applying an edited copy of the live
+// objects. Replacing with the resourceVersion the copy was taken at fails
+// if someone else changed the objects in the meantime.
+func (p DiffPlan) ReplaceArgs(file string) []string {
+	return append([]string{"replace", "-f", file}, p.Flags...)
+}
+
+// EditorCommand returns the process editing a file with $KUBE_EDITOR or
+// $EDITOR, as kubectl edit does, falling back to vi
+func EditorCommand(file string) (*exec.Cmd, error) {
+	editor := os.Getenv("KUBE_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args, err := shlex.Fields(editor)
+	if err != nil || len(args) == 0 {
+		return nil, fmt.Errorf("invalid editor %q", editor)
+	}
+	return exec.Command(args[0], append(args[1:], file)...), nil
+}
+
+// kubectlError turns a failed run into an error carrying kubectl's message
+func kubectlError(result *ExecuteResult) error {
+	if msg := strings.TrimSpace(result.Stderr); msg != "" {
+		return fmt.Errorf("%s", strings.TrimPrefix(firstLine(msg), "error: "))
+	}
+	return result.Error
+}
@@ -0,0 +1,82 @@
+package exec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanDiff(t *testing.T) {
+	tests := []struct {
+		command string
+		want    DiffPlan
+		ok      bool
+	}{
+		{"apply -f app.yaml", DiffPlan{Verb: "apply", Diff: []string{"diff", "-f", "app.yaml"}}, true},
+		{"kubectl apply -k overlays/prod --server-side --context prod -nshop -o name", DiffPlan{Verb: "apply", Diff: []string{"diff", "-k", "overlays/prod", "--server-side", "--context", "prod", "-nshop"}}, true},
+		{"replace --force -f=app.yaml", DiffPlan{Verb: "replace", Diff: []string{"diff", "-f=app.yaml"}}, true},
+		{
+			"patch deploy web -n prod -p '{\"spec\":{\"replicas\":3}}' -o json",
+			DiffPlan{
+				Verb:   "patch",
+				Target: "deploy/web",
+				Live:   []string{"get", "deploy", "web", "-n", "prod", "-o", "yaml"},
+				DryRun: []string{"patch", "deploy", "web", "-n", "prod", "-p", `{"spec":{"replicas":3}}`, "--dry-run=server", "-o", "yaml"},
+			},
+			true,
+		},
+		{"edit deploy/web --context=prod", DiffPlan{Verb: "edit", Target: "deploy/web", Live: []string{"get", "deploy/web", "--context=prod", "-o", "yaml"}, Flags: []string{"--context=prod"}}, true},
+		{"apply -f -", DiffPlan{}, false},
+		{"apply view-last-applied deploy/web", DiffPlan{}, false},
+		{"apply -f app.yaml --dry-run=client", DiffPlan{}, false},
+		{"edit -f app.yaml", DiffPlan{}, false},
+		{"patch -f app.yaml -p '{}'", DiffPlan{}, false},
+		{"delete deploy/web", DiffPlan{}, false},
+		{"!kubectl apply -f app.yaml", DiffPlan{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := PlanDiff(tt.command)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("PlanDiff(%s) = %+v, %v, want %+v, %v", tt.command, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExecutor_Diff(t *testing.T) {
+	// A fake kubectl: diff reports a change, get prints the live object and
+	// the dry-run patch prints the patched one
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$1" in
+diff) echo "-replicas: 2"; echo "+replicas: 3"; exit 1 ;;
+get) printf 'kind: Deployment\nreplicas: 2\n' ;;
+patch) printf 'kind: Deployment\nreplicas: 3\n' ;;
+*) echo "unknown command" >&2; exit 2 ;;
+esac
+`
+	kubectl := filepath.Join(dir, "kubectl")
+	if err := os.WriteFile(kubectl, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	e := &Executor{kubectlPath: kubectl}
+	ctx := context.Background()
+
+	plan, _ := PlanDiff("apply -f app.yaml")
+	if got, err := e.Diff(ctx, plan); err != nil || got != "-replicas: 2\n+replicas: 3\n" {
+		t.Errorf("Diff(apply) = %q, %v", got, err)
+	}
+
+	plan, _ = PlanDiff("patch deploy/web -p '{}'")
+	got, err := e.Diff(ctx, plan)
+	if err != nil || !strings.Contains(got, "-replicas: 2\n+replicas: 3\n") || !strings.HasPrefix(got, "--- deploy/web (live)") {
+		t.Errorf("Diff(patch) = %q, %v", got, err)
+	}
+
+	if _, err := e.Diff(ctx, DiffPlan{Verb: "apply", Diff: []string{"oops"}}); err == nil || err.Error() != "unknown command" {
+		t.Errorf("Diff(failing) error = %v, want kubectl's message", err)
+	}
+}
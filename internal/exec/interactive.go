@@ -0,0 +1,91 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/internal/shlex"
+)
+
+// InteractiveCompleteMsg is sent when a command that was handed the
+// terminal exits
+type InteractiveCompleteMsg struct {
+	Command  string
+	ExitCode int
+	Duration time.Duration
+	Error    error
+}
+
+// interactivePrograms are shell commands that take over the terminal
+var interactivePrograms = map[string]bool{
+	"vim": true, "vi": true, "nvim": true, "nano": true, "emacs": true,
+	"less": true, "more": true, "man": true,
+	"top": true, "htop": true, "btop": true, "k9s": true,
+	"ssh": true, "tmux": true, "screen": true,
+	"sh": true, "bash": true, "zsh": true, "fish": true,
+	"python": true, "python3": true, "psql": true, "mysql": true, "redis-cli": true,
+}
+
+// IsInteractiveShell checks if a "!" shell command needs the terminal
+func IsInteractiveShell(command string) bool {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(command), "!"))
+	args, _ := shlex.Fields(trimmed)
+
+	// Skip leading VAR=value assignments
+	for len(args) > 0 && strings.Contains(args[0], "=") && !strings.HasPrefix(args[0], "-") {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return false
+	}
+
+	program := filepath.Base(args[0])
+	if !interactivePrograms[program] {
+		return false
+	}
+
+	for _, arg := range args[1:] {
+		// "top -b" is batch mode, "sh -c ..." runs a script
+		if (program == "top" && arg == "-b") || (arg == "-c" && isShellProgram(program)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isShellProgram checks if a program is a shell
+func isShellProgram(program string) bool {
+	switch program {
+	case "sh", "bash", "zsh", "fish":
+		return true
+	}
+	return false
+}
+
+// ExecuteInteractive suspends the TUI, gives the command the terminal and
+// reports back with an InteractiveCompleteMsg once it exits
+func (e *Executor) ExecuteInteractive(command string) tea.Cmd {
+	cmd, err := e.Command(context.Background(), command)
+	if err != nil {
+		return func() tea.Msg {
+			return InteractiveCompleteMsg{Command: command, ExitCode: 1, Error: err}
+		}
+	}
+
+	start := time.Now()
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		msg := InteractiveCompleteMsg{Command: command, Duration: time.Since(start), Error: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			msg.ExitCode = exitErr.ExitCode()
+		} else if err != nil {
+			msg.ExitCode = -1
+		}
+		return msg
+	})
+}
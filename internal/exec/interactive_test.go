@@ -0,0 +1,28 @@
+package exec
+
+import "testing"
+
+func TestIsInteractiveShell(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected bool
+	}{
+		{"!vim deployment.yaml", true},
+		{"!/usr/bin/nvim", true},
+		{"!EDITOR=vi less README.md", true},
+		{"!top", true},
+		{"!top -b -n 1", false},
+		{"!bash", true},
+		{`!sh -c "echo hi"`, false},
+		{"!ls -la", false},
+		{"!", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := IsInteractiveShell(tt.command); got != tt.expected {
+				t.Errorf("IsInteractiveShell(%s) = %v, want %v", tt.command, got, tt.expected)
+			}
+		})
+	}
+}
@@ -6,16 +6,42 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/internal/shlex"
 )
 
 // Executor executes kubectl commands
 type Executor struct {
 	kubectlPath string
+
+	// mu guards the session below, which the UI changes while commands
+	// started earlier are still being built on other goroutines
+	mu sync.RWMutex
+
+	// kubeconfig is the kubeconfig purr reads, passed on to kubectl so
+	// commands reach the cluster the cache shows. It may be a
+	// KUBECONFIG-style list of files.
+	kubeconfig string
+
+	// kubeContext is passed as --context to every kubectl command that
+	// does not name one itself
+	kubeContext string
+
+	// namespace is passed as -n to commands that pick no namespace
+	namespace string
+
+	// plugins are the commands kubectl runs plugins for. kubectl only
+	// finds a plugin named before any flag, and plugins may not take
+	// kubectl's flags, so they get no session flags: only the kubeconfig,
+	// through $KUBECONFIG. They reach its current context.
+	plugins map[string]bool
 }
 
 // ExecuteResult contains the result of a kubectl execution
@@ -40,12 +66,162 @@ func NewExecutor() (*Executor, error) {
 	}, nil
 }
 
+// SetKubeconfig sets the kubeconfig kubectl reads. An empty path leaves
+// kubectl on $KUBECONFIG or ~/.kube/config.
+func (e *Executor) SetKubeconfig(path string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.kubeconfig = path
+}
+
+// SetContext sets the kubeconfig context commands run against. An empty
+// name leaves kubectl on the kubeconfig's current context.
+func (e *Executor) SetContext(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.kubeContext = name
+}
+
+// Context returns the kubeconfig context commands run against
+func (e *Executor) Context() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.kubeContext
+}
+
+// SetNamespace sets the session namespace. An empty name leaves kubectl
+// on the context's namespace.
+func (e *Executor) SetNamespace(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.namespace = name
+}
+
+// SetPlugins sets the first words of the commands that run kubectl
+// plugins
+func (e *Executor) SetPlugins(names []string) {
+	plugins := make(map[string]bool, len(names))
+	for _, name := range names {
+		plugins[name] = true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.plugins = plugins
+}
+
+// RunsPlugin reports whether a command string runs a kubectl plugin,
+// which gets no session flags
+func (e *Executor) RunsPlugin(command string) bool {
+	if strings.HasPrefix(strings.TrimSpace(command), "!") {
+		return false
+	}
+	args := commandArgs(command)
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(args) > 0 && e.plugins[args[0]]
+}
+
+// withSessionFlags adds the session's --kubeconfig, --context and -n
+// unless the arguments already choose them. A list of kubeconfig files
+// cannot be given as a flag; command passes it as $KUBECONFIG instead.
+// Manifests (-f, -k) get -n too: kubectl puts objects without a namespace
+// in it and refuses ones that name another. Plugins are left alone.
+func (e *Executor) withSessionFlags(args []string) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if len(args) > 0 && e.plugins[args[0]] {
+		return args
+	}
+	var global []string
+	if e.kubeconfig != "" && !strings.ContainsRune(e.kubeconfig, filepath.ListSeparator) && !hasFlag(args, "--kubeconfig") {
+		global = append(global, "--kubeconfig="+e.kubeconfig)
+	}
+	if e.kubeContext != "" && !hasFlag(args, "--context") {
+		global = append(global, "--context="+e.kubeContext)
+	}
+	if e.namespace != "" && !hasNamespaceFlag(args) {
+		global = append(global, "--namespace="+e.namespace)
+	}
+	if len(global) == 0 {
+		return args
+	}
+	return append(global, args...)
+}
+
+// command builds a kubectl process for arguments that already carry the
+// session flags. The kubeconfig also goes in $KUBECONFIG, for lists of
+// files and for anything kubectl starts.
+func (e *Executor) command(ctx context.Context, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, e.kubectlPath, args...)
+	cmd.Env = e.env()
+	return cmd
+}
+
+// env returns the environment of the processes purr starts: its own, with
+// the session's kubeconfig as $KUBECONFIG. It is nil, for purr's own
+// environment unchanged, when no kubeconfig is set.
+func (e *Executor) env() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.kubeconfig == "" {
+		return nil
+	}
+	return append(os.Environ(), "KUBECONFIG="+e.kubeconfig)
+}
+
+// CompleteCommand builds kubectl's __complete for the words of a command,
+// with the session flags the words don't choose themselves
+func (e *Executor) CompleteCommand(ctx context.Context, args []string) *exec.Cmd {
+	return e.command(ctx, append([]string{"__complete"}, e.withSessionFlags(args)...))
+}
+
+// PluginCommand builds a process running a plugin executable directly,
+// with the session's kubeconfig as kubectl would pass it on
+func (e *Executor) PluginCommand(ctx context.Context, file string, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, file, args...)
+	cmd.Env = e.env()
+	return cmd
+}
+
+// hasNamespaceFlag checks if the arguments choose a namespace, including
+// the "-nfoo" short form and --all-namespaces
+func hasNamespaceFlag(args []string) bool {
+	if hasFlag(args, "--namespace", "-A", "--all-namespaces") {
+		return true
+	}
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if strings.HasPrefix(arg, "-n") && !strings.HasPrefix(arg, "--") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFlag checks if a flag is set, in either "--flag value" or
+// "--flag=value" form, before any "--" separator
+func hasFlag(args []string, names ...string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		for _, name := range names {
+			if arg == name || strings.HasPrefix(arg, name+"=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Execute runs a kubectl command
 func (e *Executor) Execute(ctx context.Context, args []string) *ExecuteResult {
 	start := time.Now()
 	result := &ExecuteResult{}
 
-	cmd := exec.CommandContext(ctx, e.kubectlPath, args...)
+	cmd := e.command(ctx, e.withSessionFlags(args))
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -84,7 +260,10 @@ func (e *Executor) ExecuteString(ctx context.Context, command string) *ExecuteRe
 	}
 
 	// Parse command string into args
-	args := parseCommandString(trimmed)
+	args, err := parseCommandString(trimmed)
+	if err != nil {
+		return &ExecuteResult{Error: err, ExitCode: 1}
+	}
 	return e.Execute(ctx, args)
 }
 
@@ -94,6 +273,7 @@ func (e *Executor) executeShell(ctx context.Context, command string) *ExecuteRes
 	result := &ExecuteResult{}
 
 	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = e.env()
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -118,58 +298,29 @@ func (e *Executor) executeShell(ctx context.Context, command string) *ExecuteRes
 	return result
 }
 
-// parseCommandString splits a command string into arguments
-// This is a simple implementation - doesn't handle quotes perfectly
-func parseCommandString(command string) []string {
-	// Remove "kubectl" prefix if present
-	command = strings.TrimPrefix(command, "kubectl ")
-	command = strings.TrimSpace(command)
-
-	if command == "" {
-		return []string{}
-	}
-
-	// Simple split on whitespace
-	// TODO: Handle quoted strings properly
-	return strings.Fields(command)
-}
-
-// IsDestructive checks if a command is destructive (requires confirmation)
-func IsDestructive(command string) bool {
-	trimmed := strings.TrimSpace(command)
-
-	if strings.HasPrefix(trimmed, "!") {
-		return false
-	}
-
-	args := strings.Fields(trimmed)
-	if len(args) == 0 {
-		return false
-	}
-
-	// Check for destructive verbs
-	verb := args[0]
-	destructiveVerbs := []string{
-		"delete",
-		"drain",
-		"cordon",
-		"rollout",
+// parseCommandString splits a command string into kubectl arguments,
+// honouring shell quoting
+func parseCommandString(command string) ([]string, error) {
+	args, err := shlex.Fields(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command: %w", err)
 	}
 
-	for _, dv := range destructiveVerbs {
-		if verb == dv {
-			return true
-		}
+	// Remove "kubectl" prefix if present
+	if len(args) > 0 && args[0] == "kubectl" {
+		args = args[1:]
 	}
+	return args, nil
+}
 
-	// Check for --force flag
-	for _, arg := range args {
-		if arg == "--force" {
-			return true
-		}
+// commandArgs returns the kubectl arguments of a command string, without
+// the "kubectl" prefix. Unterminated quotes are tolerated.
+func commandArgs(command string) []string {
+	args, _ := shlex.Fields(command)
+	if len(args) > 0 && args[0] == "kubectl" {
+		args = args[1:]
 	}
-
-	return false
+	return args
 }
 
 // GetCommandVerb extracts the kubectl verb from a command string
@@ -180,9 +331,7 @@ func GetCommandVerb(command string) string {
 		return ""
 	}
 
-	command = strings.TrimPrefix(command, "kubectl ")
-
-	args := strings.Fields(command)
+	args := commandArgs(command)
 	if len(args) == 0 {
 		return ""
 	}
@@ -205,26 +354,39 @@ type PaneCompleteMsg struct {
 	Error    error
 }
 
-// ExecuteStreaming runs a command and streams output via tea messages
-func (e *Executor) ExecuteStreaming(ctx context.Context, command string, paneID int) tea.Cmd {
+// Command builds the process for a command string without wiring up any
+// I/O. Commands starting with "!" run through the shell.
+func (e *Executor) Command(ctx context.Context, command string) (*exec.Cmd, error) {
 	trimmed := strings.TrimSpace(command)
 
-	var cmd *exec.Cmd
 	if strings.HasPrefix(trimmed, "!") {
 		shellCmd := strings.TrimSpace(strings.TrimPrefix(trimmed, "!"))
 		if shellCmd == "" {
-			return func() tea.Msg {
-				return PaneCompleteMsg{
-					PaneID:   paneID,
-					ExitCode: 1,
-					Error:    fmt.Errorf("empty shell command"),
-				}
+			return nil, fmt.Errorf("empty shell command")
+		}
+		cmd := exec.CommandContext(ctx, "sh", "-c", shellCmd)
+		cmd.Env = e.env()
+		return cmd, nil
+	}
+
+	args, err := parseCommandString(trimmed)
+	if err != nil {
+		return nil, err
+	}
+	return e.command(ctx, e.withSessionFlags(args)), nil
+}
+
+// ExecuteStreaming runs a command and streams output via tea messages
+func (e *Executor) ExecuteStreaming(ctx context.Context, command string, paneID int) tea.Cmd {
+	cmd, err := e.Command(ctx, command)
+	if err != nil {
+		return func() tea.Msg {
+			return PaneCompleteMsg{
+				PaneID:   paneID,
+				ExitCode: 1,
+				Error:    err,
 			}
 		}
-		cmd = exec.CommandContext(ctx, "sh", "-c", shellCmd)
-	} else {
-		args := parseCommandString(trimmed)
-		cmd = exec.CommandContext(ctx, e.kubectlPath, args...)
 	}
 
 	// Create pipes for stdout and stderr
@@ -0,0 +1,186 @@
+package exec
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestExecutor_WithSessionFlags(t *testing.T) {
+	e := &Executor{}
+
+	args := []string{"get", "pods"}
+	if got := e.withSessionFlags(args); !reflect.DeepEqual(got, args) {
+		t.Errorf("withSessionFlags() without context = %q, want %q", got, args)
+	}
+
+	e.SetContext("prod")
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"get", "pods"}, []string{"--context=prod", "get", "pods"}},
+		{[]string{"get", "pods", "--context", "dev"}, []string{"get", "pods", "--context", "dev"}},
+		{[]string{"get", "pods", "--context=dev"}, []string{"get", "pods", "--context=dev"}},
+		{[]string{"exec", "p", "--", "cmd", "--context=x"}, []string{"--context=prod", "exec", "p", "--", "cmd", "--context=x"}},
+	}
+
+	for _, tt := range tests {
+		if got := e.withSessionFlags(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("withSessionFlags(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestExecutor_WithSessionNamespace(t *testing.T) {
+	e := &Executor{}
+	e.SetNamespace("payments")
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"get", "pods"}, []string{"--namespace=payments", "get", "pods"}},
+		{[]string{"get", "pods", "-n", "dev"}, []string{"get", "pods", "-n", "dev"}},
+		{[]string{"get", "pods", "-ndev"}, []string{"get", "pods", "-ndev"}},
+		{[]string{"get", "pods", "--namespace=dev"}, []string{"get", "pods", "--namespace=dev"}},
+		{[]string{"get", "pods", "-A"}, []string{"get", "pods", "-A"}},
+		{[]string{"apply", "-f", "app.yaml"}, []string{"--namespace=payments", "apply", "-f", "app.yaml"}},
+		{[]string{"apply", "-k", "overlays/prod"}, []string{"--namespace=payments", "apply", "-k", "overlays/prod"}},
+		{[]string{"apply", "-f", "app.yaml", "-n", "dev"}, []string{"apply", "-f", "app.yaml", "-n", "dev"}},
+		{[]string{"logs", "-f", "web"}, []string{"--namespace=payments", "logs", "-f", "web"}},
+		{[]string{"exec", "web", "--", "ls", "-n"}, []string{"--namespace=payments", "exec", "web", "--", "ls", "-n"}},
+	}
+
+	for _, tt := range tests {
+		if got := e.withSessionFlags(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("withSessionFlags(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	e.SetContext("prod")
+	want := []string{"--context=prod", "--namespace=payments", "get", "pods"}
+	if got := e.withSessionFlags([]string{"get", "pods"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("withSessionFlags() with context = %q, want %q", got, want)
+	}
+}
+
+func TestExecutor_WithSessionKubeconfig(t *testing.T) {
+	e := &Executor{}
+	e.SetKubeconfig("/home/me/.kube/staging")
+	e.SetContext("prod")
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"get", "pods"}, []string{"--kubeconfig=/home/me/.kube/staging", "--context=prod", "get", "pods"}},
+		{[]string{"get", "pods", "--kubeconfig", "other"}, []string{"--context=prod", "get", "pods", "--kubeconfig", "other"}},
+	}
+
+	for _, tt := range tests {
+		if got := e.withSessionFlags(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("withSessionFlags(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	// A list of files only fits in $KUBECONFIG
+	e.SetKubeconfig("/a" + string(filepath.ListSeparator) + "/b")
+	want := []string{"--context=prod", "get", "pods"}
+	if got := e.withSessionFlags([]string{"get", "pods"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("withSessionFlags() with a kubeconfig list = %q, want %q", got, want)
+	}
+	if env := e.command(context.Background(), want).Env; !slices.Contains(env, "KUBECONFIG="+e.kubeconfig) {
+		t.Errorf("command() env lacks KUBECONFIG=%s", e.kubeconfig)
+	}
+
+	// Shell commands run kubectl themselves, which reads $KUBECONFIG too
+	shell, err := e.Command(context.Background(), "!kubectl get pods | wc -l")
+	if err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+	if !slices.Contains(shell.Env, "KUBECONFIG="+e.kubeconfig) {
+		t.Errorf("Command() of a shell command: env lacks KUBECONFIG=%s", e.kubeconfig)
+	}
+}
+
+func TestExecutor_CompleteCommand(t *testing.T) {
+	e := &Executor{kubectlPath: "kubectl"}
+	e.SetKubeconfig("/home/me/.kube/staging")
+	e.SetContext("prod")
+	e.SetNamespace("payments")
+
+	cmd := e.CompleteCommand(context.Background(), []string{"get", "pods", "-n", "dev", ""})
+	want := []string{"kubectl", "__complete", "--kubeconfig=/home/me/.kube/staging", "--context=prod", "get", "pods", "-n", "dev", ""}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("CompleteCommand() args = %q, want %q", cmd.Args, want)
+	}
+
+	plugin := e.PluginCommand(context.Background(), "/bin/kubectl-tree", []string{"__complete", ""})
+	if !slices.Contains(plugin.Env, "KUBECONFIG=/home/me/.kube/staging") {
+		t.Errorf("PluginCommand() env lacks KUBECONFIG")
+	}
+}
+
+func TestExecutor_SessionConcurrent(t *testing.T) {
+	e := &Executor{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			e.SetContext("prod")
+			e.SetNamespace("payments")
+		}
+	}()
+	for range 100 {
+		_ = e.withSessionFlags([]string{"get", "pods"})
+		_ = e.Context()
+	}
+	<-done
+}
+
+func TestExecutor_WithSessionFlagsPlugins(t *testing.T) {
+	e := &Executor{}
+	e.SetContext("prod")
+	e.SetNamespace("payments")
+	e.SetPlugins([]string{"ns", "tree"})
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"ns", "kube-system"}, []string{"ns", "kube-system"}},
+		{[]string{"tree", "deployment", "web"}, []string{"tree", "deployment", "web"}},
+		{[]string{"get", "ns"}, []string{"--context=prod", "--namespace=payments", "get", "ns"}},
+	}
+
+	for _, tt := range tests {
+		if got := e.withSessionFlags(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("withSessionFlags(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestExecutor_RunsPlugin(t *testing.T) {
+	e := &Executor{}
+	e.SetPlugins([]string{"ns", "tree"})
+
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"kubectl ns kube-system", true},
+		{"tree deployment web", true},
+		{"kubectl get ns", false},
+		{"!kubectl ns kube-system", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := e.RunsPlugin(tt.command); got != tt.want {
+			t.Errorf("RunsPlugin(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
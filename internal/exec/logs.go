@@ -0,0 +1,131 @@
+package exec
+
+import (
+	"strings"
+
+	"github.com/tapcraft-io/purr/internal/shlex"
+)
+
+// LogsCommand is a kubectl logs invocation split into the parts the log
+// viewer changes while it runs. Flags it does not manage are kept in
+// Extra and passed through untouched.
+type LogsCommand struct {
+	Target     string // pod name or kind/name
+	Namespace  string
+	Container  string
+	Selector   string
+	Follow     bool
+	Previous   bool
+	Timestamps bool
+	Extra      []string
+}
+
+// logsValueFlags are the kubectl logs flags that take a value
+var logsValueFlags = map[string]bool{
+	"-n": true, "--namespace": true,
+	"-c": true, "--container": true,
+	"-l": true, "--selector": true,
+	"--since": true, "--since-time": true, "--tail": true,
+	"--limit-bytes": true, "--max-log-requests": true,
+	"--pod-running-timeout": true, "--context": true,
+}
+
+// ParseLogsCommand parses a "kubectl logs" command. ok is false for any
+// other command.
+func ParseLogsCommand(command string) (LogsCommand, bool) {
+	args := commandArgs(command)
+	if len(args) == 0 || args[0] != "logs" {
+		return LogsCommand{}, false
+	}
+
+	var lc LogsCommand
+	var positional []string
+	rest := args[1:]
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := shlex.SplitFlag(arg)
+		if !hasValue {
+			name = arg
+			if logsValueFlags[name] && i+1 < len(rest) {
+				value = rest[i+1]
+				i++
+			}
+		}
+
+		switch name {
+		case "-n", "--namespace":
+			lc.Namespace = value
+		case "-c", "--container":
+			lc.Container = value
+		case "-l", "--selector":
+			lc.Selector = value
+		case "-f", "--follow":
+			lc.Follow = value != "false"
+		case "-p", "--previous":
+			lc.Previous = value != "false"
+		case "--timestamps":
+			lc.Timestamps = value != "false"
+		default:
+			if hasValue || !logsValueFlags[name] {
+				lc.Extra = append(lc.Extra, arg)
+			} else {
+				lc.Extra = append(lc.Extra, name, value)
+			}
+		}
+	}
+
+	// "kubectl logs POD CONTAINER" is the legacy way to pick a container
+	if len(positional) > 0 {
+		lc.Target = positional[0]
+	}
+	if len(positional) > 1 && lc.Container == "" {
+		lc.Container = positional[1]
+	}
+	return lc, true
+}
+
+// Args returns the arguments to kubectl, without "kubectl" itself
+func (lc LogsCommand) Args() []string {
+	args := []string{"logs"}
+	if lc.Target != "" {
+		args = append(args, lc.Target)
+	}
+	if lc.Selector != "" {
+		args = append(args, "-l", lc.Selector)
+	}
+	if lc.Namespace != "" {
+		args = append(args, "-n", lc.Namespace)
+	}
+	if lc.Container != "" {
+		args = append(args, "-c", lc.Container)
+	}
+	if lc.Follow {
+		args = append(args, "-f")
+	}
+	if lc.Previous {
+		args = append(args, "--previous")
+	}
+	if lc.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	return append(args, lc.Extra...)
+}
+
+// String returns the full command line
+func (lc LogsCommand) String() string {
+	return "kubectl " + shlex.Join(lc.Args())
+}
+
+// TargetKind splits the target into a resource kind and name. A bare name
+// is a pod.
+func (lc LogsCommand) TargetKind() (kind, name string) {
+	if kind, name, ok := strings.Cut(lc.Target, "/"); ok {
+		return kind, name
+	}
+	return "pod", lc.Target
+}
@@ -0,0 +1,67 @@
+package exec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLogsCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		want  LogsCommand
+	}{
+		{"kubectl logs web", LogsCommand{Target: "web"}},
+		{"logs -f web -n prod -c app", LogsCommand{Target: "web", Namespace: "prod", Container: "app", Follow: true}},
+		{"logs deploy/api --namespace=prod --previous --timestamps", LogsCommand{Target: "deploy/api", Namespace: "prod", Previous: true, Timestamps: true}},
+		{"logs web sidecar --tail 100 --since=1h", LogsCommand{Target: "web", Container: "sidecar", Extra: []string{"--tail", "100", "--since=1h"}}},
+		{"logs -l app=web --all-containers", LogsCommand{Selector: "app=web", Extra: []string{"--all-containers"}}},
+		{"logs web --follow=false", LogsCommand{Target: "web"}},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseLogsCommand(tt.input)
+		if !ok {
+			t.Errorf("ParseLogsCommand(%s) not ok", tt.input)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLogsCommand(%s) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	if _, ok := ParseLogsCommand("kubectl get pods"); ok {
+		t.Error("ParseLogsCommand(get pods) should not be ok")
+	}
+}
+
+func TestLogsCommand_String(t *testing.T) {
+	lc := LogsCommand{Target: "web", Namespace: "prod", Container: "app", Follow: true, Timestamps: true, Extra: []string{"--since=1h"}}
+	want := "kubectl logs web -n prod -c app -f --timestamps --since=1h"
+	if got := lc.String(); got != want {
+		t.Errorf("String() = %v, want %v", got, want)
+	}
+
+	// Round trip
+	if got, _ := ParseLogsCommand(lc.String()); !reflect.DeepEqual(got, lc) {
+		t.Errorf("ParseLogsCommand(String()) = %+v, want %+v", got, lc)
+	}
+}
+
+func TestLogsCommand_TargetKind(t *testing.T) {
+	tests := []struct {
+		target   string
+		wantKind string
+		wantName string
+	}{
+		{"web", "pod", "web"},
+		{"deploy/api", "deploy", "api"},
+		{"statefulsets/db", "statefulsets", "db"},
+	}
+
+	for _, tt := range tests {
+		kind, name := LogsCommand{Target: tt.target}.TargetKind()
+		if kind != tt.wantKind || name != tt.wantName {
+			t.Errorf("TargetKind(%s) = %v, %v, want %v, %v", tt.target, kind, name, tt.wantKind, tt.wantName)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package exec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// multiLogRetryDelay keeps a stream that keeps failing (RBAC, a container
+// stuck restarting) from being respawned on every cache event
+const multiLogRetryDelay = 5 * time.Second
+
+// LogSource is one container in a multi-pod log stream
+type LogSource struct {
+	Namespace string
+	Pod       string
+	Container string
+}
+
+// Tag labels the source's lines
+func (s LogSource) Tag() string {
+	return s.Pod + "/" + s.Container
+}
+
+// LogLine is a line of output from one container
+type LogLine struct {
+	Source LogSource
+	Text   string
+}
+
+// MultiLogOutputMsg carries a batch of lines from a MultiLog
+type MultiLogOutputMsg struct {
+	PaneID  int
+	Lines   []LogLine
+	NextCmd tea.Cmd // Call this to continue streaming
+}
+
+// MultiLog follows the logs of many containers at once, stern-style, with
+// one kubectl logs -f process per container. The set of containers is
+// changed with Sync as pods come and go.
+type MultiLog struct {
+	executor   *Executor
+	tail       int
+	timestamps bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	lines  chan LogLine
+
+	mu      sync.Mutex
+	want    map[LogSource]bool
+	streams map[LogSource]context.CancelFunc
+	ended   map[LogSource]time.Time // when a stream last exited on its own
+}
+
+// NewMultiLog creates an empty multi-pod log stream. Each container starts
+// with its last tail lines; a negative tail starts with all of them.
+func (e *Executor) NewMultiLog(tail int, timestamps bool) *MultiLog {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &MultiLog{
+		executor:   e,
+		tail:       tail,
+		timestamps: timestamps,
+		ctx:        ctx,
+		cancel:     cancel,
+		lines:      make(chan LogLine, 100),
+		want:       make(map[LogSource]bool),
+		streams:    make(map[LogSource]context.CancelFunc),
+		ended:      make(map[LogSource]time.Time),
+	}
+}
+
+// Sync makes the streamed containers match sources, attaching new ones and
+// detaching those that are gone. It returns the containers it added and
+// removed.
+func (ml *MultiLog) Sync(sources []LogSource) (attached, detached []LogSource) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	if ml.ctx.Err() != nil {
+		return nil, nil
+	}
+
+	want := make(map[LogSource]bool, len(sources))
+	for _, src := range sources {
+		want[src] = true
+		if !ml.want[src] {
+			attached = append(attached, src)
+		}
+	}
+	for src := range ml.want {
+		if want[src] {
+			continue
+		}
+		if cancel, ok := ml.streams[src]; ok {
+			cancel()
+			delete(ml.streams, src)
+		}
+		delete(ml.ended, src)
+		detached = append(detached, src)
+	}
+	ml.want = want
+
+	for _, src := range sources {
+		ml.attach(src)
+	}
+	sortSources(attached)
+	sortSources(detached)
+	return attached, detached
+}
+
+// attach starts a container's stream unless it is running, or ended too
+// recently to retry. Callers hold ml.mu.
+func (ml *MultiLog) attach(src LogSource) {
+	if _, ok := ml.streams[src]; ok || !ml.want[src] || ml.ctx.Err() != nil {
+		return
+	}
+	if ended, ok := ml.ended[src]; ok && time.Since(ended) < multiLogRetryDelay {
+		return
+	}
+	ctx, cancel := context.WithCancel(ml.ctx)
+	ml.streams[src] = cancel
+	go ml.follow(ctx, src, ml.args(src))
+}
+
+// args builds the kubectl logs arguments for a container. A resumed
+// stream picks up from when the last one ended instead of replaying the
+// tail.
+func (ml *MultiLog) args(src LogSource) []string {
+	args := []string{"logs", src.Pod, "-n", src.Namespace, "-c", src.Container, "-f"}
+	if ml.timestamps {
+		args = append(args, "--timestamps")
+	}
+	if ended, resumed := ml.ended[src]; resumed {
+		since := int(time.Since(ended)/time.Second) + 1
+		return append(args, "--since="+strconv.Itoa(since)+"s")
+	}
+	return append(args, "--tail="+strconv.Itoa(ml.tail))
+}
+
+// follow runs one container's stream until it is detached. A stream that
+// exits on its own, as it does when the container restarts, is retried
+// after multiLogRetryDelay for as long as the container is wanted.
+func (ml *MultiLog) follow(ctx context.Context, src LogSource, args []string) {
+	cmd := ml.executor.command(ctx, ml.executor.withSessionFlags(args))
+	if err := ml.pipe(ctx, cmd, src); err != nil {
+		ml.send(ctx, LogLine{Source: src, Text: err.Error()})
+	}
+
+	ml.mu.Lock()
+	if ctx.Err() != nil {
+		ml.mu.Unlock()
+		return
+	}
+	delete(ml.streams, src)
+	ml.ended[src] = time.Now()
+	ml.mu.Unlock()
+
+	select {
+	case <-time.After(multiLogRetryDelay):
+	case <-ml.ctx.Done():
+		return
+	}
+	ml.mu.Lock()
+	ml.attach(src)
+	ml.mu.Unlock()
+}
+
+// pipe starts cmd and forwards its output until it exits
+func (ml *MultiLog) pipe(ctx context.Context, cmd *exec.Cmd, src LogSource) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+
+	scanner := bufio.NewScanner(io.MultiReader(stdout, stderr))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if !ml.send(ctx, LogLine{Source: src, Text: scanner.Text()}) {
+			break
+		}
+	}
+	_ = cmd.Wait()
+	return nil
+}
+
+// send delivers a line unless the stream was detached
+func (ml *MultiLog) send(ctx context.Context, line LogLine) bool {
+	select {
+	case ml.lines <- line:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Sources returns the containers being followed
+func (ml *MultiLog) Sources() []LogSource {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	out := make([]LogSource, 0, len(ml.want))
+	for src := range ml.want {
+		out = append(out, src)
+	}
+	sortSources(out)
+	return out
+}
+
+// Stop detaches every stream. The MultiLog cannot be restarted.
+func (ml *MultiLog) Stop() {
+	ml.cancel()
+}
+
+// Wait returns a tea.Cmd that waits for the next batch of lines. Once the
+// MultiLog is stopped it reports the pane complete.
+func (ml *MultiLog) Wait(paneID int) tea.Cmd {
+	return func() tea.Msg {
+		var batch []LogLine
+		select {
+		case line := <-ml.lines:
+			batch = append(batch, line)
+		case <-ml.ctx.Done():
+			return PaneCompleteMsg{PaneID: paneID}
+		}
+
+		// Collect whatever else arrives shortly after
+		timeout := time.NewTimer(50 * time.Millisecond)
+		defer timeout.Stop()
+		for {
+			select {
+			case line := <-ml.lines:
+				batch = append(batch, line)
+			case <-timeout.C:
+				return MultiLogOutputMsg{PaneID: paneID, Lines: batch, NextCmd: ml.Wait(paneID)}
+			}
+		}
+	}
+}
+
+// sortSources orders sources by namespace, pod and container
+func sortSources(sources []LogSource) {
+	sort.Slice(sources, func(i, j int) bool {
+		a, b := sources[i], sources[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		return a.Container < b.Container
+	})
+}
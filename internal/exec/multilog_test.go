@@ -0,0 +1,59 @@
+package exec
+
+import (
+	"os/exec"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMultiLog_Sync(t *testing.T) {
+	echo, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo not available")
+	}
+	// echo stands in for kubectl, printing the arguments it was given
+	e := &Executor{kubectlPath: echo}
+	ml := e.NewMultiLog(10, false)
+	defer ml.Stop()
+
+	a := LogSource{Namespace: "prod", Pod: "web-1", Container: "app"}
+	b := LogSource{Namespace: "prod", Pod: "web-2", Container: "app"}
+
+	attached, detached := ml.Sync([]LogSource{b, a})
+	if !reflect.DeepEqual(attached, []LogSource{a, b}) || len(detached) != 0 {
+		t.Fatalf("Sync() = %v, %v, want both attached", attached, detached)
+	}
+
+	var got []string
+	for len(got) < 2 {
+		msg, ok := ml.Wait(1)().(MultiLogOutputMsg)
+		if !ok {
+			t.Fatal("Wait() did not return output")
+		}
+		for _, line := range msg.Lines {
+			got = append(got, line.Source.Tag()+" "+line.Text)
+		}
+	}
+	sort.Strings(got)
+	want := []string{
+		"web-1/app logs web-1 -n prod -c app -f --tail=10",
+		"web-2/app logs web-2 -n prod -c app -f --tail=10",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+
+	attached, detached = ml.Sync([]LogSource{a})
+	if len(attached) != 0 || !reflect.DeepEqual(detached, []LogSource{b}) {
+		t.Errorf("Sync() = %v, %v, want %v detached", attached, detached, b)
+	}
+	if got := ml.Sources(); !reflect.DeepEqual(got, []LogSource{a}) {
+		t.Errorf("Sources() = %v, want %v", got, []LogSource{a})
+	}
+
+	ml.Stop()
+	if _, ok := ml.Wait(1)().(PaneCompleteMsg); !ok {
+		t.Error("Wait() after Stop should report completion")
+	}
+}
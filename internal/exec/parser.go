@@ -3,6 +3,7 @@ package exec
 import (
 	"strings"
 
+	"github.com/tapcraft-io/purr/internal/shlex"
 	"github.com/tapcraft-io/purr/pkg/types"
 )
 
@@ -61,6 +62,27 @@ func (p *Parser) Parse(command string) *types.ParsedCommand {
 	for position < len(tokens) {
 		token := tokens[position]
 
+		// Everything after "--" is passed through to the container/plugin
+		if token == "--" {
+			cmd.Args = append(cmd.Args, tokens[position+1:]...)
+			break
+		}
+
+		// Handle --flag=value and -f=value
+		if name, value, ok := shlex.SplitFlag(token); ok {
+			flagName := strings.TrimLeft(name, "-")
+			if !strings.HasPrefix(name, "--") {
+				flagName = expandShortFlag(flagName)
+			}
+			if isBooleanFlag(flagName) {
+				cmd.BoolFlags[flagName] = value != "false"
+			} else {
+				setFlag(cmd, flagName, value)
+			}
+			position++
+			continue
+		}
+
 		// Handle flags
 		if strings.HasPrefix(token, "--") {
 			flagName := strings.TrimPrefix(token, "--")
@@ -74,17 +96,7 @@ func (p *Parser) Parse(command string) *types.ParsedCommand {
 
 			// Check if next token is the value
 			if position+1 < len(tokens) && !strings.HasPrefix(tokens[position+1], "-") {
-				flagValue := tokens[position+1]
-				cmd.Flags[flagName] = flagValue
-
-				// Store specific flags
-				switch flagName {
-				case "namespace":
-					cmd.Namespace = flagValue
-				case "filename":
-					cmd.Files = append(cmd.Files, flagValue)
-				}
-
+				setFlag(cmd, flagName, tokens[position+1])
 				position += 2
 				continue
 			} else {
@@ -110,18 +122,7 @@ func (p *Parser) Parse(command string) *types.ParsedCommand {
 
 			// Check if next token is the value
 			if position+1 < len(tokens) && !strings.HasPrefix(tokens[position+1], "-") {
-				flagValue := tokens[position+1]
-				fullFlag := expandShortFlag(flagName)
-				cmd.Flags[fullFlag] = flagValue
-
-				// Store specific flags
-				switch fullFlag {
-				case "namespace":
-					cmd.Namespace = flagValue
-				case "filename":
-					cmd.Files = append(cmd.Files, flagValue)
-				}
-
+				setFlag(cmd, expandShortFlag(flagName), tokens[position+1])
 				position += 2
 				continue
 			} else {
@@ -152,10 +153,24 @@ func (p *Parser) Parse(command string) *types.ParsedCommand {
 	return cmd
 }
 
-// tokenize splits a command string into tokens
+// setFlag records a flag value, tracking the flags the parser cares about
+func setFlag(cmd *types.ParsedCommand, name, value string) {
+	cmd.Flags[name] = value
+
+	// Store specific flags
+	switch name {
+	case "namespace":
+		cmd.Namespace = value
+	case "filename":
+		cmd.Files = append(cmd.Files, value)
+	}
+}
+
+// tokenize splits a command string into shell words. An unterminated quote
+// keeps the partial word so half-typed commands still parse.
 func tokenize(command string) []string {
-	// Simple tokenization - doesn't handle complex quoting
-	return strings.Fields(command)
+	tokens, _ := shlex.Fields(command)
+	return tokens
 }
 
 // isBooleanFlag checks if a flag is a boolean flag
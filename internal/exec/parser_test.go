@@ -228,6 +228,30 @@ func TestIsDestructive(t *testing.T) {
 		{"logs my-pod", false},
 		{"exec my-pod -- ls", false},
 		{"rollout restart deployment my-deploy", true},
+		{"kubectl delete pod my-pod", true},
+		{"kubectl get pods", false},
+		{"apply -f deployment.yaml --force=true", true},
+		{`exec my-pod -- sh -c "rm --force x"`, false},
+		{"exec my-pod -- kubectl delete --force", false},
+		{"rollout status deployment my-deploy", false},
+		{"rollout history deployment my-deploy", false},
+		{"rollout undo deployment my-deploy", true},
+		{"scale deployment my-deploy --replicas=0", true},
+		{"scale deployment my-deploy --replicas 0", true},
+		{"scale deployment my-deploy --replicas=3", false},
+		{"patch deployment my-deploy -p '{}'", true},
+		{"replace -f deployment.yaml", false},
+		{"replace --force -f deployment.yaml", true},
+		{"apply -f manifests/ --prune -l app=web", true},
+		{"taint node my-node key=value:NoSchedule", true},
+		{"label pod my-pod app=web", false},
+		{"label pod my-pod app=web --overwrite", true},
+		{"delete pod my-pod --dry-run=client", false},
+		{"kubectl -n prod delete pod my-pod", true},
+		{"kubectl --context prod delete pod my-pod", true},
+		{"!kubectl delete pod my-pod", true},
+		{"!kubectl get pods -o name | xargs kubectl delete", true},
+		{"!echo hello", false},
 	}
 
 	for _, tt := range tests {
@@ -308,3 +332,86 @@ func TestParser_CompletionNeeds(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_ParseQuoted(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name      string
+		input     string
+		wantFlags map[string]string
+		wantBool  map[string]bool
+		wantArgs  []string
+		wantNS    string
+	}{
+		{
+			name:      "Quoted selector",
+			input:     `get pods -l 'app in (a,b)'`,
+			wantFlags: map[string]string{"selector": "app in (a,b)"},
+		},
+		{
+			name:      "Flag with equals and quotes",
+			input:     `get pods --template='{{.metadata.name}}' -n=prod`,
+			wantFlags: map[string]string{"template": "{{.metadata.name}}", "namespace": "prod"},
+			wantNS:    "prod",
+		},
+		{
+			name:     "Boolean flag with equals",
+			input:    "get pods --watch=false -A",
+			wantBool: map[string]bool{"watch": false, "all-namespaces": true},
+		},
+		{
+			name:     "Double dash separator",
+			input:    `exec my-pod -n dev -- sh -c "echo hi"`,
+			wantArgs: []string{"sh", "-c", "echo hi"},
+			wantNS:   "dev",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parser.Parse(tt.input)
+			for k, v := range tt.wantFlags {
+				if result.Flags[k] != v {
+					t.Errorf("Flags[%s] = %q, want %q", k, result.Flags[k], v)
+				}
+			}
+			for k, v := range tt.wantBool {
+				if got, ok := result.BoolFlags[k]; !ok || got != v {
+					t.Errorf("BoolFlags[%s] = %v (set %v), want %v", k, got, ok, v)
+				}
+			}
+			if len(result.Args) != len(tt.wantArgs) {
+				t.Fatalf("Args = %q, want %q", result.Args, tt.wantArgs)
+			}
+			for i := range tt.wantArgs {
+				if result.Args[i] != tt.wantArgs[i] {
+					t.Errorf("Args[%d] = %q, want %q", i, result.Args[i], tt.wantArgs[i])
+				}
+			}
+			if result.Namespace != tt.wantNS {
+				t.Errorf("Namespace = %q, want %q", result.Namespace, tt.wantNS)
+			}
+		})
+	}
+}
+
+func TestParseCommandString(t *testing.T) {
+	args, err := parseCommandString(`kubectl exec pod -- sh -c "echo hi"`)
+	if err != nil {
+		t.Fatalf("parseCommandString() error = %v", err)
+	}
+	want := []string{"exec", "pod", "--", "sh", "-c", "echo hi"}
+	if len(args) != len(want) {
+		t.Fatalf("parseCommandString() = %q, want %q", args, want)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Errorf("args[%d] = %q, want %q", i, args[i], want[i])
+		}
+	}
+
+	if _, err := parseCommandString(`get pods -l 'app=web`); err == nil {
+		t.Error("parseCommandString() with unterminated quote should fail")
+	}
+}
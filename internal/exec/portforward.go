@@ -0,0 +1,476 @@
+package exec
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/internal/shlex"
+)
+
+// forwardRetryDelays space out the reconnects of a forward whose kubectl
+// keeps exiting (a local port in use, RBAC), so it is not respawned in a
+// tight loop. The last delay repeats.
+var forwardRetryDelays = []time.Duration{
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+}
+
+// forwardStableAfter is how long a forward has to stay up for its retry
+// delays to start over
+const forwardStableAfter = time.Minute
+
+// ForwardState is where a port-forward is in its life
+type ForwardState string
+
+const (
+	ForwardStarting ForwardState = "starting" // kubectl is connecting
+	ForwardActive   ForwardState = "active"   // the local ports are listening
+	ForwardWaiting  ForwardState = "waiting"  // for a ready pod, or to retry
+)
+
+// ForwardSpec is a port-forward as it was asked for
+type ForwardSpec struct {
+	Context   string   `json:"context,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+	Target    string   `json:"target"`          // pod name or kind/name
+	Ports     []string `json:"ports"`           // [LOCAL:]REMOTE mappings
+	Extra     []string `json:"extra,omitempty"` // flags passed through, such as --address
+}
+
+// portForwardValueFlags are the kubectl port-forward flags that take a
+// value
+var portForwardValueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true,
+	"--address": true, "--pod-running-timeout": true,
+}
+
+// ParsePortForwardCommand parses a "kubectl port-forward" command. ok is
+// false for any other command.
+func ParsePortForwardCommand(command string) (ForwardSpec, bool) {
+	args := commandArgs(command)
+	if len(args) == 0 || args[0] != "port-forward" {
+		return ForwardSpec{}, false
+	}
+
+	var spec ForwardSpec
+	var positional []string
+	rest := args[1:]
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := shlex.SplitFlag(arg)
+		if !hasValue {
+			name = arg
+			if portForwardValueFlags[name] && i+1 < len(rest) {
+				value = rest[i+1]
+				i++
+			}
+		}
+
+		switch name {
+		case "-n", "--namespace":
+			spec.Namespace = value
+		case "--context":
+			spec.Context = value
+		default:
+			if hasValue || !portForwardValueFlags[name] {
+				spec.Extra = append(spec.Extra, arg)
+			} else {
+				spec.Extra = append(spec.Extra, name, value)
+			}
+		}
+	}
+
+	if len(positional) > 0 {
+		spec.Target = positional[0]
+		spec.Ports = positional[1:]
+	}
+	return spec, true
+}
+
+// String returns the forward as a command line
+func (s ForwardSpec) String() string {
+	args := append([]string{"port-forward", s.Target}, s.Ports...)
+	if s.Namespace != "" {
+		args = append(args, "-n", s.Namespace)
+	}
+	return "kubectl " + shlex.Join(append(args, s.Extra...))
+}
+
+// args builds the kubectl arguments that forward ports on target. The
+// context is pinned, so switching contexts later leaves the forward where
+// it was.
+func (s ForwardSpec) args(target string, ports []string) []string {
+	args := append([]string{"port-forward", target}, ports...)
+	if s.Namespace != "" {
+		args = append(args, "--namespace="+s.Namespace)
+	}
+	if s.Context != "" {
+		args = append(args, "--context="+s.Context)
+	}
+	return append(args, s.Extra...)
+}
+
+// ForwardResolver picks the pod a forward reaches and its port mappings
+// on that pod, keeping the pod current while it runs. An empty pod leaves
+// the choice to kubectl.
+type ForwardResolver func(spec ForwardSpec, current string) (pod string, ports []string, err error)
+
+// Forward is a snapshot of a port-forward
+type Forward struct {
+	ID        int
+	Spec      ForwardSpec
+	State     ForwardState
+	Pod       string    // the pod forwarded to, "" until one is picked
+	Listening []string  // "8080 -> 80", as kubectl reports them
+	Restarts  int       // how often kubectl was started again
+	Err       string    // why the forward last went down
+	Since     time.Time // when State was entered
+}
+
+// ForwardsChangedMsg reports that a forward started, stopped or changed
+// state
+type ForwardsChangedMsg struct {
+	NextCmd tea.Cmd // Call this to keep watching
+}
+
+// ForwardManager runs port-forwards in the background, one supervised
+// kubectl port-forward each. A forward whose kubectl exits is reconnected,
+// and with a resolver it is pinned to a pod and moved to another when Sync
+// finds its pod gone.
+type ForwardManager struct {
+	executor *Executor
+	resolve  ForwardResolver
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	changes chan struct{}
+
+	mu       sync.Mutex
+	nextID   int
+	forwards map[int]*forward
+	running  sync.WaitGroup
+}
+
+// forward is a running port-forward
+type forward struct {
+	Forward
+	cancel context.CancelFunc // stops the forward
+	kill   context.CancelFunc // ends the running kubectl, nil between runs
+	moved  bool               // kubectl was killed to move pods, reconnect at once
+	noPod  bool               // waiting because no pod is ready
+	wake   chan struct{}      // cuts a retry delay short
+}
+
+// NewForwardManager creates a manager with no forwards
+func (e *Executor) NewForwardManager() *ForwardManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ForwardManager{
+		executor: e,
+		ctx:      ctx,
+		cancel:   cancel,
+		changes:  make(chan struct{}, 1),
+		forwards: make(map[int]*forward),
+	}
+}
+
+// SetResolver sets how forwards pick their pod. Without one kubectl picks,
+// and a forward only reconnects once kubectl gives up on its pod.
+func (fm *ForwardManager) SetResolver(resolve ForwardResolver) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.resolve = resolve
+}
+
+// Start runs a forward until it is stopped and returns its ID. The caller
+// fills in the session's context and namespace where spec names none, so
+// that a forward keeps them after the session moves on.
+func (fm *ForwardManager) Start(spec ForwardSpec) int {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.nextID++
+	ctx, cancel := context.WithCancel(fm.ctx)
+	f := &forward{
+		Forward: Forward{ID: fm.nextID, Spec: spec, State: ForwardStarting, Since: time.Now()},
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+	}
+	fm.forwards[f.ID] = f
+	fm.running.Add(1)
+	go func() {
+		defer fm.running.Done()
+		fm.run(ctx, f)
+	}()
+	fm.notify()
+	return f.ID
+}
+
+// run keeps a forward connected until it is stopped
+func (fm *ForwardManager) run(ctx context.Context, f *forward) {
+	for attempt := 0; ; attempt++ {
+		started := time.Now()
+		ran, err := fm.connect(ctx, f)
+		if ctx.Err() != nil {
+			return
+		}
+
+		fm.mu.Lock()
+		moved := f.moved
+		f.kill, f.moved = nil, false
+		f.noPod = !ran
+		f.State, f.Since, f.Listening = ForwardWaiting, time.Now(), nil
+		if ran {
+			f.Restarts++
+		}
+		if err != nil && !moved {
+			f.Err = err.Error()
+		}
+		fm.mu.Unlock()
+		fm.notify()
+
+		if moved {
+			attempt = -1
+			continue
+		}
+		if time.Since(started) >= forwardStableAfter {
+			attempt = 0
+		}
+		select {
+		case <-time.After(forwardRetryDelays[min(attempt, len(forwardRetryDelays)-1)]):
+		case <-f.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// connect runs kubectl port-forward once, to the pod the resolver picks,
+// until it exits. ran is false when no pod could be picked.
+func (fm *ForwardManager) connect(ctx context.Context, f *forward) (ran bool, err error) {
+	fm.mu.Lock()
+	spec, current, resolve := f.Spec, f.Pod, fm.resolve
+	fm.mu.Unlock()
+
+	target, ports := spec.Target, spec.Ports
+	var pod string
+	if resolve != nil {
+		if pod, ports, err = resolve(spec, current); err != nil {
+			return false, err
+		}
+		if pod != "" {
+			target = "pod/" + pod
+		}
+	}
+
+	runCtx, kill := context.WithCancel(ctx)
+	defer kill()
+	fm.mu.Lock()
+	f.Pod, f.kill = pod, kill
+	f.State, f.Since = ForwardStarting, time.Now()
+	fm.mu.Unlock()
+	fm.notify()
+
+	cmd := fm.executor.command(runCtx, spec.args(target, ports))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return true, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return true, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	last := ""
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if listening, ok := parseForwarding(line); ok {
+			fm.listening(f, listening)
+			continue
+		}
+		if line != "" && !strings.HasPrefix(line, "Handling connection for") {
+			last = line
+		}
+	}
+	_, _ = io.Copy(io.Discard, stdout)
+	err = cmd.Wait()
+	switch {
+	case last != "":
+		return true, errors.New(last)
+	case err != nil:
+		return true, err
+	}
+	return true, errors.New("kubectl port-forward exited")
+}
+
+// parseForwarding parses kubectl's "Forwarding from 127.0.0.1:8080 -> 80"
+// into "8080 -> 80"
+func parseForwarding(line string) (string, bool) {
+	rest, ok := strings.CutPrefix(line, "Forwarding from ")
+	if !ok {
+		return "", false
+	}
+	addr, remote, ok := strings.Cut(rest, " -> ")
+	if !ok {
+		return "", false
+	}
+	return addr[strings.LastIndex(addr, ":")+1:] + " -> " + remote, true
+}
+
+// listening records a port kubectl listens on and marks the forward
+// active. kubectl reports each port for IPv4 and IPv6.
+func (fm *ForwardManager) listening(f *forward, port string) {
+	fm.mu.Lock()
+	for _, p := range f.Listening {
+		if p == port {
+			fm.mu.Unlock()
+			return
+		}
+	}
+	f.Listening = append(f.Listening, port)
+	if f.State != ForwardActive {
+		f.State, f.Since, f.Err = ForwardActive, time.Now(), ""
+	}
+	fm.mu.Unlock()
+	fm.notify()
+}
+
+// Sync moves forwards whose pod went away to another pod, and retries
+// forwards waiting for a pod at once when one is ready. Call it when the
+// cached pods change.
+func (fm *ForwardManager) Sync() {
+	fm.mu.Lock()
+	resolve := fm.resolve
+	forwards := make([]*forward, 0, len(fm.forwards))
+	for _, f := range fm.forwards {
+		forwards = append(forwards, f)
+	}
+	fm.mu.Unlock()
+	if resolve == nil {
+		return
+	}
+
+	for _, f := range forwards {
+		fm.mu.Lock()
+		spec, current, running, noPod := f.Spec, f.Pod, f.kill != nil, f.noPod
+		fm.mu.Unlock()
+		if !running && !noPod {
+			continue
+		}
+
+		pod, _, err := resolve(spec, current)
+		fm.mu.Lock()
+		switch {
+		case running && current != "" && f.kill != nil && f.Pod == current && (err != nil || (pod != "" && pod != current)):
+			f.moved = true
+			f.Err = "pod " + current + " went away"
+			f.kill()
+		case !running && err == nil:
+			select {
+			case f.wake <- struct{}{}:
+			default:
+			}
+		}
+		fm.mu.Unlock()
+	}
+}
+
+// Stop ends a forward and forgets it
+func (fm *ForwardManager) Stop(id int) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if f, ok := fm.forwards[id]; ok {
+		f.cancel()
+		delete(fm.forwards, id)
+		fm.notify()
+	}
+}
+
+// Close ends every forward and waits for their kubectl processes to exit,
+// so none outlive purr. The manager cannot be used after.
+func (fm *ForwardManager) Close() {
+	fm.cancel()
+	fm.running.Wait()
+}
+
+// List returns the forwards in the order they were started
+func (fm *ForwardManager) List() []Forward {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	out := make([]Forward, 0, len(fm.forwards))
+	for _, f := range fm.forwards {
+		snap := f.Forward
+		snap.Listening = append([]string(nil), f.Listening...)
+		out = append(out, snap)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// notify signals a change without blocking; changes arriving before the
+// last one was seen coalesce
+func (fm *ForwardManager) notify() {
+	select {
+	case fm.changes <- struct{}{}:
+	default:
+	}
+}
+
+// Wait returns a tea.Cmd that waits for the forwards to change. It
+// returns nil once the manager is closed.
+func (fm *ForwardManager) Wait() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case <-fm.changes:
+			if fm.ctx.Err() != nil {
+				return nil
+			}
+			return ForwardsChangedMsg{NextCmd: fm.Wait()}
+		case <-fm.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// LoadForwards reads the forwards saved to path. A missing file holds
+// none.
+func LoadForwards(path string) ([]ForwardSpec, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var specs []ForwardSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return specs, nil
+}
+
+// SaveForwards writes the forwards to path, to start them again next time
+func SaveForwards(path string, specs []ForwardSpec) error {
+	data, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,189 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParsePortForwardCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		want  ForwardSpec
+	}{
+		{"kubectl port-forward web 8080:80", ForwardSpec{Target: "web", Ports: []string{"8080:80"}}},
+		{"port-forward svc/api 8080 9090:metrics -n prod", ForwardSpec{Namespace: "prod", Target: "svc/api", Ports: []string{"8080", "9090:metrics"}}},
+		{"port-forward --context=kind deploy/api :80 --address 0.0.0.0", ForwardSpec{Context: "kind", Target: "deploy/api", Ports: []string{":80"}, Extra: []string{"--address", "0.0.0.0"}}},
+		{"port-forward --namespace=prod --pod-running-timeout=2m db-0 5432", ForwardSpec{Namespace: "prod", Target: "db-0", Ports: []string{"5432"}, Extra: []string{"--pod-running-timeout=2m"}}},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParsePortForwardCommand(tt.input)
+		if !ok {
+			t.Errorf("ParsePortForwardCommand(%s) not ok", tt.input)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePortForwardCommand(%s) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	if _, ok := ParsePortForwardCommand("kubectl get pods"); ok {
+		t.Error("ParsePortForwardCommand(get pods) should not be ok")
+	}
+}
+
+func TestParseForwarding(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{"Forwarding from 127.0.0.1:8080 -> 80", "8080 -> 80", true},
+		{"Forwarding from [::1]:8080 -> 80", "8080 -> 80", true},
+		{"Handling connection for 8080", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseForwarding(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseForwarding(%q) = %q, %v, want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// fakeKubectl writes a kubectl stand-in that logs its arguments and
+// forwards until it is killed
+func fakeKubectl(t *testing.T) (path, log string) {
+	t.Helper()
+	sh, err := os.Stat("/bin/sh")
+	if err != nil || sh.IsDir() {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	path = filepath.Join(dir, "kubectl")
+	log = filepath.Join(dir, "args")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$@" >> %q
+echo "Forwarding from 127.0.0.1:8080 -> 80"
+echo "Forwarding from [::1]:8080 -> 80"
+exec sleep 60
+`, log)
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path, log
+}
+
+// waitForward waits for the only forward to get into a state
+func waitForward(t *testing.T, fm *ForwardManager, ok func(Forward) bool) Forward {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if list := fm.List(); len(list) == 1 && ok(list[0]) {
+			return list[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("List() = %+v, never got there", fm.List())
+	return Forward{}
+}
+
+func TestForwardManager(t *testing.T) {
+	kubectl, log := fakeKubectl(t)
+	e := &Executor{kubectlPath: kubectl}
+	fm := e.NewForwardManager()
+	defer fm.Close()
+
+	// The ready pods, as the cache would have them
+	var mu sync.Mutex
+	ready := []string{"web-1", "web-2"}
+	fm.SetResolver(func(spec ForwardSpec, current string) (string, []string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, pod := range ready {
+			if pod == current {
+				return pod, spec.Ports, nil
+			}
+		}
+		if len(ready) == 0 {
+			return "", nil, fmt.Errorf("no ready pod for %s", spec.Target)
+		}
+		return ready[0], spec.Ports, nil
+	})
+	setReady := func(pods ...string) {
+		mu.Lock()
+		ready = pods
+		mu.Unlock()
+		fm.Sync()
+	}
+
+	id := fm.Start(ForwardSpec{Context: "kind", Namespace: "prod", Target: "deploy/web", Ports: []string{"8080:80"}})
+	if _, ok := fm.Wait()().(ForwardsChangedMsg); !ok {
+		t.Error("Wait() did not report the forward starting")
+	}
+	active := func(pod string) func(Forward) bool {
+		return func(f Forward) bool { return f.State == ForwardActive && f.Pod == pod }
+	}
+	f := waitForward(t, fm, active("web-1"))
+	if f.ID != id || !reflect.DeepEqual(f.Listening, []string{"8080 -> 80"}) {
+		t.Errorf("forward = %+v, want listening on 8080 -> 80", f)
+	}
+
+	// A new pod does not move the forward, its pod going away does
+	setReady("web-0", "web-1")
+	setReady("web-2")
+	f = waitForward(t, fm, active("web-2"))
+	if f.Restarts != 1 {
+		t.Errorf("Restarts = %d, want 1", f.Restarts)
+	}
+
+	// Without a ready pod the forward waits for one
+	setReady()
+	waitForward(t, fm, func(f Forward) bool { return f.State == ForwardWaiting && strings.HasPrefix(f.Err, "no ready pod") })
+	setReady("web-3")
+	waitForward(t, fm, active("web-3"))
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "port-forward pod/web-1 8080:80 --namespace=prod --context=kind\n" +
+		"port-forward pod/web-2 8080:80 --namespace=prod --context=kind\n" +
+		"port-forward pod/web-3 8080:80 --namespace=prod --context=kind\n"
+	if string(data) != want {
+		t.Errorf("kubectl ran with\n%s\nwant\n%s", data, want)
+	}
+
+	fm.Stop(id)
+	if list := fm.List(); len(list) != 0 {
+		t.Errorf("List() after Stop = %+v, want none", list)
+	}
+	fm.Close()
+	if msg := fm.Wait()(); msg != nil {
+		t.Errorf("Wait() after Close = %T, want nil", msg)
+	}
+}
+
+func TestSaveForwards(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forwards.json")
+	if specs, err := LoadForwards(path); err != nil || specs != nil {
+		t.Errorf("LoadForwards(missing) = %v, %v, want none", specs, err)
+	}
+
+	specs := []ForwardSpec{
+		{Context: "kind", Namespace: "prod", Target: "svc/api", Ports: []string{"8080:80"}},
+		{Target: "db-0", Ports: []string{"5432"}, Extra: []string{"--address", "0.0.0.0"}},
+	}
+	if err := SaveForwards(path, specs); err != nil {
+		t.Fatalf("SaveForwards() error = %v", err)
+	}
+	got, err := LoadForwards(path)
+	if err != nil || !reflect.DeepEqual(got, specs) {
+		t.Errorf("LoadForwards() = %+v, %v, want %+v", got, err, specs)
+	}
+}
@@ -0,0 +1,119 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// previewVerbs are the verbs that accept --dry-run=server, mapped to
+// whether they also accept -o name
+var previewVerbs = map[string]bool{
+	"delete": true, "scale": true, "patch": true, "label": true, "annotate": true,
+	"taint": true, "apply": true, "replace": true, "create": true, "expose": true,
+	"autoscale": true, "run": true,
+	"rollout restart": true, "rollout undo": true, "rollout pause": true, "rollout resume": true,
+	"set env": true, "set image": true, "set resources": true, "set selector": true,
+	"set serviceaccount": true, "set subject": true,
+	"cordon": false, "uncordon": false, "drain": false,
+}
+
+// PreviewArgs returns the arguments that run a kubectl command as a
+// server-side dry run printing the objects it would touch. Shell commands
+// and verbs without a dry run cannot be previewed.
+func PreviewArgs(command string) ([]string, bool) {
+	trimmed := strings.TrimSpace(command)
+	if strings.HasPrefix(trimmed, "!") {
+		return nil, false
+	}
+	a := Assess(trimmed)
+	byName, ok := previewVerbs[a.Verb]
+	if !ok {
+		return nil, false
+	}
+
+	// Flags go before "--", after which arguments belong to the container
+	args := commandArgs(trimmed)
+	var kept, rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = args[i:]
+			break
+		}
+		if name, _, _ := strings.Cut(arg, "="); name == "--dry-run" {
+			continue
+		}
+		if byName && (arg == "-o" || arg == "--output") {
+			i++ // and its value
+			continue
+		}
+		if byName && (strings.HasPrefix(arg, "-o") && !strings.HasPrefix(arg, "--") || strings.HasPrefix(arg, "--output=")) {
+			continue
+		}
+		kept = append(kept, arg)
+	}
+
+	kept = append(kept, "--dry-run=server")
+	if byName {
+		kept = append(kept, "-o", "name")
+	}
+	return append(kept, rest...), true
+}
+
+// Preview runs a command as a server-side dry run and returns the objects
+// it would touch, as kind/name
+func (e *Executor) Preview(ctx context.Context, command string) ([]string, error) {
+	args, ok := PreviewArgs(command)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be dry run", GetCommandVerb(command))
+	}
+
+	result := e.Execute(ctx, args)
+	objects := previewObjects(result.Stdout)
+	if result.Error != nil {
+		return objects, kubectlError(result)
+	}
+	return objects, nil
+}
+
+// previewObjects picks the object names out of dry-run output. With -o
+// name every line is one; verbs without it print "node/x cordoned (server
+// dry run)".
+func previewObjects(output string) []string {
+	var objects []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !strings.Contains(fields[0], "/") || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		objects = append(objects, fields[0])
+	}
+	return objects
+}
+
+// Operands returns the positional arguments after a command's verb, such
+// as the names of the objects it acts on
+func Operands(command string) []string {
+	trimmed := strings.TrimSpace(command)
+	if strings.HasPrefix(trimmed, "!") {
+		return nil
+	}
+	positional, _ := splitArgs(commandArgs(trimmed))
+	if len(positional) == 0 {
+		return nil
+	}
+	skip := 1
+	if subcommandVerbs[positional[0]] && len(positional) > 1 {
+		skip = 2
+	}
+	return positional[skip:]
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,57 @@
+package exec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPreviewArgs(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		ok      bool
+	}{
+		{"delete pods -l app=web", []string{"delete", "pods", "-l", "app=web", "--dry-run=server", "-o", "name"}, true},
+		{"kubectl delete pod web -o wide", []string{"delete", "pod", "web", "--dry-run=server", "-o", "name"}, true},
+		{"scale deploy/web --replicas=0 -oyaml", []string{"scale", "deploy/web", "--replicas=0", "--dry-run=server", "-o", "name"}, true},
+		{"rollout restart deploy/web --output=json", []string{"rollout", "restart", "deploy/web", "--dry-run=server", "-o", "name"}, true},
+		{"drain node-3 --ignore-daemonsets", []string{"drain", "node-3", "--ignore-daemonsets", "--dry-run=server"}, true},
+		{"run debug --image=busybox -- sh -o x", []string{"run", "debug", "--image=busybox", "--dry-run=server", "-o", "name", "--", "sh", "-o", "x"}, true},
+		{"exec -it web -- sh", nil, false},
+		{"get pods", nil, false},
+		{"!kubectl delete pod web", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := PreviewArgs(tt.command)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("PreviewArgs(%s) = %q, %v, want %q, %v", tt.command, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPreviewObjects(t *testing.T) {
+	output := "pod/web-a\npod/web-b\n\nnode/node-3 cordoned (server dry run)\nevicting pod prod/web-a (server dry run)\npod/web-a\n"
+	want := []string{"pod/web-a", "pod/web-b", "node/node-3"}
+	if got := previewObjects(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("previewObjects() = %q, want %q", got, want)
+	}
+}
+
+func TestOperands(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"drain node-3 --ignore-daemonsets", []string{"node-3"}},
+		{"kubectl -n prod delete pod web db", []string{"pod", "web", "db"}},
+		{"rollout restart deploy/web", []string{"deploy/web"}},
+		{"!kubectl drain node-3", nil},
+	}
+
+	for _, tt := range tests {
+		if got := Operands(tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Operands(%s) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package exec
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tapcraft-io/purr/internal/shlex"
+)
+
+// Risk grades what a command can do to the cluster
+type Risk int
+
+const (
+	RiskNone        Risk = iota // only reads
+	RiskMutating                // changes objects
+	RiskDestructive             // deletes objects, or changes them in ways that are hard to undo
+)
+
+// String returns the risk's name as used in policy rules
+func (r Risk) String() string {
+	switch r {
+	case RiskMutating:
+		return "mutating"
+	case RiskDestructive:
+		return "destructive"
+	}
+	return "none"
+}
+
+// Assessment is what Assess found out about a command
+type Assessment struct {
+	Verb          string // e.g. "delete" or "rollout restart"; "" if no kubectl verb was found
+	Risk          Risk
+	Reason        string // why the command is risky, for confirmation prompts
+	Namespace     string // from -n/--namespace, "" if not given
+	AllNamespaces bool
+	Context       string // from --context, "" if not given
+}
+
+// subcommandVerbs are the verbs whose first argument is a subcommand that
+// decides what they do
+var subcommandVerbs = map[string]bool{
+	"rollout": true, "set": true, "certificate": true, "config": true, "auth": true,
+}
+
+// mutatingVerbs change objects without necessarily destroying anything
+var mutatingVerbs = map[string]bool{
+	"create": true, "apply": true, "edit": true, "replace": true, "scale": true,
+	"autoscale": true, "label": true, "annotate": true, "expose": true, "run": true,
+	"uncordon": true, "debug": true, "rollout pause": true, "rollout resume": true,
+	"certificate approve": true, "certificate deny": true,
+	"set env": true, "set image": true, "set resources": true, "set selector": true,
+	"set serviceaccount": true, "set subject": true,
+}
+
+// globalValueFlags are the kubectl flags that take their value as the
+// next argument, so it is not mistaken for the verb
+var globalValueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true, "--cluster": true,
+	"--user": true, "--kubeconfig": true, "-s": true, "--server": true,
+	"--token": true, "--as": true, "--as-group": true, "--as-uid": true,
+	"--request-timeout": true, "--cache-dir": true, "-v": true, "--v": true,
+	"-l": true, "--selector": true, "-o": true, "--output": true,
+	"-f": true, "--filename": true, "-k": true, "--kustomize": true,
+	"-c": true, "--container": true, "--field-selector": true,
+	"--replicas": true, "--grace-period": true, "--timeout": true,
+	"-p": true, "--patch": true, "--patch-file": true, "--type": true,
+	"--field-manager": true, "--subresource": true,
+}
+
+// IsDestructive checks if a command is destructive (requires confirmation)
+func IsDestructive(command string) bool {
+	return Assess(command).Risk == RiskDestructive
+}
+
+// Assess grades a command. Shell commands ("!...") are graded by the
+// riskiest kubectl invocation they contain.
+func Assess(command string) Assessment {
+	trimmed := strings.TrimSpace(command)
+	if shell, ok := strings.CutPrefix(trimmed, "!"); ok {
+		return assessShell(shell)
+	}
+	return assessArgs(commandArgs(trimmed))
+}
+
+// shellSeparators split a shell command line into simple commands
+var shellSeparators = regexp.MustCompile("&&|\\|\\||[;|&\n`]|\\$\\(")
+
+// assessShell grades every kubectl invocation in a shell command line.
+// A kubectl anywhere in a simple command counts, which also catches
+// "xargs kubectl delete" and "sudo kubectl ...".
+func assessShell(shell string) Assessment {
+	var worst Assessment
+	for _, part := range shellSeparators.Split(shell, -1) {
+		words, _ := shlex.Fields(part)
+		for i, w := range words {
+			if filepath.Base(w) != "kubectl" {
+				continue
+			}
+			if a := assessArgs(words[i+1:]); a.Risk > worst.Risk || worst.Verb == "" {
+				worst = a
+			}
+			break
+		}
+	}
+	return worst
+}
+
+// assessArgs grades kubectl arguments, without "kubectl" itself
+func assessArgs(args []string) Assessment {
+	var a Assessment
+	positional, flags := splitArgs(args)
+	a.Namespace = flags["-n"]
+	if ns, ok := flags["--namespace"]; ok {
+		a.Namespace = ns
+	}
+	for _, name := range []string{"-A", "--all-namespaces"} {
+		if v, ok := flags[name]; ok {
+			a.AllNamespaces = v != "false"
+		}
+	}
+	a.Context = flags["--context"]
+	if len(positional) == 0 {
+		return a
+	}
+
+	a.Verb = positional[0]
+	if subcommandVerbs[a.Verb] && len(positional) > 1 {
+		a.Verb += " " + positional[1]
+	}
+
+	if dryRun, ok := flags["--dry-run"]; ok && dryRun != "none" && dryRun != "false" {
+		return a
+	}
+	a.Risk, a.Reason = classify(a.Verb, flags)
+	if a.Risk == RiskDestructive && a.AllNamespaces {
+		a.Reason += " in every namespace"
+	}
+	return a
+}
+
+// splitArgs separates kubectl arguments into positional arguments and
+// flags, with "-nfoo" filed under "-n". Arguments after "--" belong to
+// another program and are ignored.
+func splitArgs(args []string) (positional []string, flags map[string]string) {
+	flags = make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := shlex.SplitFlag(arg)
+		if !hasValue {
+			name = arg
+			if strings.HasPrefix(arg, "-n") && len(arg) > 2 && !strings.HasPrefix(arg, "--") {
+				name, value = "-n", arg[2:]
+			} else if globalValueFlags[name] && i+1 < len(args) {
+				value = args[i+1]
+				i++
+			}
+		}
+		flags[name] = value
+	}
+	return positional, flags
+}
+
+// classify grades a verb and the flags it was given
+func classify(verb string, flags map[string]string) (Risk, string) {
+	isSet := func(name string) bool {
+		v, ok := flags[name]
+		return ok && v != "false"
+	}
+
+	switch verb {
+	case "delete":
+		if isSet("--all") {
+			return RiskDestructive, "deletes every resource of the type"
+		}
+		return RiskDestructive, "deletes resources"
+	case "drain":
+		return RiskDestructive, "evicts every pod from the node"
+	case "cordon":
+		return RiskDestructive, "stops pods being scheduled on the node"
+	case "taint":
+		return RiskDestructive, "changes which pods the node accepts, and can evict running ones"
+	case "patch":
+		return RiskDestructive, "patches live objects in place"
+	case "rollout restart":
+		return RiskDestructive, "restarts every pod of the workload"
+	case "rollout undo":
+		return RiskDestructive, "rolls the workload back to an earlier revision"
+	case "scale":
+		if n, err := strconv.Atoi(flags["--replicas"]); err == nil && n == 0 {
+			return RiskDestructive, "scales to zero replicas"
+		}
+	case "apply":
+		if isSet("--prune") {
+			return RiskDestructive, "prunes resources missing from the manifests"
+		}
+	case "replace":
+		if isSet("--force") {
+			return RiskDestructive, "deletes and recreates resources"
+		}
+	case "label", "annotate":
+		if isSet("--overwrite") {
+			return RiskDestructive, "overwrites existing " + verb + "s"
+		}
+	}
+
+	if isSet("--force") {
+		return RiskDestructive, "--force skips safety checks"
+	}
+	if mutatingVerbs[verb] {
+		return RiskMutating, "changes resources"
+	}
+	return RiskNone, ""
+}
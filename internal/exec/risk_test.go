@@ -0,0 +1,27 @@
+package exec
+
+import "testing"
+
+func TestAssess(t *testing.T) {
+	tests := []struct {
+		command string
+		want    Assessment
+	}{
+		{"get pods", Assessment{Verb: "get"}},
+		{"kubectl -n prod delete pod web", Assessment{Verb: "delete", Risk: RiskDestructive, Reason: "deletes resources", Namespace: "prod"}},
+		{"delete pods --all -A", Assessment{Verb: "delete", Risk: RiskDestructive, Reason: "deletes every resource of the type in every namespace", AllNamespaces: true}},
+		{"--context=prod-eu rollout restart deploy/api -nshop", Assessment{Verb: "rollout restart", Risk: RiskDestructive, Reason: "restarts every pod of the workload", Namespace: "shop", Context: "prod-eu"}},
+		{"rollout status deploy/api", Assessment{Verb: "rollout status"}},
+		{"apply -f app.yaml", Assessment{Verb: "apply", Risk: RiskMutating, Reason: "changes resources"}},
+		{"apply -f app.yaml --force", Assessment{Verb: "apply", Risk: RiskDestructive, Reason: "--force skips safety checks"}},
+		{"set image deploy/api app=api:v2 --namespace prod", Assessment{Verb: "set image", Risk: RiskMutating, Reason: "changes resources", Namespace: "prod"}},
+		{"!kubectl get pods && kubectl delete pod web -n prod", Assessment{Verb: "delete", Risk: RiskDestructive, Reason: "deletes resources", Namespace: "prod"}},
+		{"!ls -la", Assessment{}},
+	}
+
+	for _, tt := range tests {
+		if got := Assess(tt.command); got != tt.want {
+			t.Errorf("Assess(%s) = %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+}
@@ -1,29 +1,65 @@
+// Package history keeps the commands run in purr. Entries are appended to a
+// JSONL file, one JSON object per line, under an exclusive file lock, so
+// several purr sessions can share one history without losing entries.
 package history
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/sahilm/fuzzy"
+	"github.com/tapcraft-io/purr/internal/redact"
 	"github.com/tapcraft-io/purr/pkg/types"
 )
 
+// maxOutputSnapshot is how much of a command's output an entry keeps
+const maxOutputSnapshot = 2048
+
+// fileMode keeps history files readable by their owner only, as commands
+// and their output can carry secrets redaction does not know about
+const fileMode = 0600
+
 // History manages command history
 type History struct {
-	commands []types.HistoryEntry
+	commands []types.HistoryEntry // newest first
 	maxSize  int
 	filepath string
+	redactor *redact.Redactor
 	mu       sync.RWMutex
+
+	// writeErr is the first failed append, reported by Save
+	writeErr error
 }
 
-// NewHistory creates a new history manager
+// NewHistory creates a new history manager. A history.json file written by
+// earlier versions, as one JSON array, is converted to JSONL: in place if
+// it is the file given, or into the new file if that is history.jsonl and
+// does not exist yet. Secrets are redacted with the built-in patterns
+// until SetRedactor says otherwise.
 func NewHistory(maxSize int, filepath string) (*History, error) {
 	h := &History{
 		commands: make([]types.HistoryEntry, 0, maxSize),
 		maxSize:  maxSize,
 		filepath: filepath,
+		redactor: redact.Builtin(),
+	}
+
+	if err := h.migrateLegacy(); err != nil {
+		return nil, err
+	}
+	if err := h.scrub(); err != nil {
+		return nil, err
 	}
 
 	// Try to load existing history
@@ -36,17 +72,42 @@ func NewHistory(maxSize int, filepath string) (*History, error) {
 
 // Add adds a command to history
 func (h *History) Add(cmd string, success bool, ctx, ns string) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	entry := types.HistoryEntry{
+	exitCode := 0
+	if !success {
+		exitCode = 1
+	}
+	h.AddEntry(types.HistoryEntry{
 		Command:   cmd,
-		Timestamp: time.Now(),
 		Success:   success,
+		ExitCode:  exitCode,
 		Context:   ctx,
 		Namespace: ns,
+	})
+}
+
+// AddEntry adds an entry to history and appends it to the history file.
+// The entry is stamped with the current time and working directory if it
+// has none, and its Output is cut down to a snapshot with a digest of the
+// whole, unless an OutputRecorder did that already.
+func (h *History) AddEntry(entry types.HistoryEntry) {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	if entry.Cwd == "" {
+		entry.Cwd, _ = os.Getwd()
+	}
+	h.mu.RLock()
+	entry = redactEntry(h.redactor, entry)
+	h.mu.RUnlock()
+	if entry.Output != "" && entry.OutputDigest == "" {
+		sum := sha256.Sum256([]byte(entry.Output))
+		entry.OutputDigest = hex.EncodeToString(sum[:])
+		entry.Output = snapshot(entry.Output)
 	}
 
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	// Add to beginning
 	h.commands = append([]types.HistoryEntry{entry}, h.commands...)
 
@@ -54,6 +115,88 @@ func (h *History) Add(cmd string, success bool, ctx, ns string) {
 	if len(h.commands) > h.maxSize {
 		h.commands = h.commands[:h.maxSize]
 	}
+
+	if err := h.appendToFile(entry); err != nil && h.writeErr == nil {
+		h.writeErr = err
+	}
+}
+
+// SetRedactor sets how secrets are masked before entries are stored, and
+// masks what is already stored with it
+func (h *History) SetRedactor(r *redact.Redactor) error {
+	h.mu.Lock()
+	h.redactor = r
+	for i, entry := range h.commands {
+		h.commands[i] = redactEntry(r, entry)
+	}
+	h.mu.Unlock()
+	return h.scrub()
+}
+
+// scrub redacts the entries in the history file, rewriting it only if
+// that changed any, and keeps the file and a legacy history.json beside it
+// private
+func (h *History) scrub() error {
+	if h.filepath == "" {
+		return nil
+	}
+	for _, path := range []string{h.filepath, h.legacyPath()} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0077 != 0 {
+			if err := os.Chmod(path, fileMode); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := os.Stat(h.filepath); err != nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	r := h.redactor
+	h.mu.RUnlock()
+	return h.rewriteFile(func(entries []types.HistoryEntry) []types.HistoryEntry {
+		changed := false
+		for i, entry := range entries {
+			if redacted := redactEntry(r, entry); redacted != entry {
+				entries[i] = redacted
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		return entries
+	})
+}
+
+// redactEntry masks the secrets in what an entry recorded
+func redactEntry(r *redact.Redactor, entry types.HistoryEntry) types.HistoryEntry {
+	entry.Command = r.String(entry.Command)
+	entry.Output = r.String(entry.Output)
+	entry.Diff = r.String(entry.Diff)
+	return entry
+}
+
+// snapshot keeps the start of a command's output, cut at a line break
+func snapshot(output string) string {
+	return cutSnapshot(output, len(output))
+}
+
+// cutSnapshot makes a snapshot of total bytes of output from their start,
+// which holds at least maxOutputSnapshot of them
+func cutSnapshot(start string, total int) string {
+	if total <= maxOutputSnapshot {
+		return start
+	}
+	cut := start[:maxOutputSnapshot]
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i+1]
+	}
+	cut = strings.ToValidUTF8(cut, "")
+	return cut + fmt.Sprintf("[... %d more bytes]\n", total-len(cut))
 }
 
 // Get returns the most recent n commands
@@ -131,7 +274,8 @@ func (h *History) Filter(ctx, ns string, successOnly bool) []types.HistoryEntry
 	return result
 }
 
-// Delete removes a command from history by index
+// Delete removes a command from history by index, in this session and
+// in the history file
 func (h *History) Delete(index int) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -140,33 +284,69 @@ func (h *History) Delete(index int) {
 		return
 	}
 
+	deleted := h.commands[index]
 	h.commands = append(h.commands[:index], h.commands[index+1:]...)
+	_ = h.rewriteFile(func(entries []types.HistoryEntry) []types.HistoryEntry {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Command == deleted.Command && entries[i].Timestamp.Equal(deleted.Timestamp) {
+				return append(entries[:i], entries[i+1:]...)
+			}
+		}
+		return entries
+	})
 }
 
-// Save persists history to disk
+// Save reports an append that failed since the last Save, and compacts
+// the history file once it holds more than twice the maximum number of
+// entries. Entries are written as they are added, so nothing is lost
+// without it.
 func (h *History) Save() error {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-	data, err := json.MarshalIndent(h.commands, "", "  ")
+	err := h.writeErr
+	h.writeErr = nil
 	if err != nil {
 		return err
 	}
+	if h.filepath == "" {
+		return nil
+	}
 
-	return os.WriteFile(h.filepath, data, 0644)
+	return h.rewriteFile(func(entries []types.HistoryEntry) []types.HistoryEntry {
+		if len(entries) <= 2*h.maxSize {
+			return nil
+		}
+		return entries[len(entries)-h.maxSize:]
+	})
 }
 
-// Load loads history from disk
+// Load reads the history file, picking up commands other sessions have
+// added since this one started
 func (h *History) Load() error {
-	data, err := os.ReadFile(h.filepath)
+	if h.filepath == "" {
+		return nil
+	}
+	f, err := os.Open(h.filepath)
 	if err != nil {
 		return err
 	}
+	defer f.Close()
+	if err := lockFile(f, false); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	entries, err := readEntries(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", h.filepath, err)
+	}
 
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	return json.Unmarshal(data, &h.commands)
+	h.commands = newestFirst(entries, h.maxSize)
+	return nil
 }
 
 // Clear removes all commands from history
@@ -175,6 +355,187 @@ func (h *History) Clear() {
 	defer h.mu.Unlock()
 
 	h.commands = make([]types.HistoryEntry, 0, h.maxSize)
+	_ = h.rewriteFile(func([]types.HistoryEntry) []types.HistoryEntry {
+		return []types.HistoryEntry{}
+	})
+}
+
+// appendToFile writes one entry to the end of the history file
+func (h *History) appendToFile(entry types.HistoryEntry) error {
+	if h.filepath == "" {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(h.filepath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, fileMode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := lockFile(f, true); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// rewriteFile replaces the entries in the history file, oldest first, with
+// what update returns for them. A nil result leaves the file alone. The
+// file is rewritten in place, under the same lock appends take, so no
+// other session's entry can slip in between the read and the write.
+func (h *History) rewriteFile(update func([]types.HistoryEntry) []types.HistoryEntry) error {
+	if h.filepath == "" {
+		return nil
+	}
+	f, err := os.OpenFile(h.filepath, os.O_RDWR|os.O_CREATE, fileMode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := lockFile(f, true); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	entries, err := readEntries(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", h.filepath, err)
+	}
+	entries = update(entries)
+	if entries == nil {
+		return nil
+	}
+	return writeEntries(f, entries)
+}
+
+// readEntries parses a history file, oldest entry first. Files written as
+// one JSON array by earlier versions hold the newest entry first, and are
+// reversed.
+func readEntries(r io.Reader) ([]types.HistoryEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if isLegacy(data) {
+		var legacy []types.HistoryEntry
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return nil, err
+		}
+		for i, entry := range legacy {
+			if !entry.Success {
+				legacy[i].ExitCode = 1
+			}
+		}
+		return reversed(legacy), nil
+	}
+
+	var entries []types.HistoryEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry types.HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// isLegacy reports whether a history file holds one JSON array, as written
+// by earlier versions
+func isLegacy(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// writeEntries replaces the content of an open history file
+func writeEntries(f *os.File, entries []types.HistoryEntry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(buf.Bytes(), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// migrateLegacy converts a history.json from earlier versions. A JSON
+// array at the history path itself is rewritten as JSONL; a missing
+// history.jsonl is created from the history.json beside it, which is left
+// in place.
+func (h *History) migrateLegacy() error {
+	if h.filepath == "" {
+		return nil
+	}
+	if data, err := os.ReadFile(h.filepath); err == nil {
+		if !isLegacy(data) {
+			return nil
+		}
+		return h.rewriteFile(func(entries []types.HistoryEntry) []types.HistoryEntry {
+			return entries
+		})
+	}
+
+	legacyPath := h.legacyPath()
+	if legacyPath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(legacyPath)
+	if err != nil {
+		return nil
+	}
+	entries, err := readEntries(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("migrating %s: %w", legacyPath, err)
+	}
+	return h.rewriteFile(func([]types.HistoryEntry) []types.HistoryEntry {
+		return entries
+	})
+}
+
+// legacyPath returns the history.json earlier versions kept beside a
+// JSONL history file, or "" for history files of other names
+func (h *History) legacyPath() string {
+	if filepath.Ext(h.filepath) != ".jsonl" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(h.filepath), "history.json")
+}
+
+// newestFirst returns up to max entries, newest first, from a list in file
+// order
+func newestFirst(entries []types.HistoryEntry, max int) []types.HistoryEntry {
+	if len(entries) > max {
+		entries = entries[len(entries)-max:]
+	}
+	return reversed(entries)
+}
+
+// reversed returns a reversed copy of entries
+func reversed(entries []types.HistoryEntry) []types.HistoryEntry {
+	out := make([]types.HistoryEntry, len(entries))
+	for i, entry := range entries {
+		out[len(entries)-1-i] = entry
+	}
+	return out
 }
 
 // ToListItems converts history entries to list items for display
@@ -189,7 +550,13 @@ func (h *History) ToListItems(entries []types.HistoryEntry) []types.ListItem {
 			desc += "/" + entry.Namespace
 		}
 		if !entry.Success {
-			desc += " | ✗ failed"
+			desc += fmt.Sprintf(" | ✗ exit %d", entry.ExitCode)
+		}
+		if entry.Duration > 0 {
+			desc += " | " + formatDuration(entry.Duration)
+		}
+		if entry.Diff != "" {
+			desc += " | diff reviewed"
 		}
 
 		successStr := "false"
@@ -205,8 +572,23 @@ func (h *History) ToListItems(entries []types.HistoryEntry) []types.ListItem {
 				"context":   entry.Context,
 				"namespace": entry.Namespace,
 				"success":   successStr,
+				"diff":      entry.Diff,
+				"output":    entry.Output,
+				"exit_code": strconv.Itoa(entry.ExitCode),
+				"cwd":       entry.Cwd,
 			},
 		}
 	}
 	return items
 }
+
+// formatDuration rounds a run time for display
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -1,10 +1,15 @@
 package history
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/tapcraft-io/purr/internal/redact"
+	"github.com/tapcraft-io/purr/pkg/types"
 )
 
 func TestHistory_AddAndGet(t *testing.T) {
@@ -175,6 +180,31 @@ func TestHistory_SaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestHistory_AddEntryWithDiff(t *testing.T) {
+	histFile := filepath.Join(t.TempDir(), "history.json")
+	h1, err := NewHistory(100, histFile)
+	if err != nil {
+		t.Fatalf("Failed to create history: %v", err)
+	}
+
+	h1.AddEntry(types.HistoryEntry{Command: "kubectl apply -f app.yaml", Success: true, Diff: "-replicas: 2\n+replicas: 3\n"})
+	if err := h1.Save(); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+
+	h2, err := NewHistory(100, histFile)
+	if err != nil {
+		t.Fatalf("Failed to create second history: %v", err)
+	}
+	entries := h2.GetAll()
+	if len(entries) != 1 || entries[0].Diff != "-replicas: 2\n+replicas: 3\n" {
+		t.Fatalf("Expected the diff to survive a reload, got %+v", entries)
+	}
+	if entries[0].Timestamp.IsZero() {
+		t.Error("Expected AddEntry to stamp the entry")
+	}
+}
+
 func TestHistory_Delete(t *testing.T) {
 	tmpDir := t.TempDir()
 	histFile := filepath.Join(tmpDir, "history.json")
@@ -373,3 +403,232 @@ func TestHistory_InvalidJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestHistory_AppendsJSONL(t *testing.T) {
+	histFile := filepath.Join(t.TempDir(), "history.jsonl")
+	h, err := NewHistory(100, histFile)
+	if err != nil {
+		t.Fatalf("Failed to create history: %v", err)
+	}
+
+	h.AddEntry(types.HistoryEntry{Command: "kubectl get pods", Success: true, Duration: 2 * time.Second})
+	h.AddEntry(types.HistoryEntry{Command: "kubectl delete pod web", ExitCode: 1})
+
+	// Entries are on disk without a Save, one per line, oldest first
+	data, err := os.ReadFile(histFile)
+	if err != nil {
+		t.Fatalf("Failed to read history file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"kubectl get pods"`) {
+		t.Fatalf("Expected two JSON lines, oldest first, got:\n%s", data)
+	}
+
+	h2, err := NewHistory(100, histFile)
+	if err != nil {
+		t.Fatalf("Failed to create second history: %v", err)
+	}
+	entries := h2.GetAll()
+	if len(entries) != 2 || entries[0].ExitCode != 1 || entries[1].Duration != 2*time.Second {
+		t.Errorf("Expected exit code and duration to survive a reload, got %+v", entries)
+	}
+	if entries[0].Cwd == "" {
+		t.Error("Expected the working directory to be recorded")
+	}
+}
+
+func TestHistory_ConcurrentSessions(t *testing.T) {
+	histFile := filepath.Join(t.TempDir(), "history.jsonl")
+	h1, err := NewHistory(100, histFile)
+	if err != nil {
+		t.Fatalf("Failed to create history: %v", err)
+	}
+	h2, err := NewHistory(100, histFile)
+	if err != nil {
+		t.Fatalf("Failed to create second history: %v", err)
+	}
+
+	h1.Add("kubectl get pods", true, "prod", "default")
+	h2.Add("kubectl get nodes", true, "prod", "")
+	h1.Add("kubectl get services", true, "prod", "default")
+	if err := h1.Save(); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+	if err := h2.Save(); err != nil {
+		t.Fatalf("Failed to save second history: %v", err)
+	}
+
+	if err := h1.Load(); err != nil {
+		t.Fatalf("Failed to reload history: %v", err)
+	}
+	entries := h1.GetAll()
+	if len(entries) != 3 || entries[1].Command != "kubectl get nodes" {
+		t.Errorf("Expected both sessions' commands in order, got %+v", entries)
+	}
+}
+
+func TestHistory_Compacts(t *testing.T) {
+	histFile := filepath.Join(t.TempDir(), "history.jsonl")
+	h, err := NewHistory(3, histFile)
+	if err != nil {
+		t.Fatalf("Failed to create history: %v", err)
+	}
+	for i := 0; i < 7; i++ {
+		h.Add(fmt.Sprintf("kubectl get pods %d", i), true, "", "")
+	}
+	if err := h.Save(); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+
+	data, err := os.ReadFile(histFile)
+	if err != nil {
+		t.Fatalf("Failed to read history file: %v", err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 3 {
+		t.Errorf("Expected the file compacted to 3 entries, got %d", n)
+	}
+}
+
+func TestHistory_MigratesLegacyJSON(t *testing.T) {
+	legacy := `[
+  {"command": "kubectl get services", "timestamp": "2024-05-02T10:00:00Z", "success": false},
+  {"command": "kubectl get pods", "timestamp": "2024-05-01T10:00:00Z", "success": true}
+]`
+
+	tests := []struct {
+		name     string
+		existing string // the file the legacy history is in
+		open     string // the file opened
+	}{
+		{"in place", "history.json", "history.json"},
+		{"beside the new file", "history.json", "history.jsonl"},
+		{"beside a renamed file", "history.json", "commands.jsonl"},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, tt.existing), []byte(legacy), 0644); err != nil {
+			t.Fatalf("Failed to write legacy history: %v", err)
+		}
+
+		h, err := NewHistory(100, filepath.Join(dir, tt.open))
+		if err != nil {
+			t.Fatalf("NewHistory(%s) error: %v", tt.name, err)
+		}
+		entries := h.GetAll()
+		if len(entries) != 2 || entries[0].Command != "kubectl get services" || entries[0].ExitCode != 1 {
+			t.Errorf("NewHistory(%s) loaded %+v, want the legacy entries newest first", tt.name, entries)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, tt.open))
+		if err != nil {
+			t.Fatalf("Failed to read history file: %v", err)
+		}
+		if !strings.HasPrefix(string(data), `{"command":"kubectl get pods"`) {
+			t.Errorf("NewHistory(%s) left %q, want JSONL oldest first", tt.name, data)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	line := strings.Repeat("x", 99) + "\n"
+	long := strings.Repeat(line, 50)
+
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"short", "pod/web deleted\n", "pod/web deleted\n"},
+		{"cut at a line", long, strings.Repeat(line, 20) + "[... 3000 more bytes]\n"},
+	}
+
+	for _, tt := range tests {
+		if got := snapshot(tt.output); got != tt.want {
+			t.Errorf("snapshot(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	h, err := NewHistory(10, "")
+	if err != nil {
+		t.Fatalf("Failed to create history: %v", err)
+	}
+	h.AddEntry(types.HistoryEntry{Command: "kubectl get pods -A", Output: long})
+	entry := h.GetAll()[0]
+	if len(entry.Output) >= len(long) || len(entry.OutputDigest) != 64 {
+		t.Errorf("AddEntry() kept %d bytes with digest %q, want a snapshot and a sha256", len(entry.Output), entry.OutputDigest)
+	}
+}
+
+func TestHistory_LeavesUnrelatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	// What chopping a character off hist.log would name
+	other := filepath.Join(dir, "hist.lo")
+	if err := os.WriteFile(other, []byte("[]"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	h, err := NewHistory(100, filepath.Join(dir, "hist.log"))
+	if err != nil {
+		t.Fatalf("NewHistory() error: %v", err)
+	}
+	if err := h.SetRedactor(nil); err != nil {
+		t.Fatalf("SetRedactor() error: %v", err)
+	}
+	if len(h.GetAll()) != 0 {
+		t.Errorf("NewHistory() loaded %+v, want nothing", h.GetAll())
+	}
+	info, err := os.Stat(other)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0644 {
+		t.Errorf("unrelated file mode = %o, want 644", perm)
+	}
+}
+
+func TestHistory_Redacts(t *testing.T) {
+	histFile := filepath.Join(t.TempDir(), "history.jsonl")
+	// A history written before redaction, readable by anyone
+	old := `{"command":"create secret generic db --from-literal=password=hunter2","timestamp":"2024-05-01T10:00:00Z","success":true}` + "\n"
+	if err := os.WriteFile(histFile, []byte(old), 0644); err != nil {
+		t.Fatalf("Failed to write history: %v", err)
+	}
+
+	h, err := NewHistory(100, histFile)
+	if err != nil {
+		t.Fatalf("Failed to create history: %v", err)
+	}
+	r, err := redact.New([]string{`internal-[0-9]+`})
+	if err != nil {
+		t.Fatalf("redact.New() error: %v", err)
+	}
+	if err := h.SetRedactor(r); err != nil {
+		t.Fatalf("SetRedactor() error: %v", err)
+	}
+	h.AddEntry(types.HistoryEntry{
+		Command: `!curl -H "Authorization: Bearer abc.def" https://internal-42`,
+		Output:  "apiVersion: v1\ndata:\n  token: c2VjcmV0\nkind: Secret\n",
+	})
+
+	data, err := os.ReadFile(histFile)
+	if err != nil {
+		t.Fatalf("Failed to read history file: %v", err)
+	}
+	for _, secret := range []string{"hunter2", "abc.def", "internal-42", "c2VjcmV0"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("History file contains %q:\n%s", secret, data)
+		}
+	}
+	if got := h.GetAll()[1].Command; got != "create secret generic db --from-literal=password=[REDACTED]" {
+		t.Errorf("Loaded command = %q, want it redacted", got)
+	}
+
+	info, err := os.Stat(histFile)
+	if err != nil {
+		t.Fatalf("Failed to stat history file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("History file mode = %o, want 600", perm)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package history
+
+import "os"
+
+// lockFile does nothing where there are no file locks
+func lockFile(f *os.File, exclusive bool) error { return nil }
+
+// unlockFile does nothing where there are no file locks
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,78 @@
+package history
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"sync"
+
+	"github.com/tapcraft-io/purr/internal/redact"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// recordChunk is how much output a recorder holds before redacting it
+const recordChunk = 64 * 1024
+
+// OutputRecorder takes in a command's output as it is written and keeps
+// only what an entry stores of it: the redacted snapshot and the digest
+// of the whole. Output is redacted a chunk of lines at a time, so
+// commands that never stop writing, such as logs -f, don't grow memory.
+// Stdout and stderr may write to it at once.
+type OutputRecorder struct {
+	redactor *redact.Redactor
+
+	mu      sync.Mutex
+	pending bytes.Buffer // not redacted yet
+	head    []byte       // the first maxOutputSnapshot redacted bytes
+	total   int          // redacted bytes seen
+	sum     hash.Hash
+}
+
+// NewOutputRecorder creates a recorder that redacts with the history's
+// redactor
+func (h *History) NewOutputRecorder() *OutputRecorder {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return &OutputRecorder{redactor: h.redactor, sum: sha256.New()}
+}
+
+// Write takes in output
+func (o *OutputRecorder) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pending.Write(p)
+	if o.pending.Len() >= recordChunk {
+		// Cut at the last line break so secrets spanning lines stay whole
+		n := bytes.LastIndexByte(o.pending.Bytes(), '\n') + 1
+		if n == 0 {
+			n = o.pending.Len()
+		}
+		o.flush(o.pending.Next(n))
+	}
+	return len(p), nil
+}
+
+// flush redacts a chunk and adds it to the snapshot and digest
+func (o *OutputRecorder) flush(chunk []byte) {
+	redacted := o.redactor.String(string(chunk))
+	o.sum.Write([]byte(redacted))
+	if room := maxOutputSnapshot - len(o.head); room > 0 {
+		o.head = append(o.head, redacted[:min(room, len(redacted))]...)
+	}
+	o.total += len(redacted)
+}
+
+// Fill sets an entry's Output and OutputDigest from what was recorded
+func (o *OutputRecorder) Fill(entry *types.HistoryEntry) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.pending.Len() > 0 {
+		o.flush(o.pending.Next(o.pending.Len()))
+	}
+	if o.total == 0 {
+		return
+	}
+	entry.Output = cutSnapshot(string(o.head), o.total)
+	entry.OutputDigest = hex.EncodeToString(o.sum.Sum(nil))
+}
@@ -0,0 +1,56 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+func TestOutputRecorder(t *testing.T) {
+	h, err := NewHistory(100, filepath.Join(t.TempDir(), "history.jsonl"))
+	if err != nil {
+		t.Fatalf("NewHistory() error: %v", err)
+	}
+
+	var output strings.Builder
+	for i := range 5000 {
+		fmt.Fprintf(&output, "line %d --from-literal=password=hunter%d\n", i, i)
+	}
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{"short", "pod/web created\n"},
+		{"longer than a chunk", output.String()},
+	}
+
+	for _, tt := range tests {
+		// Written in small pieces, as a pipe would
+		rec := h.NewOutputRecorder()
+		for s := tt.output; s != ""; {
+			n := min(len(s), 1000)
+			rec.Write([]byte(s[:n]))
+			s = s[n:]
+		}
+		var got types.HistoryEntry
+		rec.Fill(&got)
+
+		h.AddEntry(types.HistoryEntry{Command: "get pods", Output: tt.output})
+		want := h.Get(1)[0]
+		if got.Output != want.Output || got.OutputDigest != want.OutputDigest {
+			t.Errorf("%s: Fill() = %q, %s, want %q, %s", tt.name, got.Output, got.OutputDigest, want.Output, want.OutputDigest)
+		}
+		if strings.Contains(got.Output, "hunter") {
+			t.Errorf("%s: Fill() output %q is not redacted", tt.name, got.Output)
+		}
+	}
+
+	var empty types.HistoryEntry
+	h.NewOutputRecorder().Fill(&empty)
+	if empty.Output != "" || empty.OutputDigest != "" {
+		t.Errorf("Fill() without output = %+v, want nothing", empty)
+	}
+}
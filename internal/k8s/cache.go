@@ -3,6 +3,7 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,8 +14,20 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	networkinglisters "k8s.io/client-go/listers/networking/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/tools/cache"
 )
 
 // Cache is the interface for Kubernetes resource caching
@@ -31,1188 +44,480 @@ type Cache interface {
 	ResourceTypesForCommand(path []string) []string
 	ResourceNames(kind, namespace string) []string
 	Containers(namespace, resourceKind, resourceName string) []string
-}
-
-// ResourceCache caches Kubernetes resources for quick access
+	Labels(kind, namespace string) map[string]map[string]int
+	Fields(resourceType string, path []string) []types.SchemaField
+	Explain(resourceType string, path []string) *types.Explanation
+
+	// Discovery
+	Resources() []ResourceInfo
+	LookupResource(name string) (ResourceInfo, bool)
+
+	// Browsing
+	Table(resourceType, namespace string) (Table, bool)
+	Changes() <-chan struct{}
+
+	// Log streaming
+	LogTargets(namespace, target, selector string) ([]LogTarget, error)
+
+	// Port forwarding
+	Ports(namespace, target string) []types.Port
+	ForwardTarget(namespace, target string, ports []string, current string) (string, []string, error)
+
+	// Metrics
+	PodUsage(namespace string) []UsageSeries
+	NodeUsage() []UsageSeries
+	MetricsError() error
+
+	// Confirmation previews
+	PlanDrain(node string) (DrainPlan, error)
+}
+
+// syncTimeout bounds how long Start waits for the non-essential informers.
+// Kinds the user cannot list (e.g. secrets without RBAC) never sync, and
+// must not hold up the UI.
+const syncTimeout = 10 * time.Second
+
+// ResourceCache caches Kubernetes resources for quick access. It is backed
+// by client-go shared informers, which list once, keep a watch open from the
+// last seen resourceVersion, and relist automatically when the watch
+// expires or the API server restarts.
+//
+// The kinds with typed listers below get rich picker descriptions. Every
+// other resource the cluster serves, CRDs included, is found through
+// discovery and cached by a metadata-only informer started on first use.
 type ResourceCache struct {
-	clientset *kubernetes.Clientset
-
-	// Cached resources
-	namespaces   []corev1.Namespace
-	pods         map[string][]corev1.Pod
-	deployments  map[string][]appsv1.Deployment
-	services     map[string][]corev1.Service
-	configmaps   map[string][]corev1.ConfigMap
-	secrets      map[string][]corev1.Secret
-	ingresses    map[string][]networkingv1.Ingress
-	statefulsets map[string][]appsv1.StatefulSet
-	daemonsets   map[string][]appsv1.DaemonSet
-	jobs         map[string][]batchv1.Job
-	cronjobs     map[string][]batchv1.CronJob
-	nodes        []corev1.Node
+	clientset kubernetes.Interface
+	factory   informers.SharedInformerFactory
 
-	// Metadata
-	lastRefresh time.Time
-	refreshing  atomic.Bool
+	// Discovery and metadata informers for everything else
+	discovery   discovery.CachedDiscoveryInterface
+	metaFactory metadatainformer.SharedInformerFactory
 	mu          sync.RWMutex
+	resources   *resourceIndex
+	dynamic     map[schema.GroupVersionResource]bool
+	schemas     *schemaStore  // nil without a cluster
+	metrics     *metricsStore // nil unless SetMetricsClient was called
+
+	// Listers over the informer stores
+	namespaces   corelisters.NamespaceLister
+	pods         corelisters.PodLister
+	deployments  appslisters.DeploymentLister
+	services     corelisters.ServiceLister
+	configmaps   corelisters.ConfigMapLister
+	secrets      corelisters.SecretLister
+	ingresses    networkinglisters.IngressLister
+	statefulsets appslisters.StatefulSetLister
+	daemonsets   appslisters.DaemonSetLister
+	jobs         batchlisters.JobLister
+	cronjobs     batchlisters.CronJobLister
+	nodes        corelisters.NodeLister
+	pdbs         policylisters.PodDisruptionBudgetLister
+
+	// Metadata
+	resyncPeriod time.Duration
+	ready        atomic.Bool
+	lastWatchErr atomic.Value // error
+
+	// Label counts per kind and namespace, valid for one generation of
+	// changes
+	generation atomic.Uint64
+	labelsMu   sync.Mutex
+	labelIndex map[labelIndexKey]labelIndexEntry
+
+	// Change notifications, coalesced into a single pending signal
+	changes       chan struct{}
+	changesMu     sync.Mutex
+	changesClosed bool
 
 	// Context
-	ctx    context.Context
-	cancel context.CancelFunc
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
 }
 
-// NewResourceCache creates a new resource cache
-func NewResourceCache(clientset *kubernetes.Clientset) *ResourceCache {
-	return &ResourceCache{
-		clientset:    clientset,
-		pods:         make(map[string][]corev1.Pod),
-		deployments:  make(map[string][]appsv1.Deployment),
-		services:     make(map[string][]corev1.Service),
-		configmaps:   make(map[string][]corev1.ConfigMap),
-		secrets:      make(map[string][]corev1.Secret),
-		ingresses:    make(map[string][]networkingv1.Ingress),
-		statefulsets: make(map[string][]appsv1.StatefulSet),
-		daemonsets:   make(map[string][]appsv1.DaemonSet),
-		jobs:         make(map[string][]batchv1.Job),
-		cronjobs:     make(map[string][]batchv1.CronJob),
-	}
-}
+// NewResourceCache creates a new resource cache. resyncPeriod controls how
+// often the informers replay their stores to handlers as a safety net, and
+// how often discovery is refreshed to pick up new CRDs. metadataClient may
+// be nil, in which case only the typed kinds are cached.
+func NewResourceCache(clientset kubernetes.Interface, metadataClient metadata.Interface, resyncPeriod time.Duration) *ResourceCache {
+	factory := informers.NewSharedInformerFactory(clientset, resyncPeriod)
 
-// Start initializes and starts background refresh with watchers
+	rc := &ResourceCache{
+		clientset:    clientset,
+		factory:      factory,
+		resources:    newResourceIndex(builtinResources),
+		dynamic:      make(map[schema.GroupVersionResource]bool),
+		labelIndex:   make(map[labelIndexKey]labelIndexEntry),
+		resyncPeriod: resyncPeriod,
+		changes:      make(chan struct{}, 1),
+		namespaces:   factory.Core().V1().Namespaces().Lister(),
+		pods:         factory.Core().V1().Pods().Lister(),
+		deployments:  factory.Apps().V1().Deployments().Lister(),
+		services:     factory.Core().V1().Services().Lister(),
+		configmaps:   factory.Core().V1().ConfigMaps().Lister(),
+		secrets:      factory.Core().V1().Secrets().Lister(),
+		ingresses:    factory.Networking().V1().Ingresses().Lister(),
+		statefulsets: factory.Apps().V1().StatefulSets().Lister(),
+		daemonsets:   factory.Apps().V1().DaemonSets().Lister(),
+		jobs:         factory.Batch().V1().Jobs().Lister(),
+		cronjobs:     factory.Batch().V1().CronJobs().Lister(),
+		nodes:        factory.Core().V1().Nodes().Lister(),
+		pdbs:         factory.Policy().V1().PodDisruptionBudgets().Lister(),
+	}
+	if clientset != nil {
+		rc.discovery = memory.NewMemCacheClient(clientset.Discovery())
+		if rest := clientset.Discovery().RESTClient(); rest != nil {
+			rc.schemas = newSchemaStore(func(ctx context.Context, uri string) ([]byte, error) {
+				return rest.Get().RequestURI(uri).SetHeader("Accept", "application/json").Do(ctx).Raw()
+			})
+		}
+	}
+	if metadataClient != nil {
+		rc.metaFactory = metadatainformer.NewSharedInformerFactory(metadataClient, resyncPeriod)
+	}
+	return rc
+}
+
+// Start starts the informers and blocks until namespaces are synced. The
+// remaining kinds are given syncTimeout to catch up before the cache
+// reports ready; they keep syncing in the background after that.
 func (rc *ResourceCache) Start(ctx context.Context) error {
+	rc.mu.Lock()
 	rc.ctx, rc.cancel = context.WithCancel(ctx)
-
-	// Initial refresh
-	if err := rc.Refresh(); err != nil {
-		return err
-	}
-
-	// Start watchers for real-time updates
-	go rc.watchNamespaces()
-	go rc.watchPods()
-	go rc.watchDeployments()
-	go rc.watchServices()
-	go rc.watchNodes()
-	go rc.watchConfigMaps()
-	go rc.watchSecrets()
-	go rc.watchStatefulSets()
-	go rc.watchDaemonSets()
-	go rc.watchJobs()
-	go rc.watchCronJobs()
-	go rc.watchIngresses()
-
-	// Still do periodic full refresh as a fallback (every 5 minutes)
-	// This catches any missed events and handles reconnections
-	go rc.backgroundRefresh(5 * time.Minute)
-
-	return nil
-}
-
-// Stop stops the background refresh
-func (rc *ResourceCache) Stop() {
-	if rc.cancel != nil {
+	if rc.stopped {
+		// Stop raced ahead of Start
 		rc.cancel()
 	}
-}
-
-// watchNamespaces watches for namespace changes and updates cache
-func (rc *ResourceCache) watchNamespaces() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.CoreV1().Namespaces().Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			ns, ok := event.Object.(*corev1.Namespace)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			switch event.Type {
-			case "ADDED":
-				// Check if already exists
-				exists := false
-				for _, existing := range rc.namespaces {
-					if existing.Name == ns.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.namespaces = append(rc.namespaces, *ns)
-				}
-			case "DELETED":
-				for i, existing := range rc.namespaces {
-					if existing.Name == ns.Name {
-						rc.namespaces = append(rc.namespaces[:i], rc.namespaces[i+1:]...)
-						// Clean up associated resources
-						delete(rc.pods, ns.Name)
-						delete(rc.deployments, ns.Name)
-						delete(rc.services, ns.Name)
-						delete(rc.configmaps, ns.Name)
-						delete(rc.secrets, ns.Name)
-						delete(rc.statefulsets, ns.Name)
-						delete(rc.daemonsets, ns.Name)
-						delete(rc.jobs, ns.Name)
-						delete(rc.cronjobs, ns.Name)
-						delete(rc.ingresses, ns.Name)
-						break
-					}
-				}
-			case "MODIFIED":
-				for i, existing := range rc.namespaces {
-					if existing.Name == ns.Name {
-						rc.namespaces[i] = *ns
-						break
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
-
-		// Watcher closed, restart after brief delay
-		time.Sleep(time.Second)
-	}
-}
-
-// watchPods watches for pod changes across all namespaces
-func (rc *ResourceCache) watchPods() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.CoreV1().Pods("").Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			pod, ok := event.Object.(*corev1.Pod)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			ns := pod.Namespace
-			switch event.Type {
-			case "ADDED":
-				if _, ok := rc.pods[ns]; !ok {
-					rc.pods[ns] = []corev1.Pod{}
-				}
-				// Check if already exists
-				exists := false
-				for _, existing := range rc.pods[ns] {
-					if existing.Name == pod.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.pods[ns] = append(rc.pods[ns], *pod)
-				}
-			case "DELETED":
-				if pods, ok := rc.pods[ns]; ok {
-					for i, existing := range pods {
-						if existing.Name == pod.Name {
-							rc.pods[ns] = append(pods[:i], pods[i+1:]...)
-							break
-						}
-					}
-				}
-			case "MODIFIED":
-				if pods, ok := rc.pods[ns]; ok {
-					for i, existing := range pods {
-						if existing.Name == pod.Name {
-							rc.pods[ns][i] = *pod
-							break
-						}
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
+	rc.mu.Unlock()
 
-		time.Sleep(time.Second)
+	// Watch failures are retried by the reflectors; record them instead of
+	// letting client-go log over the TUI
+	for _, informer := range rc.informers() {
+		_ = informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+			rc.lastWatchErr.Store(err)
+		})
+		_, _ = informer.AddEventHandler(rc.changeHandler())
 	}
-}
 
-// watchDeployments watches for deployment changes across all namespaces
-func (rc *ResourceCache) watchDeployments() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.AppsV1().Deployments("").Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
+	rc.factory.Start(rc.ctx.Done())
 
-		for event := range watcher.ResultChan() {
-			dep, ok := event.Object.(*appsv1.Deployment)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			ns := dep.Namespace
-			switch event.Type {
-			case "ADDED":
-				if _, ok := rc.deployments[ns]; !ok {
-					rc.deployments[ns] = []appsv1.Deployment{}
-				}
-				exists := false
-				for _, existing := range rc.deployments[ns] {
-					if existing.Name == dep.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.deployments[ns] = append(rc.deployments[ns], *dep)
-				}
-			case "DELETED":
-				if deps, ok := rc.deployments[ns]; ok {
-					for i, existing := range deps {
-						if existing.Name == dep.Name {
-							rc.deployments[ns] = append(deps[:i], deps[i+1:]...)
-							break
-						}
-					}
-				}
-			case "MODIFIED":
-				if deps, ok := rc.deployments[ns]; ok {
-					for i, existing := range deps {
-						if existing.Name == dep.Name {
-							rc.deployments[ns][i] = *dep
-							break
-						}
-					}
-				}
-			}
-			rc.mu.Unlock()
+	nsInformer := rc.factory.Core().V1().Namespaces().Informer()
+	if !cache.WaitForCacheSync(rc.ctx.Done(), nsInformer.HasSynced) {
+		if err := rc.LastWatchError(); err != nil {
+			return fmt.Errorf("failed to sync namespaces: %w", err)
 		}
-
-		time.Sleep(time.Second)
+		return fmt.Errorf("failed to sync namespaces: %w", rc.ctx.Err())
 	}
-}
 
-// watchServices watches for service changes across all namespaces
-func (rc *ResourceCache) watchServices() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.CoreV1().Services("").Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			svc, ok := event.Object.(*corev1.Service)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			ns := svc.Namespace
-			switch event.Type {
-			case "ADDED":
-				if _, ok := rc.services[ns]; !ok {
-					rc.services[ns] = []corev1.Service{}
-				}
-				exists := false
-				for _, existing := range rc.services[ns] {
-					if existing.Name == svc.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.services[ns] = append(rc.services[ns], *svc)
-				}
-			case "DELETED":
-				if svcs, ok := rc.services[ns]; ok {
-					for i, existing := range svcs {
-						if existing.Name == svc.Name {
-							rc.services[ns] = append(svcs[:i], svcs[i+1:]...)
-							break
-						}
-					}
-				}
-			case "MODIFIED":
-				if svcs, ok := rc.services[ns]; ok {
-					for i, existing := range svcs {
-						if existing.Name == svc.Name {
-							rc.services[ns][i] = *svc
-							break
-						}
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
+	syncCtx, cancel := context.WithTimeout(rc.ctx, syncTimeout)
+	defer cancel()
+	rc.factory.WaitForCacheSync(syncCtx.Done())
 
-		time.Sleep(time.Second)
+	// Discovery failures keep the built-in resource list
+	_ = rc.RefreshDiscovery()
+	go rc.discoveryLoop()
+	if rc.schemas != nil {
+		go rc.schemas.prefetch(rc.ctx, schemaPrefetch)
 	}
-}
-
-// watchNodes watches for node changes
-func (rc *ResourceCache) watchNodes() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.CoreV1().Nodes().Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			node, ok := event.Object.(*corev1.Node)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			switch event.Type {
-			case "ADDED":
-				exists := false
-				for _, existing := range rc.nodes {
-					if existing.Name == node.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.nodes = append(rc.nodes, *node)
-				}
-			case "DELETED":
-				for i, existing := range rc.nodes {
-					if existing.Name == node.Name {
-						rc.nodes = append(rc.nodes[:i], rc.nodes[i+1:]...)
-						break
-					}
-				}
-			case "MODIFIED":
-				for i, existing := range rc.nodes {
-					if existing.Name == node.Name {
-						rc.nodes[i] = *node
-						break
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
-
-		time.Sleep(time.Second)
+	if rc.metrics != nil {
+		go rc.metricsLoop()
 	}
-}
 
-// watchConfigMaps watches for configmap changes across all namespaces
-func (rc *ResourceCache) watchConfigMaps() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.CoreV1().ConfigMaps("").Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			cm, ok := event.Object.(*corev1.ConfigMap)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			ns := cm.Namespace
-			switch event.Type {
-			case "ADDED":
-				if _, ok := rc.configmaps[ns]; !ok {
-					rc.configmaps[ns] = []corev1.ConfigMap{}
-				}
-				exists := false
-				for _, existing := range rc.configmaps[ns] {
-					if existing.Name == cm.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.configmaps[ns] = append(rc.configmaps[ns], *cm)
-				}
-			case "DELETED":
-				if cms, ok := rc.configmaps[ns]; ok {
-					for i, existing := range cms {
-						if existing.Name == cm.Name {
-							rc.configmaps[ns] = append(cms[:i], cms[i+1:]...)
-							break
-						}
-					}
-				}
-			case "MODIFIED":
-				if cms, ok := rc.configmaps[ns]; ok {
-					for i, existing := range cms {
-						if existing.Name == cm.Name {
-							rc.configmaps[ns][i] = *cm
-							break
-						}
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
-
-		time.Sleep(time.Second)
-	}
+	rc.ready.Store(true)
+	return nil
 }
 
-// watchSecrets watches for secret changes across all namespaces
-func (rc *ResourceCache) watchSecrets() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.CoreV1().Secrets("").Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			secret, ok := event.Object.(*corev1.Secret)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			ns := secret.Namespace
-			switch event.Type {
-			case "ADDED":
-				if _, ok := rc.secrets[ns]; !ok {
-					rc.secrets[ns] = []corev1.Secret{}
-				}
-				exists := false
-				for _, existing := range rc.secrets[ns] {
-					if existing.Name == secret.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.secrets[ns] = append(rc.secrets[ns], *secret)
-				}
-			case "DELETED":
-				if secrets, ok := rc.secrets[ns]; ok {
-					for i, existing := range secrets {
-						if existing.Name == secret.Name {
-							rc.secrets[ns] = append(secrets[:i], secrets[i+1:]...)
-							break
-						}
-					}
-				}
-			case "MODIFIED":
-				if secrets, ok := rc.secrets[ns]; ok {
-					for i, existing := range secrets {
-						if existing.Name == secret.Name {
-							rc.secrets[ns][i] = *secret
-							break
-						}
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
-
-		time.Sleep(time.Second)
+// Stop stops the informers
+func (rc *ResourceCache) Stop() {
+	rc.mu.Lock()
+	rc.stopped = true
+	cancel := rc.cancel
+	rc.mu.Unlock()
+	if cancel != nil {
+		cancel()
 	}
-}
-
-// watchStatefulSets watches for statefulset changes across all namespaces
-func (rc *ResourceCache) watchStatefulSets() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.AppsV1().StatefulSets("").Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			sts, ok := event.Object.(*appsv1.StatefulSet)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			ns := sts.Namespace
-			switch event.Type {
-			case "ADDED":
-				if _, ok := rc.statefulsets[ns]; !ok {
-					rc.statefulsets[ns] = []appsv1.StatefulSet{}
-				}
-				exists := false
-				for _, existing := range rc.statefulsets[ns] {
-					if existing.Name == sts.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.statefulsets[ns] = append(rc.statefulsets[ns], *sts)
-				}
-			case "DELETED":
-				if stsList, ok := rc.statefulsets[ns]; ok {
-					for i, existing := range stsList {
-						if existing.Name == sts.Name {
-							rc.statefulsets[ns] = append(stsList[:i], stsList[i+1:]...)
-							break
-						}
-					}
-				}
-			case "MODIFIED":
-				if stsList, ok := rc.statefulsets[ns]; ok {
-					for i, existing := range stsList {
-						if existing.Name == sts.Name {
-							rc.statefulsets[ns][i] = *sts
-							break
-						}
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
-
-		time.Sleep(time.Second)
+	rc.factory.Shutdown()
+	if rc.metaFactory != nil {
+		rc.metaFactory.Shutdown()
 	}
-}
-
-// watchDaemonSets watches for daemonset changes across all namespaces
-func (rc *ResourceCache) watchDaemonSets() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.AppsV1().DaemonSets("").Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			ds, ok := event.Object.(*appsv1.DaemonSet)
-			if !ok {
-				continue
-			}
 
-			rc.mu.Lock()
-			ns := ds.Namespace
-			switch event.Type {
-			case "ADDED":
-				if _, ok := rc.daemonsets[ns]; !ok {
-					rc.daemonsets[ns] = []appsv1.DaemonSet{}
-				}
-				exists := false
-				for _, existing := range rc.daemonsets[ns] {
-					if existing.Name == ds.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.daemonsets[ns] = append(rc.daemonsets[ns], *ds)
-				}
-			case "DELETED":
-				if dsList, ok := rc.daemonsets[ns]; ok {
-					for i, existing := range dsList {
-						if existing.Name == ds.Name {
-							rc.daemonsets[ns] = append(dsList[:i], dsList[i+1:]...)
-							break
-						}
-					}
-				}
-			case "MODIFIED":
-				if dsList, ok := rc.daemonsets[ns]; ok {
-					for i, existing := range dsList {
-						if existing.Name == ds.Name {
-							rc.daemonsets[ns][i] = *ds
-							break
-						}
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
-
-		time.Sleep(time.Second)
+	rc.changesMu.Lock()
+	if !rc.changesClosed {
+		rc.changesClosed = true
+		close(rc.changes)
 	}
+	rc.changesMu.Unlock()
 }
 
-// watchJobs watches for job changes across all namespaces
-func (rc *ResourceCache) watchJobs() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.BatchV1().Jobs("").Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			job, ok := event.Object.(*batchv1.Job)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			ns := job.Namespace
-			switch event.Type {
-			case "ADDED":
-				if _, ok := rc.jobs[ns]; !ok {
-					rc.jobs[ns] = []batchv1.Job{}
-				}
-				exists := false
-				for _, existing := range rc.jobs[ns] {
-					if existing.Name == job.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.jobs[ns] = append(rc.jobs[ns], *job)
-				}
-			case "DELETED":
-				if jobs, ok := rc.jobs[ns]; ok {
-					for i, existing := range jobs {
-						if existing.Name == job.Name {
-							rc.jobs[ns] = append(jobs[:i], jobs[i+1:]...)
-							break
-						}
-					}
-				}
-			case "MODIFIED":
-				if jobs, ok := rc.jobs[ns]; ok {
-					for i, existing := range jobs {
-						if existing.Name == job.Name {
-							rc.jobs[ns][i] = *job
-							break
-						}
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
-
-		time.Sleep(time.Second)
-	}
+// Changes returns a channel that receives a value after cached objects
+// change. Bursts of changes are coalesced into one pending signal, and the
+// channel is closed when the cache stops.
+func (rc *ResourceCache) Changes() <-chan struct{} {
+	return rc.changes
 }
 
-// watchCronJobs watches for cronjob changes across all namespaces
-func (rc *ResourceCache) watchCronJobs() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.BatchV1().CronJobs("").Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			cj, ok := event.Object.(*batchv1.CronJob)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			ns := cj.Namespace
-			switch event.Type {
-			case "ADDED":
-				if _, ok := rc.cronjobs[ns]; !ok {
-					rc.cronjobs[ns] = []batchv1.CronJob{}
-				}
-				exists := false
-				for _, existing := range rc.cronjobs[ns] {
-					if existing.Name == cj.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.cronjobs[ns] = append(rc.cronjobs[ns], *cj)
-				}
-			case "DELETED":
-				if cjs, ok := rc.cronjobs[ns]; ok {
-					for i, existing := range cjs {
-						if existing.Name == cj.Name {
-							rc.cronjobs[ns] = append(cjs[:i], cjs[i+1:]...)
-							break
-						}
-					}
-				}
-			case "MODIFIED":
-				if cjs, ok := rc.cronjobs[ns]; ok {
-					for i, existing := range cjs {
-						if existing.Name == cj.Name {
-							rc.cronjobs[ns][i] = *cj
-							break
-						}
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
-
-		time.Sleep(time.Second)
+// notifyChange signals Changes without blocking
+func (rc *ResourceCache) notifyChange() {
+	rc.generation.Add(1)
+	rc.changesMu.Lock()
+	defer rc.changesMu.Unlock()
+	if rc.changesClosed {
+		return
 	}
-}
-
-// watchIngresses watches for ingress changes across all namespaces
-func (rc *ResourceCache) watchIngresses() {
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		default:
-		}
-
-		watcher, err := rc.clientset.NetworkingV1().Ingresses("").Watch(rc.ctx, metav1.ListOptions{})
-		if err != nil {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		for event := range watcher.ResultChan() {
-			ing, ok := event.Object.(*networkingv1.Ingress)
-			if !ok {
-				continue
-			}
-
-			rc.mu.Lock()
-			ns := ing.Namespace
-			switch event.Type {
-			case "ADDED":
-				if _, ok := rc.ingresses[ns]; !ok {
-					rc.ingresses[ns] = []networkingv1.Ingress{}
-				}
-				exists := false
-				for _, existing := range rc.ingresses[ns] {
-					if existing.Name == ing.Name {
-						exists = true
-						break
-					}
-				}
-				if !exists {
-					rc.ingresses[ns] = append(rc.ingresses[ns], *ing)
-				}
-			case "DELETED":
-				if ings, ok := rc.ingresses[ns]; ok {
-					for i, existing := range ings {
-						if existing.Name == ing.Name {
-							rc.ingresses[ns] = append(ings[:i], ings[i+1:]...)
-							break
-						}
-					}
-				}
-			case "MODIFIED":
-				if ings, ok := rc.ingresses[ns]; ok {
-					for i, existing := range ings {
-						if existing.Name == ing.Name {
-							rc.ingresses[ns][i] = *ing
-							break
-						}
-					}
-				}
-			}
-			rc.mu.Unlock()
-		}
-
-		time.Sleep(time.Second)
+	select {
+	case rc.changes <- struct{}{}:
+	default:
 	}
 }
 
-// Refresh updates all cached resources
-func (rc *ResourceCache) Refresh() error {
-	if !rc.refreshing.CompareAndSwap(false, true) {
-		// Already refreshing
-		return nil
-	}
-	defer rc.refreshing.Store(false)
-
-	ctx := context.Background()
-	if rc.ctx != nil {
-		ctx = rc.ctx
-	}
-
-	// Refresh namespaces
-	nsList, err := rc.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to list namespaces: %w", err)
-	}
-
-	rc.mu.Lock()
-	rc.namespaces = nsList.Items
-	rc.mu.Unlock()
-
-	// Refresh resources for each namespace
-	for _, ns := range nsList.Items {
-		if err := rc.refreshNamespace(ctx, ns.Name); err != nil {
-			// Log error but continue
-			continue
-		}
+// changeHandler returns an informer event handler that signals Changes
+func (rc *ResourceCache) changeHandler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { rc.notifyChange() },
+		UpdateFunc: func(any, any) { rc.notifyChange() },
+		DeleteFunc: func(any) { rc.notifyChange() },
 	}
-
-	// Refresh cluster-wide resources
-	nodesList, err := rc.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.nodes = nodesList.Items
-		rc.mu.Unlock()
-	}
-
-	rc.mu.Lock()
-	rc.lastRefresh = time.Now()
-	rc.mu.Unlock()
-
-	return nil
 }
 
-// refreshNamespace refreshes resources for a specific namespace
-func (rc *ResourceCache) refreshNamespace(ctx context.Context, namespace string) error {
-	// Pods
-	podsList, err := rc.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.pods[namespace] = podsList.Items
-		rc.mu.Unlock()
-	}
-
-	// Deployments
-	depList, err := rc.clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.deployments[namespace] = depList.Items
-		rc.mu.Unlock()
-	}
-
-	// Services
-	svcList, err := rc.clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.services[namespace] = svcList.Items
-		rc.mu.Unlock()
-	}
-
-	// ConfigMaps
-	cmList, err := rc.clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.configmaps[namespace] = cmList.Items
-		rc.mu.Unlock()
-	}
-
-	// Secrets
-	secretList, err := rc.clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.secrets[namespace] = secretList.Items
-		rc.mu.Unlock()
-	}
-
-	// StatefulSets
-	stsList, err := rc.clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.statefulsets[namespace] = stsList.Items
-		rc.mu.Unlock()
-	}
-
-	// DaemonSets
-	dsList, err := rc.clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.daemonsets[namespace] = dsList.Items
-		rc.mu.Unlock()
-	}
-
-	// Jobs
-	jobsList, err := rc.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.jobs[namespace] = jobsList.Items
-		rc.mu.Unlock()
-	}
-
-	// CronJobs
-	cjList, err := rc.clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.cronjobs[namespace] = cjList.Items
-		rc.mu.Unlock()
-	}
-
-	// Ingresses
-	ingList, err := rc.clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		rc.mu.Lock()
-		rc.ingresses[namespace] = ingList.Items
-		rc.mu.Unlock()
-	}
-
-	return nil
+// LastWatchError returns the most recent list/watch failure, if any
+func (rc *ResourceCache) LastWatchError() error {
+	err, _ := rc.lastWatchErr.Load().(error)
+	return err
 }
 
-// backgroundRefresh periodically refreshes the cache
-func (rc *ResourceCache) backgroundRefresh(interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-rc.ctx.Done():
-			return
-		case <-ticker.C:
-			_ = rc.Refresh()
-		}
+// informers returns the informers for every cached kind
+func (rc *ResourceCache) informers() []cache.SharedIndexInformer {
+	return []cache.SharedIndexInformer{
+		rc.factory.Core().V1().Namespaces().Informer(),
+		rc.factory.Core().V1().Pods().Informer(),
+		rc.factory.Apps().V1().Deployments().Informer(),
+		rc.factory.Core().V1().Services().Informer(),
+		rc.factory.Core().V1().ConfigMaps().Informer(),
+		rc.factory.Core().V1().Secrets().Informer(),
+		rc.factory.Networking().V1().Ingresses().Informer(),
+		rc.factory.Apps().V1().StatefulSets().Informer(),
+		rc.factory.Apps().V1().DaemonSets().Informer(),
+		rc.factory.Batch().V1().Jobs().Informer(),
+		rc.factory.Batch().V1().CronJobs().Informer(),
+		rc.factory.Core().V1().Nodes().Informer(),
+		rc.factory.Policy().V1().PodDisruptionBudgets().Informer(),
 	}
 }
 
 // GetNamespaces returns all cached namespaces
 func (rc *ResourceCache) GetNamespaces() []string {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	names := make([]string, len(rc.namespaces))
-	for i, ns := range rc.namespaces {
+	list, _ := rc.namespaces.List(labels.Everything())
+	names := make([]string, len(list))
+	for i, ns := range list {
 		names[i] = ns.Name
 	}
+	sort.Strings(names)
 	return names
 }
 
+// getNamespaceObjects returns all cached namespace objects sorted by name
+func (rc *ResourceCache) getNamespaceObjects() []corev1.Namespace {
+	list, _ := rc.namespaces.List(labels.Everything())
+	result := make([]corev1.Namespace, len(list))
+	for i, ns := range list {
+		result[i] = *ns
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
 // GetPods returns pods in a namespace
 func (rc *ResourceCache) GetPods(namespace string) []corev1.Pod {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	if pods, ok := rc.pods[namespace]; ok {
-		result := make([]corev1.Pod, len(pods))
-		copy(result, pods)
-		return result
+	list, _ := rc.pods.Pods(namespace).List(labels.Everything())
+	result := make([]corev1.Pod, len(list))
+	for i, obj := range list {
+		result[i] = *obj
 	}
-	return []corev1.Pod{}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
 }
 
 // GetDeployments returns deployments in a namespace
 func (rc *ResourceCache) GetDeployments(namespace string) []appsv1.Deployment {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	if deps, ok := rc.deployments[namespace]; ok {
-		result := make([]appsv1.Deployment, len(deps))
-		copy(result, deps)
-		return result
+	list, _ := rc.deployments.Deployments(namespace).List(labels.Everything())
+	result := make([]appsv1.Deployment, len(list))
+	for i, obj := range list {
+		result[i] = *obj
 	}
-	return []appsv1.Deployment{}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
 }
 
 // GetServices returns services in a namespace
 func (rc *ResourceCache) GetServices(namespace string) []corev1.Service {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	if svcs, ok := rc.services[namespace]; ok {
-		result := make([]corev1.Service, len(svcs))
-		copy(result, svcs)
-		return result
+	list, _ := rc.services.Services(namespace).List(labels.Everything())
+	result := make([]corev1.Service, len(list))
+	for i, obj := range list {
+		result[i] = *obj
 	}
-	return []corev1.Service{}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
 }
 
 // GetNodes returns all nodes
 func (rc *ResourceCache) GetNodes() []corev1.Node {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	result := make([]corev1.Node, len(rc.nodes))
-	copy(result, rc.nodes)
+	list, _ := rc.nodes.List(labels.Everything())
+	result := make([]corev1.Node, len(list))
+	for i, obj := range list {
+		result[i] = *obj
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
 	return result
 }
 
 // GetStatefulSets returns statefulsets in a namespace
 func (rc *ResourceCache) GetStatefulSets(namespace string) []appsv1.StatefulSet {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	if sts, ok := rc.statefulsets[namespace]; ok {
-		result := make([]appsv1.StatefulSet, len(sts))
-		copy(result, sts)
-		return result
+	list, _ := rc.statefulsets.StatefulSets(namespace).List(labels.Everything())
+	result := make([]appsv1.StatefulSet, len(list))
+	for i, obj := range list {
+		result[i] = *obj
 	}
-	return []appsv1.StatefulSet{}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
 }
 
 // GetDaemonSets returns daemonsets in a namespace
 func (rc *ResourceCache) GetDaemonSets(namespace string) []appsv1.DaemonSet {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	if ds, ok := rc.daemonsets[namespace]; ok {
-		result := make([]appsv1.DaemonSet, len(ds))
-		copy(result, ds)
-		return result
+	list, _ := rc.daemonsets.DaemonSets(namespace).List(labels.Everything())
+	result := make([]appsv1.DaemonSet, len(list))
+	for i, obj := range list {
+		result[i] = *obj
 	}
-	return []appsv1.DaemonSet{}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
 }
 
 // GetJobs returns jobs in a namespace
 func (rc *ResourceCache) GetJobs(namespace string) []batchv1.Job {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	if jobs, ok := rc.jobs[namespace]; ok {
-		result := make([]batchv1.Job, len(jobs))
-		copy(result, jobs)
-		return result
+	list, _ := rc.jobs.Jobs(namespace).List(labels.Everything())
+	result := make([]batchv1.Job, len(list))
+	for i, obj := range list {
+		result[i] = *obj
 	}
-	return []batchv1.Job{}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
 }
 
 // GetCronJobs returns cronjobs in a namespace
 func (rc *ResourceCache) GetCronJobs(namespace string) []batchv1.CronJob {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	if cj, ok := rc.cronjobs[namespace]; ok {
-		result := make([]batchv1.CronJob, len(cj))
-		copy(result, cj)
-		return result
+	list, _ := rc.cronjobs.CronJobs(namespace).List(labels.Everything())
+	result := make([]batchv1.CronJob, len(list))
+	for i, obj := range list {
+		result[i] = *obj
 	}
-	return []batchv1.CronJob{}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
 }
 
 // GetConfigMaps returns configmaps in a namespace
 func (rc *ResourceCache) GetConfigMaps(namespace string) []corev1.ConfigMap {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	if cm, ok := rc.configmaps[namespace]; ok {
-		result := make([]corev1.ConfigMap, len(cm))
-		copy(result, cm)
-		return result
+	list, _ := rc.configmaps.ConfigMaps(namespace).List(labels.Everything())
+	result := make([]corev1.ConfigMap, len(list))
+	for i, obj := range list {
+		result[i] = *obj
 	}
-	return []corev1.ConfigMap{}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
 }
 
 // GetSecrets returns secrets in a namespace
 func (rc *ResourceCache) GetSecrets(namespace string) []corev1.Secret {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	if secrets, ok := rc.secrets[namespace]; ok {
-		result := make([]corev1.Secret, len(secrets))
-		copy(result, secrets)
-		return result
+	list, _ := rc.secrets.Secrets(namespace).List(labels.Everything())
+	result := make([]corev1.Secret, len(list))
+	for i, obj := range list {
+		result[i] = *obj
 	}
-	return []corev1.Secret{}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
 }
 
 // GetIngresses returns ingresses in a namespace
 func (rc *ResourceCache) GetIngresses(namespace string) []networkingv1.Ingress {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	if ing, ok := rc.ingresses[namespace]; ok {
-		result := make([]networkingv1.Ingress, len(ing))
-		copy(result, ing)
-		return result
+	list, _ := rc.ingresses.Ingresses(namespace).List(labels.Everything())
+	result := make([]networkingv1.Ingress, len(list))
+	for i, obj := range list {
+		result[i] = *obj
 	}
-	return []networkingv1.Ingress{}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
 }
 
-// GetResourceByType returns resources of a specific type
+// GetResourceByType returns resources of a specific type. Any name
+// discovery knows about is accepted, including short names and the
+// "plural.group" form.
 func (rc *ResourceCache) GetResourceByType(resourceType, namespace string) []types.ListItem {
+	info, ok := rc.LookupResource(resourceType)
+	if !ok {
+		if items, ok := rc.typedResourceByType(resourceType, namespace); ok {
+			return items
+		}
+		return []types.ListItem{}
+	}
+
+	if typedGroups[info.Name] == info.Group {
+		if items, ok := rc.typedResourceByType(info.Name, namespace); ok {
+			return items
+		}
+	}
+	return rc.MetadataToListItems(info, rc.metadataObjects(info, namespace))
+}
+
+// typedGroups maps the kinds with typed listers to their API group
+var typedGroups = map[string]string{
+	"pods":         "",
+	"deployments":  "apps",
+	"services":     "",
+	"nodes":        "",
+	"namespaces":   "",
+	"statefulsets": "apps",
+	"daemonsets":   "apps",
+	"jobs":         "batch",
+	"cronjobs":     "batch",
+	"configmaps":   "",
+	"secrets":      "",
+	"ingresses":    "networking.k8s.io",
+}
+
+// typedResourceByType returns resources from the typed listers
+func (rc *ResourceCache) typedResourceByType(resourceType, namespace string) ([]types.ListItem, bool) {
 	switch resourceType {
 	case "pods", "pod", "po":
-		return rc.PodsToListItems(rc.GetPods(namespace))
+		return rc.PodsToListItems(rc.GetPods(namespace)), true
 	case "deployments", "deployment", "deploy":
-		return rc.DeploymentsToListItems(rc.GetDeployments(namespace))
+		return rc.DeploymentsToListItems(rc.GetDeployments(namespace)), true
 	case "services", "service", "svc":
-		return rc.ServicesToListItems(rc.GetServices(namespace))
+		return rc.ServicesToListItems(rc.GetServices(namespace)), true
 	case "nodes", "node", "no":
-		return rc.NodesToListItems(rc.GetNodes())
+		return rc.NodesToListItems(rc.GetNodes()), true
 	case "namespaces", "namespace", "ns":
-		return rc.NamespacesToListItems()
+		return rc.NamespacesToListItems(), true
 	case "statefulsets", "statefulset", "sts":
-		return rc.StatefulSetsToListItems(rc.GetStatefulSets(namespace))
+		return rc.StatefulSetsToListItems(rc.GetStatefulSets(namespace)), true
 	case "daemonsets", "daemonset", "ds":
-		return rc.DaemonSetsToListItems(rc.GetDaemonSets(namespace))
+		return rc.DaemonSetsToListItems(rc.GetDaemonSets(namespace)), true
 	case "jobs", "job":
-		return rc.JobsToListItems(rc.GetJobs(namespace))
+		return rc.JobsToListItems(rc.GetJobs(namespace)), true
 	case "cronjobs", "cronjob", "cj":
-		return rc.CronJobsToListItems(rc.GetCronJobs(namespace))
+		return rc.CronJobsToListItems(rc.GetCronJobs(namespace)), true
 	case "configmaps", "configmap", "cm":
-		return rc.ConfigMapsToListItems(rc.GetConfigMaps(namespace))
+		return rc.ConfigMapsToListItems(rc.GetConfigMaps(namespace)), true
 	case "secrets", "secret":
-		return rc.SecretsToListItems(rc.GetSecrets(namespace))
+		return rc.SecretsToListItems(rc.GetSecrets(namespace)), true
 	case "ingresses", "ingress", "ing":
-		return rc.IngressesToListItems(rc.GetIngresses(namespace))
+		return rc.IngressesToListItems(rc.GetIngresses(namespace)), true
 	default:
-		return []types.ListItem{}
+		return nil, false
 	}
 }
 
 // PodsToListItems converts pods to list items
 func (rc *ResourceCache) PodsToListItems(pods []corev1.Pod) []types.ListItem {
+	usage := usageIndex(rc.PodUsage(""))
 	items := make([]types.ListItem, len(pods))
 	for i, pod := range pods {
 		status := string(pod.Status.Phase)
@@ -1227,6 +532,9 @@ func (rc *ResourceCache) PodsToListItems(pods []corev1.Pod) []types.ListItem {
 				"age":       age,
 			},
 		}
+		if u, ok := usage[pod.Namespace+"/"+pod.Name]; ok {
+			addUsage(&items[i], u)
+		}
 	}
 	return items
 }
@@ -1273,6 +581,7 @@ func (rc *ResourceCache) ServicesToListItems(svcs []corev1.Service) []types.List
 
 // NodesToListItems converts nodes to list items
 func (rc *ResourceCache) NodesToListItems(nodes []corev1.Node) []types.ListItem {
+	usage := usageIndex(rc.NodeUsage())
 	items := make([]types.ListItem, len(nodes))
 	for i, node := range nodes {
 		status := "Ready"
@@ -1292,17 +601,18 @@ func (rc *ResourceCache) NodesToListItems(nodes []corev1.Node) []types.ListItem
 				"age":    age,
 			},
 		}
+		if u, ok := usage["/"+node.Name]; ok {
+			addUsage(&items[i], u)
+		}
 	}
 	return items
 }
 
 // NamespacesToListItems converts namespaces to list items
 func (rc *ResourceCache) NamespacesToListItems() []types.ListItem {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
-	items := make([]types.ListItem, len(rc.namespaces))
-	for i, ns := range rc.namespaces {
+	namespaces := rc.getNamespaceObjects()
+	items := make([]types.ListItem, len(namespaces))
+	for i, ns := range namespaces {
 		status := string(ns.Status.Phase)
 		age := time.Since(ns.CreationTimestamp.Time).Round(time.Second).String()
 
@@ -1467,9 +777,7 @@ func (rc *ResourceCache) IngressesToListItems(ing []networkingv1.Ingress) []type
 
 // IsReady returns true if the cache has been initialized
 func (rc *ResourceCache) IsReady() bool {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-	return !rc.lastRefresh.IsZero()
+	return rc.ready.Load()
 }
 
 // Namespaces returns all cached namespace names (alias for GetNamespaces for ClusterCache interface)
@@ -1477,39 +785,12 @@ func (rc *ResourceCache) Namespaces() []string {
 	return rc.GetNamespaces()
 }
 
-// ResourceTypes returns all known resource types
+// ResourceTypes returns the resource type names the cluster serves, with
+// short names and categories
 func (rc *ResourceCache) ResourceTypes() []string {
-	return []string{
-		"pods", "po",
-		"deployments", "deploy",
-		"services", "svc",
-		"replicasets", "rs",
-		"statefulsets", "sts",
-		"daemonsets", "ds",
-		"jobs",
-		"cronjobs", "cj",
-		"configmaps", "cm",
-		"secrets",
-		"persistentvolumeclaims", "pvc",
-		"persistentvolumes", "pv",
-		"storageclasses", "sc",
-		"ingresses", "ing",
-		"networkpolicies", "netpol",
-		"nodes", "no",
-		"namespaces", "ns",
-		"serviceaccounts", "sa",
-		"roles",
-		"rolebindings",
-		"clusterroles",
-		"clusterrolebindings",
-		"replicationcontrollers", "rc",
-		"horizontalpodautoscalers", "hpa",
-		"poddisruptionbudgets", "pdb",
-		"endpoints", "ep",
-		"events", "ev",
-		"limitranges", "limits",
-		"resourcequotas", "quota",
-	}
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.resources.typeNames()
 }
 
 // ResourceTypesForCommand returns resource types specific to a command path
@@ -1547,9 +828,6 @@ func (rc *ResourceCache) ResourceNames(kind, namespace string) []string {
 
 // Containers returns container names for a given pod/workload
 func (rc *ResourceCache) Containers(namespace, resourceKind, resourceName string) []string {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
-
 	if namespace == "" {
 		namespace = "default"
 	}
@@ -1557,7 +835,7 @@ func (rc *ResourceCache) Containers(namespace, resourceKind, resourceName string
 	var containers []string
 
 	// Get containers from pods
-	if pods, ok := rc.pods[namespace]; ok {
+	if pods := rc.GetPods(namespace); len(pods) > 0 {
 		for _, pod := range pods {
 			// If resourceName is specified, only get containers from that pod
 			if resourceName != "" && pod.Name != resourceName {
@@ -1575,7 +853,7 @@ func (rc *ResourceCache) Containers(namespace, resourceKind, resourceName string
 
 	// If looking for a deployment/statefulset/daemonset, find their pods and get containers
 	if resourceName != "" && (resourceKind == "deployment" || resourceKind == "deployments" || resourceKind == "deploy") {
-		if deps, ok := rc.deployments[namespace]; ok {
+		if deps := rc.GetDeployments(namespace); len(deps) > 0 {
 			for _, dep := range deps {
 				if dep.Name == resourceName {
 					for _, container := range dep.Spec.Template.Spec.Containers {
@@ -1590,7 +868,7 @@ func (rc *ResourceCache) Containers(namespace, resourceKind, resourceName string
 	}
 
 	if resourceName != "" && (resourceKind == "statefulset" || resourceKind == "statefulsets" || resourceKind == "sts") {
-		if sts, ok := rc.statefulsets[namespace]; ok {
+		if sts := rc.GetStatefulSets(namespace); len(sts) > 0 {
 			for _, s := range sts {
 				if s.Name == resourceName {
 					for _, container := range s.Spec.Template.Spec.Containers {
@@ -1605,7 +883,7 @@ func (rc *ResourceCache) Containers(namespace, resourceKind, resourceName string
 	}
 
 	if resourceName != "" && (resourceKind == "daemonset" || resourceKind == "daemonsets" || resourceKind == "ds") {
-		if ds, ok := rc.daemonsets[namespace]; ok {
+		if ds := rc.GetDaemonSets(namespace); len(ds) > 0 {
 			for _, d := range ds {
 				if d.Name == resourceName {
 					for _, container := range d.Spec.Template.Spec.Containers {
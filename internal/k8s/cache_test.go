@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMockResourceCache_Listers(t *testing.T) {
+	rc := NewMockResourceCache()
+
+	if !rc.IsReady() {
+		t.Fatal("mock cache should be ready without Start")
+	}
+
+	wantNS := []string{"default", "development", "kube-public", "kube-system", "production", "staging"}
+	if got := rc.GetNamespaces(); !reflect.DeepEqual(got, wantNS) {
+		t.Errorf("GetNamespaces() = %v, want %v", got, wantNS)
+	}
+
+	if got := len(rc.GetPods("production")); got != 3 {
+		t.Errorf("len(GetPods(production)) = %d, want 3", got)
+	}
+	if got := len(rc.GetPods("staging")); got != 0 {
+		t.Errorf("len(GetPods(staging)) = %d, want 0", got)
+	}
+
+	names := rc.ResourceNames("deploy", "default")
+	for i := 1; i < len(names); i++ {
+		if names[i-1] > names[i] {
+			t.Errorf("ResourceNames() not sorted: %v", names)
+			break
+		}
+	}
+
+	if got := rc.GetResourceByType("nodes", ""); len(got) != 3 {
+		t.Errorf("len(GetResourceByType(nodes)) = %d, want 3", len(got))
+	}
+	if got := rc.GetResourceByType("deployments.apps", "default"); len(got) != 3 {
+		t.Errorf("len(GetResourceByType(deployments.apps)) = %d, want 3", len(got))
+	}
+	if _, ok := rc.LookupResource("pvc"); !ok {
+		t.Error("LookupResource(pvc) should resolve from the built-in list")
+	}
+}
+
+func TestMockResourceCache_Containers(t *testing.T) {
+	rc := NewMockResourceCache()
+
+	for _, dep := range rc.GetDeployments("default") {
+		if len(dep.Spec.Template.Spec.Containers) == 0 {
+			continue
+		}
+		got := rc.Containers("default", "deployment", dep.Name)
+		if len(got) == 0 {
+			t.Errorf("Containers(deployment/%s) returned nothing", dep.Name)
+		}
+	}
+}
+
+func TestResourceCache_Labels(t *testing.T) {
+	rc := NewResourceCache(nil, nil, 0)
+	pods := rc.factory.Core().V1().Pods().Informer()
+	addMockObjects(pods, []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "prod", Labels: map[string]string{"app": "web", "tier": "frontend"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-b", Namespace: "prod", Labels: map[string]string{"app": "web"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "prod", Labels: map[string]string{"app": "db"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "dev", Labels: map[string]string{"app": "web"}}},
+	})
+
+	want := map[string]map[string]int{"app": {"web": 2, "db": 1}, "tier": {"frontend": 1}}
+	if got := rc.Labels("po", "prod"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels(po, prod) = %v, want %v", got, want)
+	}
+	want = map[string]map[string]int{"app": {"web": 3, "db": 1}, "tier": {"frontend": 1}}
+	if got := rc.Labels("pods", ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels(pods, all) = %v, want %v", got, want)
+	}
+	if got := rc.Labels("nosuchkind", "prod"); got != nil {
+		t.Errorf("Labels(nosuchkind) = %v, want nil", got)
+	}
+
+	// The index is rebuilt once the cache changes
+	addMockObjects(pods, []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "cache-0", Namespace: "prod", Labels: map[string]string{"app": "cache"}}},
+	})
+	if got := rc.Labels("pods", "prod")["app"]["cache"]; got != 0 {
+		t.Errorf("Labels() before a change = %d cache pods, want the indexed 0", got)
+	}
+	rc.notifyChange()
+	if got := rc.Labels("pods", "prod")["app"]["cache"]; got != 1 {
+		t.Errorf("Labels() after a change = %d cache pods, want 1", got)
+	}
+}
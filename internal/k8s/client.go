@@ -1,11 +1,14 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -13,79 +16,164 @@ import (
 // Client wraps the Kubernetes client
 type Client struct {
 	Clientset  *kubernetes.Clientset
+	Metadata   metadata.Interface
+	Metrics    *MetricsClient
 	RestConfig *rest.Config
 }
 
-// NewClient creates a new Kubernetes client
+// NewClient creates a new Kubernetes client for the kubeconfig's current
+// context, or the in-cluster config when running inside a pod
 func NewClient(kubeconfigPath string) (*Client, error) {
-	// If no path provided, use default
-	if kubeconfigPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
-		}
-		kubeconfigPath = filepath.Join(homeDir, ".kube", "config")
-	}
-
 	// Check if running in-cluster
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		// Not in cluster, use kubeconfig
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+		config, err = clientConfig(kubeconfigPath, "").ClientConfig()
 		if err != nil {
 			return nil, fmt.Errorf("failed to build config: %w", err)
 		}
 	}
+	return newClientForConfig(config)
+}
+
+// NewClientForContext creates a new Kubernetes client for a named
+// kubeconfig context
+func NewClientForContext(kubeconfigPath, contextName string) (*Client, error) {
+	config, err := clientConfig(kubeconfigPath, contextName).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build config for context %q: %w", contextName, err)
+	}
+	return newClientForConfig(config)
+}
 
+// newClientForConfig creates the typed and metadata clients
+func newClientForConfig(config *rest.Config) (*Client, error) {
 	// Create clientset
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create clientset: %w", err)
 	}
 
+	// Metadata-only client for resources without typed listers
+	metadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metadata client: %w", err)
+	}
+
 	return &Client{
 		Clientset:  clientset,
+		Metadata:   metadataClient,
+		Metrics:    NewMetricsClient(clientset.Discovery().RESTClient()),
 		RestConfig: config,
 	}, nil
 }
 
+// clientConfig loads the kubeconfig the way kubectl does. kubeconfigPath
+// may be a KUBECONFIG-style list of files; empty means ~/.kube/config.
+func clientConfig(kubeconfigPath, contextName string) clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if paths := filepath.SplitList(kubeconfigPath); len(paths) > 1 {
+		rules.Precedence = paths
+	} else if kubeconfigPath != "" {
+		rules.ExplicitPath = kubeconfigPath
+	}
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+}
+
+// ContextInfo describes a kubeconfig context
+type ContextInfo struct {
+	Name      string
+	Cluster   string
+	User      string
+	Namespace string
+	Current   bool
+}
+
 // GetCurrentContext returns the current kubectl context
 func GetCurrentContext(kubeconfigPath string) (string, error) {
-	if kubeconfigPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", err
-		}
-		kubeconfigPath = filepath.Join(homeDir, ".kube", "config")
+	raw, err := clientConfig(kubeconfigPath, "").RawConfig()
+	if err != nil {
+		return "", err
 	}
+	return raw.CurrentContext, nil
+}
 
-	config, err := clientcmd.LoadFromFile(kubeconfigPath)
+// ContextNamespace returns the namespace a kubeconfig context selects,
+// "default" when it sets none. An empty contextName means the current one.
+func ContextNamespace(kubeconfigPath, contextName string) (string, error) {
+	ns, _, err := clientConfig(kubeconfigPath, contextName).Namespace()
 	if err != nil {
 		return "", err
 	}
-
-	return config.CurrentContext, nil
+	return ns, nil
 }
 
 // GetContexts returns all available contexts
 func GetContexts(kubeconfigPath string) ([]string, error) {
-	if kubeconfigPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, err
-		}
-		kubeconfigPath = filepath.Join(homeDir, ".kube", "config")
+	infos, err := ListContexts(kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := make([]string, 0, len(infos))
+	for _, info := range infos {
+		contexts = append(contexts, info.Name)
 	}
 
-	config, err := clientcmd.LoadFromFile(kubeconfigPath)
+	return contexts, nil
+}
+
+// ListContexts returns every kubeconfig context with its cluster, user and
+// namespace, sorted by name
+func ListContexts(kubeconfigPath string) ([]ContextInfo, error) {
+	raw, err := clientConfig(kubeconfigPath, "").RawConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	contexts := make([]string, 0, len(config.Contexts))
-	for name := range config.Contexts {
-		contexts = append(contexts, name)
+	infos := make([]ContextInfo, 0, len(raw.Contexts))
+	for name, ctx := range raw.Contexts {
+		infos = append(infos, ContextInfo{
+			Name:      name,
+			Cluster:   ctx.Cluster,
+			User:      ctx.AuthInfo,
+			Namespace: ctx.Namespace,
+			Current:   name == raw.CurrentContext,
+		})
 	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
 
-	return contexts, nil
+	return infos, nil
+}
+
+// Connect builds a client for a kubeconfig context and starts a cache for
+// it, returning once namespaces have synced. The cache lives until ctx is
+// cancelled or it is stopped. OpenAPI schemas are kept in schemaDir, and
+// metrics are polled every metricsInterval (0 for never).
+func Connect(ctx context.Context, kubeconfigPath, contextName, schemaDir string, resyncPeriod, metricsInterval, timeout time.Duration) (*ResourceCache, error) {
+	client, err := NewClientForContext(kubeconfigPath, contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := NewResourceCache(client.Clientset, client.Metadata, resyncPeriod)
+	cache.SetSchemaCacheDir(schemaDir)
+	cache.SetMetricsClient(client.Metrics, metricsInterval)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- cache.Start(ctx) }()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			cache.Stop()
+			return nil, err
+		}
+		return cache, nil
+	case <-time.After(timeout):
+		cache.Stop()
+		return nil, fmt.Errorf("timed out connecting to context %q", contextName)
+	}
 }
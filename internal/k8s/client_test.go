@@ -0,0 +1,109 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com
+- name: staging-cluster
+  cluster:
+    server: https://staging.example.com
+users:
+- name: alice
+  user:
+    token: abc
+contexts:
+- name: staging
+  context:
+    cluster: staging-cluster
+    user: alice
+- name: prod
+  context:
+    cluster: prod-cluster
+    user: alice
+    namespace: payments
+`
+
+func writeKubeconfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestListContexts(t *testing.T) {
+	path := writeKubeconfig(t)
+
+	contexts, err := ListContexts(path)
+	if err != nil {
+		t.Fatalf("ListContexts() error = %v", err)
+	}
+
+	want := []ContextInfo{
+		{Name: "prod", Cluster: "prod-cluster", User: "alice", Namespace: "payments"},
+		{Name: "staging", Cluster: "staging-cluster", User: "alice", Current: true},
+	}
+	if len(contexts) != len(want) {
+		t.Fatalf("ListContexts() returned %d contexts, want %d", len(contexts), len(want))
+	}
+	for i := range want {
+		if contexts[i] != want[i] {
+			t.Errorf("contexts[%d] = %+v, want %+v", i, contexts[i], want[i])
+		}
+	}
+
+	current, err := GetCurrentContext(path)
+	if err != nil || current != "staging" {
+		t.Errorf("GetCurrentContext() = %q, %v, want staging", current, err)
+	}
+}
+
+func TestNewClientForContext(t *testing.T) {
+	path := writeKubeconfig(t)
+
+	client, err := NewClientForContext(path, "prod")
+	if err != nil {
+		t.Fatalf("NewClientForContext() error = %v", err)
+	}
+	if client.RestConfig.Host != "https://prod.example.com" {
+		t.Errorf("Host = %q, want https://prod.example.com", client.RestConfig.Host)
+	}
+
+	if _, err := NewClientForContext(path, "missing"); err == nil {
+		t.Error("NewClientForContext() with unknown context should fail")
+	}
+}
+
+func TestContextNamespace(t *testing.T) {
+	path := writeKubeconfig(t)
+
+	tests := []struct {
+		context string
+		want    string
+	}{
+		{"prod", "payments"},
+		{"staging", "default"},
+		{"", "default"},
+	}
+
+	for _, tt := range tests {
+		got, err := ContextNamespace(path, tt.context)
+		if err != nil {
+			t.Errorf("ContextNamespace(%s) error = %v", tt.context, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ContextNamespace(%s) = %v, want %v", tt.context, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package k8s
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// ResourceInfo describes a listable API resource as reported by discovery
+type ResourceInfo struct {
+	Name         string // plural, e.g. "certificates"
+	SingularName string
+	Kind         string
+	Group        string
+	Version      string
+	Namespaced   bool
+	ShortNames   []string
+	Categories   []string
+}
+
+// GVR returns the group/version/resource used to talk to the API
+func (r ResourceInfo) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Name}
+}
+
+// QualifiedName returns the resource in kubectl's "plural.group" form
+func (r ResourceInfo) QualifiedName() string {
+	if r.Group == "" {
+		return r.Name
+	}
+	return r.Name + "." + r.Group
+}
+
+// CommandName returns the name to hand kubectl: the plural for built-in
+// kinds, and plural.group for extensions, whose plurals may collide
+func (r ResourceInfo) CommandName() string {
+	if isExtensionGroup(r.Group) {
+		return r.QualifiedName()
+	}
+	return r.Name
+}
+
+// resourceIndex resolves the names kubectl accepts for a resource type
+type resourceIndex struct {
+	resources  []ResourceInfo
+	aliases    map[string]int      // alias -> index into resources
+	categories map[string][]int    // category -> indexes into resources
+	ambiguous  map[string]struct{} // plural names served by more than one group
+}
+
+// newResourceIndex builds an index from discovery results. Lists are
+// expected in server preference order; when two groups serve the same
+// name the first one wins, matching kubectl's RESTMapper.
+func newResourceIndex(lists []*metav1.APIResourceList) *resourceIndex {
+	idx := &resourceIndex{
+		aliases:    make(map[string]int),
+		categories: make(map[string][]int),
+		ambiguous:  make(map[string]struct{}),
+	}
+	groupsByName := make(map[string]string)
+
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			// Skip subresources (pods/log) and anything we cannot list/watch
+			if strings.Contains(r.Name, "/") || !hasVerbs(r.Verbs, "list", "watch") {
+				continue
+			}
+			info := ResourceInfo{
+				Name:         r.Name,
+				SingularName: r.SingularName,
+				Kind:         r.Kind,
+				Group:        gv.Group,
+				Version:      gv.Version,
+				Namespaced:   r.Namespaced,
+				ShortNames:   r.ShortNames,
+				Categories:   r.Categories,
+			}
+			if info.SingularName == "" {
+				info.SingularName = strings.ToLower(r.Kind)
+			}
+			if g, ok := groupsByName[info.Name]; ok && g != info.Group {
+				idx.ambiguous[info.Name] = struct{}{}
+			}
+			groupsByName[info.Name] = info.Group
+			idx.add(info)
+		}
+	}
+	return idx
+}
+
+// add registers a resource under every name kubectl accepts for it
+func (idx *resourceIndex) add(info ResourceInfo) {
+	i := len(idx.resources)
+	idx.resources = append(idx.resources, info)
+
+	names := []string{info.Name, info.SingularName, strings.ToLower(info.Kind)}
+	names = append(names, info.ShortNames...)
+	for _, n := range names {
+		if n == "" {
+			continue
+		}
+		if _, ok := idx.aliases[n]; !ok {
+			idx.aliases[n] = i
+		}
+		if info.Group != "" {
+			idx.aliases[n+"."+info.Group] = i
+			idx.aliases[n+"."+info.Version+"."+info.Group] = i
+		}
+	}
+	for _, c := range info.Categories {
+		idx.categories[c] = append(idx.categories[c], i)
+	}
+}
+
+// lookup resolves a type name such as "deploy", "pods" or
+// "certificates.cert-manager.io"
+func (idx *resourceIndex) lookup(name string) (ResourceInfo, bool) {
+	if idx == nil {
+		return ResourceInfo{}, false
+	}
+	i, ok := idx.aliases[strings.ToLower(name)]
+	if !ok {
+		return ResourceInfo{}, false
+	}
+	return idx.resources[i], true
+}
+
+// category returns the resources in a category such as "all"
+func (idx *resourceIndex) category(name string) []ResourceInfo {
+	if idx == nil {
+		return nil
+	}
+	var out []ResourceInfo
+	for _, i := range idx.categories[name] {
+		out = append(out, idx.resources[i])
+	}
+	return out
+}
+
+// typeNames returns the names offered for completion: plural names, short
+// names and categories, plus "plural.group" for extension APIs and for any
+// name that more than one group serves
+func (idx *resourceIndex) typeNames() []string {
+	if idx == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var out []string
+	add := func(n string) {
+		if n != "" && !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+
+	for _, r := range idx.resources {
+		add(r.Name)
+		for _, s := range r.ShortNames {
+			add(s)
+		}
+		if _, ok := idx.ambiguous[r.Name]; ok || isExtensionGroup(r.Group) {
+			add(r.QualifiedName())
+		}
+	}
+	cats := make([]string, 0, len(idx.categories))
+	for c := range idx.categories {
+		cats = append(cats, c)
+	}
+	sort.Strings(cats)
+	for _, c := range cats {
+		add(c)
+	}
+	return out
+}
+
+// isExtensionGroup reports whether a group is served by a CRD or aggregated
+// API rather than by Kubernetes itself
+func isExtensionGroup(group string) bool {
+	return strings.Contains(group, ".") && !strings.HasSuffix(group, ".k8s.io")
+}
+
+// hasVerbs reports whether all wanted verbs are supported
+func hasVerbs(verbs metav1.Verbs, wanted ...string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, v := range verbs {
+			if v == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// discoverResources asks the API server for its preferred resources. A
+// failing aggregated API (e.g. metrics-server down) only drops its own
+// group, so partial results are returned alongside the error.
+func discoverResources(dc discovery.DiscoveryInterface) (*resourceIndex, error) {
+	lists, err := dc.ServerPreferredResources()
+	if err != nil {
+		var groupErr *discovery.ErrGroupDiscoveryFailed
+		if !errors.As(err, &groupErr) || len(lists) == 0 {
+			return nil, err
+		}
+	}
+	return newResourceIndex(lists), err
+}
+
+// builtinResources is used until discovery succeeds, and in demo mode
+var builtinResources = []*metav1.APIResourceList{
+	{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			builtin("pods", "Pod", true, []string{"po"}, "all"),
+			builtin("services", "Service", true, []string{"svc"}, "all"),
+			builtin("configmaps", "ConfigMap", true, []string{"cm"}),
+			builtin("secrets", "Secret", true, nil),
+			builtin("persistentvolumeclaims", "PersistentVolumeClaim", true, []string{"pvc"}),
+			builtin("persistentvolumes", "PersistentVolume", false, []string{"pv"}),
+			builtin("nodes", "Node", false, []string{"no"}),
+			builtin("namespaces", "Namespace", false, []string{"ns"}),
+			builtin("serviceaccounts", "ServiceAccount", true, []string{"sa"}),
+			builtin("replicationcontrollers", "ReplicationController", true, []string{"rc"}, "all"),
+			builtin("endpoints", "Endpoints", true, []string{"ep"}),
+			builtin("events", "Event", true, []string{"ev"}),
+			builtin("limitranges", "LimitRange", true, []string{"limits"}),
+			builtin("resourcequotas", "ResourceQuota", true, []string{"quota"}),
+		},
+	},
+	{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{
+			builtin("deployments", "Deployment", true, []string{"deploy"}, "all"),
+			builtin("replicasets", "ReplicaSet", true, []string{"rs"}, "all"),
+			builtin("statefulsets", "StatefulSet", true, []string{"sts"}, "all"),
+			builtin("daemonsets", "DaemonSet", true, []string{"ds"}, "all"),
+		},
+	},
+	{
+		GroupVersion: "batch/v1",
+		APIResources: []metav1.APIResource{
+			builtin("jobs", "Job", true, nil, "all"),
+			builtin("cronjobs", "CronJob", true, []string{"cj"}, "all"),
+		},
+	},
+	{
+		GroupVersion: "autoscaling/v2",
+		APIResources: []metav1.APIResource{
+			builtin("horizontalpodautoscalers", "HorizontalPodAutoscaler", true, []string{"hpa"}, "all"),
+		},
+	},
+	{
+		GroupVersion: "policy/v1",
+		APIResources: []metav1.APIResource{
+			builtin("poddisruptionbudgets", "PodDisruptionBudget", true, []string{"pdb"}),
+		},
+	},
+	{
+		GroupVersion: "networking.k8s.io/v1",
+		APIResources: []metav1.APIResource{
+			builtin("ingresses", "Ingress", true, []string{"ing"}),
+			builtin("networkpolicies", "NetworkPolicy", true, []string{"netpol"}),
+		},
+	},
+	{
+		GroupVersion: "storage.k8s.io/v1",
+		APIResources: []metav1.APIResource{
+			builtin("storageclasses", "StorageClass", false, []string{"sc"}),
+		},
+	},
+	{
+		GroupVersion: "rbac.authorization.k8s.io/v1",
+		APIResources: []metav1.APIResource{
+			builtin("roles", "Role", true, nil),
+			builtin("rolebindings", "RoleBinding", true, nil),
+			builtin("clusterroles", "ClusterRole", false, nil),
+			builtin("clusterrolebindings", "ClusterRoleBinding", false, nil),
+		},
+	},
+}
+
+// builtin describes a core resource for builtinResources
+func builtin(name, kind string, namespaced bool, shortNames []string, categories ...string) metav1.APIResource {
+	return metav1.APIResource{
+		Name:         name,
+		SingularName: strings.ToLower(kind),
+		Kind:         kind,
+		Namespaced:   namespaced,
+		ShortNames:   shortNames,
+		Categories:   categories,
+		Verbs:        metav1.Verbs{"get", "list", "watch"},
+	}
+}
@@ -0,0 +1,104 @@
+package k8s
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testResourceLists() []*metav1.APIResourceList {
+	verbs := metav1.Verbs{"get", "list", "watch"}
+	return []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", SingularName: "pod", Kind: "Pod", Namespaced: true, ShortNames: []string{"po"}, Categories: []string{"all"}, Verbs: verbs},
+				{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"get"}},
+				{Name: "bindings", Kind: "Binding", Namespaced: true, Verbs: metav1.Verbs{"create"}},
+			},
+		},
+		{
+			GroupVersion: "cert-manager.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "certificates", SingularName: "certificate", Kind: "Certificate", Namespaced: true, ShortNames: []string{"cert", "certs"}, Categories: []string{"cert-manager"}, Verbs: verbs},
+			},
+		},
+		{
+			GroupVersion: "events.k8s.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "events", SingularName: "event", Kind: "Event", Namespaced: true, ShortNames: []string{"ev"}, Verbs: verbs},
+			},
+		},
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "events", SingularName: "event", Kind: "Event", Namespaced: true, ShortNames: []string{"ev"}, Verbs: verbs},
+			},
+		},
+	}
+}
+
+func TestResourceIndex_Lookup(t *testing.T) {
+	idx := newResourceIndex(testResourceLists())
+
+	tests := []struct {
+		name      string
+		wantName  string
+		wantGroup string
+		wantOK    bool
+	}{
+		{"pods", "pods", "", true},
+		{"po", "pods", "", true},
+		{"pod", "pods", "", true},
+		{"Pod", "pods", "", true},
+		{"cert", "certificates", "cert-manager.io", true},
+		{"certificates.cert-manager.io", "certificates", "cert-manager.io", true},
+		{"certificate.v1.cert-manager.io", "certificates", "cert-manager.io", true},
+		{"events", "events", "events.k8s.io", true},
+		{"events.events.k8s.io", "events", "events.k8s.io", true},
+		{"pods/log", "", "", false},
+		{"bindings", "", "", false},
+		{"unknown", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, ok := idx.lookup(tt.name)
+			if ok != tt.wantOK {
+				t.Fatalf("lookup(%q) ok = %v, want %v", tt.name, ok, tt.wantOK)
+			}
+			if info.Name != tt.wantName || info.Group != tt.wantGroup {
+				t.Errorf("lookup(%q) = %s.%s, want %s.%s", tt.name, info.Name, info.Group, tt.wantName, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func TestResourceIndex_TypeNames(t *testing.T) {
+	idx := newResourceIndex(testResourceLists())
+
+	got := make(map[string]bool)
+	for _, n := range idx.typeNames() {
+		got[n] = true
+	}
+
+	for _, want := range []string{"pods", "po", "certificates", "cert", "certificates.cert-manager.io", "events", "events.events.k8s.io", "all", "cert-manager"} {
+		if !got[want] {
+			t.Errorf("typeNames() missing %q", want)
+		}
+	}
+	for _, unwanted := range []string{"pods/log", "bindings", "pods.v1"} {
+		if got[unwanted] {
+			t.Errorf("typeNames() should not contain %q", unwanted)
+		}
+	}
+}
+
+func TestResourceIndex_Category(t *testing.T) {
+	idx := newResourceIndex(testResourceLists())
+
+	all := idx.category("all")
+	if len(all) != 1 || all[0].Name != "pods" {
+		t.Errorf("category(all) = %v, want [pods]", all)
+	}
+}
@@ -0,0 +1,135 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// mirrorPodAnnotation marks the API copies of static pods, which drain
+// cannot remove
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// DrainPlan is what draining a node would do, as far as the cache knows
+type DrainPlan struct {
+	Node    string
+	Evict   []DrainPod
+	Skip    []DrainPod       // left running on the node
+	Budgets []BlockingBudget // budgets that would refuse some of the evictions
+}
+
+// DrainPod is a pod on a drained node. Note says why it is skipped, or
+// which drain flag evicting it needs.
+type DrainPod struct {
+	Namespace string
+	Name      string
+	Note      string
+}
+
+// BlockingBudget is a PodDisruptionBudget covering more of the evicted
+// pods than it currently allows to be disrupted
+type BlockingBudget struct {
+	Namespace string
+	Name      string
+	Allowed   int32    // disruptions the budget allows right now
+	Pods      []string // evicted pods it covers
+}
+
+// PlanDrain works out which pods draining a node would evict, following
+// kubectl drain's rules, and which PodDisruptionBudgets would block them
+func (rc *ResourceCache) PlanDrain(node string) (DrainPlan, error) {
+	plan := DrainPlan{Node: node}
+	if _, err := rc.nodes.Get(node); err != nil {
+		return plan, fmt.Errorf("node %s not found", node)
+	}
+
+	pods, _ := rc.pods.List(labels.Everything())
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+
+	var disrupted []*corev1.Pod
+	for _, pod := range pods {
+		if pod.Spec.NodeName != node {
+			continue
+		}
+		p := DrainPod{Namespace: pod.Namespace, Name: pod.Name}
+		controller := metav1.GetControllerOf(pod)
+		finished := pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+
+		switch {
+		case pod.Annotations[mirrorPodAnnotation] != "":
+			p.Note = "static pod"
+			plan.Skip = append(plan.Skip, p)
+			continue
+		case controller != nil && controller.Kind == "DaemonSet" && !finished:
+			p.Note = "DaemonSet"
+			plan.Skip = append(plan.Skip, p)
+			continue
+		case finished:
+			p.Note = "finished"
+		case controller == nil:
+			p.Note = "unmanaged, needs --force"
+		case usesEmptyDir(pod):
+			p.Note = "emptyDir data is lost, needs --delete-emptydir-data"
+		}
+		plan.Evict = append(plan.Evict, p)
+		if !finished {
+			disrupted = append(disrupted, pod)
+		}
+	}
+
+	plan.Budgets = rc.blockingBudgets(disrupted)
+	return plan, nil
+}
+
+// blockingBudgets returns the budgets that allow fewer disruptions than
+// the number of pods they cover
+func (rc *ResourceCache) blockingBudgets(pods []*corev1.Pod) []BlockingBudget {
+	var blocking []BlockingBudget
+	budgets, _ := rc.pdbs.List(labels.Everything())
+	sort.Slice(budgets, func(i, j int) bool {
+		if budgets[i].Namespace != budgets[j].Namespace {
+			return budgets[i].Namespace < budgets[j].Namespace
+		}
+		return budgets[i].Name < budgets[j].Name
+	})
+
+	for _, pdb := range budgets {
+		sel, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		var covered []string
+		for _, pod := range pods {
+			if pod.Namespace == pdb.Namespace && sel.Matches(labels.Set(pod.Labels)) {
+				covered = append(covered, pod.Name)
+			}
+		}
+		if len(covered) > int(pdb.Status.DisruptionsAllowed) {
+			blocking = append(blocking, BlockingBudget{
+				Namespace: pdb.Namespace,
+				Name:      pdb.Name,
+				Allowed:   pdb.Status.DisruptionsAllowed,
+				Pods:      covered,
+			})
+		}
+	}
+	return blocking
+}
+
+// usesEmptyDir checks if a pod keeps data in an emptyDir volume
+func usesEmptyDir(pod *corev1.Pod) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
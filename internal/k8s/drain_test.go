@@ -0,0 +1,81 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResourceCache_PlanDrain(t *testing.T) {
+	rc := NewResourceCache(nil, nil, 0)
+	web := map[string]string{"app": "web"}
+	isController := true
+	owner := func(kind string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: kind, Name: "owner", Controller: &isController}}
+	}
+	running := corev1.PodStatus{Phase: corev1.PodRunning}
+
+	addMockObjects(rc.factory.Core().V1().Nodes().Informer(), []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+	})
+	addMockObjects(rc.factory.Core().V1().Pods().Informer(), []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "prod", Labels: web, OwnerReferences: owner("ReplicaSet")}, Spec: corev1.PodSpec{NodeName: "node-1"}, Status: running},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-b", Namespace: "prod", Labels: web, OwnerReferences: owner("ReplicaSet")}, Spec: corev1.PodSpec{NodeName: "node-1"}, Status: running},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-c", Namespace: "prod", Labels: web, OwnerReferences: owner("ReplicaSet")}, Spec: corev1.PodSpec{NodeName: "node-2"}, Status: running},
+		{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "kube-system", OwnerReferences: owner("DaemonSet")}, Spec: corev1.PodSpec{NodeName: "node-1"}, Status: running},
+		{ObjectMeta: metav1.ObjectMeta{Name: "etcd", Namespace: "kube-system", Annotations: map[string]string{mirrorPodAnnotation: "x"}}, Spec: corev1.PodSpec{NodeName: "node-1"}, Status: running},
+		{ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "prod"}, Spec: corev1.PodSpec{NodeName: "node-1"}, Status: running},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "prod", OwnerReferences: owner("StatefulSet")}, Spec: corev1.PodSpec{NodeName: "node-1", Volumes: []corev1.Volume{{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}}, Status: running},
+		{ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "prod", Labels: web, OwnerReferences: owner("Job")}, Spec: corev1.PodSpec{NodeName: "node-1"}, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+	})
+	addMockObjects(rc.factory.Policy().V1().PodDisruptionBudgets().Informer(), []policyv1.PodDisruptionBudget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: web}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "relaxed", Namespace: "prod"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: web}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 2},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "none", Namespace: "prod"},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 0},
+		},
+	})
+
+	got, err := rc.PlanDrain("node-1")
+	if err != nil {
+		t.Fatalf("PlanDrain() error = %v", err)
+	}
+	want := DrainPlan{
+		Node: "node-1",
+		Evict: []DrainPod{
+			{Namespace: "prod", Name: "cache", Note: "emptyDir data is lost, needs --delete-emptydir-data"},
+			{Namespace: "prod", Name: "debug", Note: "unmanaged, needs --force"},
+			{Namespace: "prod", Name: "migrate", Note: "finished"},
+			{Namespace: "prod", Name: "web-a"},
+			{Namespace: "prod", Name: "web-b"},
+		},
+		Skip: []DrainPod{
+			{Namespace: "kube-system", Name: "agent", Note: "DaemonSet"},
+			{Namespace: "kube-system", Name: "etcd", Note: "static pod"},
+		},
+		Budgets: []BlockingBudget{{Namespace: "prod", Name: "web", Allowed: 1, Pods: []string{"web-a", "web-b"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PlanDrain(node-1) = %+v, want %+v", got, want)
+	}
+
+	if got, err := rc.PlanDrain("node-2"); err != nil || len(got.Evict) != 1 || len(got.Budgets) != 0 {
+		t.Errorf("PlanDrain(node-2) = %+v, %v, want web-c evicted and nothing blocking", got, err)
+	}
+	if _, err := rc.PlanDrain("node-9"); err == nil {
+		t.Error("PlanDrain(node-9) error = nil, want not found")
+	}
+}
@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// RefreshDiscovery re-reads the resource types the cluster serves. Partial
+// failures (an unavailable aggregated API) still update the index.
+func (rc *ResourceCache) RefreshDiscovery() error {
+	if rc.discovery == nil {
+		return nil
+	}
+	rc.discovery.Invalidate()
+
+	idx, err := discoverResources(rc.discovery)
+	if idx != nil {
+		rc.mu.Lock()
+		rc.resources = idx
+		rc.mu.Unlock()
+	}
+	if rc.schemas != nil {
+		rc.schemas.invalidate()
+	}
+	return err
+}
+
+// discoveryLoop refreshes discovery every resync period so CRDs installed
+// while purr runs become completable
+func (rc *ResourceCache) discoveryLoop() {
+	period := rc.resyncPeriod
+	if period <= 0 {
+		period = 5 * time.Minute
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rc.ctx.Done():
+			return
+		case <-ticker.C:
+			_ = rc.RefreshDiscovery()
+		}
+	}
+}
+
+// Resources returns every listable resource type the cluster serves
+func (rc *ResourceCache) Resources() []ResourceInfo {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	out := make([]ResourceInfo, len(rc.resources.resources))
+	copy(out, rc.resources.resources)
+	return out
+}
+
+// LookupResource resolves a resource type name the way kubectl does
+func (rc *ResourceCache) LookupResource(name string) (ResourceInfo, bool) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.resources.lookup(name)
+}
+
+// metadataInformer returns the metadata informer for a resource, starting
+// it on first use. It does not wait for the informer to sync, as lookups
+// come from completion while the user types: the first ones find nothing,
+// and Changes fires as the objects arrive.
+func (rc *ResourceCache) metadataInformer(info ResourceInfo) informers.GenericInformer {
+	if rc.metaFactory == nil || rc.ctx == nil {
+		return nil
+	}
+
+	gvr := info.GVR()
+	informer := rc.metaFactory.ForResource(gvr)
+
+	rc.mu.Lock()
+	started := rc.dynamic[gvr]
+	if !started {
+		rc.dynamic[gvr] = true
+		_ = informer.Informer().SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+			rc.lastWatchErr.Store(err)
+		})
+		_, _ = informer.Informer().AddEventHandler(rc.changeHandler())
+	}
+	rc.mu.Unlock()
+
+	if !started {
+		rc.metaFactory.Start(rc.ctx.Done())
+	}
+	return informer
+}
+
+// metadataObjects returns cached object metadata for any resource type,
+// sorted by name. Cluster-scoped kinds ignore the namespace.
+func (rc *ResourceCache) metadataObjects(info ResourceInfo, namespace string) []*metav1.PartialObjectMetadata {
+	informer := rc.metadataInformer(info)
+	if informer == nil {
+		return nil
+	}
+
+	var objs []runtime.Object
+	if info.Namespaced && namespace != "" {
+		objs, _ = informer.Lister().ByNamespace(namespace).List(labels.Everything())
+	} else {
+		objs, _ = informer.Lister().List(labels.Everything())
+	}
+
+	result := make([]*metav1.PartialObjectMetadata, 0, len(objs))
+	for _, obj := range objs {
+		if m, ok := obj.(*metav1.PartialObjectMetadata); ok {
+			result = append(result, m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// MetadataToListItems converts object metadata to list items
+func (rc *ResourceCache) MetadataToListItems(info ResourceInfo, objs []*metav1.PartialObjectMetadata) []types.ListItem {
+	items := make([]types.ListItem, len(objs))
+	for i, obj := range objs {
+		age := time.Since(obj.CreationTimestamp.Time).Round(time.Second).String()
+
+		desc := fmt.Sprintf("Kind: %s | Age: %s", info.Kind, age)
+		if obj.Namespace != "" {
+			desc += " | NS: " + obj.Namespace
+		}
+
+		items[i] = types.ListItem{
+			Title:       obj.Name,
+			Description: desc,
+			Metadata: map[string]string{
+				"namespace": obj.Namespace,
+				"kind":      info.Kind,
+				"age":       age,
+			},
+		}
+	}
+	return items
+}
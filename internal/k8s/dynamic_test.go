@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	metadatafake "k8s.io/client-go/metadata/fake"
+)
+
+func TestResourceCache_MetadataObjectsDoNotWait(t *testing.T) {
+	info := ResourceInfo{Name: "certificates", Kind: "Certificate", Group: "cert-manager.io", Version: "v1", Namespaced: true}
+	cert := &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "cert-manager.io/v1", Kind: "Certificate"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "shop"},
+	}
+	scheme := metadatafake.NewTestScheme()
+	gv := schema.GroupVersion{Group: info.Group, Version: info.Version}
+	scheme.AddKnownTypeWithName(gv.WithKind("Certificate"), &metav1.PartialObjectMetadata{})
+	scheme.AddKnownTypeWithName(gv.WithKind("CertificateList"), &metav1.PartialObjectMetadataList{})
+	client := metadatafake.NewSimpleMetadataClient(scheme, cert)
+
+	rc := NewResourceCache(nil, client, 0)
+	rc.ctx, rc.cancel = context.WithCancel(context.Background())
+	defer rc.Stop()
+
+	// The first lookup starts the informer and returns what it has so far
+	start := time.Now()
+	rc.metadataObjects(info, "shop")
+	if waited := time.Since(start); waited > 100*time.Millisecond {
+		t.Errorf("first lookup took %v, want it not to wait for the informer", waited)
+	}
+
+	// Changes fires once the objects arrive
+	select {
+	case <-rc.Changes():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Changes() never fired for the lazily started informer")
+	}
+	objs := rc.metadataObjects(info, "shop")
+	if len(objs) != 1 || objs[0].Name != "web-tls" {
+		t.Errorf("metadataObjects() = %v, want web-tls", objs)
+	}
+}
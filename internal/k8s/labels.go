@@ -0,0 +1,90 @@
+package k8s
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// labelIndexKey is a kind in a namespace, "" for all namespaces
+type labelIndexKey struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+// labelIndexEntry is the labels of a kind as of a generation of changes
+type labelIndexEntry struct {
+	generation uint64
+	labels     map[string]map[string]int // key → value → objects
+}
+
+// Labels returns the label keys and values of the cached objects of a kind
+// in a namespace ("" for all namespaces), with how many objects carry
+// each. The counts are indexed per kind and namespace, and rebuilt after
+// the cache changes.
+func (rc *ResourceCache) Labels(kind, namespace string) map[string]map[string]int {
+	info, ok := rc.LookupResource(kind)
+	if !ok {
+		return nil
+	}
+	if !info.Namespaced {
+		namespace = ""
+	}
+	key := labelIndexKey{gvr: info.GVR(), namespace: namespace}
+	generation := rc.generation.Load()
+
+	rc.labelsMu.Lock()
+	entry, ok := rc.labelIndex[key]
+	rc.labelsMu.Unlock()
+	if ok && entry.generation == generation {
+		return entry.labels
+	}
+
+	counts := make(map[string]map[string]int)
+	for _, obj := range rc.objects(info, namespace) {
+		for k, v := range obj.GetLabels() {
+			if counts[k] == nil {
+				counts[k] = make(map[string]int)
+			}
+			counts[k][v]++
+		}
+	}
+
+	rc.labelsMu.Lock()
+	rc.labelIndex[key] = labelIndexEntry{generation: generation, labels: counts}
+	rc.labelsMu.Unlock()
+	return counts
+}
+
+// objects returns the cached objects of a kind in a namespace, from the
+// typed informers if they hold the kind and the metadata ones otherwise
+func (rc *ResourceCache) objects(info ResourceInfo, namespace string) []metav1.Object {
+	if typedGroups[info.Name] != info.Group {
+		metas := rc.metadataObjects(info, namespace)
+		out := make([]metav1.Object, len(metas))
+		for i, m := range metas {
+			out[i] = m
+		}
+		return out
+	}
+
+	informer, err := rc.factory.ForResource(info.GVR())
+	if err != nil {
+		return nil
+	}
+	var objs []runtime.Object
+	if info.Namespaced && namespace != "" {
+		objs, _ = informer.Lister().ByNamespace(namespace).List(labels.Everything())
+	} else {
+		objs, _ = informer.Lister().List(labels.Everything())
+	}
+	out := make([]metav1.Object, 0, len(objs))
+	for _, obj := range objs {
+		if m, err := meta.Accessor(obj); err == nil {
+			out = append(out, m)
+		}
+	}
+	return out
+}
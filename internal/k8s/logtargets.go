@@ -0,0 +1,138 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// LogTarget is one container whose logs can be streamed
+type LogTarget struct {
+	Namespace string
+	Pod       string
+	Container string
+}
+
+// LogTargets resolves a workload ("deploy/api", "sts/db", "ds/agent",
+// "svc/web", a bare pod name) or a label selector to the containers of the
+// pods it selects. Only running containers are returned, since following
+// the logs of one that has not started fails straight away. Exactly one of
+// target and selector should be set.
+func (rc *ResourceCache) LogTargets(namespace, target, selector string) ([]LogTarget, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	var sel labels.Selector
+	switch {
+	case selector != "":
+		var err error
+		if sel, err = labels.Parse(selector); err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+	case target != "":
+		kind, name := "pods", target
+		if k, n, ok := strings.Cut(target, "/"); ok {
+			kind, name = k, n
+		}
+		info, ok := rc.LookupResource(kind)
+		if !ok {
+			return nil, fmt.Errorf("unknown resource type %s", kind)
+		}
+		if info.Name == "pods" {
+			pod, err := rc.pods.Pods(namespace).Get(name)
+			if err != nil {
+				return nil, fmt.Errorf("pod %s not found in %s", name, namespace)
+			}
+			return podLogTargets(*pod), nil
+		}
+		var err error
+		if sel, err = rc.workloadSelector(info.Name, namespace, name); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("no workload or selector given")
+	}
+
+	pods, _ := rc.pods.Pods(namespace).List(sel)
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	var targets []LogTarget
+	for _, pod := range pods {
+		targets = append(targets, podLogTargets(*pod)...)
+	}
+	return targets, nil
+}
+
+// workloadSelector returns the pod selector of a cached workload or
+// service
+func (rc *ResourceCache) workloadSelector(resource, namespace, name string) (labels.Selector, error) {
+	notFound := fmt.Errorf("%s %s not found in %s", resource, name, namespace)
+
+	var ls *metav1.LabelSelector
+	switch resource {
+	case "deployments":
+		dep, err := rc.deployments.Deployments(namespace).Get(name)
+		if err != nil {
+			return nil, notFound
+		}
+		ls = dep.Spec.Selector
+	case "statefulsets":
+		sts, err := rc.statefulsets.StatefulSets(namespace).Get(name)
+		if err != nil {
+			return nil, notFound
+		}
+		ls = sts.Spec.Selector
+	case "daemonsets":
+		ds, err := rc.daemonsets.DaemonSets(namespace).Get(name)
+		if err != nil {
+			return nil, notFound
+		}
+		ls = ds.Spec.Selector
+	case "jobs":
+		job, err := rc.jobs.Jobs(namespace).Get(name)
+		if err != nil {
+			return nil, notFound
+		}
+		ls = job.Spec.Selector
+	case "services":
+		svc, err := rc.services.Services(namespace).Get(name)
+		if err != nil {
+			return nil, notFound
+		}
+		ls = &metav1.LabelSelector{MatchLabels: svc.Spec.Selector}
+	default:
+		return nil, fmt.Errorf("%s do not select pods", resource)
+	}
+
+	// An empty selector would match every pod in the namespace
+	if ls == nil || (len(ls.MatchLabels) == 0 && len(ls.MatchExpressions) == 0) {
+		return nil, fmt.Errorf("%s %s has no pod selector", resource, name)
+	}
+	return metav1.LabelSelectorAsSelector(ls)
+}
+
+// podLogTargets returns a pod's running containers. Pods reported running
+// without container statuses (as in demo mode) count all their containers.
+func podLogTargets(pod corev1.Pod) []LogTarget {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return nil
+	}
+
+	running := make(map[string]bool)
+	for _, cs := range pod.Status.ContainerStatuses {
+		running[cs.Name] = cs.State.Running != nil
+	}
+
+	var targets []LogTarget
+	for _, c := range pod.Spec.Containers {
+		if ok, known := running[c.Name]; known && !ok {
+			continue
+		}
+		targets = append(targets, LogTarget{Namespace: pod.Namespace, Pod: pod.Name, Container: c.Name})
+	}
+	return targets
+}
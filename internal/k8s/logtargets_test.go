@@ -0,0 +1,85 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResourceCache_LogTargets(t *testing.T) {
+	rc := NewResourceCache(nil, nil, 0)
+	web := map[string]string{"app": "web"}
+	containers := []corev1.Container{{Name: "app"}, {Name: "proxy"}}
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	waiting := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+
+	addMockObjects(rc.factory.Core().V1().Pods().Informer(), []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-b", Namespace: "prod", Labels: web},
+			Spec:       corev1.PodSpec{Containers: containers},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", State: running},
+				{Name: "proxy", State: waiting},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "prod", Labels: web},
+			Spec:       corev1.PodSpec{Containers: containers},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-pending", Namespace: "prod", Labels: web},
+			Spec:       corev1.PodSpec{Containers: containers},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "prod", Labels: map[string]string{"app": "db"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "postgres"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	})
+	addMockObjects(rc.factory.Apps().V1().Deployments().Informer(), []appsv1.Deployment{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: web}},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "prod"}},
+	})
+
+	webTargets := []LogTarget{
+		{Namespace: "prod", Pod: "web-a", Container: "app"},
+		{Namespace: "prod", Pod: "web-a", Container: "proxy"},
+		{Namespace: "prod", Pod: "web-b", Container: "app"},
+	}
+	tests := []struct {
+		target, selector string
+		want             []LogTarget
+		wantErr          bool
+	}{
+		{target: "deploy/web", want: webTargets},
+		{target: "deployments.apps/web", want: webTargets},
+		{selector: "app=web", want: webTargets},
+		{selector: "app in (db)", want: []LogTarget{{Namespace: "prod", Pod: "db-0", Container: "postgres"}}},
+		{target: "db-0", want: []LogTarget{{Namespace: "prod", Pod: "db-0", Container: "postgres"}}},
+		{target: "web-pending"},
+		{target: "deploy/missing", wantErr: true},
+		{target: "deploy/broken", wantErr: true},
+		{target: "cm/settings", wantErr: true},
+		{selector: "app in (", wantErr: true},
+		{wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := rc.LogTargets("prod", tt.target, tt.selector)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("LogTargets(%s, %s) error = %v, wantErr %v", tt.target, tt.selector, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LogTargets(%s, %s) = %v, want %v", tt.target, tt.selector, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// metricsHistory is how many samples are kept per pod and node, for the
+// sparklines of the top view
+const metricsHistory = 30
+
+// metricsRetry is how long to wait before asking a cluster without a
+// working metrics-server again
+const metricsRetry = 5 * time.Minute
+
+// metricsTimeout bounds one poll of metrics-server
+const metricsTimeout = 10 * time.Second
+
+// errMetricsPending is reported until the first poll is done
+var errMetricsPending = errors.New("metrics are not loaded yet")
+
+// Usage is the CPU and memory a pod or node uses
+type Usage struct {
+	CPU    int64 // millicores
+	Memory int64 // bytes
+}
+
+// UsageSample is what a pod or node used in a metrics-server window. A
+// pod's usage is the sum of its containers'.
+type UsageSample struct {
+	Name      string
+	Namespace string // empty for nodes
+	Time      time.Time
+	Usage
+}
+
+// UsageSeries is the latest usage of a pod or node and the samples before
+// it
+type UsageSeries struct {
+	Name      string
+	Namespace string  // empty for nodes
+	Usage             // the latest sample
+	History   []Usage // oldest first, ending with the latest
+	Capacity  Usage   // what a node can allocate, zero for pods
+}
+
+// MetricsClient reads the CPU and memory pods and nodes use from the
+// metrics.k8s.io API that metrics-server serves
+type MetricsClient struct {
+	fetch func(ctx context.Context, path string) ([]byte, error)
+}
+
+// NewMetricsClient creates a metrics client that talks to the API server
+// through client
+func NewMetricsClient(client rest.Interface) *MetricsClient {
+	return &MetricsClient{fetch: func(ctx context.Context, path string) ([]byte, error) {
+		return client.Get().AbsPath(path).Do(ctx).Raw()
+	}}
+}
+
+// metricsList is a PodMetricsList or NodeMetricsList
+type metricsList struct {
+	Items []struct {
+		metav1.ObjectMeta `json:"metadata"`
+		Timestamp         metav1.Time         `json:"timestamp"`
+		Usage             corev1.ResourceList `json:"usage"` // nodes
+		Containers        []struct {
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"` // pods
+	} `json:"items"`
+}
+
+// Pods returns the usage of every pod in the cluster
+func (mc *MetricsClient) Pods(ctx context.Context) ([]UsageSample, error) {
+	return mc.list(ctx, "/apis/metrics.k8s.io/v1beta1/pods")
+}
+
+// Nodes returns the usage of every node
+func (mc *MetricsClient) Nodes(ctx context.Context) ([]UsageSample, error) {
+	return mc.list(ctx, "/apis/metrics.k8s.io/v1beta1/nodes")
+}
+
+// list fetches and sums up a metrics list
+func (mc *MetricsClient) list(ctx context.Context, path string) ([]UsageSample, error) {
+	data, err := mc.fetch(ctx, path)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil, errors.New("metrics are not available: metrics-server is not installed")
+	case apierrors.IsServiceUnavailable(err):
+		return nil, errors.New("metrics are not available: metrics-server is not responding")
+	case err != nil:
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+
+	var list metricsList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+	samples := make([]UsageSample, len(list.Items))
+	for i, item := range list.Items {
+		usage := resourceUsage(item.Usage)
+		for _, c := range item.Containers {
+			u := resourceUsage(c.Usage)
+			usage.CPU += u.CPU
+			usage.Memory += u.Memory
+		}
+		samples[i] = UsageSample{Name: item.Name, Namespace: item.Namespace, Time: item.Timestamp.Time, Usage: usage}
+	}
+	return samples, nil
+}
+
+// resourceUsage reads the CPU and memory of a resource list
+func resourceUsage(list corev1.ResourceList) Usage {
+	return Usage{CPU: list.Cpu().MilliValue(), Memory: list.Memory().Value()}
+}
+
+// metricsStore keeps the recent usage of pods and nodes, polled from
+// metrics-server
+type metricsStore struct {
+	client   *MetricsClient // nil when the samples are filled in directly
+	interval time.Duration
+
+	mu    sync.RWMutex
+	pods  map[string]*usageHistory // namespace/name → samples
+	nodes map[string]*usageHistory
+	err   error // why the last poll failed
+}
+
+// usageHistory is the samples of one pod or node, oldest first
+type usageHistory struct {
+	last    time.Time // when the latest sample was taken
+	samples []Usage
+}
+
+// newMetricsStore creates a store that polls client every interval
+func newMetricsStore(client *MetricsClient, interval time.Duration) *metricsStore {
+	return &metricsStore{
+		client:   client,
+		interval: interval,
+		pods:     make(map[string]*usageHistory),
+		nodes:    make(map[string]*usageHistory),
+		err:      errMetricsPending,
+	}
+}
+
+// SetMetricsClient makes the cache poll client for the usage of pods and
+// nodes every interval. It must be called before Start.
+func (rc *ResourceCache) SetMetricsClient(client *MetricsClient, interval time.Duration) {
+	if client != nil && interval > 0 {
+		rc.metrics = newMetricsStore(client, interval)
+	}
+}
+
+// metricsLoop polls metrics-server until the cache stops. A cluster
+// without a working metrics-server is asked again every metricsRetry.
+func (rc *ResourceCache) metricsLoop() {
+	for {
+		wait := rc.metrics.interval
+		if err := rc.metrics.poll(rc.ctx); err != nil {
+			wait = max(wait, metricsRetry)
+		}
+		rc.notifyChange()
+
+		select {
+		case <-rc.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// poll reads the usage of every pod and node once
+func (s *metricsStore) poll(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, metricsTimeout)
+	defer cancel()
+
+	pods, err := s.client.Pods(ctx)
+	var nodes []UsageSample
+	if err == nil {
+		nodes, err = s.client.Nodes(ctx)
+	}
+	if err != nil {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+		return err
+	}
+	s.record(pods, nodes)
+	return nil
+}
+
+// record adds a poll's samples, and forgets pods and nodes it lacks
+func (s *metricsStore) record(pods, nodes []UsageSample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = nil
+	s.pods = addSamples(s.pods, pods)
+	s.nodes = addSamples(s.nodes, nodes)
+}
+
+// addSamples appends samples to their histories. metrics-server refreshes
+// every 15s or so, and a sample seen before is not counted twice.
+func addSamples(old map[string]*usageHistory, samples []UsageSample) map[string]*usageHistory {
+	out := make(map[string]*usageHistory, len(samples))
+	for _, sample := range samples {
+		key := sample.Namespace + "/" + sample.Name
+		h := old[key]
+		if h == nil {
+			h = &usageHistory{}
+		}
+		if len(h.samples) == 0 || sample.Time.After(h.last) {
+			h.last = sample.Time
+			h.samples = append(h.samples, sample.Usage)
+			if len(h.samples) > metricsHistory {
+				h.samples = h.samples[len(h.samples)-metricsHistory:]
+			}
+		}
+		out[key] = h
+	}
+	return out
+}
+
+// series returns the usage of the pods or nodes in a namespace ("" for
+// all), by namespace and name
+func series(histories map[string]*usageHistory, namespace string) []UsageSeries {
+	var out []UsageSeries
+	for key, h := range histories {
+		ns, name, _ := strings.Cut(key, "/")
+		if namespace != "" && ns != namespace {
+			continue
+		}
+		out = append(out, UsageSeries{
+			Name:      name,
+			Namespace: ns,
+			Usage:     h.samples[len(h.samples)-1],
+			History:   append([]Usage(nil), h.samples...),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// PodUsage returns the CPU and memory the pods in a namespace ("" for all)
+// use, with their recent samples. It returns nil while MetricsError
+// reports a problem.
+func (rc *ResourceCache) PodUsage(namespace string) []UsageSeries {
+	if rc.metrics == nil {
+		return nil
+	}
+	rc.metrics.mu.RLock()
+	defer rc.metrics.mu.RUnlock()
+	if rc.metrics.err != nil {
+		return nil
+	}
+	return series(rc.metrics.pods, namespace)
+}
+
+// NodeUsage returns the CPU and memory the nodes use, with their recent
+// samples and what they can allocate. It returns nil while MetricsError
+// reports a problem.
+func (rc *ResourceCache) NodeUsage() []UsageSeries {
+	if rc.metrics == nil {
+		return nil
+	}
+	rc.metrics.mu.RLock()
+	series := series(rc.metrics.nodes, "")
+	failed := rc.metrics.err != nil
+	rc.metrics.mu.RUnlock()
+	if failed {
+		return nil
+	}
+
+	for i := range series {
+		if node, err := rc.nodes.Get(series[i].Name); err == nil {
+			series[i].Capacity = resourceUsage(node.Status.Allocatable)
+		}
+	}
+	return series
+}
+
+// MetricsError returns why pod and node usage is not available: metrics
+// are turned off, metrics-server is missing or failing, or the first poll
+// is not done yet. It returns nil once usage is known.
+func (rc *ResourceCache) MetricsError() error {
+	if rc.metrics == nil {
+		return errors.New("metrics are turned off")
+	}
+	rc.metrics.mu.RLock()
+	defer rc.metrics.mu.RUnlock()
+	return rc.metrics.err
+}
+
+// usageIndex maps namespace/name to the latest usage of pods or nodes
+func usageIndex(usage []UsageSeries) map[string]Usage {
+	index := make(map[string]Usage, len(usage))
+	for _, u := range usage {
+		index[u.Namespace+"/"+u.Name] = u.Usage
+	}
+	return index
+}
+
+// addUsage adds what a pod or node uses to its picker item
+func addUsage(item *types.ListItem, u Usage) {
+	cpu, memory := FormatCPU(u.CPU), FormatMemory(u.Memory)
+	item.Description += fmt.Sprintf(" | CPU: %s | Mem: %s", cpu, memory)
+	item.Metadata["cpu"], item.Metadata["memory"] = cpu, memory
+}
+
+// FormatCPU formats millicores the way kubectl top does, e.g. 250m
+func FormatCPU(milli int64) string {
+	return fmt.Sprintf("%dm", milli)
+}
+
+// FormatMemory formats bytes the way kubectl top does, e.g. 128Mi
+func FormatMemory(bytes int64) string {
+	return fmt.Sprintf("%dMi", bytes/(1024*1024))
+}
@@ -0,0 +1,178 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const podMetricsJSON = `{"kind":"PodMetricsList","items":[
+ {"metadata":{"name":"web-0","namespace":"prod"},"timestamp":"2024-05-01T10:00:00Z","window":"15s",
+  "containers":[{"name":"app","usage":{"cpu":"250m","memory":"128Mi"}},{"name":"proxy","usage":{"cpu":"1500000n","memory":"16Mi"}}]}
+]}`
+
+const nodeMetricsJSON = `{"kind":"NodeMetricsList","items":[
+ {"metadata":{"name":"node-1"},"timestamp":"2024-05-01T10:00:00Z","window":"20s","usage":{"cpu":"2","memory":"4Gi"}}
+]}`
+
+// fakeMetricsServer serves metrics lists, or fails with err
+func fakeMetricsServer(err error) *MetricsClient {
+	return &MetricsClient{fetch: func(_ context.Context, path string) ([]byte, error) {
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(path, "/nodes") {
+			return []byte(nodeMetricsJSON), nil
+		}
+		return []byte(podMetricsJSON), nil
+	}}
+}
+
+func TestMetricsClient(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Local()
+	mc := fakeMetricsServer(nil)
+
+	pods, err := mc.Pods(context.Background())
+	want := []UsageSample{{Name: "web-0", Namespace: "prod", Time: at, Usage: Usage{CPU: 252, Memory: 144 << 20}}}
+	if err != nil || !reflect.DeepEqual(pods, want) {
+		t.Errorf("Pods() = %+v, %v, want %+v", pods, err, want)
+	}
+
+	nodes, err := mc.Nodes(context.Background())
+	want = []UsageSample{{Name: "node-1", Time: at, Usage: Usage{CPU: 2000, Memory: 4 << 30}}}
+	if err != nil || !reflect.DeepEqual(nodes, want) {
+		t.Errorf("Nodes() = %+v, %v, want %+v", nodes, err, want)
+	}
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{apierrors.NewNotFound(schema.GroupResource{Group: "metrics.k8s.io", Resource: "pods"}, ""), "metrics-server is not installed"},
+		{apierrors.NewServiceUnavailable("no endpoints"), "metrics-server is not responding"},
+		{errors.New("connection refused"), "failed to read metrics: connection refused"},
+	}
+	for _, tt := range tests {
+		if _, err := fakeMetricsServer(tt.err).Pods(context.Background()); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Pods() with %v error = %v, want %q", tt.err, err, tt.want)
+		}
+	}
+}
+
+func TestAddSamples(t *testing.T) {
+	at := time.Now()
+	sample := func(name string, cpu int64, age time.Duration) UsageSample {
+		return UsageSample{Name: name, Namespace: "prod", Time: at.Add(-age), Usage: Usage{CPU: cpu}}
+	}
+
+	h := addSamples(nil, []UsageSample{sample("a", 1, time.Minute), sample("b", 1, time.Minute)})
+	// The same window again is not a new sample, and b is gone
+	h = addSamples(h, []UsageSample{sample("a", 1, time.Minute)})
+	h = addSamples(h, []UsageSample{sample("a", 2, 0)})
+
+	got := series(h, "")
+	want := []UsageSeries{{Name: "a", Namespace: "prod", Usage: Usage{CPU: 2}, History: []Usage{{CPU: 1}, {CPU: 2}}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("series() = %+v, want %+v", got, want)
+	}
+	if got := series(h, "dev"); got != nil {
+		t.Errorf("series(dev) = %+v, want none", got)
+	}
+
+	for i := range 2 * metricsHistory {
+		h = addSamples(h, []UsageSample{sample("a", int64(i), time.Duration(-i-1)*time.Second)})
+	}
+	if n := len(h["prod/a"].samples); n != metricsHistory {
+		t.Errorf("kept %d samples, want %d", n, metricsHistory)
+	}
+}
+
+func TestResourceCache_Metrics(t *testing.T) {
+	rc := NewMockResourceCache()
+	rc.metrics = newMetricsStore(fakeMetricsServer(nil), time.Second)
+	if err := rc.MetricsError(); err != errMetricsPending {
+		t.Errorf("MetricsError() before polling = %v, want %v", err, errMetricsPending)
+	}
+	if table, _ := rc.Table("pods", "prod"); len(table.Columns) != 7 {
+		t.Errorf("Table(pods) before polling = %v, want no usage columns", table.Columns)
+	}
+
+	if err := rc.metrics.poll(context.Background()); err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+	if err := rc.MetricsError(); err != nil {
+		t.Errorf("MetricsError() = %v, want nil", err)
+	}
+	if usage := rc.PodUsage("prod"); len(usage) != 1 || usage[0].Name != "web-0" {
+		t.Errorf("PodUsage(prod) = %+v, want web-0", usage)
+	}
+	if usage := rc.NodeUsage(); len(usage) != 1 || usage[0].Capacity != (Usage{CPU: 4000, Memory: 16 << 30}) {
+		t.Errorf("NodeUsage() = %+v, want node-1 with its allocatable", usage)
+	}
+
+	// Pods without metrics show as unknown
+	table, _ := rc.Table("pods", "default")
+	cpu := 4
+	if table.Columns[cpu] != "CPU" || table.Columns[cpu+1] != "MEMORY" {
+		t.Fatalf("Table(pods) columns = %v, want CPU and MEMORY before AGE", table.Columns)
+	}
+	for _, row := range table.Rows {
+		if row.Cells[cpu] != "<unknown>" {
+			t.Errorf("row %s CPU = %s, want <unknown>", row.Name, row.Cells[cpu])
+		}
+	}
+
+	items := rc.GetResourceByType("nodes", "")
+	if items[0].Metadata["cpu"] != "2000m" || !strings.HasSuffix(items[0].Description, "CPU: 2000m | Mem: 4096Mi") {
+		t.Errorf("node item = %+v, want its usage", items[0])
+	}
+
+	// Without metrics-server the tables lose the columns again
+	rc.metrics.client = fakeMetricsServer(apierrors.NewNotFound(schema.GroupResource{}, ""))
+	if err := rc.metrics.poll(context.Background()); err == nil {
+		t.Fatal("poll() without metrics-server should fail")
+	}
+	if rc.PodUsage("") != nil || rc.NodeUsage() != nil {
+		t.Error("usage without metrics-server should be nil")
+	}
+	if table, _ := rc.Table("nodes", ""); len(table.Columns) != 5 {
+		t.Errorf("Table(nodes) without metrics-server = %v, want no usage columns", table.Columns)
+	}
+}
+
+func TestTable_SortedByUsage(t *testing.T) {
+	table := Table{
+		Columns: []string{"NAME", "CPU", "MEMORY"},
+		Rows: []TableRow{
+			{Name: "a", Cells: []string{"a", "950m", "64Mi"}},
+			{Name: "b", Cells: []string{"b", "<unknown>", "<unknown>"}},
+			{Name: "c", Cells: []string{"c", "1200m", "1024Mi"}},
+			{Name: "d", Cells: []string{"d", "80m", "512Mi"}},
+		},
+	}
+
+	tests := []struct {
+		col  int
+		desc bool
+		want []string
+	}{
+		{1, false, []string{"b", "d", "a", "c"}},
+		{1, true, []string{"c", "a", "d", "b"}},
+		{2, true, []string{"c", "d", "a", "b"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, row := range table.Sorted(tt.col, tt.desc) {
+			got = append(got, row.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Sorted(%d, %v) = %v, want %v", tt.col, tt.desc, got, tt.want)
+		}
+	}
+}
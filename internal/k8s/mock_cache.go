@@ -2,13 +2,18 @@ package k8s
 
 import (
 	"context"
+	"hash/fnv"
+	"math"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/cache"
 )
 
 // MockResourceCache is a mock implementation of ResourceCache for testing/demo
@@ -16,24 +21,16 @@ type MockResourceCache struct {
 	*ResourceCache
 }
 
-// NewMockResourceCache creates a new mock cache with fake data
+// NewMockResourceCache creates a new mock cache with fake data. The fixtures
+// are loaded straight into informer stores that are never started, so the
+// listers behave exactly as they do against a live cluster.
 func NewMockResourceCache() *MockResourceCache {
-	rc := &ResourceCache{
-		pods:         make(map[string][]corev1.Pod),
-		deployments:  make(map[string][]appsv1.Deployment),
-		services:     make(map[string][]corev1.Service),
-		configmaps:   make(map[string][]corev1.ConfigMap),
-		secrets:      make(map[string][]corev1.Secret),
-		ingresses:    make(map[string][]networkingv1.Ingress),
-		statefulsets: make(map[string][]appsv1.StatefulSet),
-		daemonsets:   make(map[string][]appsv1.DaemonSet),
-		jobs:         make(map[string][]batchv1.Job),
-		cronjobs:     make(map[string][]batchv1.CronJob),
-		lastRefresh:  time.Now(),
-	}
+	rc := NewResourceCache(nil, nil, 0)
 
 	// Populate with mock data
 	rc.populateMockData()
+	rc.populateMockMetrics()
+	rc.ready.Store(true)
 
 	return &MockResourceCache{ResourceCache: rc}
 }
@@ -51,35 +48,43 @@ func (rc *ResourceCache) populateMockData() {
 	oneDayAgo := metav1.NewTime(time.Now().Add(-24 * time.Hour))
 
 	// Mock namespaces
-	rc.namespaces = []corev1.Namespace{
+	addMockObjects(rc.factory.Core().V1().Namespaces().Informer(), []corev1.Namespace{
 		{ObjectMeta: metav1.ObjectMeta{Name: "default", CreationTimestamp: oneDayAgo}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceActive}},
 		{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", CreationTimestamp: oneDayAgo}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceActive}},
 		{ObjectMeta: metav1.ObjectMeta{Name: "kube-public", CreationTimestamp: oneDayAgo}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceActive}},
 		{ObjectMeta: metav1.ObjectMeta{Name: "production", CreationTimestamp: oneDayAgo}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceActive}},
 		{ObjectMeta: metav1.ObjectMeta{Name: "staging", CreationTimestamp: oneDayAgo}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceActive}},
 		{ObjectMeta: metav1.ObjectMeta{Name: "development", CreationTimestamp: oneDayAgo}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceActive}},
-	}
+	})
+
+	// Container ports, for port-forward completion
+	nginxSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 80, Protocol: corev1.ProtocolTCP}}}}}
+	backendSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "api", Ports: []corev1.ContainerPort{
+		{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+		{Name: "metrics", ContainerPort: 9090, Protocol: corev1.ProtocolTCP},
+	}}}}
+	redisSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "redis", Ports: []corev1.ContainerPort{{Name: "redis", ContainerPort: 6379, Protocol: corev1.ProtocolTCP}}}}}
 
 	// Mock pods in default namespace
-	rc.pods["default"] = []corev1.Pod{
-		{ObjectMeta: metav1.ObjectMeta{Name: "nginx-app-7d8f9c-abc12", Namespace: "default", CreationTimestamp: oneHourAgo}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
-		{ObjectMeta: metav1.ObjectMeta{Name: "nginx-app-7d8f9c-def34", Namespace: "default", CreationTimestamp: oneHourAgo}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
-		{ObjectMeta: metav1.ObjectMeta{Name: "backend-api-6b5c4d-xyz56", Namespace: "default", CreationTimestamp: oneHourAgo}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
-		{ObjectMeta: metav1.ObjectMeta{Name: "frontend-web-8a7f2e-qrs78", Namespace: "default", CreationTimestamp: oneHourAgo}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
-		{ObjectMeta: metav1.ObjectMeta{Name: "redis-cache-5c9d3a-mno90", Namespace: "default", CreationTimestamp: oneHourAgo}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
-	}
+	addMockObjects(rc.factory.Core().V1().Pods().Informer(), []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "nginx-app-7d8f9c-abc12", Namespace: "default", Labels: map[string]string{"app": "nginx", "tier": "frontend"}, CreationTimestamp: oneHourAgo}, Spec: nginxSpec, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "nginx-app-7d8f9c-def34", Namespace: "default", Labels: map[string]string{"app": "nginx", "tier": "frontend"}, CreationTimestamp: oneHourAgo}, Spec: nginxSpec, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "backend-api-6b5c4d-xyz56", Namespace: "default", Labels: map[string]string{"app": "backend-api", "tier": "backend"}, CreationTimestamp: oneHourAgo}, Spec: backendSpec, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "frontend-web-8a7f2e-qrs78", Namespace: "default", Labels: map[string]string{"app": "frontend-web", "tier": "frontend"}, CreationTimestamp: oneHourAgo}, Spec: nginxSpec, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "redis-cache-5c9d3a-mno90", Namespace: "default", Labels: map[string]string{"app": "redis", "tier": "cache"}, CreationTimestamp: oneHourAgo}, Spec: redisSpec, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+	})
 
 	// Mock pods in production namespace
-	rc.pods["production"] = []corev1.Pod{
-		{ObjectMeta: metav1.ObjectMeta{Name: "my-app-prod-1a2b3c-xyz", Namespace: "production", CreationTimestamp: now}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
-		{ObjectMeta: metav1.ObjectMeta{Name: "my-app-prod-1a2b3c-abc", Namespace: "production", CreationTimestamp: now}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
-		{ObjectMeta: metav1.ObjectMeta{Name: "database-primary-4d5e6f", Namespace: "production", CreationTimestamp: oneDayAgo}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
-	}
+	addMockObjects(rc.factory.Core().V1().Pods().Informer(), []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "my-app-prod-1a2b3c-xyz", Namespace: "production", Labels: map[string]string{"app": "my-app", "env": "production"}, CreationTimestamp: now}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "my-app-prod-1a2b3c-abc", Namespace: "production", Labels: map[string]string{"app": "my-app", "env": "production"}, CreationTimestamp: now}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "database-primary-4d5e6f", Namespace: "production", Labels: map[string]string{"app": "database", "env": "production", "role": "primary"}, CreationTimestamp: oneDayAgo}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+	})
 
 	replicas := int32(2)
 
 	// Mock deployments
-	rc.deployments["default"] = []appsv1.Deployment{
+	addMockObjects(rc.factory.Apps().V1().Deployments().Informer(), []appsv1.Deployment{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "nginx-app", Namespace: "default", CreationTimestamp: oneHourAgo},
 			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
@@ -95,34 +100,40 @@ func (rc *ResourceCache) populateMockData() {
 			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
 			Status:     appsv1.DeploymentStatus{ReadyReplicas: 2},
 		},
-	}
+	})
 
-	rc.deployments["production"] = []appsv1.Deployment{
+	addMockObjects(rc.factory.Apps().V1().Deployments().Informer(), []appsv1.Deployment{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "my-app-prod", Namespace: "production", CreationTimestamp: now},
 			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
 			Status:     appsv1.DeploymentStatus{ReadyReplicas: 2},
 		},
-	}
+	})
 
 	// Mock services
-	rc.services["default"] = []corev1.Service{
-		{ObjectMeta: metav1.ObjectMeta{Name: "nginx-service", Namespace: "default", CreationTimestamp: oneHourAgo}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}},
-		{ObjectMeta: metav1.ObjectMeta{Name: "backend-api-service", Namespace: "default", CreationTimestamp: oneHourAgo}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}},
-		{ObjectMeta: metav1.ObjectMeta{Name: "frontend-web-service", Namespace: "default", CreationTimestamp: oneHourAgo}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer}},
-	}
+	addMockObjects(rc.factory.Core().V1().Services().Informer(), []corev1.Service{
+		{ObjectMeta: metav1.ObjectMeta{Name: "nginx-service", Namespace: "default", CreationTimestamp: oneHourAgo}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Selector: map[string]string{"app": "nginx"}, Ports: []corev1.ServicePort{
+			{Name: "http", Port: 80, TargetPort: intstr.FromString("http"), Protocol: corev1.ProtocolTCP},
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "backend-api-service", Namespace: "default", CreationTimestamp: oneHourAgo}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Selector: map[string]string{"app": "backend-api"}, Ports: []corev1.ServicePort{
+			{Name: "http", Port: 80, TargetPort: intstr.FromInt32(8080), Protocol: corev1.ProtocolTCP},
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "frontend-web-service", Namespace: "default", CreationTimestamp: oneHourAgo}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Selector: map[string]string{"app": "frontend-web"}, Ports: []corev1.ServicePort{
+			{Name: "http", Port: 80, TargetPort: intstr.FromString("http"), Protocol: corev1.ProtocolTCP},
+		}}},
+	})
 
 	// Mock StatefulSets
-	rc.statefulsets["default"] = []appsv1.StatefulSet{
+	addMockObjects(rc.factory.Apps().V1().StatefulSets().Informer(), []appsv1.StatefulSet{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "redis-cluster", Namespace: "default", CreationTimestamp: oneHourAgo},
 			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
 			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 2},
 		},
-	}
+	})
 
 	// Mock DaemonSets
-	rc.daemonsets["kube-system"] = []appsv1.DaemonSet{
+	addMockObjects(rc.factory.Apps().V1().DaemonSets().Informer(), []appsv1.DaemonSet{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy", Namespace: "kube-system", CreationTimestamp: oneDayAgo},
 			Status:     appsv1.DaemonSetStatus{NumberReady: 3, DesiredNumberScheduled: 3},
@@ -131,10 +142,10 @@ func (rc *ResourceCache) populateMockData() {
 			ObjectMeta: metav1.ObjectMeta{Name: "fluentd", Namespace: "kube-system", CreationTimestamp: oneDayAgo},
 			Status:     appsv1.DaemonSetStatus{NumberReady: 3, DesiredNumberScheduled: 3},
 		},
-	}
+	})
 
 	// Mock ConfigMaps
-	rc.configmaps["default"] = []corev1.ConfigMap{
+	addMockObjects(rc.factory.Core().V1().ConfigMaps().Informer(), []corev1.ConfigMap{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "default", CreationTimestamp: oneHourAgo},
 			Data:       map[string]string{"key1": "value1", "key2": "value2"},
@@ -143,10 +154,10 @@ func (rc *ResourceCache) populateMockData() {
 			ObjectMeta: metav1.ObjectMeta{Name: "nginx-config", Namespace: "default", CreationTimestamp: oneHourAgo},
 			Data:       map[string]string{"nginx.conf": "server {}"},
 		},
-	}
+	})
 
 	// Mock Secrets
-	rc.secrets["default"] = []corev1.Secret{
+	addMockObjects(rc.factory.Core().V1().Secrets().Informer(), []corev1.Secret{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "default", CreationTimestamp: oneHourAgo},
 			Type:       corev1.SecretTypeOpaque,
@@ -157,20 +168,20 @@ func (rc *ResourceCache) populateMockData() {
 			Type:       corev1.SecretTypeOpaque,
 			Data:       map[string][]byte{"api-key": []byte("abc123")},
 		},
-	}
+	})
 
 	// Mock Jobs
 	completions := int32(1)
-	rc.jobs["default"] = []batchv1.Job{
+	addMockObjects(rc.factory.Batch().V1().Jobs().Informer(), []batchv1.Job{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "data-migration-job", Namespace: "default", CreationTimestamp: oneHourAgo},
 			Spec:       batchv1.JobSpec{Completions: &completions},
 			Status:     batchv1.JobStatus{Succeeded: 1},
 		},
-	}
+	})
 
 	// Mock CronJobs
-	rc.cronjobs["default"] = []batchv1.CronJob{
+	addMockObjects(rc.factory.Batch().V1().CronJobs().Informer(), []batchv1.CronJob{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "backup-cronjob", Namespace: "default", CreationTimestamp: oneHourAgo},
 			Spec:       batchv1.CronJobSpec{Schedule: "0 2 * * *"},
@@ -179,10 +190,10 @@ func (rc *ResourceCache) populateMockData() {
 			ObjectMeta: metav1.ObjectMeta{Name: "cleanup-cronjob", Namespace: "default", CreationTimestamp: oneHourAgo},
 			Spec:       batchv1.CronJobSpec{Schedule: "0 */6 * * *"},
 		},
-	}
+	})
 
 	// Mock Ingresses
-	rc.ingresses["default"] = []networkingv1.Ingress{
+	addMockObjects(rc.factory.Networking().V1().Ingresses().Informer(), []networkingv1.Ingress{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "main-ingress", Namespace: "default", CreationTimestamp: oneHourAgo},
 			Spec: networkingv1.IngressSpec{
@@ -192,16 +203,21 @@ func (rc *ResourceCache) populateMockData() {
 				},
 			},
 		},
-	}
+	})
 
 	// Mock Nodes
-	rc.nodes = []corev1.Node{
+	nodeAllocatable := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("16Gi"),
+	}
+	addMockObjects(rc.factory.Core().V1().Nodes().Informer(), []corev1.Node{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "node-1", CreationTimestamp: oneDayAgo},
 			Status: corev1.NodeStatus{
 				Conditions: []corev1.NodeCondition{
 					{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
 				},
+				Allocatable: nodeAllocatable,
 			},
 		},
 		{
@@ -210,6 +226,7 @@ func (rc *ResourceCache) populateMockData() {
 				Conditions: []corev1.NodeCondition{
 					{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
 				},
+				Allocatable: nodeAllocatable,
 			},
 		},
 		{
@@ -218,7 +235,48 @@ func (rc *ResourceCache) populateMockData() {
 				Conditions: []corev1.NodeCondition{
 					{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
 				},
+				Allocatable: nodeAllocatable,
 			},
 		},
+	})
+}
+
+// populateMockMetrics makes up a history of usage for every mock pod and
+// node, as if metrics-server had been polled for a while
+func (rc *ResourceCache) populateMockMetrics() {
+	rc.metrics = newMetricsStore(nil, 0)
+
+	// Each pod and node wobbles around its own level
+	usage := func(name string, cpu, memory int64, step int) Usage {
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		seed := int64(h.Sum32())
+		wave := math.Sin(float64(step)/3 + float64(seed%7))
+		return Usage{
+			CPU:    cpu/2 + seed%cpu + int64(wave*float64(cpu)/4),
+			Memory: (memory/2 + seed%memory + int64(wave*float64(memory)/16)) * 1024 * 1024,
+		}
+	}
+
+	start := time.Now().Add(-metricsHistory * 15 * time.Second)
+	for step := range metricsHistory {
+		at := start.Add(time.Duration(step) * 15 * time.Second)
+		var pods, nodes []UsageSample
+		for _, pod := range rc.GetPods("") {
+			if pod.Status.Phase == corev1.PodRunning {
+				pods = append(pods, UsageSample{Name: pod.Name, Namespace: pod.Namespace, Time: at, Usage: usage(pod.Name, 200, 256, step)})
+			}
+		}
+		for _, node := range rc.GetNodes() {
+			nodes = append(nodes, UsageSample{Name: node.Name, Time: at, Usage: usage(node.Name, 2000, 8192, step)})
+		}
+		rc.metrics.record(pods, nodes)
+	}
+}
+
+// addMockObjects adds fixtures to an informer's store
+func addMockObjects[T any](informer cache.SharedIndexInformer, objs []T) {
+	for i := range objs {
+		_ = informer.GetIndexer().Add(&objs[i])
 	}
 }
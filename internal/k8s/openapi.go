@@ -0,0 +1,448 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// schemaLoadTimeout bounds loading the OpenAPI schema of a group version,
+// which for the core group is a couple of megabytes
+const schemaLoadTimeout = 30 * time.Second
+
+// schemaPrefetch are the group versions of the typed kinds, loaded when the
+// cache starts so their fields complete at once
+var schemaPrefetch = []string{"api/v1", "apis/apps/v1", "apis/batch/v1"}
+
+// schemaFetcher gets a path from the API server
+type schemaFetcher func(ctx context.Context, uri string) ([]byte, error)
+
+// schemaStore loads the OpenAPI v3 schemas of group versions. The server
+// names each schema by a hash of its content, so a copy on disk stays
+// valid until the hash changes, whichever cluster it came from.
+type schemaStore struct {
+	fetch schemaFetcher
+	dir   string // the disk cache, "" for none
+
+	mu      sync.Mutex
+	urls    map[string]string // group version → server-relative URL, with the hash
+	docs    map[string]*openAPIDoc
+	loading map[string]bool
+}
+
+// newSchemaStore creates a store that fetches schemas with fetch
+func newSchemaStore(fetch schemaFetcher) *schemaStore {
+	return &schemaStore{
+		fetch:   fetch,
+		docs:    make(map[string]*openAPIDoc),
+		loading: make(map[string]bool),
+	}
+}
+
+// openAPIDoc is the part of an OpenAPI v3 document fields are read from
+type openAPIDoc struct {
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	} `json:"components"`
+
+	byKind map[string]string // group/version/Kind → schema name
+}
+
+// openAPISchema is a schema of an OpenAPI v3 document
+type openAPISchema struct {
+	Type                 string                    `json:"type"`
+	Description          string                    `json:"description"`
+	Properties           map[string]*openAPISchema `json:"properties"`
+	Items                *openAPISchema            `json:"items"`
+	AdditionalProperties additionalProperties      `json:"additionalProperties"`
+	Ref                  string                    `json:"$ref"`
+	AllOf                []*openAPISchema          `json:"allOf"`
+	IntOrString          bool                      `json:"x-kubernetes-int-or-string"`
+	GroupVersionKind     []struct {
+		Group   string `json:"group"`
+		Version string `json:"version"`
+		Kind    string `json:"kind"`
+	} `json:"x-kubernetes-group-version-kind"`
+}
+
+// additionalProperties is the schema of a map's values. It may also be a
+// bool, which says nothing about them.
+type additionalProperties struct {
+	*openAPISchema
+}
+
+// UnmarshalJSON reads a schema, or a bool as no schema
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("true")) || bytes.Equal(data, []byte("false")) {
+		return nil
+	}
+	a.openAPISchema = new(openAPISchema)
+	return json.Unmarshal(data, a.openAPISchema)
+}
+
+// schemaPath returns the group version path the OpenAPI v3 discovery
+// lists a resource under, e.g. "api/v1" or "apis/apps/v1"
+func schemaPath(info ResourceInfo) string {
+	if info.Group == "" {
+		return "api/" + info.Version
+	}
+	return "apis/" + info.Group + "/" + info.Version
+}
+
+// Fields returns the fields of a resource type at a path of field names,
+// list items walked through, from the cluster's OpenAPI schema. It returns
+// nil while the schema of the type's group version is still loading.
+func (rc *ResourceCache) Fields(resourceType string, path []string) []types.SchemaField {
+	if rc.schemas == nil {
+		return nil
+	}
+	info, ok := rc.LookupResource(resourceType)
+	if !ok {
+		return nil
+	}
+	doc := rc.schemas.doc(schemaPath(info))
+	if doc == nil {
+		return nil
+	}
+	return doc.fields(info.Group, info.Version, info.Kind, path)
+}
+
+// Explain documents a resource type, or the field at a path of field
+// names in it, from the cluster's OpenAPI schema the way kubectl explain
+// does. It returns nil while the schema is still loading, or if the path
+// leads nowhere.
+func (rc *ResourceCache) Explain(resourceType string, path []string) *types.Explanation {
+	if rc.schemas == nil {
+		return nil
+	}
+	info, ok := rc.LookupResource(resourceType)
+	if !ok {
+		return nil
+	}
+	doc := rc.schemas.doc(schemaPath(info))
+	if doc == nil {
+		return nil
+	}
+	return doc.explain(info.Group, info.Version, info.Kind, path)
+}
+
+// SetSchemaCacheDir sets where OpenAPI schemas are kept between runs. It
+// must be called before Start.
+func (rc *ResourceCache) SetSchemaCacheDir(dir string) {
+	if rc.schemas != nil {
+		rc.schemas.dir = dir
+	}
+}
+
+// doc returns the schema of a group version if it is loaded, and starts
+// loading it in the background otherwise
+func (s *schemaStore) doc(gv string) *openAPIDoc {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if doc, ok := s.docs[gv]; ok {
+		return doc
+	}
+	if !s.loading[gv] {
+		s.loading[gv] = true
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), schemaLoadTimeout)
+			defer cancel()
+			_ = s.load(ctx, gv)
+		}()
+	}
+	return nil
+}
+
+// invalidate forgets which group versions the server has schemas for, so
+// those of CRDs installed since are found
+func (s *schemaStore) invalidate() {
+	s.mu.Lock()
+	s.urls = nil
+	s.mu.Unlock()
+}
+
+// prefetch loads the schemas of group versions in the background
+func (s *schemaStore) prefetch(ctx context.Context, gvs []string) {
+	for _, gv := range gvs {
+		s.mu.Lock()
+		busy := s.loading[gv] || s.docs[gv] != nil
+		s.loading[gv] = true
+		s.mu.Unlock()
+		if busy {
+			continue
+		}
+		loadCtx, cancel := context.WithTimeout(ctx, schemaLoadTimeout)
+		_ = s.load(loadCtx, gv)
+		cancel()
+	}
+}
+
+// load reads the schema of a group version from disk if the server's hash
+// of it matches, and fetches it otherwise. A failed load may be retried.
+func (s *schemaStore) load(ctx context.Context, gv string) error {
+	doc, err := s.read(ctx, gv)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.loading, gv)
+	if err != nil {
+		return err
+	}
+	s.docs[gv] = doc
+	return nil
+}
+
+// read gets and parses the schema of a group version
+func (s *schemaStore) read(ctx context.Context, gv string) (*openAPIDoc, error) {
+	uri, err := s.url(ctx, gv)
+	if err != nil {
+		return nil, err
+	}
+
+	file := s.cacheFile(gv, uri)
+	if file != "" {
+		if data, err := os.ReadFile(file); err == nil {
+			if doc, err := parseOpenAPIDoc(data); err == nil {
+				return doc, nil
+			}
+			os.Remove(file)
+		}
+	}
+
+	data, err := s.fetch(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the OpenAPI schema of %s: %w", gv, err)
+	}
+	doc, err := parseOpenAPIDoc(data)
+	if err != nil {
+		return nil, err
+	}
+	s.save(gv, file, data)
+	return doc, nil
+}
+
+// url returns the server-relative URL of a group version's schema
+func (s *schemaStore) url(ctx context.Context, gv string) (string, error) {
+	s.mu.Lock()
+	urls := s.urls
+	s.mu.Unlock()
+
+	if urls == nil {
+		data, err := s.fetch(ctx, "/openapi/v3")
+		if err != nil {
+			return "", fmt.Errorf("failed to discover OpenAPI schemas: %w", err)
+		}
+		var discovery struct {
+			Paths map[string]struct {
+				ServerRelativeURL string `json:"serverRelativeURL"`
+			} `json:"paths"`
+		}
+		if err := json.Unmarshal(data, &discovery); err != nil {
+			return "", fmt.Errorf("failed to discover OpenAPI schemas: %w", err)
+		}
+		urls = make(map[string]string, len(discovery.Paths))
+		for path, item := range discovery.Paths {
+			urls[path] = item.ServerRelativeURL
+		}
+		s.mu.Lock()
+		s.urls = urls
+		s.mu.Unlock()
+	}
+
+	uri, ok := urls[gv]
+	if !ok {
+		return "", fmt.Errorf("the server has no OpenAPI schema for %s", gv)
+	}
+	return uri, nil
+}
+
+// cacheFile returns where a schema is kept on disk, named by its hash, or
+// "" if it cannot be kept
+func (s *schemaStore) cacheFile(gv, uri string) string {
+	if s.dir == "" {
+		return ""
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	hash := u.Query().Get("hash")
+	if hash == "" || strings.ContainsAny(hash, `/\.`) {
+		return ""
+	}
+	return filepath.Join(s.dir, strings.ReplaceAll(gv, "/", "_")+"-"+hash+".json")
+}
+
+// save keeps a schema on disk in place of older ones of its group version.
+// Failing to is not an error, the schema is fetched again next time.
+func (s *schemaStore) save(gv, file string, data []byte) {
+	if file == "" || os.MkdirAll(s.dir, 0755) != nil {
+		return
+	}
+	old, _ := filepath.Glob(filepath.Join(s.dir, strings.ReplaceAll(gv, "/", "_")+"-*.json"))
+	tmp := file + ".tmp"
+	if os.WriteFile(tmp, data, 0644) != nil || os.Rename(tmp, file) != nil {
+		os.Remove(tmp)
+		return
+	}
+	for _, f := range old {
+		if f != file {
+			os.Remove(f)
+		}
+	}
+}
+
+// parseOpenAPIDoc parses an OpenAPI v3 document and indexes its kinds
+func parseOpenAPIDoc(data []byte) (*openAPIDoc, error) {
+	var doc openAPIDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI schema: %w", err)
+	}
+	doc.byKind = make(map[string]string)
+	for name, s := range doc.Components.Schemas {
+		for _, gvk := range s.GroupVersionKind {
+			doc.byKind[gvk.Group+"/"+gvk.Version+"/"+gvk.Kind] = name
+		}
+	}
+	return &doc, nil
+}
+
+// resolve follows references, including the allOf Kubernetes wraps one in
+// to describe a field
+func (d *openAPIDoc) resolve(s *openAPISchema) *openAPISchema {
+	for i := 0; s != nil && i < 10; i++ {
+		switch {
+		case s.Ref != "":
+			s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		case len(s.AllOf) == 1 && len(s.Properties) == 0:
+			s = s.AllOf[0]
+		default:
+			return s
+		}
+	}
+	return s
+}
+
+// elem resolves a schema and walks through lists to their items
+func (d *openAPIDoc) elem(s *openAPISchema) *openAPISchema {
+	s = d.resolve(s)
+	for s != nil && s.Type == "array" && s.Items != nil {
+		s = d.resolve(s.Items)
+	}
+	return s
+}
+
+// lookup walks the schema of a kind down a path of field names, returning
+// the field at its end as declared and as resolved. Map values are walked
+// into by any key.
+func (d *openAPIDoc) lookup(group, version, kind string, path []string) (field, s *openAPISchema) {
+	name, ok := d.byKind[group+"/"+version+"/"+kind]
+	if !ok {
+		return nil, nil
+	}
+	field = d.Components.Schemas[name]
+	s = d.elem(field)
+	for _, p := range path {
+		if s == nil {
+			return nil, nil
+		}
+		if f, ok := s.Properties[p]; ok {
+			field = f
+		} else if s.AdditionalProperties.openAPISchema != nil {
+			field = s.AdditionalProperties.openAPISchema
+		} else {
+			return nil, nil
+		}
+		s = d.elem(field)
+	}
+	return field, s
+}
+
+// fields returns the fields of a kind at a path, sorted by name
+func (d *openAPIDoc) fields(group, version, kind string, path []string) []types.SchemaField {
+	_, s := d.lookup(group, version, kind, path)
+	if s == nil {
+		return nil
+	}
+	return d.properties(s)
+}
+
+// explain documents a kind, or the field at a path in it
+func (d *openAPIDoc) explain(group, version, kind string, path []string) *types.Explanation {
+	field, s := d.lookup(group, version, kind, path)
+	if s == nil {
+		return nil
+	}
+	exp := &types.Explanation{
+		Group:       group,
+		Version:     version,
+		Kind:        kind,
+		Path:        path,
+		Type:        d.typeName(field),
+		Description: d.description(field),
+		Fields:      d.properties(s),
+	}
+	if len(path) == 0 {
+		exp.Type = kind
+	}
+	return exp
+}
+
+// properties returns the fields of an object schema, sorted by name
+func (d *openAPIDoc) properties(s *openAPISchema) []types.SchemaField {
+	out := make([]types.SchemaField, 0, len(s.Properties))
+	for name, field := range s.Properties {
+		out = append(out, types.SchemaField{
+			Name:        name,
+			Type:        d.typeName(field),
+			Description: d.description(field),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// description returns a field's description, or that of the type it
+// refers to if the field has none of its own
+func (d *openAPIDoc) description(field *openAPISchema) string {
+	if field.Description != "" {
+		return field.Description
+	}
+	if resolved := d.resolve(field); resolved != nil {
+		return resolved.Description
+	}
+	return ""
+}
+
+// typeName returns how kubectl explain names a field's type, e.g.
+// "[]Container" or "map[string]string"
+func (d *openAPIDoc) typeName(s *openAPISchema) string {
+	switch {
+	case s == nil:
+		return ""
+	case s.Ref != "":
+		ref := s.Ref[strings.LastIndex(s.Ref, "/")+1:]
+		return ref[strings.LastIndex(ref, ".")+1:]
+	case len(s.AllOf) == 1:
+		return d.typeName(s.AllOf[0])
+	case s.Type == "array":
+		return "[]" + d.typeName(s.Items)
+	case s.AdditionalProperties.openAPISchema != nil:
+		return "map[string]" + d.typeName(s.AdditionalProperties.openAPISchema)
+	case s.IntOrString:
+		return "int-or-string"
+	case s.Type == "":
+		return "object"
+	}
+	return s.Type
+}
@@ -0,0 +1,194 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeOpenAPI is a trimmed OpenAPI v3 document of the core group
+const fakeOpenAPI = `{"components":{"schemas":{
+	"io.k8s.api.core.v1.Pod":{
+		"type":"object",
+		"properties":{
+			"metadata":{"allOf":[{"$ref":"#/components/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}],"description":"Standard object's metadata."},
+			"spec":{"allOf":[{"$ref":"#/components/schemas/io.k8s.api.core.v1.PodSpec"}]},
+			"status":{"allOf":[{"$ref":"#/components/schemas/io.k8s.api.core.v1.PodStatus"}]}
+		},
+		"x-kubernetes-group-version-kind":[{"group":"","version":"v1","kind":"Pod"}]
+	},
+	"io.k8s.api.core.v1.PodSpec":{
+		"type":"object",
+		"description":"PodSpec is a description of a pod.",
+		"properties":{
+			"containers":{"type":"array","items":{"allOf":[{"$ref":"#/components/schemas/io.k8s.api.core.v1.Container"}]},"description":"List of containers."},
+			"nodeName":{"type":"string","description":"The node the pod runs on."}
+		}
+	},
+	"io.k8s.api.core.v1.PodStatus":{
+		"type":"object",
+		"properties":{
+			"containerStatuses":{"type":"array","items":{"$ref":"#/components/schemas/io.k8s.api.core.v1.ContainerStatus"}},
+			"phase":{"type":"string","description":"The phase of a Pod."}
+		}
+	},
+	"io.k8s.api.core.v1.Container":{
+		"type":"object",
+		"properties":{
+			"image":{"type":"string","description":"Container image name."},
+			"ports":{"type":"array","items":{"type":"object","properties":{"containerPort":{"type":"integer","format":"int32"}}}}
+		}
+	},
+	"io.k8s.api.core.v1.ContainerStatus":{
+		"type":"object",
+		"properties":{"restartCount":{"type":"integer","format":"int32","description":"The number of times the container has been restarted."}}
+	},
+	"io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta":{
+		"type":"object",
+		"properties":{
+			"creationTimestamp":{"allOf":[{"$ref":"#/components/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.Time"}]},
+			"labels":{"type":"object","additionalProperties":{"type":"string","default":""}},
+			"annotations":{"type":"object","additionalProperties":true}
+		}
+	},
+	"io.k8s.apimachinery.pkg.apis.meta.v1.Time":{"type":"string","format":"date-time","description":"Time is a wrapper around time.Time."}
+}}}`
+
+// fakeSchemaServer serves the OpenAPI v3 discovery and the core schema,
+// and counts the fetches of the schema
+func fakeSchemaServer(hash string, fetches *int) schemaFetcher {
+	schemaURL := "/openapi/v3/api/v1?hash=" + hash
+	return func(ctx context.Context, uri string) ([]byte, error) {
+		switch uri {
+		case "/openapi/v3":
+			return []byte(`{"paths":{"api/v1":{"serverRelativeURL":"` + schemaURL + `"}}}`), nil
+		case schemaURL:
+			*fetches++
+			return []byte(fakeOpenAPI), nil
+		}
+		return nil, fmt.Errorf("the server could not find %s", uri)
+	}
+}
+
+func TestResourceCache_Fields(t *testing.T) {
+	rc := NewResourceCache(nil, nil, 0)
+	fetches := 0
+	rc.schemas = newSchemaStore(fakeSchemaServer("AAA", &fetches))
+	if err := rc.schemas.load(context.Background(), "api/v1"); err != nil {
+		t.Fatalf("load(api/v1) error = %v", err)
+	}
+
+	tests := []struct {
+		path  []string
+		names []string
+	}{
+		{nil, []string{"metadata", "spec", "status"}},
+		{[]string{"spec", "containers"}, []string{"image", "ports"}},
+		{[]string{"spec", "containers", "ports"}, []string{"containerPort"}},
+		{[]string{"status", "containerStatuses"}, []string{"restartCount"}},
+		{[]string{"metadata", "labels", "app"}, []string{}},
+		{[]string{"spec", "bogus"}, nil},
+	}
+	for _, tt := range tests {
+		fields := rc.Fields("po", tt.path)
+		var names []string
+		if fields != nil {
+			names = []string{}
+		}
+		for _, f := range fields {
+			names = append(names, f.Name)
+		}
+		if !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("Fields(po, %v) = %v, want %v", tt.path, names, tt.names)
+		}
+	}
+
+	types := make(map[string]string)
+	for _, f := range rc.Fields("pods", []string{"metadata"}) {
+		types[f.Name] = f.Type
+	}
+	want := map[string]string{"creationTimestamp": "Time", "labels": "map[string]string", "annotations": "object"}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("Fields(pods, metadata) types = %v, want %v", types, want)
+	}
+	for _, f := range rc.Fields("pods", []string{"spec"}) {
+		if f.Name == "containers" && (f.Type != "[]Container" || f.Description != "List of containers.") {
+			t.Errorf("containers = %+v, want a []Container with its description", f)
+		}
+	}
+	if got := rc.Fields("deployments", nil); got != nil {
+		t.Errorf("Fields(deployments) = %v, want nil for a group version the server lacks", got)
+	}
+}
+
+func TestSchemaStore_DiskCache(t *testing.T) {
+	dir := t.TempDir()
+	fetches := 0
+
+	first := newSchemaStore(fakeSchemaServer("AAA", &fetches))
+	first.dir = dir
+	if err := first.load(context.Background(), "api/v1"); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "api_v1-AAA.json")); err != nil {
+		t.Fatalf("schema not kept on disk: %v", err)
+	}
+
+	// Another run with the same hash reads the disk
+	second := newSchemaStore(fakeSchemaServer("AAA", &fetches))
+	second.dir = dir
+	if err := second.load(context.Background(), "api/v1"); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if fetches != 1 {
+		t.Errorf("schema fetched %d times, want once", fetches)
+	}
+
+	// A new hash fetches again and replaces the old copy
+	third := newSchemaStore(fakeSchemaServer("BBB", &fetches))
+	third.dir = dir
+	if err := third.load(context.Background(), "api/v1"); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if fetches != 2 || len(files) != 1 || filepath.Base(files[0]) != "api_v1-BBB.json" {
+		t.Errorf("after a new hash: %d fetches, files %v, want 2 and only api_v1-BBB.json", fetches, files)
+	}
+}
+
+func TestResourceCache_Explain(t *testing.T) {
+	rc := NewResourceCache(nil, nil, 0)
+	fetches := 0
+	rc.schemas = newSchemaStore(fakeSchemaServer("AAA", &fetches))
+	if err := rc.schemas.load(context.Background(), "api/v1"); err != nil {
+		t.Fatalf("load(api/v1) error = %v", err)
+	}
+
+	tests := []struct {
+		path        []string
+		typ         string
+		description string
+		fields      int
+	}{
+		{nil, "Pod", "", 3},
+		{[]string{"spec"}, "PodSpec", "PodSpec is a description of a pod.", 2},
+		{[]string{"spec", "containers"}, "[]Container", "List of containers.", 2},
+		{[]string{"status", "phase"}, "string", "The phase of a Pod.", 0},
+	}
+	for _, tt := range tests {
+		exp := rc.Explain("pods", tt.path)
+		if exp == nil {
+			t.Errorf("Explain(pods, %v) = nil", tt.path)
+			continue
+		}
+		if exp.Kind != "Pod" || exp.Version != "v1" || exp.Type != tt.typ || exp.Description != tt.description || len(exp.Fields) != tt.fields {
+			t.Errorf("Explain(pods, %v) = %+v, want a %s with %q and %d fields", tt.path, exp, tt.typ, tt.description, tt.fields)
+		}
+	}
+	if exp := rc.Explain("pods", []string{"spec", "bogus"}); exp != nil {
+		t.Errorf("Explain(pods, spec.bogus) = %+v, want nil", exp)
+	}
+}
@@ -0,0 +1,208 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Ports returns the ports a port-forward to target can reach: a service's
+// ports, or the container ports of a pod or of a workload's pod template.
+// A bare name is a pod.
+func (rc *ResourceCache) Ports(namespace, target string) []types.Port {
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	var spec *corev1.PodSpec
+	resource, name := rc.forwardKind(target)
+	switch resource {
+	case "services":
+		svc, err := rc.services.Services(namespace).Get(name)
+		if err != nil {
+			return nil
+		}
+		ports := make([]types.Port, 0, len(svc.Spec.Ports))
+		for _, p := range svc.Spec.Ports {
+			ports = append(ports, types.Port{Number: p.Port, Name: p.Name, Protocol: string(p.Protocol)})
+		}
+		return ports
+	case "pods":
+		pod, err := rc.pods.Pods(namespace).Get(name)
+		if err != nil {
+			return nil
+		}
+		spec = &pod.Spec
+	case "deployments":
+		dep, err := rc.deployments.Deployments(namespace).Get(name)
+		if err != nil {
+			return nil
+		}
+		spec = &dep.Spec.Template.Spec
+	case "statefulsets":
+		sts, err := rc.statefulsets.StatefulSets(namespace).Get(name)
+		if err != nil {
+			return nil
+		}
+		spec = &sts.Spec.Template.Spec
+	case "daemonsets":
+		ds, err := rc.daemonsets.DaemonSets(namespace).Get(name)
+		if err != nil {
+			return nil
+		}
+		spec = &ds.Spec.Template.Spec
+	default:
+		return nil
+	}
+
+	var ports []types.Port
+	for _, c := range spec.Containers {
+		for _, p := range c.Ports {
+			ports = append(ports, types.Port{Number: p.ContainerPort, Name: p.Name, Protocol: string(p.Protocol), Container: c.Name})
+		}
+	}
+	return ports
+}
+
+// ForwardTarget picks the pod a port-forward to target reaches, and the
+// ports on it: kubectl's [LOCAL:]REMOTE mappings, with a service's ports
+// translated to the ports they target the way kubectl does. The pod
+// current is kept while it runs, so a forward only moves when its pod goes
+// away.
+func (rc *ResourceCache) ForwardTarget(namespace, target string, ports []string, current string) (string, []string, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	var pods []*corev1.Pod
+	var svc *corev1.Service
+	resource, name := rc.forwardKind(target)
+	switch resource {
+	case "":
+		return "", nil, fmt.Errorf("unknown resource type in %s", target)
+	case "pods":
+		pod, err := rc.pods.Pods(namespace).Get(name)
+		if err != nil {
+			return "", nil, fmt.Errorf("pod %s not found in %s", name, namespace)
+		}
+		pods = []*corev1.Pod{pod}
+	default:
+		sel, err := rc.workloadSelector(resource, namespace, name)
+		if err != nil {
+			return "", nil, err
+		}
+		if resource == "services" {
+			svc, _ = rc.services.Services(namespace).Get(name)
+		}
+		pods, _ = rc.pods.Pods(namespace).List(sel)
+	}
+
+	pod := pickForwardPod(pods, current)
+	if pod == nil {
+		return "", nil, fmt.Errorf("no ready pod for %s in %s", target, namespace)
+	}
+	if svc == nil {
+		return pod.Name, ports, nil
+	}
+	mapped := make([]string, len(ports))
+	for i, mapping := range ports {
+		var err error
+		if mapped[i], err = servicePortMapping(svc, pod, mapping); err != nil {
+			return "", nil, err
+		}
+	}
+	return pod.Name, mapped, nil
+}
+
+// forwardKind splits a port-forward target into its resource and name. A
+// bare name is a pod. The resource is "" when the cluster serves no such
+// type.
+func (rc *ResourceCache) forwardKind(target string) (resource, name string) {
+	kind, name := "pods", target
+	if k, n, ok := strings.Cut(target, "/"); ok {
+		kind, name = k, n
+	}
+	info, ok := rc.LookupResource(kind)
+	if !ok {
+		return "", name
+	}
+	return info.Name, name
+}
+
+// pickForwardPod returns current while it is running, even if a
+// readiness probe fails for a moment, or else the first ready pod by name.
+// Pods reported running without conditions (as in demo mode) count as
+// ready.
+func pickForwardPod(pods []*corev1.Pod, current string) *corev1.Pod {
+	var ready []*corev1.Pod
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if pod.Name == current {
+			return pod
+		}
+		ok := len(pod.Status.Conditions) == 0
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodReady {
+				ok = c.Status == corev1.ConditionTrue
+			}
+		}
+		if ok {
+			ready = append(ready, pod)
+		}
+	}
+	if len(ready) == 0 {
+		return nil
+	}
+	sort.Slice(ready, func(i, j int) bool { return ready[i].Name < ready[j].Name })
+	return ready[0]
+}
+
+// servicePortMapping translates a [LOCAL:]REMOTE mapping of a service
+// port, by number or name, to one for the port it targets on pod. The
+// local port defaults to the service port.
+func servicePortMapping(svc *corev1.Service, pod *corev1.Pod, mapping string) (string, error) {
+	local, remote, hasLocal := strings.Cut(mapping, ":")
+	if !hasLocal {
+		remote = local
+	}
+
+	var port *corev1.ServicePort
+	for i, p := range svc.Spec.Ports {
+		if strconv.Itoa(int(p.Port)) == remote || (p.Name != "" && p.Name == remote) {
+			port = &svc.Spec.Ports[i]
+			break
+		}
+	}
+	if port == nil {
+		return "", fmt.Errorf("service %s has no port %s", svc.Name, remote)
+	}
+	if !hasLocal {
+		local = strconv.Itoa(int(port.Port))
+	}
+
+	target := port.Port
+	switch {
+	case port.TargetPort.Type == intstr.String:
+		target = 0
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.Name == port.TargetPort.StrVal {
+					target = p.ContainerPort
+				}
+			}
+		}
+		if target == 0 {
+			return "", fmt.Errorf("pod %s has no port named %s", pod.Name, port.TargetPort.StrVal)
+		}
+	case port.TargetPort.IntVal != 0:
+		target = port.TargetPort.IntVal
+	}
+	return local + ":" + strconv.Itoa(int(target)), nil
+}
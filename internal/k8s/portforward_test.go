@@ -0,0 +1,112 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func newForwardCache() *ResourceCache {
+	rc := NewResourceCache(nil, nil, 0)
+	web := map[string]string{"app": "web"}
+	spec := corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Ports: []corev1.ContainerPort{
+		{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+		{Name: "metrics", ContainerPort: 9090, Protocol: corev1.ProtocolTCP},
+	}}}}
+	ready := func(status corev1.ConditionStatus) corev1.PodStatus {
+		return corev1.PodStatus{Phase: corev1.PodRunning, Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}}
+	}
+
+	addMockObjects(rc.factory.Core().V1().Pods().Informer(), []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "prod", Labels: web}, Spec: spec, Status: ready(corev1.ConditionFalse)},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-b", Namespace: "prod", Labels: web}, Spec: spec, Status: ready(corev1.ConditionTrue)},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-c", Namespace: "prod", Labels: web}, Spec: spec, Status: ready(corev1.ConditionTrue)},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-pending", Namespace: "prod", Labels: web}, Spec: spec, Status: corev1.PodStatus{Phase: corev1.PodPending}},
+	})
+	addMockObjects(rc.factory.Apps().V1().Deployments().Informer(), []appsv1.Deployment{{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: web},
+			Template: corev1.PodTemplateSpec{Spec: spec},
+		},
+	}})
+	addMockObjects(rc.factory.Core().V1().Services().Informer(), []corev1.Service{{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+		Spec: corev1.ServiceSpec{Selector: web, Ports: []corev1.ServicePort{
+			{Name: "http", Port: 80, TargetPort: intstr.FromString("http"), Protocol: corev1.ProtocolTCP},
+			{Name: "metrics", Port: 9000, TargetPort: intstr.FromInt32(9090), Protocol: corev1.ProtocolTCP},
+			{Name: "admin", Port: 7000, Protocol: corev1.ProtocolTCP},
+		}},
+	}})
+	return rc
+}
+
+func TestResourceCache_Ports(t *testing.T) {
+	rc := newForwardCache()
+	containerPorts := []types.Port{
+		{Number: 8080, Name: "http", Protocol: "TCP", Container: "app"},
+		{Number: 9090, Name: "metrics", Protocol: "TCP", Container: "app"},
+	}
+
+	tests := []struct {
+		target string
+		want   []types.Port
+	}{
+		{"web-b", containerPorts},
+		{"pod/web-b", containerPorts},
+		{"deploy/web", containerPorts},
+		{"svc/web", []types.Port{
+			{Number: 80, Name: "http", Protocol: "TCP"},
+			{Number: 9000, Name: "metrics", Protocol: "TCP"},
+			{Number: 7000, Name: "admin", Protocol: "TCP"},
+		}},
+		{"missing", nil},
+		{"cm/settings", nil},
+	}
+	for _, tt := range tests {
+		if got := rc.Ports("prod", tt.target); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Ports(%s) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestResourceCache_ForwardTarget(t *testing.T) {
+	rc := newForwardCache()
+
+	tests := []struct {
+		target  string
+		ports   []string
+		current string
+		pod     string
+		want    []string
+		wantErr bool
+	}{
+		{target: "web-b", ports: []string{"8080"}, pod: "web-b", want: []string{"8080"}},
+		{target: "deploy/web", ports: []string{"8080:8080"}, pod: "web-b", want: []string{"8080:8080"}},
+		{target: "deploy/web", ports: []string{"8080"}, current: "web-c", pod: "web-c", want: []string{"8080"}},
+		{target: "deploy/web", ports: []string{"8080"}, current: "web-a", pod: "web-a", want: []string{"8080"}},
+		{target: "deploy/web", ports: []string{"8080"}, current: "web-pending", pod: "web-b", want: []string{"8080"}},
+		{target: "deploy/web", ports: []string{"8080"}, current: "web-gone", pod: "web-b", want: []string{"8080"}},
+		{target: "svc/web", ports: []string{"80", "8000:9000", ":7000", "8443:http"}, pod: "web-b", want: []string{"80:8080", "8000:9090", ":7000", "8443:8080"}},
+		{target: "svc/web", ports: []string{"81"}, wantErr: true},
+		{target: "web-a", ports: []string{"8080"}, wantErr: true},
+		{target: "web-pending", ports: []string{"8080"}, wantErr: true},
+		{target: "deploy/missing", ports: []string{"8080"}, wantErr: true},
+		{target: "cm/settings", ports: []string{"8080"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		pod, ports, err := rc.ForwardTarget("prod", tt.target, tt.ports, tt.current)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ForwardTarget(%s, %v) error = %v, wantErr %v", tt.target, tt.ports, err, tt.wantErr)
+			continue
+		}
+		if pod != tt.pod || !reflect.DeepEqual(ports, tt.want) {
+			t.Errorf("ForwardTarget(%s, %v, %q) = %s, %v, want %s, %v", tt.target, tt.ports, tt.current, pod, ports, tt.pod, tt.want)
+		}
+	}
+}
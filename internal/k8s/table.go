@@ -0,0 +1,435 @@
+package k8s
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// Table is a kind-specific tabular view of cached objects, with the
+// columns kubectl get would print
+type Table struct {
+	Resource ResourceInfo
+	Columns  []string
+	Rows     []TableRow
+}
+
+// TableRow is one object in a Table. Cells line up with Table.Columns.
+type TableRow struct {
+	Name      string
+	Namespace string
+	Created   time.Time
+	Cells     []string
+}
+
+// Table returns the cached objects of a resource type as a table. An empty
+// namespace means all namespaces and adds a NAMESPACE column for
+// namespaced kinds. ok is false for unknown resource types.
+func (rc *ResourceCache) Table(resourceType, namespace string) (Table, bool) {
+	info, ok := rc.LookupResource(resourceType)
+	if !ok {
+		return Table{}, false
+	}
+	if !info.Namespaced {
+		namespace = ""
+	}
+
+	var t Table
+	typed := false
+	if typedGroups[info.Name] == info.Group {
+		t, typed = rc.typedTable(info.Name, namespace)
+	}
+	if !typed {
+		t = metadataTable(rc.metadataObjects(info, namespace))
+	}
+	t.Resource = info
+
+	if info.Namespaced && namespace == "" {
+		t.Columns = append([]string{"NAMESPACE"}, t.Columns...)
+		for i := range t.Rows {
+			t.Rows[i].Cells = append([]string{t.Rows[i].Namespace}, t.Rows[i].Cells...)
+		}
+	}
+	return t, true
+}
+
+// typedTable builds tables for the kinds with typed listers
+func (rc *ResourceCache) typedTable(resource, namespace string) (Table, bool) {
+	switch resource {
+	case "pods":
+		return rc.withUsage(podTable(rc.GetPods(namespace)), rc.PodUsage(namespace)), true
+	case "deployments":
+		return deploymentTable(rc.GetDeployments(namespace)), true
+	case "statefulsets":
+		return statefulSetTable(rc.GetStatefulSets(namespace)), true
+	case "daemonsets":
+		return daemonSetTable(rc.GetDaemonSets(namespace)), true
+	case "jobs":
+		return jobTable(rc.GetJobs(namespace)), true
+	case "cronjobs":
+		return cronJobTable(rc.GetCronJobs(namespace)), true
+	case "services":
+		return serviceTable(rc.GetServices(namespace)), true
+	case "nodes":
+		return rc.withUsage(nodeTable(rc.GetNodes()), rc.NodeUsage()), true
+	case "namespaces":
+		return namespaceTable(rc.getNamespaceObjects()), true
+	case "configmaps":
+		return configMapTable(rc.GetConfigMaps(namespace)), true
+	case "secrets":
+		return secretTable(rc.GetSecrets(namespace)), true
+	case "ingresses":
+		return ingressTable(rc.GetIngresses(namespace)), true
+	default:
+		return Table{}, false
+	}
+}
+
+// usageColumns are the columns metrics add to pod and node tables
+var usageColumns = map[string]bool{"CPU": true, "MEMORY": true}
+
+// withUsage adds CPU and MEMORY columns before AGE, when metrics are
+// available
+func (rc *ResourceCache) withUsage(t Table, usage []UsageSeries) Table {
+	if rc.MetricsError() != nil {
+		return t
+	}
+	index := usageIndex(usage)
+	at := slices.Index(t.Columns, "AGE")
+	t.Columns = slices.Insert(t.Columns, at, "CPU", "MEMORY")
+	for i, row := range t.Rows {
+		cpu, memory := "<unknown>", "<unknown>"
+		if u, ok := index[row.Namespace+"/"+row.Name]; ok {
+			cpu, memory = FormatCPU(u.CPU), FormatMemory(u.Memory)
+		}
+		t.Rows[i].Cells = slices.Insert(row.Cells, at, cpu, memory)
+	}
+	return t
+}
+
+// usageValue reads a CPU or MEMORY cell for sorting, -1 when it is
+// unknown
+func usageValue(cell string) int64 {
+	q, err := resource.ParseQuantity(cell)
+	if err != nil {
+		return -1
+	}
+	return q.MilliValue()
+}
+
+// Sorted returns the rows ordered by a column. AGE sorts by creation time,
+// CPU and MEMORY by quantity, columns where both cells are integers sort
+// numerically, and everything else sorts as text. Ties keep name order.
+func (t Table) Sorted(col int, desc bool) []TableRow {
+	rows := make([]TableRow, len(t.Rows))
+	copy(rows, t.Rows)
+	if col < 0 || col >= len(t.Columns) {
+		return rows
+	}
+
+	byAge := t.Columns[col] == "AGE"
+	byUsage := usageColumns[t.Columns[col]]
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if desc {
+			a, b = b, a
+		}
+		if byAge {
+			// Younger objects have smaller ages
+			return a.Created.After(b.Created)
+		}
+		if byUsage {
+			return usageValue(a.Cells[col]) < usageValue(b.Cells[col])
+		}
+		x, y := a.Cells[col], b.Cells[col]
+		if xi, err := strconv.Atoi(x); err == nil {
+			if yi, err := strconv.Atoi(y); err == nil {
+				return xi < yi
+			}
+		}
+		return x < y
+	})
+	return rows
+}
+
+// newRow starts a row with the object's name
+func newRow(meta metav1.ObjectMeta, cells ...string) TableRow {
+	return TableRow{
+		Name:      meta.Name,
+		Namespace: meta.Namespace,
+		Created:   meta.CreationTimestamp.Time,
+		Cells:     append([]string{meta.Name}, cells...),
+	}
+}
+
+// formatAge formats an object's age the way kubectl does (e.g. 5m, 3h, 12d)
+func formatAge(t metav1.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t.Time))
+}
+
+// orNone returns s, or "<none>" when it is empty
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+// images lists the images of a pod template's containers
+func images(spec corev1.PodSpec) string {
+	list := make([]string, len(spec.Containers))
+	for i, c := range spec.Containers {
+		list[i] = c.Image
+	}
+	return orNone(strings.Join(list, ","))
+}
+
+// replicas dereferences a replica count, which defaults to 1
+func replicas(n *int32) int32 {
+	if n == nil {
+		return 1
+	}
+	return *n
+}
+
+func podTable(pods []corev1.Pod) Table {
+	t := Table{Columns: []string{"NAME", "READY", "STATUS", "RESTARTS", "AGE", "NODE", "IMAGES"}}
+	for _, pod := range pods {
+		ready, restarts := 0, int32(0)
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Ready {
+				ready++
+			}
+			restarts += cs.RestartCount
+		}
+		t.Rows = append(t.Rows, newRow(pod.ObjectMeta,
+			fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+			PodStatus(pod),
+			strconv.Itoa(int(restarts)),
+			formatAge(pod.CreationTimestamp),
+			orNone(pod.Spec.NodeName),
+			images(pod.Spec),
+		))
+	}
+	return t
+}
+
+// PodStatus summarizes a pod the way the STATUS column of kubectl get
+// does: a waiting or terminated container's reason wins over the phase
+func PodStatus(pod corev1.Pod) string {
+	if pod.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	status := string(pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		status = pod.Status.Reason
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if w := cs.State.Waiting; w != nil && w.Reason != "" {
+			return w.Reason
+		}
+		if term := cs.State.Terminated; term != nil && term.Reason != "" && pod.Status.Phase != corev1.PodSucceeded {
+			return term.Reason
+		}
+	}
+	return orNone(status)
+}
+
+func deploymentTable(deps []appsv1.Deployment) Table {
+	t := Table{Columns: []string{"NAME", "READY", "UP-TO-DATE", "AVAILABLE", "AGE", "IMAGES"}}
+	for _, dep := range deps {
+		t.Rows = append(t.Rows, newRow(dep.ObjectMeta,
+			fmt.Sprintf("%d/%d", dep.Status.ReadyReplicas, replicas(dep.Spec.Replicas)),
+			strconv.Itoa(int(dep.Status.UpdatedReplicas)),
+			strconv.Itoa(int(dep.Status.AvailableReplicas)),
+			formatAge(dep.CreationTimestamp),
+			images(dep.Spec.Template.Spec),
+		))
+	}
+	return t
+}
+
+func statefulSetTable(sts []appsv1.StatefulSet) Table {
+	t := Table{Columns: []string{"NAME", "READY", "AGE", "IMAGES"}}
+	for _, s := range sts {
+		t.Rows = append(t.Rows, newRow(s.ObjectMeta,
+			fmt.Sprintf("%d/%d", s.Status.ReadyReplicas, replicas(s.Spec.Replicas)),
+			formatAge(s.CreationTimestamp),
+			images(s.Spec.Template.Spec),
+		))
+	}
+	return t
+}
+
+func daemonSetTable(ds []appsv1.DaemonSet) Table {
+	t := Table{Columns: []string{"NAME", "DESIRED", "CURRENT", "READY", "AVAILABLE", "AGE", "IMAGES"}}
+	for _, d := range ds {
+		t.Rows = append(t.Rows, newRow(d.ObjectMeta,
+			strconv.Itoa(int(d.Status.DesiredNumberScheduled)),
+			strconv.Itoa(int(d.Status.CurrentNumberScheduled)),
+			strconv.Itoa(int(d.Status.NumberReady)),
+			strconv.Itoa(int(d.Status.NumberAvailable)),
+			formatAge(d.CreationTimestamp),
+			images(d.Spec.Template.Spec),
+		))
+	}
+	return t
+}
+
+func jobTable(jobs []batchv1.Job) Table {
+	t := Table{Columns: []string{"NAME", "COMPLETIONS", "AGE", "IMAGES"}}
+	for _, job := range jobs {
+		t.Rows = append(t.Rows, newRow(job.ObjectMeta,
+			fmt.Sprintf("%d/%d", job.Status.Succeeded, replicas(job.Spec.Completions)),
+			formatAge(job.CreationTimestamp),
+			images(job.Spec.Template.Spec),
+		))
+	}
+	return t
+}
+
+func cronJobTable(cjs []batchv1.CronJob) Table {
+	t := Table{Columns: []string{"NAME", "SCHEDULE", "SUSPEND", "ACTIVE", "LAST SCHEDULE", "AGE"}}
+	for _, cj := range cjs {
+		suspend := cj.Spec.Suspend != nil && *cj.Spec.Suspend
+		last := "<none>"
+		if cj.Status.LastScheduleTime != nil {
+			last = formatAge(*cj.Status.LastScheduleTime)
+		}
+		t.Rows = append(t.Rows, newRow(cj.ObjectMeta,
+			cj.Spec.Schedule,
+			strconv.FormatBool(suspend),
+			strconv.Itoa(len(cj.Status.Active)),
+			last,
+			formatAge(cj.CreationTimestamp),
+		))
+	}
+	return t
+}
+
+func serviceTable(svcs []corev1.Service) Table {
+	t := Table{Columns: []string{"NAME", "TYPE", "CLUSTER-IP", "PORTS", "AGE"}}
+	for _, svc := range svcs {
+		ports := make([]string, len(svc.Spec.Ports))
+		for i, p := range svc.Spec.Ports {
+			ports[i] = fmt.Sprintf("%d/%s", p.Port, p.Protocol)
+		}
+		t.Rows = append(t.Rows, newRow(svc.ObjectMeta,
+			string(svc.Spec.Type),
+			orNone(svc.Spec.ClusterIP),
+			orNone(strings.Join(ports, ",")),
+			formatAge(svc.CreationTimestamp),
+		))
+	}
+	return t
+}
+
+func nodeTable(nodes []corev1.Node) Table {
+	t := Table{Columns: []string{"NAME", "STATUS", "ROLES", "AGE", "VERSION"}}
+	for _, node := range nodes {
+		status := "Unknown"
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == corev1.NodeReady {
+				status = "NotReady"
+				if cond.Status == corev1.ConditionTrue {
+					status = "Ready"
+				}
+			}
+		}
+		if node.Spec.Unschedulable {
+			status += ",SchedulingDisabled"
+		}
+
+		var roles []string
+		for label := range node.Labels {
+			if role, ok := strings.CutPrefix(label, "node-role.kubernetes.io/"); ok && role != "" {
+				roles = append(roles, role)
+			}
+		}
+		sort.Strings(roles)
+
+		t.Rows = append(t.Rows, newRow(node.ObjectMeta,
+			status,
+			orNone(strings.Join(roles, ",")),
+			formatAge(node.CreationTimestamp),
+			node.Status.NodeInfo.KubeletVersion,
+		))
+	}
+	return t
+}
+
+func namespaceTable(namespaces []corev1.Namespace) Table {
+	t := Table{Columns: []string{"NAME", "STATUS", "AGE"}}
+	for _, ns := range namespaces {
+		t.Rows = append(t.Rows, newRow(ns.ObjectMeta, string(ns.Status.Phase), formatAge(ns.CreationTimestamp)))
+	}
+	return t
+}
+
+func configMapTable(cms []corev1.ConfigMap) Table {
+	t := Table{Columns: []string{"NAME", "DATA", "AGE"}}
+	for _, cm := range cms {
+		t.Rows = append(t.Rows, newRow(cm.ObjectMeta,
+			strconv.Itoa(len(cm.Data)+len(cm.BinaryData)),
+			formatAge(cm.CreationTimestamp),
+		))
+	}
+	return t
+}
+
+func secretTable(secrets []corev1.Secret) Table {
+	t := Table{Columns: []string{"NAME", "TYPE", "DATA", "AGE"}}
+	for _, s := range secrets {
+		t.Rows = append(t.Rows, newRow(s.ObjectMeta,
+			string(s.Type),
+			strconv.Itoa(len(s.Data)),
+			formatAge(s.CreationTimestamp),
+		))
+	}
+	return t
+}
+
+func ingressTable(ings []networkingv1.Ingress) Table {
+	t := Table{Columns: []string{"NAME", "CLASS", "HOSTS", "AGE"}}
+	for _, ing := range ings {
+		class := ""
+		if ing.Spec.IngressClassName != nil {
+			class = *ing.Spec.IngressClassName
+		}
+		var hosts []string
+		for _, rule := range ing.Spec.Rules {
+			if rule.Host != "" {
+				hosts = append(hosts, rule.Host)
+			}
+		}
+		t.Rows = append(t.Rows, newRow(ing.ObjectMeta,
+			orNone(class),
+			orNone(strings.Join(hosts, ",")),
+			formatAge(ing.CreationTimestamp),
+		))
+	}
+	return t
+}
+
+// metadataTable builds a NAME/AGE table for kinds only known by metadata
+func metadataTable(objs []*metav1.PartialObjectMetadata) Table {
+	t := Table{Columns: []string{"NAME", "AGE"}}
+	for _, obj := range objs {
+		t.Rows = append(t.Rows, newRow(obj.ObjectMeta, formatAge(obj.CreationTimestamp)))
+	}
+	return t
+}
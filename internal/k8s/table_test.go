@@ -0,0 +1,135 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResourceCache_Table(t *testing.T) {
+	rc := NewMockResourceCache()
+
+	pods, ok := rc.Table("po", "production")
+	if !ok {
+		t.Fatal("Table(po) should resolve")
+	}
+	wantCols := []string{"NAME", "READY", "STATUS", "RESTARTS", "CPU", "MEMORY", "AGE", "NODE", "IMAGES"}
+	if !reflect.DeepEqual(pods.Columns, wantCols) {
+		t.Errorf("Columns = %v, want %v", pods.Columns, wantCols)
+	}
+	if len(pods.Rows) != 3 {
+		t.Fatalf("len(Rows) = %d, want 3", len(pods.Rows))
+	}
+	for _, row := range pods.Rows {
+		if len(row.Cells) != len(pods.Columns) {
+			t.Errorf("row %s has %d cells, want %d", row.Name, len(row.Cells), len(pods.Columns))
+		}
+		if row.Namespace != "production" {
+			t.Errorf("row %s namespace = %s, want production", row.Name, row.Namespace)
+		}
+	}
+
+	all, _ := rc.Table("pods", "")
+	if all.Columns[0] != "NAMESPACE" || len(all.Rows) <= len(pods.Rows) {
+		t.Errorf("Table(pods, all) = %v with %d rows, want a NAMESPACE column and more rows", all.Columns, len(all.Rows))
+	}
+
+	nodes, _ := rc.Table("nodes", "default")
+	if nodes.Columns[0] != "NAME" || len(nodes.Rows) != 3 {
+		t.Errorf("Table(nodes) = %v with %d rows, want cluster-scoped with 3 rows", nodes.Columns, len(nodes.Rows))
+	}
+
+	if _, ok := rc.Table("widgets", "default"); ok {
+		t.Error("Table(widgets) should not resolve")
+	}
+}
+
+func TestTable_Sorted(t *testing.T) {
+	now := time.Now()
+	table := Table{
+		Columns: []string{"NAME", "RESTARTS", "AGE"},
+		Rows: []TableRow{
+			{Name: "a", Created: now.Add(-time.Hour), Cells: []string{"a", "10", "1h"}},
+			{Name: "b", Created: now.Add(-time.Minute), Cells: []string{"b", "9", "1m"}},
+			{Name: "c", Created: now.Add(-24 * time.Hour), Cells: []string{"c", "0", "1d"}},
+		},
+	}
+
+	names := func(rows []TableRow) []string {
+		out := make([]string, len(rows))
+		for i, r := range rows {
+			out[i] = r.Name
+		}
+		return out
+	}
+
+	tests := []struct {
+		col  int
+		desc bool
+		want []string
+	}{
+		{-1, false, []string{"a", "b", "c"}},
+		{0, true, []string{"c", "b", "a"}},
+		{1, false, []string{"c", "b", "a"}},
+		{2, false, []string{"b", "a", "c"}},
+		{2, true, []string{"c", "a", "b"}},
+	}
+
+	for _, tt := range tests {
+		if got := names(table.Sorted(tt.col, tt.desc)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Sorted(%d, %v) = %v, want %v", tt.col, tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestPodStatus(t *testing.T) {
+	deleted := metav1.Now()
+	tests := []struct {
+		name string
+		pod  corev1.Pod
+		want string
+	}{
+		{"phase", corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}}, "Running"},
+		{"waiting", corev1.Pod{Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}},
+		}}, "CrashLoopBackOff"},
+		{"terminating", corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deleted},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}, "Terminating"},
+	}
+
+	for _, tt := range tests {
+		if got := PodStatus(tt.pod); got != tt.want {
+			t.Errorf("PodStatus(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResourceCache_Changes(t *testing.T) {
+	rc := NewMockResourceCache()
+
+	// Bursts coalesce into one pending signal
+	rc.notifyChange()
+	rc.notifyChange()
+	if _, ok := <-rc.Changes(); !ok {
+		t.Fatal("Changes() closed before Stop")
+	}
+	select {
+	case <-rc.Changes():
+		t.Error("Changes() should hold a single pending signal")
+	default:
+	}
+
+	rc.Stop()
+	rc.notifyChange()
+	if _, ok := <-rc.Changes(); ok {
+		t.Error("Changes() should be closed after Stop")
+	}
+}
@@ -1,5 +1,7 @@
 package kubecomplete
 
+import "github.com/tapcraft-io/purr/pkg/types"
+
 // ClusterCache is your abstraction over client-go/cache.
 type ClusterCache interface {
 	Namespaces() []string
@@ -13,4 +15,12 @@ type ClusterCache interface {
 
 	// Container names for a pod/workload target.
 	Containers(namespace, resourceKind, resourceName string) []string
+
+	// Label keys → values → how many objects of a kind carry them,
+	// in a namespace ("" for all).
+	Labels(kind, namespace string) map[string]map[string]int
+
+	// Ports a port-forward to a target ("svc/web", a bare pod name) can
+	// reach, in a namespace.
+	Ports(namespace, target string) []types.Port
 }
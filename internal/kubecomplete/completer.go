@@ -2,22 +2,21 @@ package kubecomplete
 
 import (
 	"fmt"
-	"os"
 	"strings"
-)
+	"sync"
 
-func debugLog(msg string) {
-	f, err := os.OpenFile("/tmp/purr-completer-debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return
-	}
-	defer f.Close()
-	fmt.Fprintf(f, "%s\n", msg)
-}
+	"github.com/tapcraft-io/purr/internal/debuglog"
+	"github.com/tapcraft-io/purr/internal/shlex"
+)
 
 type Completer struct {
 	Registry *Registry
 	Cache    ClusterCache
+	History  History // optional; ranks what was used before first
+	Schema   Schema  // optional; completes field paths in -o and --sort-by
+
+	mu   sync.Mutex
+	used *usage
 }
 
 func NewCompleter(reg *Registry, cache ClusterCache) *Completer {
@@ -28,45 +27,54 @@ func NewCompleter(reg *Registry, cache ClusterCache) *Completer {
 }
 
 // Complete is the main entry: pass the full line and cursor pos (byte offset).
+// Suggestions used before in history rank first.
 func (c *Completer) Complete(line string, cursor int, ctx CompletionContext) []Suggestion {
-	debugLog(fmt.Sprintf("=== Complete called: line=%q, cursor=%d ===", line, cursor))
+	out := c.complete(line, cursor, ctx)
+	if c.Registry != nil && c.History != nil {
+		if cursor < 0 || cursor > len(line) {
+			cursor = len(line)
+		}
+		tokens, hasTrailingSpace := SplitAtCursor(line, cursor)
+		c.rankByUsage(normalizeKubectl(tokens), hasTrailingSpace, ctx, out)
+	}
+	return out
+}
+
+// complete works out the suggestions for the token at the cursor
+func (c *Completer) complete(line string, cursor int, ctx CompletionContext) []Suggestion {
+	debuglog.Printf("=== Complete called: line=%q, cursor=%d ===", line, cursor)
 
 	if c.Registry == nil {
-		debugLog("Registry is nil, returning empty")
+		debuglog.Printf("Registry is nil, returning empty")
 		return nil
 	}
 	if cursor < 0 || cursor > len(line) {
 		cursor = len(line)
 	}
-	segment := line[:cursor]
-
-	// Check if we have trailing space (user finished typing current token)
-	hasTrailingSpace := len(segment) > 0 && (segment[len(segment)-1] == ' ' || segment[len(segment)-1] == '\t')
-
-	tokens := shellSplit(segment)
+	tokens, hasTrailingSpace := SplitAtCursor(line, cursor)
 	tokens = normalizeKubectl(tokens)
 
-	debugLog(fmt.Sprintf("tokens=%v, hasTrailingSpace=%v", tokens, hasTrailingSpace))
+	debuglog.Printf("tokens=%v, hasTrailingSpace=%v", tokens, hasTrailingSpace)
 
 	if len(tokens) == 0 {
-		debugLog("No tokens, suggesting top-level commands")
+		debuglog.Printf("No tokens, suggesting top-level commands")
 		return c.suggestTopLevelCommands("")
 	}
 
 	cmd, pathLen := c.Registry.MatchCommand(tokens)
-	debugLog(fmt.Sprintf("MatchCommand result: cmd=%v, pathLen=%d", cmd != nil, pathLen))
+	debuglog.Printf("MatchCommand result: cmd=%v, pathLen=%d", cmd != nil, pathLen)
 
 	if cmd == nil {
-		debugLog("No command match, checking for subcommands")
+		debuglog.Printf("No command match, checking for subcommands")
 		// No exact command match - check if we're building a subcommand
 		// e.g., "rollout " or "rollout" or "rollout re" should suggest subcommands
 		subcommands := c.suggestSubcommands(tokens)
-		debugLog(fmt.Sprintf("suggestSubcommands returned %d results", len(subcommands)))
+		debuglog.Printf("suggestSubcommands returned %d results", len(subcommands))
 		if len(subcommands) > 0 {
 			return subcommands
 		}
 		// Otherwise suggest top-level command names
-		debugLog(fmt.Sprintf("No subcommands, suggesting top-level with prefix=%q", tokens[0]))
+		debuglog.Printf("No subcommands, suggesting top-level with prefix=%q", tokens[0])
 		return c.suggestTopLevelCommands(tokens[0])
 	}
 
@@ -75,17 +83,31 @@ func (c *Completer) Complete(line string, cursor int, ctx CompletionContext) []S
 	// If we matched a partial path OR a complete command without trailing space,
 	// check for subcommands before suggesting positionals
 	if pathLen < len(tokens) || (!hasTrailingSpace && pathLen == len(tokens)) {
-		debugLog(fmt.Sprintf("Checking for subcommands (pathLen=%d < len(tokens)=%d OR no trailing space)", pathLen, len(tokens)))
+		debuglog.Printf("Checking for subcommands (pathLen=%d < len(tokens)=%d OR no trailing space)", pathLen, len(tokens))
 		subcommands := c.suggestSubcommands(tokens)
-		debugLog(fmt.Sprintf("suggestSubcommands returned %d results", len(subcommands)))
+		debuglog.Printf("suggestSubcommands returned %d results", len(subcommands))
 		if len(subcommands) > 0 {
 			return subcommands
 		}
-		debugLog("No subcommands found, continuing to positionals/flags")
+		debuglog.Printf("No subcommands found, continuing to positionals/flags")
 	}
 
 	args := tokens[pathLen:] // after command path
-	debugLog(fmt.Sprintf("args=%v (tokens after command path)", args))
+	debuglog.Printf("args=%v (tokens after command path)", args)
+
+	// Case 0: We're typing a value attached to its flag (e.g., "get pods --sort-by=.me")
+	if !hasTrailingSpace && len(args) > 0 {
+		lastArg := args[len(args)-1]
+		if flag, value, ok := attachedFlagValue(cmd, lastArg); ok {
+			debuglog.Printf("Typing attached flag value: flag=%s, value=%s", flag, value)
+			flagArgs := append(args[:len(args)-1:len(args)-1], flag)
+			out := c.suggestAfterFlag(cmd, ctx, flagArgs, value, true)
+			for i := range out {
+				out[i].Value = lastArg[:len(lastArg)-len(value)] + out[i].Value
+			}
+			return out
+		}
+	}
 
 	// Case 1: We're typing a flag value (e.g., "get pods -n d")
 	// Check if second-to-last arg is a flag and last arg is not a flag
@@ -93,26 +115,38 @@ func (c *Completer) Complete(line string, cursor int, ctx CompletionContext) []S
 		secondToLast := args[len(args)-2]
 		lastArg := args[len(args)-1]
 		if isFlagToken(secondToLast) && !isFlagToken(lastArg) {
-			debugLog(fmt.Sprintf("Typing flag value: flag=%s, value=%s", secondToLast, lastArg))
+			debuglog.Printf("Typing flag value: flag=%s, value=%s", secondToLast, lastArg)
 			// We're typing a flag value - suggest completions for that flag
 			// Pass args without the partial value so suggestAfterFlag can identify the flag
-			return c.suggestAfterFlag(cmd, ctx, args[:len(args)-1], true)
+			return c.suggestAfterFlag(cmd, ctx, args[:len(args)-1], lastArg, true)
 		}
 	}
 
 	// Case 2: We just finished typing a flag and added space - suggest flag value
 	if hasTrailingSpace && len(args) > 0 && isFlagToken(args[len(args)-1]) {
-		debugLog(fmt.Sprintf("Just typed flag with space: %s", args[len(args)-1]))
-		return c.suggestAfterFlag(cmd, ctx, args, hasTrailingSpace)
+		debuglog.Printf("Just typed flag with space: %s", args[len(args)-1])
+		return c.suggestAfterFlag(cmd, ctx, args, "", hasTrailingSpace)
 	}
 
 	// Case 3: Otherwise - suggest positionals and flags
-	debugLog("Calling suggestPositionalsAndFlags")
+	debuglog.Printf("Calling suggestPositionalsAndFlags")
 	return c.suggestPositionalsAndFlags(cmd, ctx, args, hasTrailingSpace)
 }
 
-func shellSplit(s string) []string {
-	return strings.Fields(s) // good enough; you can swap in a real shell parser later
+// SplitAtCursor tokenizes line up to cursor. hasTrailingSpace reports
+// whether the cursor sits after whitespace (the current token is finished)
+// rather than inside a word, including an unterminated quoted word.
+func SplitAtCursor(line string, cursor int) (tokens []string, hasTrailingSpace bool) {
+	if cursor < 0 || cursor > len(line) {
+		cursor = len(line)
+	}
+	segment := line[:cursor]
+
+	toks, _ := shlex.Split(segment)
+	if len(toks) == 0 {
+		return nil, len(segment) > 0
+	}
+	return shlex.Values(toks), toks[len(toks)-1].End < len(segment)
 }
 
 func normalizeKubectl(tokens []string) []string {
@@ -122,6 +156,28 @@ func normalizeKubectl(tokens []string) []string {
 	return tokens
 }
 
+// attachedFlagValue splits a flag token that carries its value, as in
+// "--output=json", "-o=json" or "-ojson", if the flag takes one
+func attachedFlagValue(cmd *CommandRuntime, tok string) (flag, value string, ok bool) {
+	switch {
+	case strings.HasPrefix(tok, "--"):
+		flag, value, ok = strings.Cut(tok, "=")
+	case strings.HasPrefix(tok, "-") && len(tok) > 2:
+		flag, value, ok = tok[:2], strings.TrimPrefix(tok[2:], "="), true
+	}
+	if !ok {
+		return "", "", false
+	}
+	primary, known := cmd.AliasToPrimary[flag]
+	if !known {
+		return "", "", false
+	}
+	if f, ok := cmd.Spec.Flags[primary]; !ok || f.After == nil {
+		return "", "", false
+	}
+	return flag, value, true
+}
+
 func isFlagToken(tok string) bool {
 	return strings.HasPrefix(tok, "-")
 }
@@ -131,10 +187,14 @@ func (c *Completer) suggestTopLevelCommands(prefix string) []Suggestion {
 	var out []Suggestion
 	for _, name := range names {
 		if prefix == "" || strings.HasPrefix(name, prefix) {
+			description := ""
+			if c.Registry.isPlugin(name) {
+				description = "plugin"
+			}
 			out = append(out, Suggestion{
 				Value:       name,
 				Kind:        SuggestCommand,
-				Description: "",
+				Description: description,
 				Score:       scorePrefix(name, prefix),
 			})
 		}
@@ -227,7 +287,9 @@ func scorePrefix(value, prefix string) float64 {
 	return 0
 }
 
-func (c *Completer) suggestAfterFlag(cmd *CommandRuntime, ctx CompletionContext, args []string, hasTrailingSpace bool) []Suggestion {
+// suggestAfterFlag suggests values for the flag that ends args, partial
+// being what is typed of the value so far
+func (c *Completer) suggestAfterFlag(cmd *CommandRuntime, ctx CompletionContext, args []string, partial string, hasTrailingSpace bool) []Suggestion {
 	if len(args) == 0 {
 		return nil
 	}
@@ -249,10 +311,14 @@ func (c *Completer) suggestAfterFlag(cmd *CommandRuntime, ctx CompletionContext,
 	case TokenNamespace:
 		return c.suggestNamespaces(ctx)
 	case TokenOutput:
-		return c.suggestEnumValues(td.Allowed, "Output format")
+		return c.suggestOutput(cmd, args, td, partial)
+	case TokenJSONPath:
+		return c.suggestSortBy(cmd, args, td, partial)
 	case TokenSelector:
-		// Usually freeform; you could still suggest recent selectors if you track them.
-		return nil
+		if flagDesc.Role == "field-selector" {
+			return c.suggestFieldSelector(cmd, ctx, args, partial)
+		}
+		return c.suggestLabelSelector(cmd, ctx, args, partial)
 	case TokenContainerName:
 		// we *could* inspect earlier args to find pod/workload; for now just ask cache with empty.
 		return c.suggestContainers(ctx, "", "", "")
@@ -417,39 +483,46 @@ func inferResourceKindFromArgs(cmd *CommandRuntime, args []string) string {
 func (c *Completer) suggestPositionalsAndFlags(cmd *CommandRuntime, ctx CompletionContext, args []string, hasTrailingSpace bool) []Suggestion {
 	spec := cmd.Spec
 
-	debugLog(fmt.Sprintf("suggestPositionalsAndFlags: args=%v, hasTrailingSpace=%v, numPositionals=%d", args, hasTrailingSpace, len(spec.Positionals)))
+	debuglog.Printf("suggestPositionalsAndFlags: args=%v, hasTrailingSpace=%v, numPositionals=%d", args, hasTrailingSpace, len(spec.Positionals))
 
 	usedFlags := parseUsedFlags(cmd, args)
 	posIndex := countSatisfiedPositionals(spec.Positionals, cmd, args, hasTrailingSpace)
 
-	debugLog(fmt.Sprintf("posIndex=%d (satisfied positionals)", posIndex))
+	debuglog.Printf("posIndex=%d (satisfied positionals)", posIndex)
 
 	var out []Suggestion
 
-	// 1. Suggest next positional (if any)
-	if posIndex < len(spec.Positionals) {
+	// 1. Suggest next positional (if any). Port mappings repeat, so they
+	// are suggested for as long as more are typed.
+	if last := len(spec.Positionals) - 1; last > 0 && posIndex >= last && spec.Positionals[last].Kind == TokenPortMapping {
+		var partial string
+		if !hasTrailingSpace && len(args) > 0 {
+			partial = args[len(args)-1]
+		}
+		out = append(out, c.suggestPorts(cmd, ctx, args, partial)...)
+	} else if posIndex < len(spec.Positionals) {
 		td := &spec.Positionals[posIndex]
-		debugLog(fmt.Sprintf("Suggesting positional %d, kind=%s", posIndex, td.Kind))
+		debuglog.Printf("Suggesting positional %d, kind=%s", posIndex, td.Kind)
 		out = append(out, c.suggestForPositional(cmd, ctx, td, args)...)
 	} else if posIndex > 0 && posIndex == len(spec.Positionals) {
-		debugLog("All positionals satisfied, checking for resource name suggestions")
+		debuglog.Printf("All positionals satisfied, checking for resource name suggestions")
 		// All positionals are satisfied, but if the first positional was a resource type,
 		// suggest resource names for that type (e.g., "rollout restart deployment" -> suggest deployment names)
 		firstPos := &spec.Positionals[0]
-		debugLog(fmt.Sprintf("First positional kind=%s", firstPos.Kind))
+		debuglog.Printf("First positional kind=%s", firstPos.Kind)
 		if firstPos.Kind == TokenResourceType || firstPos.Kind == TokenResourceName {
 			// Get the resource type from the first non-flag arg
 			resourceType := getFirstNonFlagArg(args)
-			debugLog(fmt.Sprintf("Resource type from args: %q", resourceType))
+			debuglog.Printf("Resource type from args: %q", resourceType)
 			if resourceType != "" && c.Cache != nil {
 				// Extract namespace from flags if specified
 				ns := extractNamespaceFromArgs(cmd, args)
 				if ns == "" {
 					ns = ctx.CurrentNamespace
 				}
-				debugLog(fmt.Sprintf("Looking up resource names for type=%s, namespace=%s", resourceType, ns))
+				debuglog.Printf("Looking up resource names for type=%s, namespace=%s", resourceType, ns)
 				names := c.Cache.ResourceNames(resourceType, ns)
-				debugLog(fmt.Sprintf("Found %d resource names", len(names)))
+				debuglog.Printf("Found %d resource names", len(names))
 				for _, name := range names {
 					out = append(out, Suggestion{
 						Value:       name,
@@ -477,8 +550,8 @@ func (c *Completer) suggestPositionalsAndFlags(cmd *CommandRuntime, ctx Completi
 		flagCount++
 	}
 
-	debugLog(fmt.Sprintf("Added %d flags to suggestions", flagCount))
-	debugLog(fmt.Sprintf("Total suggestions before sort: %d", len(out)))
+	debuglog.Printf("Added %d flags to suggestions", flagCount)
+	debuglog.Printf("Total suggestions before sort: %d", len(out))
 
 	sortSuggestions(out)
 	return out
@@ -582,14 +655,14 @@ func (c *Completer) suggestForPositional(cmd *CommandRuntime, ctx CompletionCont
 		// the first non-flag arg (which would be the resource type from a previous positional)
 		if kind == "" {
 			kind = getFirstNonFlagArg(args)
-			debugLog(fmt.Sprintf("inferResourceKindFromArgs returned empty, using first non-flag arg: %q", kind))
+			debuglog.Printf("inferResourceKindFromArgs returned empty, using first non-flag arg: %q", kind)
 		}
 
 		// If still empty and no args, suggest resource types first
 		if kind == "" && len(args) == 0 {
 			// First positional with no args - suggest resource type instead
 			// This handles commands like "logs", "describe", "delete", etc.
-			debugLog("No kind and no args, suggesting resource types")
+			debuglog.Printf("No kind and no args, suggesting resource types")
 			return c.suggestResourceTypes(cmd, ctx, td)
 		}
 
@@ -599,7 +672,7 @@ func (c *Completer) suggestForPositional(cmd *CommandRuntime, ctx CompletionCont
 			ns = ctx.CurrentNamespace
 		}
 
-		debugLog(fmt.Sprintf("Suggesting resource names for kind=%q, namespace=%q", kind, ns))
+		debuglog.Printf("Suggesting resource names for kind=%q, namespace=%q", kind, ns)
 		return c.suggestResourceNames(ctx, kind, ns, td)
 	case TokenResourceTarget:
 		// resource-target is a flexible token that accepts TYPE/NAME or just NAME
@@ -608,7 +681,7 @@ func (c *Completer) suggestForPositional(cmd *CommandRuntime, ctx CompletionCont
 		if ns == "" {
 			ns = ctx.CurrentNamespace
 		}
-		debugLog(fmt.Sprintf("TokenResourceTarget: suggesting pods for namespace=%q", ns))
+		debuglog.Printf("TokenResourceTarget: suggesting pods for namespace=%q", ns)
 		// Default to pods for logs-like commands (role is "pod-or-workload")
 		return c.suggestResourceNames(ctx, "pod", ns, td)
 	case TokenNamespace:
@@ -0,0 +1,267 @@
+package kubecomplete
+
+import (
+	"strings"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// Schema describes the fields of resource types
+type Schema interface {
+	// Fields returns the fields of a resource type at a path of field
+	// names, list items walked through; nil if it is not known (yet)
+	Fields(resourceType string, path []string) []types.SchemaField
+}
+
+// templateFormats are the output formats that take a template of field
+// paths, with what starts one
+var templateFormats = map[string]string{
+	"jsonpath":         "jsonpath={",
+	"jsonpath-as-json": "jsonpath-as-json={",
+	"custom-columns":   "custom-columns=",
+}
+
+// suggestOutputFormats suggests output formats, the ones that take a
+// template with it started
+func (c *Completer) suggestOutputFormats(allowed []string) []Suggestion {
+	out := c.suggestEnumValues(allowed, "Output format")
+	for i, s := range out {
+		if start, ok := templateFormats[s.Value]; ok {
+			out[i].Value = start
+			out[i].NoSpace = true
+		}
+	}
+	return out
+}
+
+// fieldPathValue is a flag value that ends in a field path being typed
+type fieldPathValue struct {
+	before   string // the value up to the path
+	expr     string // the path typed so far
+	index    string // how lists are stepped into
+	list     bool   // the path may start at the items of a list of objects
+	finishes bool   // a path to a value is the whole flag value
+}
+
+// parseFieldPathValue finds the field path at the end of a value of -o, in
+// a jsonpath template or the last column of a custom-columns spec, or of
+// --sort-by. Sorting needs one value, so its lists are stepped into at
+// their first item.
+func parseFieldPathValue(td *TokenDescriptor, value string) (fieldPathValue, bool) {
+	if td.Kind == TokenJSONPath {
+		if len(td.Allowed) > 0 {
+			return fieldPathValue{}, false
+		}
+		if strings.HasPrefix(value, "{") {
+			return fieldPathValue{before: "{", expr: value[1:], index: "[0]"}, true
+		}
+		return fieldPathValue{expr: value, index: "[0]", finishes: true}, true
+	}
+	if td.Kind != TokenOutput {
+		return fieldPathValue{}, false
+	}
+
+	format, template, _ := strings.Cut(value, "=")
+	switch format {
+	case "jsonpath", "jsonpath-as-json":
+		// The expression in the last open braces
+		open := strings.LastIndex(template, "{")
+		if open < 0 || strings.Contains(template[open:], "}") {
+			return fieldPathValue{}, false
+		}
+		inner := template[open+1:]
+		start := jsonPathStart(inner)
+		v := fieldPathValue{
+			before: format + "=" + template[:open+1] + inner[:start],
+			expr:   inner[start:],
+			index:  "[*]",
+			list:   true,
+		}
+		if strings.HasPrefix(v.expr, "@") {
+			v.before += "@"
+			v.expr = v.expr[1:]
+		}
+		return v, true
+
+	case "custom-columns":
+		// The path of the last column, after its header
+		cut := strings.LastIndex(template, ",") + 1
+		header, expr, ok := strings.Cut(template[cut:], ":")
+		if !ok {
+			return fieldPathValue{}, false
+		}
+		return fieldPathValue{before: format + "=" + template[:cut] + header + ":", expr: expr, index: "[*]"}, true
+	}
+	return fieldPathValue{}, false
+}
+
+// suggestOutput completes -o: a format, or the field paths of a jsonpath
+// template or custom-columns spec
+func (c *Completer) suggestOutput(cmd *CommandRuntime, args []string, td *TokenDescriptor, partial string) []Suggestion {
+	if !strings.Contains(partial, "=") {
+		return c.suggestOutputFormats(td.Allowed)
+	}
+	return c.suggestFieldPathValue(cmd, args, td, partial)
+}
+
+// suggestSortBy completes --sort-by: its fixed keys if it has them, and
+// otherwise the field path to sort by
+func (c *Completer) suggestSortBy(cmd *CommandRuntime, args []string, td *TokenDescriptor, partial string) []Suggestion {
+	if len(td.Allowed) > 0 {
+		return c.suggestEnumValues(td.Allowed, "Sort by")
+	}
+	return c.suggestFieldPathValue(cmd, args, td, partial)
+}
+
+// suggestFieldPathValue completes the field path at the end of a flag value
+func (c *Completer) suggestFieldPathValue(cmd *CommandRuntime, args []string, td *TokenDescriptor, partial string) []Suggestion {
+	kind := c.fieldPathKind(cmd, args)
+	if kind == "" {
+		return nil
+	}
+	v, ok := parseFieldPathValue(td, partial)
+	if !ok {
+		return nil
+	}
+	return c.suggestFieldPath(kind, v)
+}
+
+// fieldPathKind returns the resource type field paths are completed for,
+// "" without a schema to complete them from
+func (c *Completer) fieldPathKind(cmd *CommandRuntime, args []string) string {
+	if c.Schema == nil || c.Cache == nil {
+		return ""
+	}
+	kinds := c.targetKinds(cmd, args)
+	if len(kinds) == 0 {
+		return ""
+	}
+	return kinds[0]
+}
+
+// jsonPathStart returns where the field path at the end of a jsonpath
+// expression starts, e.g. after "range " or inside a filter's brackets
+func jsonPathStart(expr string) int {
+	depth := 0
+	for i := len(expr) - 1; i >= 0; i-- {
+		switch expr[i] {
+		case ']':
+			depth++
+		case '[':
+			if depth == 0 {
+				return i + 1
+			}
+			depth--
+		case ' ', '(', ',', '{', '=', '!', '<', '>':
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// splitFieldPath splits a field path like ".status.containerStatuses[*].re"
+// into the names of the fields it walks, the text up to the last dot and
+// the name typed after it. Indexes and filters in brackets are skipped.
+func splitFieldPath(expr string) (path []string, head, partial string) {
+	depth := 0
+	lastDot := -1
+	start := 0
+	var segments []string
+	for i, r := range expr {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				segments = append(segments, expr[start:i])
+				start = i + 1
+				lastDot = i
+			}
+		}
+	}
+	for _, seg := range segments {
+		if name, _, _ := strings.Cut(seg, "["); name != "" {
+			path = append(path, name)
+		}
+	}
+	return path, expr[:lastDot+1], expr[lastDot+1:]
+}
+
+// suggestFieldPath completes the field path at the end of a flag value
+// with the fields of a resource type. A path to an object or a list of
+// them goes on with a dot; one to a value ends the word only if it
+// finishes the flag value.
+func (c *Completer) suggestFieldPath(kind string, v fieldPathValue) []Suggestion {
+	path, head, _, listRoot := v.path()
+	fields := c.Schema.Fields(kind, path)
+	if fields == nil {
+		return nil
+	}
+	if listRoot {
+		fields = append(fields, types.SchemaField{
+			Name:        "items",
+			Type:        "[]object",
+			Description: "The objects listed, when there are several.",
+		})
+	}
+
+	out := make([]Suggestion, 0, len(fields))
+	for _, f := range fields {
+		value := v.before + head + f.Name
+		if strings.HasPrefix(f.Type, "[]") {
+			value += v.index
+		}
+		noSpace := !v.finishes
+		child := append(path[:len(path):len(path)], f.Name)
+		if (listRoot && f.Name == "items") || len(c.Schema.Fields(kind, child)) > 0 {
+			value += "."
+			noSpace = true
+		}
+		out = append(out, Suggestion{
+			Value:       value,
+			Kind:        SuggestFlagValue,
+			Description: fieldDescription(f),
+			Score:       40,
+			NoSpace:     noSpace,
+		})
+	}
+	sortSuggestions(out)
+	return out
+}
+
+// path splits the field path of a value as splitFieldPath does. A path that
+// may start at a list's items does not count them, and is at its root
+// before them.
+func (v fieldPathValue) path() (path []string, head, partial string, listRoot bool) {
+	expr := v.expr
+	if expr == "" {
+		expr = "."
+	}
+	path, head, partial = splitFieldPath(expr)
+	if v.list && len(path) > 0 && path[0] == "items" {
+		path = path[1:]
+	} else if v.list && len(path) == 0 {
+		listRoot = true
+	}
+	return path, head, partial, listRoot
+}
+
+// fieldDescription describes a field the way kubectl explain does, its
+// type and the first sentence of its description
+func fieldDescription(f types.SchemaField) string {
+	description := strings.TrimSpace(f.Description)
+	if i := strings.Index(description, "\n"); i >= 0 {
+		description = description[:i]
+	}
+	if i := strings.Index(description, ". "); i >= 0 {
+		description = description[:i+1]
+	}
+	if description == "" {
+		return "<" + f.Type + ">"
+	}
+	return "<" + f.Type + "> " + description
+}
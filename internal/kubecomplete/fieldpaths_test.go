@@ -0,0 +1,122 @@
+package kubecomplete
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// fakeSchema knows a few fields of pods, by path
+type fakeSchema map[string][]types.SchemaField
+
+func (s fakeSchema) Fields(resourceType string, path []string) []types.SchemaField {
+	if resourceType != "pods" {
+		return nil
+	}
+	return s[strings.Join(path, ".")]
+}
+
+var podSchema = fakeSchema{
+	"": {
+		{Name: "metadata", Type: "ObjectMeta", Description: "Standard object's metadata. More info: https://..."},
+		{Name: "spec", Type: "PodSpec"},
+		{Name: "status", Type: "PodStatus"},
+	},
+	"metadata":              {{Name: "labels", Type: "map[string]string"}, {Name: "name", Type: "string"}},
+	"metadata.labels":       {},
+	"metadata.name":         {},
+	"spec":                  {{Name: "containers", Type: "[]Container"}, {Name: "nodeName", Type: "string"}},
+	"spec.containers":       {{Name: "image", Type: "string"}, {Name: "name", Type: "string"}},
+	"spec.containers.image": {},
+	"spec.containers.name":  {},
+	"spec.nodeName":         {},
+	"status":                {{Name: "phase", Type: "string"}},
+	"status.phase":          {},
+}
+
+func TestSplitFieldPath(t *testing.T) {
+	tests := []struct {
+		expr          string
+		path          []string
+		head, partial string
+	}{
+		{".", nil, ".", ""},
+		{".me", nil, ".", "me"},
+		{".spec.containers[*].im", []string{"spec", "containers"}, ".spec.containers[*].", "im"},
+		{".items[?(@.a.b)].sp", []string{"items"}, ".items[?(@.a.b)].", "sp"},
+	}
+	for _, tt := range tests {
+		path, head, partial := splitFieldPath(tt.expr)
+		if !reflect.DeepEqual(path, tt.path) || head != tt.head || partial != tt.partial {
+			t.Errorf("splitFieldPath(%q) = %v, %q, %q, want %v, %q, %q", tt.expr, path, head, partial, tt.path, tt.head, tt.partial)
+		}
+	}
+}
+
+func TestCompleter_FieldPaths(t *testing.T) {
+	c := newTestCompleter(t, nil)
+	c.Schema = podSchema
+	ctx := CompletionContext{CurrentNamespace: "payments"}
+
+	tests := []struct {
+		line    string
+		want    string
+		noSpace bool
+		without string
+	}{
+		{"get pods -o ", "jsonpath={", true, ""},
+		{"get pods -o ", "json", false, ""},
+		{"get pods -o jsonpath={", "jsonpath={.items[*].", true, ""},
+		{"get pods -o jsonpath={", "jsonpath={.metadata.", true, ""},
+		{"get pods -o jsonpath={.items[*].", "jsonpath={.items[*].spec.", true, "jsonpath={.items[*].items[*]."},
+		{"get pods -o jsonpath={.spec.containers[*].", "jsonpath={.spec.containers[*].image", true, ""},
+		{"get pods -o 'jsonpath={range .items[*]}{@.metadata.", "jsonpath={range .items[*]}{@.metadata.name", true, ""},
+		{"get pods -o custom-columns=NAME:.metadata.name,NODE:.spec.", "custom-columns=NAME:.metadata.name,NODE:.spec.nodeName", true, ""},
+		{"get pods -o custom-columns=NAME:", "custom-columns=NAME:.status.", true, "custom-columns=NAME:.items[*]."},
+		{"get pods --sort-by ", ".status.", true, ".items[*]."},
+		{"get pods --sort-by .spec.", ".spec.containers[0].", true, ""},
+		{"get pods --sort-by .spec.", ".spec.nodeName", false, ""},
+		{"get pods --sort-by={.spec.", "--sort-by={.spec.nodeName", true, ""},
+		{"get pods --sort-by=.metadata.n", "--sort-by=.metadata.name", false, ""},
+		{"get pods -ojsonpath={.st", "-ojsonpath={.status.", true, ""},
+		{"get pods -o=jsonpath={.st", "-o=jsonpath={.status.", true, ""},
+		{"top pod --sort-by ", "memory", false, ".status."},
+		{"get services --sort-by ", "", false, ".status."},
+	}
+	for _, tt := range tests {
+		got := c.Complete(tt.line, len(tt.line), ctx)
+		found := tt.want == ""
+		for _, s := range got {
+			if s.Value == tt.without {
+				t.Errorf("Complete(%q) = %v, want no %q", tt.line, got, tt.without)
+			}
+			if s.Value == tt.want {
+				found = true
+				if s.NoSpace != tt.noSpace {
+					t.Errorf("Complete(%q) %q NoSpace = %v, want %v", tt.line, s.Value, s.NoSpace, tt.noSpace)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Complete(%q) = %v, want %q among them", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFieldDescription(t *testing.T) {
+	tests := []struct {
+		field types.SchemaField
+		want  string
+	}{
+		{types.SchemaField{Type: "string"}, "<string>"},
+		{types.SchemaField{Type: "ObjectMeta", Description: "Standard object's metadata. More info: https://..."}, "<ObjectMeta> Standard object's metadata."},
+		{types.SchemaField{Type: "[]Container", Description: "List of containers\nbelonging to the pod."}, "<[]Container> List of containers"},
+	}
+	for _, tt := range tests {
+		if got := fieldDescription(tt.field); got != tt.want {
+			t.Errorf("fieldDescription(%+v) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package kubecomplete
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// PluginPrefix starts the name of every kubectl plugin executable
+const PluginPrefix = "kubectl-"
+
+// Plugin is a kubectl-* executable
+type Plugin struct {
+	Path []string // the command it adds, e.g. ["foo", "bar"] for kubectl-foo-bar
+	File string   // the executable
+}
+
+// KrewBinDir returns where krew installs plugins: $KREW_ROOT/bin, or
+// ~/.krew/bin
+func KrewBinDir() string {
+	root := os.Getenv("KREW_ROOT")
+	if root == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		root = filepath.Join(home, ".krew")
+	}
+	return filepath.Join(root, "bin")
+}
+
+// DiscoverPlugins finds the kubectl plugins in the directories of a PATH
+// list and then the extra ones. As with kubectl, the first executable for
+// a command wins.
+func DiscoverPlugins(pathList string, extra ...string) []Plugin {
+	dirs := append(filepath.SplitList(pathList), extra...)
+	seen := make(map[string]bool)
+	var plugins []Plugin
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, PluginPrefix) || entry.IsDir() {
+				continue
+			}
+			file := filepath.Join(dir, name)
+			if !isExecutable(file) {
+				continue
+			}
+			path := pluginPath(name)
+			key := strings.Join(path, " ")
+			if len(path) == 0 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			plugins = append(plugins, Plugin{Path: path, File: file})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return strings.Join(plugins[i].Path, " ") < strings.Join(plugins[j].Path, " ")
+	})
+	return plugins
+}
+
+// pluginPath returns the command a plugin executable adds: dashes separate
+// words and underscores stand for dashes, so kubectl-foo-bar_baz is
+// "kubectl foo bar-baz"
+func pluginPath(name string) []string {
+	name = strings.TrimPrefix(name, PluginPrefix)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	var path []string
+	for _, word := range strings.Split(name, "-") {
+		if word == "" {
+			return nil
+		}
+		path = append(path, strings.ReplaceAll(word, "_", "-"))
+	}
+	return path
+}
+
+// isExecutable reports whether a file can be run: an executable regular
+// file, or on Windows one with an extension in PATHEXT
+func isExecutable(file string) bool {
+	info, err := os.Stat(file)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS != "windows" {
+		return info.Mode().Perm()&0111 != 0
+	}
+	exts := os.Getenv("PATHEXT")
+	if exts == "" {
+		exts = ".com;.exe;.bat;.cmd"
+	}
+	for _, ext := range filepath.SplitList(strings.ToLower(exts)) {
+		if strings.EqualFold(filepath.Ext(file), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddPlugins registers plugins as commands. Plugins cannot replace or
+// extend a built-in command, as kubectl runs its own first.
+func (r *Registry) AddPlugins(plugins []Plugin) {
+	builtin := make(map[string]bool)
+	for _, rt := range r.Commands {
+		if !rt.Spec.Plugin {
+			builtin[rt.Spec.Path[0]] = true
+		}
+	}
+	for _, p := range plugins {
+		key := strings.Join(p.Path, " ")
+		if rt, ok := r.Commands[key]; ok {
+			if rt.Spec.Plugin && rt.PluginFile == "" {
+				rt.PluginFile = p.File
+			}
+			continue
+		}
+		if builtin[p.Path[0]] {
+			continue
+		}
+		r.Commands[key] = &CommandRuntime{
+			Spec: &CommandSpec{
+				Path:        p.Path,
+				Synopsis:    "kubectl " + key + " [args]",
+				Description: "plugin",
+				Flags:       map[string]FlagDescriptor{},
+				Plugin:      true,
+			},
+			Key:            key,
+			AliasToPrimary: map[string]string{},
+			PluginFile:     p.File,
+		}
+	}
+}
+
+// PluginNames returns the first words of the plugin commands
+func (r *Registry) PluginNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, rt := range r.Commands {
+		if rt.Spec.Plugin && !seen[rt.Spec.Path[0]] {
+			seen[rt.Spec.Path[0]] = true
+			names = append(names, rt.Spec.Path[0])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isPlugin reports whether a top-level command word belongs to plugins
+func (r *Registry) isPlugin(name string) bool {
+	for key, rt := range r.Commands {
+		if rt.Spec.Plugin && (key == name || strings.HasPrefix(key, name+" ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsComplete reports whether a plugin executable answers cobra's
+// __complete, judged by whether it contains the word at all. Plugins that
+// don't are never run to ask, since they would take it as arguments.
+func SupportsComplete(file string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	needle := []byte("__complete")
+	buf := make([]byte, 64*1024)
+	carry := 0
+	for {
+		n, err := f.Read(buf[carry:])
+		end := carry + n
+		if bytes.Contains(buf[:end], needle) {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+		// Keep enough of the end to find the word across reads
+		carry = min(len(needle)-1, end)
+		copy(buf, buf[end-carry:end])
+	}
+}
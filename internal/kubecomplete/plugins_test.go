@@ -0,0 +1,107 @@
+package kubecomplete
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writePlugin(t *testing.T, dir, name, content string, mode os.FileMode) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are found by PATHEXT on Windows")
+	}
+	first, second, krew := t.TempDir(), t.TempDir(), t.TempDir()
+	ns := writePlugin(t, first, "kubectl-ns", "#!/bin/sh\n", 0755)
+	writePlugin(t, first, "kubectl-foo-bar_baz", "#!/bin/sh\n", 0755)
+	writePlugin(t, first, "kubectl-notes", "not a plugin\n", 0644)
+	writePlugin(t, first, "kubectl--bad", "#!/bin/sh\n", 0755)
+	writePlugin(t, first, "kubeadm", "#!/bin/sh\n", 0755)
+	writePlugin(t, second, "kubectl-ns", "#!/bin/sh\n", 0755)
+	tree := writePlugin(t, krew, "kubectl-tree", "#!/bin/sh\n", 0755)
+
+	pathList := strings.Join([]string{first, "", second, filepath.Join(first, "missing")}, string(os.PathListSeparator))
+	plugins := DiscoverPlugins(pathList, krew)
+
+	var got []string
+	files := make(map[string]string)
+	for _, p := range plugins {
+		key := strings.Join(p.Path, " ")
+		got = append(got, key)
+		files[key] = p.File
+	}
+	if want := []string{"foo bar-baz", "ns", "tree"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("DiscoverPlugins() = %v, want %v", got, want)
+	}
+	if files["ns"] != ns {
+		t.Errorf("ns plugin = %s, want the first on PATH %s", files["ns"], ns)
+	}
+	if files["tree"] != tree {
+		t.Errorf("tree plugin = %s, want %s from the krew dir", files["tree"], tree)
+	}
+}
+
+func TestRegistry_AddPlugins(t *testing.T) {
+	root, err := LoadRootSpec()
+	if err != nil {
+		t.Fatalf("LoadRootSpec() error = %v", err)
+	}
+	reg := NewRegistry(root)
+	reg.AddPlugins([]Plugin{
+		{Path: []string{"ns"}, File: "/bin/kubectl-ns"},
+		{Path: []string{"get", "all"}, File: "/bin/kubectl-get-all"},
+		{Path: []string{"logs"}, File: "/bin/kubectl-logs"},
+	})
+
+	if got, want := reg.PluginNames(), []string{"ns"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PluginNames() = %v, want %v", got, want)
+	}
+	cmd, n := reg.MatchCommand([]string{"ns", "kube-system"})
+	if cmd == nil || n != 1 || !cmd.Spec.Plugin || cmd.PluginFile != "/bin/kubectl-ns" {
+		t.Fatalf("MatchCommand(ns kube-system) = %+v, %d, want the ns plugin", cmd, n)
+	}
+	if err := reg.Validate([]string{"ns", "--current"}); err != nil {
+		t.Errorf("Validate(ns --current) = %v, want nil", err)
+	}
+	if cmd, _ := reg.MatchCommand([]string{"logs"}); cmd == nil || cmd.Spec.Plugin {
+		t.Errorf("MatchCommand(logs) = %+v, want the built-in command", cmd)
+	}
+	if cmd, _ := reg.MatchCommand([]string{"get", "all"}); cmd == nil || cmd.Spec.Plugin {
+		t.Errorf("MatchCommand(get all) = %+v, want the built-in get", cmd)
+	}
+}
+
+func TestSupportsComplete(t *testing.T) {
+	dir := t.TempDir()
+	// Put the word across the boundary of the first read
+	padding := strings.Repeat("x", 64*1024-4)
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"kubectl-cobra", padding + "__complete\n", true},
+		{"kubectl-script", "#!/bin/sh\necho hi\n", false},
+		{"kubectl-empty", "", false},
+	}
+	for _, tt := range tests {
+		file := writePlugin(t, dir, tt.name, tt.content, 0755)
+		if got := SupportsComplete(file); got != tt.want {
+			t.Errorf("SupportsComplete(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if SupportsComplete(filepath.Join(dir, "missing")) {
+		t.Error("SupportsComplete(missing) = true, want false")
+	}
+}
@@ -0,0 +1,85 @@
+package kubecomplete
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// suggestPorts completes a port mapping of port-forward from the ports
+// the target exposes: REMOTE, or LOCAL:REMOTE once a local port and colon
+// are typed. Ports already forwarded are left out.
+func (c *Completer) suggestPorts(cmd *CommandRuntime, ctx CompletionContext, args []string, partial string) []Suggestion {
+	if c.Cache == nil || isFlagToken(partial) {
+		return nil
+	}
+	typed := args
+	if partial != "" {
+		typed = args[:len(args)-1]
+	}
+	positional := positionalArgs(cmd, typed)
+	if len(positional) == 0 {
+		return nil
+	}
+
+	used := make(map[string]bool)
+	for _, mapping := range positional[1:] {
+		if _, remote, ok := strings.Cut(mapping, ":"); ok {
+			mapping = remote
+		}
+		used[mapping] = true
+	}
+	ns := extractNamespaceFromArgs(cmd, args)
+	if ns == "" {
+		ns = ctx.CurrentNamespace
+	}
+	local, _, hasLocal := strings.Cut(partial, ":")
+
+	var out []Suggestion
+	for _, p := range c.Cache.Ports(ns, positional[0]) {
+		number := strconv.Itoa(int(p.Number))
+		if used[number] || (p.Name != "" && used[p.Name]) {
+			continue
+		}
+		value := number
+		if hasLocal {
+			value = local + ":" + number
+		}
+		out = append(out, Suggestion{
+			Value:       value,
+			Kind:        SuggestPort,
+			Description: portDescription(p),
+			Score:       50,
+		})
+	}
+	sortSuggestions(out)
+	return out
+}
+
+// portDescription describes a port as "http/TCP in app"
+func portDescription(p types.Port) string {
+	desc := p.Protocol
+	if p.Name != "" {
+		desc = p.Name + "/" + desc
+	}
+	if p.Container != "" {
+		desc += " in " + p.Container
+	} else {
+		desc += " service port"
+	}
+	return strings.TrimPrefix(desc, " ")
+}
+
+// positionalArgs returns the arguments that are not flags or their values
+func positionalArgs(cmd *CommandRuntime, args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		if !isFlagToken(args[i]) {
+			out = append(out, args[i])
+		} else if takesValue(cmd, args[i]) {
+			i++
+		}
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package kubecomplete
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+func TestCompleter_Ports(t *testing.T) {
+	c := newTestCompleter(t, nil)
+	ctx := CompletionContext{CurrentNamespace: "payments"}
+
+	tests := []struct {
+		line string
+		want []string // the ports suggested, in order
+	}{
+		{"port-forward svc/api ", []string{"80", "9090"}},
+		{"port-forward svc/api 8", []string{"80", "9090"}},
+		{"port-forward svc/api 8080:", []string{"8080:80", "8080:9090"}},
+		{"port-forward svc/api 8080:80 ", []string{"9090"}},
+		{"port-forward -n web svc/api http ", []string{"9090"}},
+		{"port-forward svc/api 80 9090 ", nil},
+		{"port-forward api-1 ", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, s := range c.Complete(tt.line, len(tt.line), ctx) {
+			if s.Kind == SuggestPort {
+				got = append(got, s.Value)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q) ports = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestPortDescription(t *testing.T) {
+	tests := []struct {
+		port types.Port
+		want string
+	}{
+		{types.Port{Number: 80, Name: "http", Protocol: "TCP"}, "http/TCP service port"},
+		{types.Port{Number: 8080, Protocol: "TCP", Container: "app"}, "TCP in app"},
+		{types.Port{Number: 9090, Name: "metrics", Protocol: "TCP", Container: "app"}, "metrics/TCP in app"},
+	}
+	for _, tt := range tests {
+		if got := portDescription(tt.port); got != tt.want {
+			t.Errorf("portDescription(%+v) = %q, want %q", tt.port, got, tt.want)
+		}
+	}
+}
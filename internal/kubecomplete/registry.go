@@ -3,6 +3,9 @@ package kubecomplete
 import (
 	_ "embed"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
 	"sort"
 	"strings"
 )
@@ -15,6 +18,7 @@ type CommandRuntime struct {
 	Spec           *CommandSpec
 	Key            string            // "get", "rollout restart"
 	AliasToPrimary map[string]string // "--namespace" -> "-n"
+	PluginFile     string            // the executable of a plugin found on PATH
 }
 
 type Registry struct {
@@ -30,6 +34,46 @@ func LoadRootSpec() (*RootSpec, error) {
 	return &root, nil
 }
 
+// ErrStaleSpec is returned by LoadSpec for a spec generated from another
+// kubectl release than the one installed
+var ErrStaleSpec = errors.New("run `purr spec generate` to update it")
+
+// LoadSpec loads a kubectl commands specification written by `purr spec
+// generate`. A missing file leaves the embedded one. A spec of another
+// kubectl minor release than kubectlVersion, the installed one, fails with
+// ErrStaleSpec; an empty kubectlVersion skips the check.
+func LoadSpec(path, kubectlVersion string) (*RootSpec, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return LoadRootSpec()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var root RootSpec
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(root.Commands) == 0 {
+		return nil, fmt.Errorf("%s: no commands", path)
+	}
+	if kubectlVersion != "" && root.KubectlVersion != "" && minorRelease(root.KubectlVersion) != minorRelease(kubectlVersion) {
+		return nil, fmt.Errorf("%s is the spec of kubectl %s, but kubectl is %s: %w",
+			path, root.KubectlVersion, kubectlVersion, ErrStaleSpec)
+	}
+	return &root, nil
+}
+
+// minorRelease cuts a version such as v1.31.2 down to v1.31. Patch
+// releases don't change kubectl's commands.
+func minorRelease(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
+
 func NewRegistry(root *RootSpec) *Registry {
 	cmds := make(map[string]*CommandRuntime, len(root.Commands))
 	for i := range root.Commands {
@@ -71,6 +115,37 @@ func (r *Registry) MatchCommand(tokens []string) (*CommandRuntime, int) {
 	return nil, 0
 }
 
+// leadingFlags counts the tokens before the command that are global flags
+// and their values, as in "-n prod exec ...". Every command's spec lists
+// the global flags, so any one of them tells which take a value.
+func (r *Registry) leadingFlags(args []string) int {
+	cmd := r.Commands["get"]
+	for _, rt := range r.Commands {
+		if cmd != nil {
+			break
+		}
+		if !rt.Spec.Plugin {
+			cmd = rt
+		}
+	}
+
+	i := 0
+	for i < len(args) && isFlagToken(args[i]) && args[i] != "--" {
+		name := args[i]
+		i++
+		if strings.Contains(name, "=") || (!strings.HasPrefix(name, "--") && len(name) > 2) {
+			continue // the value is attached
+		}
+		if cmd == nil {
+			continue
+		}
+		if primary, ok := cmd.AliasToPrimary[name]; ok && cmd.Spec.Flags[primary].After != nil {
+			i++
+		}
+	}
+	return min(i, len(args))
+}
+
 // TopLevelCommands returns unique first tokens for suggestion.
 func (r *Registry) TopLevelCommands() []string {
 	seen := make(map[string]struct{})
@@ -89,3 +164,185 @@ func (r *Registry) TopLevelCommands() []string {
 	sort.Strings(out)
 	return out
 }
+
+// IsInteractive reports whether a kubectl command (without the "kubectl"
+// prefix) needs the real terminal: the spec marks it interactive, it asks
+// for stdin or a TTY (-i, -t, -it, --stdin, --tty), or it opens an editor
+// with --edit.
+func (r *Registry) IsInteractive(args []string) bool {
+	args = args[r.leadingFlags(args):]
+	cmd, pathLen := r.MatchCommand(args)
+	if cmd == nil {
+		return false
+	}
+	if cmd.Spec.Interactive {
+		return true
+	}
+
+	for _, arg := range args[pathLen:] {
+		if arg == "--" {
+			break
+		}
+		if !isFlagToken(arg) {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		if hasValue && value == "false" {
+			continue
+		}
+		if name == "--edit" {
+			return true
+		}
+		if primary, ok := cmd.AliasToPrimary[name]; ok {
+			if primary == "-i" || primary == "-t" {
+				return true
+			}
+			continue
+		}
+
+		// Combined short flags like -it, only when every letter is a
+		// boolean flag of this command (so -ndefault is not mistaken)
+		if !hasValue && !strings.HasPrefix(name, "--") && len(name) > 2 && cmd.allBoolShortFlags(name[1:]) {
+			if strings.ContainsAny(name[1:], "it") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allBoolShortFlags reports whether each letter is a known value-less
+// short flag
+func (c *CommandRuntime) allBoolShortFlags(letters string) bool {
+	for _, l := range letters {
+		primary, ok := c.AliasToPrimary["-"+string(l)]
+		if !ok || c.Spec.Flags[primary].After != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks a kubectl command (without the "kubectl" prefix) against
+// the spec: the command and subcommand must exist, and every flag before
+// "--" must be one the command accepts, given a value if it takes one.
+// Plugins only need to exist.
+func (r *Registry) Validate(args []string) error {
+	cmd, pathLen := r.MatchCommand(args)
+	if cmd == nil {
+		if len(args) == 0 {
+			return fmt.Errorf("empty command")
+		}
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	if cmd.Spec.Plugin {
+		return nil
+	}
+	if len(cmd.Spec.Positionals) == 0 && r.hasSubcommands(cmd.Key) {
+		if i := firstPositional(cmd, args[pathLen:]); i >= 0 {
+			return fmt.Errorf("unknown %s subcommand %q", cmd.Key, args[pathLen+i])
+		}
+	}
+
+	rest := args[pathLen:]
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		if arg == "--" {
+			break
+		}
+		if !isFlagToken(arg) || arg == "-" {
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(arg, "=")
+		if primary, ok := cmd.AliasToPrimary[name]; ok {
+			if cmd.Spec.Flags[primary].After != nil && !hasValue {
+				if i+1 >= len(rest) {
+					return fmt.Errorf("flag %s needs a value", name)
+				}
+				i++
+			}
+			continue
+		}
+
+		// -ndefault, or combined boolean short flags like -it
+		if !strings.HasPrefix(name, "--") && len(name) > 2 {
+			primary, ok := cmd.AliasToPrimary[name[:2]]
+			if ok && cmd.Spec.Flags[primary].After != nil {
+				continue
+			}
+			if cmd.allBoolShortFlags(name[1:]) {
+				continue
+			}
+		}
+
+		err := fmt.Sprintf("unknown flag %s for %s", name, cmd.Key)
+		if guess := cmd.closestFlag(name); guess != "" {
+			err += fmt.Sprintf(" (did you mean %s?)", guess)
+		}
+		return fmt.Errorf("%s", err)
+	}
+	return nil
+}
+
+// hasSubcommands reports whether any command extends the path key
+func (r *Registry) hasSubcommands(key string) bool {
+	for k := range r.Commands {
+		if strings.HasPrefix(k, key+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// firstPositional returns the index of the first argument that is neither
+// a flag nor a flag's value, or -1
+func firstPositional(cmd *CommandRuntime, args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return -1
+		}
+		if !isFlagToken(arg) {
+			return i
+		}
+		if primary, ok := cmd.AliasToPrimary[arg]; ok && cmd.Spec.Flags[primary].After != nil {
+			i++
+		}
+	}
+	return -1
+}
+
+// closestFlag returns the flag of the command nearest to a mistyped one,
+// or "" if none is close
+func (c *CommandRuntime) closestFlag(name string) string {
+	best, bestDist := "", 3
+	for alias := range c.AliasToPrimary {
+		if d := editDistance(name, alias); d < bestDist || (d == bestDist && best != "" && alias < best) {
+			best, bestDist = alias, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
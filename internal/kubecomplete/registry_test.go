@@ -0,0 +1,141 @@
+package kubecomplete
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_IsInteractive(t *testing.T) {
+	root, err := LoadRootSpec()
+	if err != nil {
+		t.Fatalf("LoadRootSpec() error = %v", err)
+	}
+	reg := NewRegistry(root)
+
+	tests := []struct {
+		command  string
+		expected bool
+	}{
+		{"exec -it my-pod -- sh", true},
+		{"exec my-pod -ti -- sh", true},
+		{"exec --stdin --tty my-pod -- sh", true},
+		{"exec -i my-pod -- cat", true},
+		{"exec my-pod -- ls", false},
+		{"exec my-pod --tty=false -- ls", false},
+		{"exec my-pod -- vim -t tag", false},
+		{"edit deployment/nginx", true},
+		{"apply edit-last-applied deployment/nginx", true},
+		{"debug -it my-pod --image=busybox", true},
+		{"run tmp --rm -it --image=busybox", true},
+		{"create deployment nginx --image=nginx --edit", true},
+		{"attach my-pod", false},
+		{"get pods -ndefault", false},
+		{"logs my-pod -f", false},
+		{"-n prod exec -it api -- sh", true},
+		{"--context x attach -it my-pod", true},
+		{"--kubeconfig=/tmp/kc -n prod edit deploy/web", true},
+		{"-nprod --request-timeout 5s exec api -- ls", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := reg.IsInteractive(strings.Fields(tt.command)); got != tt.expected {
+				t.Errorf("IsInteractive(%s) = %v, want %v", tt.command, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	root, err := LoadRootSpec()
+	if err != nil {
+		t.Fatalf("LoadRootSpec() error = %v", err)
+	}
+	reg := NewRegistry(root)
+
+	tests := []struct {
+		command string
+		wantErr string // "" for valid
+	}{
+		{"get pods -n kube-system -o wide", ""},
+		{"get pods --namespace=kube-system -ojson", ""},
+		{"delete pod web --grace-period 0 --force", ""},
+		{"rollout restart deployment/web", ""},
+		{"exec -it web -- sh -c 'ls --weird'", ""},
+		{"create deployment web --image=nginx", ""},
+		{"", "empty command"},
+		{"gte pods", `unknown command "gte"`},
+		{"rollout restrat deployment/web", `unknown rollout subcommand "restrat"`},
+		{"get pods --namespce default", "unknown flag --namespce for get (did you mean --namespace?)"},
+		{"get pods --bogus-flag", "unknown flag --bogus-flag for get"},
+		{"get pods -n", "flag -n needs a value"},
+	}
+
+	for _, tt := range tests {
+		err := reg.Validate(strings.Fields(tt.command))
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.wantErr {
+			t.Errorf("Validate(%s) = %q, want %q", tt.command, got, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadSpec(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kubectl_commands.json")
+
+	root, err := LoadSpec(path, "")
+	if err != nil || len(root.Commands) == 0 {
+		t.Fatalf("LoadSpec(missing) = %v, %v, want the embedded spec", root, err)
+	}
+
+	spec := `{"version":"1.0","kubectl_version":"v1.31.0","commands":[
+		{"path":["get"],"flags":{"-n":{"primary":"-n","aliases":["--namespace"],"after":{"kind":"namespace"}}}},
+		{"path":["cnpg"],"flags":{},"plugin":true}]}`
+	if err := os.WriteFile(path, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	root, err = LoadSpec(path, "")
+	if err != nil {
+		t.Fatalf("LoadSpec() error = %v", err)
+	}
+	if root.KubectlVersion != "v1.31.0" || len(root.Commands) != 2 {
+		t.Errorf("LoadSpec() = %s with %d commands, want v1.31.0 with 2", root.KubectlVersion, len(root.Commands))
+	}
+
+	tests := []struct {
+		kubectl string
+		stale   bool
+	}{
+		{"v1.31.0", false},
+		{"v1.31.4", false},
+		{"v1.32.0", true},
+		{"v1.30.2", true},
+	}
+	for _, tt := range tests {
+		if _, err := LoadSpec(path, tt.kubectl); errors.Is(err, ErrStaleSpec) != tt.stale {
+			t.Errorf("LoadSpec(%s) error = %v, want stale %v", tt.kubectl, err, tt.stale)
+		}
+	}
+
+	reg := NewRegistry(root)
+	if err := reg.Validate([]string{"cnpg", "status", "--verbose"}); err != nil {
+		t.Errorf("Validate(plugin) = %v, want nil", err)
+	}
+	if err := reg.Validate([]string{"get", "pods", "-o", "wide"}); err == nil {
+		t.Error("Validate(get -o) = nil, want the generated spec to reject -o")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"commands":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSpec(path, ""); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("LoadSpec(empty) error = %v, want it to name the file", err)
+	}
+}
@@ -0,0 +1,364 @@
+package kubecomplete
+
+import (
+	"fmt"
+	"strings"
+)
+
+// selectorField is a field the API server can select a kind by
+type selectorField struct {
+	Path    string
+	Values  []string // the values it can take, when they are fixed
+	NamesOf string   // a resource type whose names are its values
+}
+
+// commonSelectorFields can select any kind
+var commonSelectorFields = []selectorField{
+	{Path: "metadata.name"},
+	{Path: "metadata.namespace", NamesOf: "namespaces"},
+}
+
+// selectorFields lists the well-known fields each kind can be selected by,
+// under every name kubectl takes for the kind
+var selectorFields = []struct {
+	kinds  []string
+	fields []selectorField
+}{
+	{[]string{"pods", "pod", "po"}, []selectorField{
+		{Path: "spec.nodeName", NamesOf: "nodes"},
+		{Path: "spec.restartPolicy", Values: []string{"Always", "OnFailure", "Never"}},
+		{Path: "spec.schedulerName"},
+		{Path: "spec.serviceAccountName", NamesOf: "serviceaccounts"},
+		{Path: "spec.hostNetwork", Values: []string{"true", "false"}},
+		{Path: "status.phase", Values: []string{"Pending", "Running", "Succeeded", "Failed", "Unknown"}},
+		{Path: "status.podIP"},
+		{Path: "status.nominatedNodeName", NamesOf: "nodes"},
+	}},
+	{[]string{"events", "event", "ev"}, []selectorField{
+		{Path: "involvedObject.kind"},
+		{Path: "involvedObject.name"},
+		{Path: "involvedObject.namespace", NamesOf: "namespaces"},
+		{Path: "involvedObject.uid"},
+		{Path: "involvedObject.apiVersion"},
+		{Path: "involvedObject.fieldPath"},
+		{Path: "reason"},
+		{Path: "reportingComponent"},
+		{Path: "source"},
+		{Path: "type", Values: []string{"Normal", "Warning"}},
+	}},
+	{[]string{"nodes", "node", "no"}, []selectorField{
+		{Path: "spec.unschedulable", Values: []string{"true", "false"}},
+	}},
+	{[]string{"namespaces", "namespace", "ns"}, []selectorField{
+		{Path: "status.phase", Values: []string{"Active", "Terminating"}},
+	}},
+	{[]string{"secrets", "secret"}, []selectorField{
+		{Path: "type", Values: []string{
+			"Opaque",
+			"kubernetes.io/service-account-token",
+			"kubernetes.io/dockercfg",
+			"kubernetes.io/dockerconfigjson",
+			"kubernetes.io/basic-auth",
+			"kubernetes.io/ssh-auth",
+			"kubernetes.io/tls",
+			"bootstrap.kubernetes.io/token",
+		}},
+	}},
+	{[]string{"replicasets", "replicaset", "rs"}, []selectorField{
+		{Path: "status.replicas"},
+	}},
+	{[]string{"replicationcontrollers", "replicationcontroller", "rc"}, []selectorField{
+		{Path: "status.replicas"},
+	}},
+	{[]string{"jobs", "job"}, []selectorField{
+		{Path: "status.successful"},
+	}},
+	{[]string{"certificatesigningrequests", "certificatesigningrequest", "csr"}, []selectorField{
+		{Path: "spec.signerName"},
+	}},
+}
+
+// fieldsOf returns the fields a kind can be selected by
+func fieldsOf(kind string) []selectorField {
+	fields := append([]selectorField{}, commonSelectorFields...)
+	for _, entry := range selectorFields {
+		for _, name := range entry.kinds {
+			if name == kind {
+				return append(fields, entry.fields...)
+			}
+		}
+	}
+	return fields
+}
+
+// splitSelector splits a selector before its last requirement. Commas
+// inside a set, as in "env in (a,b)", do not separate requirements.
+func splitSelector(selector string) (done, last string) {
+	depth := 0
+	cut := 0
+	for i, r := range selector {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				cut = i + 1
+			}
+		}
+	}
+	return selector[:cut], selector[cut:]
+}
+
+// targetKinds returns the resource types a command line is about: those
+// named in the arguments, or the one the command works on
+func (c *Completer) targetKinds(cmd *CommandRuntime, args []string) []string {
+	kind := inferResourceKindFromArgs(cmd, args)
+	if kind == "" {
+		kind = getFirstNonFlagArg(args)
+	}
+	if kind == "" {
+		if types := c.Cache.ResourceTypesForCommand(cmd.Spec.Path); len(types) > 0 {
+			kind = types[0]
+		}
+	}
+	if kind == "" {
+		return nil
+	}
+	return strings.Split(kind, ",")
+}
+
+// selectorNamespace returns the namespace a selector applies in, "" for
+// all of them
+func selectorNamespace(cmd *CommandRuntime, ctx CompletionContext, args []string) string {
+	for _, a := range args {
+		if a == "-A" || a == "--all-namespaces" {
+			return ""
+		}
+	}
+	if ns := extractNamespaceFromArgs(cmd, args); ns != "" {
+		return ns
+	}
+	return ctx.CurrentNamespace
+}
+
+// suggestLabelSelector completes the last requirement of a label selector
+// from the labels of the objects it applies to: keys, then their values
+// after =, == or !=, or a set after in and notin. A key typed after ! is
+// negated. Suggestions carry the requirements typed before.
+func (c *Completer) suggestLabelSelector(cmd *CommandRuntime, ctx CompletionContext, args []string, partial string) []Suggestion {
+	if c.Cache == nil {
+		return nil
+	}
+	labels := make(map[string]map[string]int)
+	ns := selectorNamespace(cmd, ctx, args)
+	for _, kind := range c.targetKinds(cmd, args) {
+		for key, values := range c.Cache.Labels(kind, ns) {
+			if labels[key] == nil {
+				labels[key] = make(map[string]int)
+			}
+			for value, n := range values {
+				labels[key][value] += n
+			}
+		}
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+
+	done, req := splitSelector(partial)
+	var out []Suggestion
+	addValues := func(key, before string, exclude map[string]bool, after string) {
+		for value, n := range labels[key] {
+			if exclude[value] {
+				continue
+			}
+			out = append(out, Suggestion{
+				Value:       done + before + value + after,
+				Kind:        SuggestFlagValue,
+				Description: fmt.Sprintf("Label value on %d", n),
+				Score:       float64(n),
+			})
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(req, "!"):
+		// Without the key
+		for key, values := range labels {
+			out = append(out, Suggestion{
+				Value:       done + "!" + key,
+				Kind:        SuggestFlagValue,
+				Description: "Without label " + key,
+				Score:       float64(countObjects(values)),
+			})
+		}
+
+	case strings.Contains(req, "("):
+		// The values of a set, closing it
+		open := strings.Index(req, "(")
+		if strings.Contains(req[open:], ")") {
+			return nil
+		}
+		key, _, _ := strings.Cut(strings.TrimSpace(req[:open]), " ")
+		listed := strings.Split(req[open+1:], ",")
+		before := req[:open+1]
+		exclude := make(map[string]bool)
+		for _, v := range listed[:len(listed)-1] {
+			before += v + ","
+			exclude[strings.TrimSpace(v)] = true
+		}
+		addValues(key, before, exclude, ")")
+
+	case strings.Contains(req, "="):
+		// The values of a key after =, == or !=
+		i := strings.IndexAny(req, "!=")
+		op := req[i:]
+		op = op[:len(op)-len(strings.TrimLeft(op, "!="))]
+		addValues(req[:i], req[:i]+op, nil, "")
+
+	case strings.Contains(req, " "):
+		// The set operators after a key and a space
+		key, _, _ := strings.Cut(req, " ")
+		for i, op := range []string{" in (", " notin ("} {
+			out = append(out, Suggestion{
+				Value:       done + key + op,
+				Kind:        SuggestFlagValue,
+				Description: "Set of values",
+				Score:       float64(2 - i),
+				NoSpace:     true,
+			})
+		}
+
+	default:
+		// Keys, to type a value after; a whole key also takes an operator
+		for key, values := range labels {
+			n := countObjects(values)
+			out = append(out, Suggestion{
+				Value:       done + key + "=",
+				Kind:        SuggestFlagValue,
+				Description: fmt.Sprintf("Label on %d", n),
+				Score:       float64(n),
+				NoSpace:     true,
+			})
+		}
+		if values, ok := labels[req]; ok {
+			n := float64(countObjects(values))
+			ops := []struct{ suffix, description string }{
+				{"!=", "Label is not"},
+				{" in (", "Label is one of"},
+				{" notin (", "Label is none of"},
+				{"", "Label is set"},
+			}
+			for i, op := range ops {
+				out = append(out, Suggestion{
+					Value:       done + req + op.suffix,
+					Kind:        SuggestFlagValue,
+					Description: op.description,
+					Score:       n - float64(i+1)/10,
+					NoSpace:     op.suffix != "",
+				})
+			}
+		}
+	}
+	sortSuggestions(out)
+	return out
+}
+
+// suggestFieldSelector completes the last requirement of a field selector:
+// the fields the kind can be selected by, then their values after =, ==
+// or != where they are known
+func (c *Completer) suggestFieldSelector(cmd *CommandRuntime, ctx CompletionContext, args []string, partial string) []Suggestion {
+	var fields []selectorField
+	seen := make(map[string]bool)
+	var kinds []string
+	if c.Cache != nil {
+		kinds = c.targetKinds(cmd, args)
+	}
+	if len(kinds) == 0 {
+		kinds = []string{""}
+	}
+	for _, kind := range kinds {
+		for _, f := range fieldsOf(kind) {
+			if !seen[f.Path] {
+				seen[f.Path] = true
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	done, req := splitSelector(partial)
+	var out []Suggestion
+	if i := strings.IndexAny(req, "!="); i >= 0 {
+		path := req[:i]
+		op := req[i:]
+		op = op[:len(op)-len(strings.TrimLeft(op, "!="))]
+		for _, f := range fields {
+			if f.Path != path {
+				continue
+			}
+			for _, value := range c.fieldValues(f, kinds, selectorNamespace(cmd, ctx, args)) {
+				out = append(out, Suggestion{
+					Value:       done + path + op + value,
+					Kind:        SuggestFlagValue,
+					Description: path,
+					Score:       40,
+				})
+			}
+		}
+		sortSuggestions(out)
+		return out
+	}
+
+	for i, f := range fields {
+		// Keep the order of the table, most useful first
+		score := float64(len(fields) - i)
+		out = append(out, Suggestion{
+			Value:       done + f.Path + "=",
+			Kind:        SuggestFlagValue,
+			Description: "Field",
+			Score:       score,
+			NoSpace:     true,
+		})
+		if f.Path == req {
+			out = append(out, Suggestion{
+				Value:       done + f.Path + "!=",
+				Kind:        SuggestFlagValue,
+				Description: "Field is not",
+				Score:       score - 0.5,
+				NoSpace:     true,
+			})
+		}
+	}
+	sortSuggestions(out)
+	return out
+}
+
+// fieldValues returns the values a field selector can compare a field to
+func (c *Completer) fieldValues(f selectorField, kinds []string, namespace string) []string {
+	if len(f.Values) > 0 {
+		return f.Values
+	}
+	if c.Cache == nil {
+		return nil
+	}
+	switch {
+	case f.NamesOf == "namespaces":
+		return c.Cache.Namespaces()
+	case f.NamesOf != "":
+		return c.Cache.ResourceNames(f.NamesOf, namespace)
+	case f.Path == "metadata.name" && len(kinds) == 1 && kinds[0] != "":
+		return c.Cache.ResourceNames(kinds[0], namespace)
+	}
+	return nil
+}
+
+// countObjects sums how many objects carry each value of a label
+func countObjects(values map[string]int) int {
+	n := 0
+	for _, v := range values {
+		n += v
+	}
+	return n
+}
@@ -0,0 +1,94 @@
+package kubecomplete
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSplitSelector(t *testing.T) {
+	tests := []struct {
+		selector, done, last string
+	}{
+		{"", "", ""},
+		{"app=web", "", "app=web"},
+		{"app=web,ti", "app=web,", "ti"},
+		{"env in (a,b", "", "env in (a,b"},
+		{"env in (a,b),app", "env in (a,b),", "app"},
+	}
+	for _, tt := range tests {
+		done, last := splitSelector(tt.selector)
+		if done != tt.done || last != tt.last {
+			t.Errorf("splitSelector(%q) = %q, %q, want %q, %q", tt.selector, done, last, tt.done, tt.last)
+		}
+	}
+}
+
+func TestCompleter_LabelSelector(t *testing.T) {
+	c := newTestCompleter(t, nil)
+	ctx := CompletionContext{CurrentNamespace: "payments"}
+
+	tests := []struct {
+		line    string
+		want    []string // sorted
+		noSpace bool
+	}{
+		{"get pods -l ", []string{"app=", "tier="}, true},
+		{"get pods -l app", []string{"app", "app in (", "app notin (", "app!=", "app=", "tier="}, true},
+		{"get pods -l app=", []string{"app=api", "app=worker"}, false},
+		{"get pods --selector app!=w", []string{"app!=api", "app!=worker"}, false},
+		{"get pods -l tier=backend,", []string{"tier=backend,app=", "tier=backend,tier="}, true},
+		{"get pods -l tier=backend,!", []string{"tier=backend,!app", "tier=backend,!tier"}, false},
+		{"get pods -l 'app ", []string{"app in (", "app notin ("}, true},
+		{"get pods -l 'app in (api,", []string{"app in (api,worker)"}, false},
+		{"get services -l ", nil, false},
+		{"logs -l ", []string{"app=", "tier="}, true},
+	}
+	for _, tt := range tests {
+		got := c.Complete(tt.line, len(tt.line), ctx)
+		var values []string
+		for _, s := range got {
+			values = append(values, s.Value)
+			// A bare key is a whole requirement
+			if s.NoSpace != tt.noSpace && s.Value != "app" {
+				t.Errorf("Complete(%q) %q NoSpace = %v, want %v", tt.line, s.Value, s.NoSpace, tt.noSpace)
+			}
+		}
+		sort.Strings(values)
+		if !reflect.DeepEqual(values, tt.want) {
+			t.Errorf("Complete(%q) = %q, want %q", tt.line, values, tt.want)
+		}
+	}
+}
+
+func TestCompleter_FieldSelector(t *testing.T) {
+	c := newTestCompleter(t, nil)
+	ctx := CompletionContext{CurrentNamespace: "payments"}
+
+	tests := []struct {
+		line    string
+		want    string
+		without string
+	}{
+		{"get pods --field-selector ", "status.phase=", "type="},
+		{"get pods --field-selector status.phase=", "status.phase=Running", ""},
+		{"get pods --field-selector status.phase", "status.phase!=", ""},
+		{"get pods --field-selector status.phase=Running,", "status.phase=Running,spec.nodeName=", ""},
+		{"get pods --field-selector metadata.namespace=", "metadata.namespace=payments", ""},
+		{"get events --field-selector type=", "type=Warning", ""},
+		{"get services --field-selector ", "metadata.name=", "status.phase="},
+	}
+	for _, tt := range tests {
+		got := c.Complete(tt.line, len(tt.line), ctx)
+		values := make(map[string]bool)
+		for _, s := range got {
+			values[s.Value] = true
+		}
+		if !values[tt.want] {
+			t.Errorf("Complete(%q) = %v, want %q among them", tt.line, got, tt.want)
+		}
+		if tt.without != "" && values[tt.without] {
+			t.Errorf("Complete(%q) = %v, want no %q", tt.line, got, tt.without)
+		}
+	}
+}
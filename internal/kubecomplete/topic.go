@@ -0,0 +1,108 @@
+package kubecomplete
+
+import "strings"
+
+// Topic is what the word at the cursor is about, to document it
+type Topic struct {
+	Command  *CommandSpec    // the command typed, nil until there is one
+	Flag     *FlagDescriptor // the flag at the cursor, or whose value is
+	Resource string          // the resource type the line is about
+	Field    []string        // the field path in Resource at the cursor, its last name maybe partly typed
+}
+
+// TopicAt works out what the word at the cursor is about: a field of a
+// field path in a flag value, a flag, the resource type named, and the
+// command. kubectl explain takes the field path after the type, as in
+// "pods.spec.containers".
+func (c *Completer) TopicAt(line string, cursor int) Topic {
+	var topic Topic
+	if c.Registry == nil {
+		return topic
+	}
+	if cursor < 0 || cursor > len(line) {
+		cursor = len(line)
+	}
+	tokens, hasTrailingSpace := SplitAtCursor(line, cursor)
+	tokens = normalizeKubectl(tokens)
+	cmd, pathLen := c.Registry.MatchCommand(tokens)
+	if cmd == nil {
+		return topic
+	}
+	topic.Command = cmd.Spec
+	args := tokens[pathLen:]
+
+	// The flag at the cursor, or whose value is typed there
+	n := len(args)
+	switch {
+	case n > 0 && !hasTrailingSpace:
+		last := args[n-1]
+		if flag, value, ok := attachedFlagValue(cmd, last); ok {
+			c.flagTopic(&topic, cmd, args[:n-1], flag, value)
+		} else if isFlagToken(last) {
+			topic.Flag = lookupFlag(cmd, last)
+		} else if n >= 2 && takesValue(cmd, args[n-2]) {
+			c.flagTopic(&topic, cmd, args[:n-2], args[n-2], last)
+		}
+	case n > 0 && takesValue(cmd, args[n-1]):
+		c.flagTopic(&topic, cmd, args[:n-1], args[n-1], "")
+	}
+
+	if topic.Resource == "" && c.Cache != nil {
+		if kinds := c.targetKinds(cmd, args); len(kinds) > 0 {
+			topic.Resource = kinds[0]
+			if cmd.Key == "explain" {
+				if resource, path, ok := strings.Cut(kinds[0], "."); ok {
+					topic.Resource = resource
+					topic.Field = strings.Split(path, ".")
+				}
+			}
+		}
+	}
+	return topic
+}
+
+// flagTopic makes a flag the topic, and the field at the end of its value
+// if that is a field path
+func (c *Completer) flagTopic(topic *Topic, cmd *CommandRuntime, args []string, flag, value string) {
+	topic.Flag = lookupFlag(cmd, flag)
+	if topic.Flag == nil || c.Cache == nil {
+		return
+	}
+	v, ok := parseFieldPathValue(topic.Flag.After, value)
+	if !ok {
+		return
+	}
+	kinds := c.targetKinds(cmd, args)
+	if len(kinds) == 0 {
+		return
+	}
+	path, _, partial, _ := v.path()
+	if name, _, _ := strings.Cut(partial, "["); name != "" {
+		path = append(path, name)
+	}
+	topic.Resource = kinds[0]
+	if len(path) > 0 {
+		topic.Field = path
+	}
+}
+
+// lookupFlag returns the flag a token names, nil if the command has none
+// by that name
+func lookupFlag(cmd *CommandRuntime, tok string) *FlagDescriptor {
+	name, _, _ := strings.Cut(tok, "=")
+	primary, ok := cmd.AliasToPrimary[name]
+	if !ok {
+		return nil
+	}
+	f, ok := cmd.Spec.Flags[primary]
+	if !ok {
+		return nil
+	}
+	return &f
+}
+
+// takesValue reports whether a token is a flag that takes a value
+func takesValue(cmd *CommandRuntime, tok string) bool {
+	f := lookupFlag(cmd, tok)
+	return f != nil && f.After != nil && !strings.Contains(tok, "=")
+}
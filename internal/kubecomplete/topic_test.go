@@ -0,0 +1,51 @@
+package kubecomplete
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompleter_TopicAt(t *testing.T) {
+	c := newTestCompleter(t, nil)
+
+	tests := []struct {
+		line     string
+		command  string
+		flag     string
+		resource string
+		field    []string
+	}{
+		{"ge", "", "", "", nil},
+		{"get", "get", "", "", nil},
+		{"get po", "get", "", "po", nil},
+		{"get pods -", "get", "", "pods", nil},
+		{"get pods --sel", "get", "", "pods", nil},
+		{"get pods --selector", "get", "-l", "pods", nil},
+		{"get pods -n ", "get", "-n", "pods", nil},
+		{"get pods -o json", "get", "-o", "pods", nil},
+		{"get pods -o jsonpath={.spec.containers[*].ima", "get", "-o", "pods", []string{"spec", "containers", "ima"}},
+		{"get pods -o jsonpath={.items[*].", "get", "-o", "pods", nil},
+		{"get deployments --sort-by=.metadata.", "get", "--sort-by", "deployments", []string{"metadata"}},
+		{"get pods -o custom-columns=NAME:.status.phase", "get", "-o", "pods", []string{"status", "phase"}},
+		{"explain pods.spec.containers", "explain", "", "pods", []string{"spec", "containers"}},
+		{"explain deployments ", "explain", "", "deployments", nil},
+		{"rollout restart", "rollout restart", "", "", nil},
+	}
+	for _, tt := range tests {
+		topic := c.TopicAt(tt.line, len(tt.line))
+		command, flag := "", ""
+		if topic.Command != nil {
+			command = topic.Command.Path[0]
+			if len(topic.Command.Path) > 1 {
+				command += " " + topic.Command.Path[1]
+			}
+		}
+		if topic.Flag != nil {
+			flag = topic.Flag.Primary
+		}
+		if command != tt.command || flag != tt.flag || topic.Resource != tt.resource || !reflect.DeepEqual(topic.Field, tt.field) {
+			t.Errorf("TopicAt(%q) = %q, %q, %q, %q, want %q, %q, %q, %q",
+				tt.line, command, flag, topic.Resource, topic.Field, tt.command, tt.flag, tt.resource, tt.field)
+		}
+	}
+}
@@ -14,6 +14,8 @@ const (
 	TokenSelector               TokenKind = "selector"
 	TokenContainerName          TokenKind = "container-name"
 	TokenOutput                 TokenKind = "output"
+	TokenJSONPath               TokenKind = "jsonpath"
+	TokenPortMapping            TokenKind = "port-mapping"
 	TokenDuration               TokenKind = "duration"
 	TokenOther                  TokenKind = "other"
 )
@@ -43,12 +45,15 @@ type CommandSpec struct {
 	Description string                    `json:"description"`
 	Positionals []TokenDescriptor         `json:"positionals"`
 	Flags       map[string]FlagDescriptor `json:"flags"` // keyed by primary
+	Interactive bool                      `json:"interactive,omitempty"` // always needs the terminal (e.g. edit)
+	Plugin      bool                      `json:"plugin,omitempty"`      // a kubectl-* plugin, its flags are its own
 }
 
 type RootSpec struct {
-	Version       string        `json:"version"`
-	GeneratedFrom string        `json:"generated_from"`
-	Commands      []CommandSpec `json:"commands"`
+	Version        string        `json:"version"`
+	GeneratedFrom  string        `json:"generated_from"`
+	KubectlVersion string        `json:"kubectl_version,omitempty"` // of the kubectl the spec was generated from
+	Commands       []CommandSpec `json:"commands"`
 }
 
 // Suggestion model
@@ -62,6 +67,7 @@ const (
 	SuggestResourceName SuggestionKind = "resource-name"
 	SuggestNamespace    SuggestionKind = "namespace"
 	SuggestContainer    SuggestionKind = "container"
+	SuggestPort         SuggestionKind = "port"
 	SuggestOther        SuggestionKind = "other"
 )
 
@@ -70,12 +76,14 @@ type Suggestion struct {
 	Kind        SuggestionKind
 	Description string
 	Score       float64
+	NoSpace     bool // accepting it should not add a space, more is typed after it
 }
 
 // CompletionContext holds context for completion
 type CompletionContext struct {
 	Line             string
 	Cursor           int
+	CurrentContext   string
 	CurrentNamespace string
 }
 
@@ -0,0 +1,259 @@
+package kubecomplete
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tapcraft-io/purr/internal/redact"
+	"github.com/tapcraft-io/purr/internal/shlex"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// History is the commands run before, newest first
+type History interface {
+	GetAll() []types.HistoryEntry
+}
+
+// usageBoost lifts anything used before above everything that never was;
+// among used suggestions the frecency decides
+const usageBoost = 100
+
+// usageMaxAge is how long frecencies are kept before the ages they were
+// computed with are refreshed
+const usageMaxAge = time.Minute
+
+// usage is how often and how recently commands, flags and values were
+// used, weighted towards the current context and namespace
+type usage struct {
+	// What it was built from
+	count     int
+	newest    time.Time
+	context   string
+	namespace string
+	built     time.Time
+
+	tokens   map[string]float64 // usageKey(scope, token) → frecency
+	commands []usedCommand      // full command lines without "kubectl ", best first
+}
+
+// usedCommand is a full command line and its frecency
+type usedCommand struct {
+	line  string
+	score float64
+}
+
+// usageKey names a token used within a scope: the command words before it
+// for command words, the command path for everything else
+func usageKey(scope, token string) string {
+	return scope + "\x00" + token
+}
+
+// frecency weighs one run of a command: recent runs count more, as do runs
+// in the current context and namespace, and failed runs count little
+func frecency(entry types.HistoryEntry, context, namespace string, now time.Time) float64 {
+	var weight float64
+	switch age := now.Sub(entry.Timestamp); {
+	case age < time.Hour:
+		weight = 4
+	case age < 24*time.Hour:
+		weight = 2
+	case age < 7*24*time.Hour:
+		weight = 1
+	default:
+		weight = 0.5
+	}
+	if context != "" && entry.Context == context {
+		weight *= 2
+	}
+	if namespace != "" && entry.Namespace == namespace {
+		weight *= 1.5
+	}
+	if !entry.Success {
+		weight *= 0.25
+	}
+	return weight
+}
+
+// cutKubectl splits the optional "kubectl " off a command line, as purr
+// records every kubectl command with it and users mostly type it without
+func cutKubectl(line string) (prefix, rest string) {
+	after, ok := strings.CutPrefix(line, "kubectl ")
+	if !ok {
+		return "", line
+	}
+	rest = strings.TrimLeft(after, " ")
+	return line[:len(line)-len(rest)], rest
+}
+
+// buildUsage scores what the history used, as of now. Commands with
+// redacted secrets are left out: offering them would run the mask.
+func buildUsage(reg *Registry, entries []types.HistoryEntry, context, namespace string, now time.Time) *usage {
+	u := &usage{
+		count:     len(entries),
+		context:   context,
+		namespace: namespace,
+		built:     now,
+		tokens:    make(map[string]float64),
+	}
+	if len(entries) > 0 {
+		u.newest = entries[0].Timestamp
+	}
+
+	lines := make(map[string]float64)
+	for _, entry := range entries {
+		weight := frecency(entry, context, namespace, now)
+		_, line := cutKubectl(strings.TrimSpace(entry.Command))
+		if line == "" || strings.Contains(line, redact.Mask) {
+			continue
+		}
+		if entry.Success {
+			lines[line] += weight
+		}
+		if strings.HasPrefix(line, "!") || reg == nil {
+			continue
+		}
+		for _, key := range usedTokens(reg, line) {
+			u.tokens[key] += weight
+		}
+	}
+
+	for line, score := range lines {
+		u.commands = append(u.commands, usedCommand{line, score})
+	}
+	sort.Slice(u.commands, func(i, j int) bool {
+		if u.commands[i].score == u.commands[j].score {
+			return u.commands[i].line < u.commands[j].line
+		}
+		return u.commands[i].score > u.commands[j].score
+	})
+	return u
+}
+
+// usedTokens returns the usage keys of the command words, flags and values
+// in a kubectl command line, each once
+func usedTokens(reg *Registry, line string) []string {
+	toks, _ := shlex.Split(line)
+	tokens := normalizeKubectl(shlex.Values(toks))
+	cmd, pathLen := reg.MatchCommand(tokens)
+	if cmd == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var keys []string
+	add := func(scope, token string) {
+		if token == "" {
+			return
+		}
+		key := usageKey(scope, token)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	for i := 0; i < pathLen; i++ {
+		add(strings.Join(tokens[:i], " "), tokens[i])
+	}
+	scope := cmd.Key
+	for _, arg := range tokens[pathLen:] {
+		if arg == "--" {
+			break
+		}
+		if isFlagToken(arg) {
+			name, value, hasValue := strings.Cut(arg, "=")
+			if primary, ok := cmd.AliasToPrimary[name]; ok {
+				name = cmd.Spec.Flags[primary].Primary
+			}
+			add(scope, name)
+			if hasValue {
+				add(scope, value)
+			}
+			continue
+		}
+		add(scope, arg)
+		// TYPE/NAME counts for the type and the name on their own
+		if kind, name, ok := strings.Cut(arg, "/"); ok {
+			add(scope, kind)
+			add(scope, name)
+		}
+	}
+	return keys
+}
+
+// usage returns the frecencies for a completion context, rebuilt when the
+// history, context or namespace changed or the ages are stale
+func (c *Completer) usage(ctx CompletionContext) *usage {
+	if c.History == nil {
+		return nil
+	}
+	entries := c.History.GetAll()
+	var newest time.Time
+	if len(entries) > 0 {
+		newest = entries[0].Timestamp
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	u := c.used
+	if u == nil || u.count != len(entries) || !u.newest.Equal(newest) ||
+		u.context != ctx.CurrentContext || u.namespace != ctx.CurrentNamespace || now.Sub(u.built) > usageMaxAge {
+		u = buildUsage(c.Registry, entries, ctx.CurrentContext, ctx.CurrentNamespace, now)
+		c.used = u
+	}
+	return u
+}
+
+// rankByUsage lifts suggestions used before to the top, the most frecent
+// first. Command words are scoped by the words typed before them, flags and
+// values by the command they belong to.
+func (c *Completer) rankByUsage(tokens []string, hasTrailingSpace bool, ctx CompletionContext, out []Suggestion) {
+	u := c.usage(ctx)
+	if u == nil || len(u.tokens) == 0 || len(out) == 0 {
+		return
+	}
+
+	words := tokens
+	if !hasTrailingSpace && len(words) > 0 {
+		words = words[:len(words)-1]
+	}
+	commandScope := strings.Join(words, " ")
+	argScope := ""
+	if cmd, _ := c.Registry.MatchCommand(tokens); cmd != nil {
+		argScope = cmd.Key
+	}
+
+	for i := range out {
+		scope := argScope
+		if out[i].Kind == SuggestCommand {
+			scope = commandScope
+		}
+		if score := u.tokens[usageKey(scope, out[i].Value)]; score > 0 {
+			out[i].Score += usageBoost + score
+		}
+	}
+	sortSuggestions(out)
+}
+
+// Predict returns the most frecent full command from history that starts
+// with line, for showing the rest of it as ghost text. "kubectl " is
+// optional on either side, and the command is returned the way line is
+// typed. It returns "" when nothing but line itself matches.
+func (c *Completer) Predict(line string, ctx CompletionContext) string {
+	prefix, rest := cutKubectl(line)
+	if strings.TrimSpace(rest) == "" {
+		return ""
+	}
+	u := c.usage(ctx)
+	if u == nil {
+		return ""
+	}
+	for _, cmd := range u.commands {
+		if len(cmd.line) > len(rest) && strings.HasPrefix(cmd.line, rest) {
+			return prefix + cmd.line
+		}
+	}
+	return ""
+}
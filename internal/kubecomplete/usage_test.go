@@ -0,0 +1,156 @@
+package kubecomplete
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// fakeHistory is a fixed history, newest first
+type fakeHistory []types.HistoryEntry
+
+func (h fakeHistory) GetAll() []types.HistoryEntry { return h }
+
+// fakeCache knows a few namespaces, pods and their labels, and the ports
+// of a service
+type fakeCache struct{}
+
+func (fakeCache) Namespaces() []string    { return []string{"default", "payments", "web"} }
+func (fakeCache) ResourceTypes() []string { return []string{"pods", "deployments", "services"} }
+func (fakeCache) ResourceTypesForCommand(path []string) []string {
+	if len(path) == 1 && path[0] == "logs" {
+		return []string{"pods"}
+	}
+	return nil
+}
+func (fakeCache) ResourceNames(kind, namespace string) []string {
+	return []string{"api-1", "api-2", "worker-1"}
+}
+func (fakeCache) Containers(namespace, kind, name string) []string { return nil }
+func (fakeCache) Ports(namespace, target string) []types.Port {
+	if target != "svc/api" {
+		return nil
+	}
+	return []types.Port{{Number: 80, Name: "http", Protocol: "TCP"}, {Number: 9090, Protocol: "TCP"}}
+}
+func (fakeCache) Labels(kind, namespace string) map[string]map[string]int {
+	if kind != "pods" && kind != "deployments" {
+		return nil
+	}
+	return map[string]map[string]int{
+		"app":  {"api": 2, "worker": 1},
+		"tier": {"backend": 3},
+	}
+}
+
+func newTestCompleter(t *testing.T, hist History) *Completer {
+	t.Helper()
+	root, err := LoadRootSpec()
+	if err != nil {
+		t.Fatalf("LoadRootSpec() error = %v", err)
+	}
+	c := NewCompleter(NewRegistry(root), fakeCache{})
+	c.History = hist
+	return c
+}
+
+func TestCompleter_RanksByUsage(t *testing.T) {
+	now := time.Now()
+	hist := fakeHistory{
+		{Command: "kubectl get pods worker-1 -n payments", Success: true, Context: "prod", Namespace: "payments", Timestamp: now.Add(-time.Minute)},
+		{Command: "kubectl describe pods worker-1 -n payments", Success: true, Context: "prod", Namespace: "payments", Timestamp: now.Add(-time.Hour * 2)},
+		{Command: "kubectl get pods -n web -o wide", Success: true, Context: "staging", Namespace: "web", Timestamp: now.Add(-time.Hour * 30)},
+		{Command: "kubectl rollout restart deployment/api", Success: true, Context: "prod", Namespace: "payments", Timestamp: now.Add(-time.Hour * 3)},
+	}
+	c := newTestCompleter(t, hist)
+	ctx := CompletionContext{CurrentContext: "prod", CurrentNamespace: "payments"}
+
+	tests := []struct {
+		line   string
+		want   string // ranked first, or before before
+		before string
+	}{
+		{"get pods ", "worker-1", "api-1"},
+		{"get pods -n ", "payments", ""},
+		{"r", "rollout", ""},
+		{"d", "describe", ""},
+		{"get pods worker-1 ", "-o", "-l"},
+	}
+
+	for _, tt := range tests {
+		got := c.Complete(tt.line, len(tt.line), ctx)
+		rank := make(map[string]int)
+		for i := len(got) - 1; i >= 0; i-- {
+			rank[got[i].Value] = i
+		}
+		wantRank, ok := rank[tt.want]
+		switch {
+		case !ok:
+			t.Errorf("Complete(%q) = %v, want %q among them", tt.line, got, tt.want)
+		case tt.before == "" && wantRank != 0:
+			t.Errorf("Complete(%q)[0] = %q, want %q", tt.line, got[0].Value, tt.want)
+		case tt.before != "" && wantRank > rank[tt.before]:
+			t.Errorf("Complete(%q) ranks %q at %d, want before %q at %d", tt.line, tt.want, wantRank, tt.before, rank[tt.before])
+		}
+	}
+
+	// In another context the namespace used there wins
+	staging := CompletionContext{CurrentContext: "staging", CurrentNamespace: "web"}
+	if got := c.Complete("get pods -n ", 12, staging); len(got) == 0 || got[0].Value != "web" {
+		t.Errorf("Complete(get pods -n ) in staging = %v, want web first", got)
+	}
+}
+
+func TestCompleter_Predict(t *testing.T) {
+	now := time.Now()
+	hist := fakeHistory{
+		{Command: "kubectl get pods -n payments -l app=api", Success: true, Context: "prod", Timestamp: now.Add(-2 * time.Hour)},
+		{Command: "kubectl get pods -n payments -l app=api", Success: true, Context: "prod", Timestamp: now.Add(-3 * time.Hour)},
+		{Command: "kubectl get pods -n web", Success: true, Context: "staging", Timestamp: now},
+		{Command: "kubectl get pods -n payments --watchh", Success: false, Context: "prod", Timestamp: now},
+		{Command: "kubectl logs deploy/api -f", Success: true, Context: "prod", Timestamp: now.Add(-24 * time.Hour * 10)},
+		{Command: "kubectl create secret generic db --from-literal=password=[REDACTED]", Success: true, Context: "prod", Timestamp: now},
+		{Command: "!kubectl get pods | wc -l", Success: true, Context: "prod", Timestamp: now.Add(-time.Hour)},
+	}
+	c := newTestCompleter(t, hist)
+
+	tests := []struct {
+		line    string
+		context string
+		want    string
+	}{
+		{"get po", "prod", "get pods -n payments -l app=api"},
+		{"get po", "staging", "get pods -n web"},
+		{"get pods -n payments --w", "prod", ""},
+		{"lo", "prod", "logs deploy/api -f"},
+		{"logs deploy/api -f", "prod", ""},
+		{"  ", "prod", ""},
+		// Typed with kubectl, as purr records them
+		{"kubectl get po", "prod", "kubectl get pods -n payments -l app=api"},
+		{"kubectl  lo", "prod", "kubectl  logs deploy/api -f"},
+		{"kubectl ", "prod", ""},
+		{"!kubectl get", "prod", "!kubectl get pods | wc -l"},
+		// Redacted secrets are never offered
+		{"create secret", "prod", ""},
+	}
+
+	for _, tt := range tests {
+		if got := c.Predict(tt.line, CompletionContext{CurrentContext: tt.context}); got != tt.want {
+			t.Errorf("Predict(%q) in %s = %q, want %q", tt.line, tt.context, got, tt.want)
+		}
+	}
+}
+
+func TestCompleter_UsageRefreshes(t *testing.T) {
+	hist := fakeHistory{{Command: "kubectl get pods", Success: true, Timestamp: time.Now()}}
+	c := newTestCompleter(t, hist)
+	if got := c.Predict("get s", CompletionContext{}); got != "" {
+		t.Fatalf("Predict(get s) = %q, want none", got)
+	}
+
+	c.History = append(fakeHistory{{Command: "kubectl get svc", Success: true, Timestamp: time.Now()}}, hist...)
+	if got := c.Predict("get s", CompletionContext{}); got != "get svc" {
+		t.Errorf("Predict(get s) after a new command = %q, want get svc", got)
+	}
+}
@@ -0,0 +1,267 @@
+// Package policy decides whether a command may run, and how it has to be
+// confirmed, from rules scoped to kubeconfig contexts and namespaces.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/tapcraft-io/purr/internal/exec"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFileName is the name of the policy file inside the config dir
+const DefaultFileName = "policy.yaml"
+
+// Action is what a rule requires before a command runs
+type Action string
+
+const (
+	ActionAllow         Action = "allow"          // run without asking
+	ActionConfirm       Action = "confirm"        // ask yes or no
+	ActionTypeNamespace Action = "type-namespace" // make the user type the namespace
+	ActionDeny          Action = "deny"           // refuse to run
+)
+
+// Rule matches commands by where they run and what they do. Empty
+// patterns match everything.
+type Rule struct {
+	Name      string   `json:"name,omitempty"`
+	Context   string   `json:"context,omitempty"`   // regex for the whole context name
+	Namespace string   `json:"namespace,omitempty"` // regex for the whole namespace
+	Verbs     []string `json:"verbs,omitempty"`     // e.g. "delete", "rollout restart"; "rollout" covers all rollout subcommands
+	Risk      string   `json:"risk,omitempty"`      // least risk matched: destructive, mutating or any
+	Action    Action   `json:"action"`
+	Reason    string   `json:"reason,omitempty"` // shown when the rule applies
+
+	context   *regexp.Regexp
+	namespace *regexp.Regexp
+	minRisk   exec.Risk
+}
+
+// Policy is an ordered list of rules. The first rule matching a command
+// decides what happens to it.
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Request is a command about to run
+type Request struct {
+	Context    string
+	Namespace  string // namespace the command runs in
+	Assessment exec.Assessment
+}
+
+// Session is where purr runs commands: the context and namespace picked
+// in it, and the kubeconfig's current ones, which commands that get no
+// session flags reach instead
+type Session struct {
+	Context          string
+	Namespace        string
+	CurrentContext   string
+	CurrentNamespace string
+}
+
+// Check decides what a command needs before it runs in a session. It is
+// judged against the context and namespace it names, falling back to the
+// session's. Shell commands and plugins get no session flags, so they
+// fall back to the kubeconfig's current ones instead, and running kubectl
+// there is confirmed first when that is not where the session is. The
+// request returned is where the command runs.
+func (p *Policy) Check(s Session, a exec.Assessment, sessionFlags, confirmDestructive bool) (Request, Decision) {
+	req := Request{Context: s.Context, Namespace: s.Namespace, Assessment: a}
+	if !sessionFlags {
+		req.Context, req.Namespace = s.CurrentContext, s.CurrentNamespace
+	}
+	if a.Context != "" {
+		req.Context = a.Context
+	}
+	if a.Namespace != "" {
+		req.Namespace = a.Namespace
+	}
+
+	d := p.Evaluate(req, confirmDestructive)
+	if reason := req.Elsewhere(s.Context, s.Namespace); !sessionFlags && a.Verb != "" && reason != "" {
+		d = d.Confirmed("without the session's --context and -n, kubectl " + reason)
+	}
+	return req, d
+}
+
+// Elsewhere explains how a request reaches another context or namespace
+// than the session's, or returns "" when it doesn't
+func (req Request) Elsewhere(context, namespace string) string {
+	if req.Context == context && (req.Namespace == namespace || req.Assessment.AllNamespaces) {
+		return ""
+	}
+	return fmt.Sprintf("runs in %s/%s, not in %s/%s", req.Context, req.Namespace, context, namespace)
+}
+
+// Decision is what the policy requires for a Request
+type Decision struct {
+	Action Action
+	Reason string
+	Rule   string // name of the rule that decided, "" for the built-in default
+	Expect string // what the user must type for ActionTypeNamespace
+}
+
+// Load reads a policy file. A missing file is an empty policy.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Policy{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse decodes and validates a YAML policy
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	var errs []error
+	for i := range p.Rules {
+		if err := p.Rules[i].compile(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %w", p.Rules[i].label(i), err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &p, nil
+}
+
+// label names a rule in error messages
+func (r Rule) label(i int) string {
+	if r.Name != "" {
+		return fmt.Sprintf("%q", r.Name)
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// compile checks a rule and prepares its patterns
+func (r *Rule) compile() error {
+	switch r.Action {
+	case ActionAllow, ActionConfirm, ActionTypeNamespace, ActionDeny:
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("unknown action %q (valid: allow, confirm, type-namespace, deny)", r.Action)
+	}
+
+	var err error
+	if r.context, err = anchored(r.Context); err != nil {
+		return fmt.Errorf("context: %w", err)
+	}
+	if r.namespace, err = anchored(r.Namespace); err != nil {
+		return fmt.Errorf("namespace: %w", err)
+	}
+
+	switch r.Risk {
+	case "":
+		// Naming verbs is enough; otherwise only destructive commands match
+		r.minRisk = exec.RiskDestructive
+		if len(r.Verbs) > 0 {
+			r.minRisk = exec.RiskNone
+		}
+	case "destructive":
+		r.minRisk = exec.RiskDestructive
+	case "mutating":
+		r.minRisk = exec.RiskMutating
+	case "any":
+		r.minRisk = exec.RiskNone
+	default:
+		return fmt.Errorf("unknown risk %q (valid: destructive, mutating, any)", r.Risk)
+	}
+	return nil
+}
+
+// anchored compiles a pattern that must match the whole string
+func anchored(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// matches checks if a rule applies to a request. A command across all
+// namespaces matches every namespace pattern.
+func (r Rule) matches(req Request) bool {
+	a := req.Assessment
+	if a.Risk < r.minRisk {
+		return false
+	}
+	if r.context != nil && !r.context.MatchString(req.Context) {
+		return false
+	}
+	if r.namespace != nil && !a.AllNamespaces && !r.namespace.MatchString(req.Namespace) {
+		return false
+	}
+	if len(r.Verbs) == 0 {
+		return true
+	}
+	for _, v := range r.Verbs {
+		if a.Verb == v || strings.HasPrefix(a.Verb, v+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate decides what a request needs. Without a matching rule,
+// destructive commands are confirmed when confirmDestructive is set and
+// everything else runs.
+func (p *Policy) Evaluate(req Request, confirmDestructive bool) Decision {
+	a := req.Assessment
+	if p != nil {
+		for _, r := range p.Rules {
+			if !r.matches(req) {
+				continue
+			}
+			d := Decision{Action: r.Action, Reason: r.Reason, Rule: r.Name}
+			if d.Reason == "" {
+				d.Reason = a.Reason
+			}
+			if d.Action == ActionTypeNamespace {
+				// Cluster-wide commands have no one namespace to type
+				d.Expect = req.Namespace
+				if a.AllNamespaces || d.Expect == "" {
+					d.Expect = req.Context
+				}
+			}
+			return d
+		}
+	}
+
+	if a.Risk == exec.RiskDestructive && confirmDestructive {
+		return Decision{Action: ActionConfirm, Reason: a.Reason}
+	}
+	return Decision{Action: ActionAllow}
+}
+
+// Confirmed makes a decision ask before the command runs, giving reason
+// first. A command the decision refuses stays refused.
+func (d Decision) Confirmed(reason string) Decision {
+	switch {
+	case d.Action == ActionDeny:
+		return d
+	case d.Action == ActionAllow:
+		return Decision{Action: ActionConfirm, Reason: reason}
+	case d.Reason != "":
+		d.Reason = reason + "; " + d.Reason
+	default:
+		d.Reason = reason
+	}
+	return d
+}
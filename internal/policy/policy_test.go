@@ -0,0 +1,190 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tapcraft-io/purr/internal/exec"
+)
+
+const testPolicy = `
+rules:
+  - name: kube-system is read-only
+    namespace: kube-system
+    risk: mutating
+    action: deny
+    reason: kube-system is managed by the platform team
+  - name: no node surgery
+    verbs: [drain, taint]
+    action: deny
+  - name: dev is a playground
+    context: kind-.*|minikube
+    action: allow
+  - name: production
+    context: prod-.*
+    action: type-namespace
+    reason: Production cluster
+`
+
+func TestPolicy_Evaluate(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		context, namespace, command string
+		want                        Decision
+	}{
+		{"prod-eu", "shop", "delete pod web", Decision{Action: ActionTypeNamespace, Reason: "Production cluster", Rule: "production", Expect: "shop"}},
+		{"prod-eu", "", "cordon node-1", Decision{Action: ActionTypeNamespace, Reason: "Production cluster", Rule: "production", Expect: "prod-eu"}},
+		{"prod-eu", "shop", "delete pods --all -A", Decision{Action: ActionDeny, Reason: "kube-system is managed by the platform team", Rule: "kube-system is read-only"}},
+		{"prod-eu", "shop", "get pods", Decision{Action: ActionAllow}},
+		{"prod-eu", "shop", "apply -f app.yaml", Decision{Action: ActionAllow}},
+		{"prod-eu", "kube-system", "apply -f app.yaml", Decision{Action: ActionDeny, Reason: "kube-system is managed by the platform team", Rule: "kube-system is read-only"}},
+		{"prod-eu", "shop", "label pod web a=b -n kube-system", Decision{Action: ActionDeny, Reason: "kube-system is managed by the platform team", Rule: "kube-system is read-only"}},
+		{"kind-dev", "kube-system", "get pods", Decision{Action: ActionAllow}},
+		{"kind-dev", "default", "drain node-1", Decision{Action: ActionDeny, Reason: "evicts every pod from the node", Rule: "no node surgery"}},
+		{"kind-dev", "default", "delete pod web", Decision{Action: ActionAllow, Reason: "deletes resources", Rule: "dev is a playground"}},
+		{"staging", "default", "delete pod web", Decision{Action: ActionConfirm, Reason: "deletes resources"}},
+		{"staging", "default", "rollout status deploy/web", Decision{Action: ActionAllow}},
+		{"prod-eu-2", "shop", "scale deploy/web --replicas=0", Decision{Action: ActionTypeNamespace, Reason: "Production cluster", Rule: "production", Expect: "shop"}},
+		{"production", "shop", "delete pod web", Decision{Action: ActionConfirm, Reason: "deletes resources"}},
+	}
+
+	for _, tt := range tests {
+		a := exec.Assess(tt.command)
+		ns := tt.namespace
+		if a.Namespace != "" {
+			ns = a.Namespace
+		}
+		got := p.Evaluate(Request{Context: tt.context, Namespace: ns, Assessment: a}, true)
+		if got != tt.want {
+			t.Errorf("Evaluate(%s, %s, %s) = %+v, want %+v", tt.context, tt.namespace, tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestPolicy_EvaluateWithoutConfirmation(t *testing.T) {
+	req := Request{Context: "staging", Namespace: "default", Assessment: exec.Assess("delete pod web")}
+	if got := (&Policy{}).Evaluate(req, false); got.Action != ActionAllow {
+		t.Errorf("Evaluate() = %+v, want allow when confirmation is off", got)
+	}
+	var p *Policy
+	if got := p.Evaluate(req, true); got.Action != ActionConfirm {
+		t.Errorf("nil Policy Evaluate() = %+v, want confirm", got)
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	// The session is on a dev cluster, the kubeconfig still on production
+	session := Session{Context: "kind-dev", Namespace: "default", CurrentContext: "prod-eu", CurrentNamespace: "payments"}
+
+	tests := []struct {
+		command      string
+		sessionFlags bool
+		wantReq      string // context/namespace
+		want         Decision
+	}{
+		{"delete pod web", true, "kind-dev/default", Decision{Action: ActionAllow, Reason: "deletes resources", Rule: "dev is a playground"}},
+		{"!kubectl delete ns payments", false, "prod-eu/payments", Decision{Action: ActionTypeNamespace,
+			Reason: "without the session's --context and -n, kubectl runs in prod-eu/payments, not in kind-dev/default; Production cluster",
+			Rule:   "production", Expect: "payments"}},
+		{"!kubectl delete cm cfg -n kube-system", false, "prod-eu/kube-system", Decision{Action: ActionDeny,
+			Reason: "kube-system is managed by the platform team", Rule: "kube-system is read-only"}},
+		{"!kubectl --context kind-dev -n default delete pod web", false, "kind-dev/default", Decision{Action: ActionAllow, Reason: "deletes resources", Rule: "dev is a playground"}},
+		{"!kubectl get pods", false, "prod-eu/payments", Decision{Action: ActionConfirm,
+			Reason: "without the session's --context and -n, kubectl runs in prod-eu/payments, not in kind-dev/default"}},
+		{"!ls -l", false, "prod-eu/payments", Decision{Action: ActionAllow}},
+		{"kubectl neat get pod web", false, "prod-eu/payments", Decision{Action: ActionConfirm,
+			Reason: "without the session's --context and -n, kubectl runs in prod-eu/payments, not in kind-dev/default"}},
+	}
+
+	for _, tt := range tests {
+		req, got := p.Check(session, exec.Assess(tt.command), tt.sessionFlags, true)
+		if where := req.Context + "/" + req.Namespace; where != tt.wantReq {
+			t.Errorf("Check(%q) runs in %s, want %s", tt.command, where, tt.wantReq)
+		}
+		if got != tt.want {
+			t.Errorf("Check(%q) = %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestRequest_Elsewhere(t *testing.T) {
+	tests := []struct {
+		context, namespace, command string
+		want                        string
+	}{
+		{"prod", "shop", "get pods", ""},
+		{"prod", "default", "get pods", "runs in prod/default, not in prod/shop"},
+		{"kind", "shop", "get pods", "runs in kind/shop, not in prod/shop"},
+		{"prod", "default", "get pods -A", ""},
+	}
+
+	for _, tt := range tests {
+		req := Request{Context: tt.context, Namespace: tt.namespace, Assessment: exec.Assess(tt.command)}
+		if got := req.Elsewhere("prod", "shop"); got != tt.want {
+			t.Errorf("Elsewhere() for %s/%s %q = %q, want %q", tt.context, tt.namespace, tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestDecision_Confirmed(t *testing.T) {
+	tests := []struct {
+		d    Decision
+		want Decision
+	}{
+		{Decision{Action: ActionAllow, Rule: "dev"}, Decision{Action: ActionConfirm, Reason: "elsewhere"}},
+		{Decision{Action: ActionConfirm, Reason: "deletes resources"}, Decision{Action: ActionConfirm, Reason: "elsewhere; deletes resources"}},
+		{Decision{Action: ActionTypeNamespace, Rule: "production", Expect: "shop"}, Decision{Action: ActionTypeNamespace, Reason: "elsewhere", Rule: "production", Expect: "shop"}},
+		{Decision{Action: ActionDeny, Reason: "read-only"}, Decision{Action: ActionDeny, Reason: "read-only"}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.d.Confirmed("elsewhere"); got != tt.want {
+			t.Errorf("%+v.Confirmed() = %+v, want %+v", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"rules:\n  - context: prod\n", "action is required"},
+		{"rules:\n  - action: explode\n", `unknown action "explode"`},
+		{"rules:\n  - name: bad\n    context: '('\n    action: deny\n", `rule "bad": context`},
+		{"rules:\n  - risk: huge\n    action: deny\n", `rule #1: unknown risk "huge"`},
+		{"rules:\n  - action: deny\n    verb: delete\n", "invalid YAML"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want it to mention %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	p, err := Load(filepath.Join(t.TempDir(), DefaultFileName))
+	if err != nil || p == nil || len(p.Rules) != 0 {
+		t.Errorf("Load(missing) = %v, %v, want an empty policy", p, err)
+	}
+
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	if err := os.WriteFile(path, []byte("rules: [{action: nope}]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Load(invalid) error = %v, want it to name the file", err)
+	}
+}
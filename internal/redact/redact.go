@@ -0,0 +1,215 @@
+// Package redact masks secrets in commands and their output before they are
+// stored, exported or shown: secret literals and tokens passed as flags,
+// bearer tokens, AWS keys, the data of Secret manifests, and whatever the
+// user's own patterns match.
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFileName is the name of the redaction file inside the config dir
+const DefaultFileName = "redact.yaml"
+
+// Mask replaces every secret
+const Mask = "[REDACTED]"
+
+// builtinPatterns match secrets wherever they appear. Only the capture
+// groups are masked, or the whole match when there are none.
+var builtinPatterns = []string{
+	// create secret --from-literal=key=value, quoted as a whole or not
+	`--from-literal[= ]'[^='\s]+=([^']*)'`,
+	`--from-literal[= ]"[^="\s]+=([^"]*)"`,
+	`--from-literal[= ][^='"\s]+=('[^']*'|"[^"]*"|[^'"\s]+)`,
+	// --password, --token, --docker-password, --api-key, ...
+	`(?i)--[\w-]*(?:password|passwd|token|secret-key|api-key)[= ]('[^']*'|"[^"]*"|[^'"\s]+)`,
+	// Authorization headers
+	`(?i)\bbearer\s+([A-Za-z0-9._~+/-]+=*)`,
+	`(?i)authorization:\s*basic\s+([A-Za-z0-9+/]+=*)`,
+	// AWS access key IDs and secret keys
+	`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`,
+	`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`,
+}
+
+// File is the redaction file: extra regular expressions to mask, in RE2
+// syntax
+type File struct {
+	Patterns []string `json:"patterns"`
+}
+
+// Redactor masks secrets in text
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+var (
+	builtinOnce sync.Once
+	builtin     *Redactor
+)
+
+// Builtin returns a Redactor with only the built-in patterns
+func Builtin() *Redactor {
+	builtinOnce.Do(func() {
+		r, err := New(nil)
+		if err != nil {
+			panic(err)
+		}
+		builtin = r
+	})
+	return builtin
+}
+
+// New returns a Redactor with the built-in patterns and the extra ones
+func New(extra []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range builtinPatterns {
+		r.patterns = append(r.patterns, regexp.MustCompile(p))
+	}
+	for i, p := range extra {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %d: %w", i+1, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Load reads a redaction file. A missing file leaves the built-in patterns.
+func Load(path string) (*Redactor, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Builtin(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Parse parses a redaction file
+func Parse(data []byte) (*Redactor, error) {
+	var f File
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, err
+	}
+	return New(f.Patterns)
+}
+
+// String masks the secrets in s. A nil Redactor uses the built-in patterns.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		r = Builtin()
+	}
+	if s == "" {
+		return s
+	}
+	s = secretData(s)
+	for _, re := range r.patterns {
+		s = mask(re, s)
+	}
+	return s
+}
+
+// mask replaces the capture groups of every match, or the whole match if
+// the pattern has no groups
+func mask(re *regexp.Regexp, s string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		spans := [][2]int{{m[0], m[1]}}
+		if len(m) > 2 {
+			spans = spans[:0]
+			for g := 2; g < len(m); g += 2 {
+				if m[g] >= 0 && m[g] >= last && m[g+1] > m[g] {
+					spans = append(spans, [2]int{m[g], m[g+1]})
+				}
+			}
+		}
+		for _, span := range spans {
+			if span[0] < last {
+				continue
+			}
+			b.WriteString(s[last:span[0]])
+			b.WriteString(Mask)
+			last = span[1]
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// secretKind finds Secret manifests, in YAML or JSON, JSON escaped inside
+// an annotation included
+var secretKind = regexp.MustCompile(`(?m)^[\s+-]*kind:\s*Secret\s*$|\\?"kind\\?"\s*:\s*\\?"Secret\\?"`)
+
+// dataKey starts the data of a Secret in YAML
+var dataKey = regexp.MustCompile(`^(\s*(?:-\s+)?)(?:data|stringData):\s*$`)
+
+// jsonData and jsonValue find the values of a Secret's data in JSON
+var (
+	jsonData  = regexp.MustCompile(`(\\?"(?:data|stringData)\\?"\s*:\s*\{)([^{}]*)\}`)
+	jsonValue = regexp.MustCompile(`(\\?"[^"\\]*\\?"\s*:\s*)(\\?")[^"\\]*(\\?")`)
+)
+
+// secretData masks the values under data and stringData of Secret
+// manifests. YAML lines may carry the +, - or space of a unified diff.
+func secretData(s string) string {
+	if !secretKind.MatchString(s) {
+		return s
+	}
+
+	s = jsonData.ReplaceAllStringFunc(s, func(block string) string {
+		return jsonValue.ReplaceAllString(block, "${1}${2}"+Mask+"${3}")
+	})
+
+	isDiff := strings.HasPrefix(s, "--- ")
+	lines := strings.Split(s, "\n")
+	dataIndent, keyIndent := -1, -1
+	for i, line := range lines {
+		marker, body := "", line
+		if isDiff && body != "" && strings.ContainsRune("+- ", rune(body[0])) {
+			marker, body = body[:1], body[1:]
+		}
+		trimmed := strings.TrimSpace(body)
+		indent := len(body) - len(strings.TrimLeft(body, " "))
+
+		if dataIndent >= 0 && trimmed != "" {
+			if indent <= dataIndent {
+				dataIndent, keyIndent = -1, -1
+			} else {
+				if keyIndent < 0 {
+					keyIndent = indent
+				}
+				if indent > keyIndent {
+					// A line of a multi-line value
+					lines[i] = marker + body[:indent] + Mask
+				} else if key, value, ok := strings.Cut(body, ":"); ok && strings.TrimSpace(value) != "" {
+					lines[i] = marker + key + ": " + Mask
+				}
+				continue
+			}
+		}
+		if m := dataKey.FindStringSubmatch(body); m != nil {
+			dataIndent = len(m[1])
+			keyIndent = -1
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,109 @@
+// Package ringbuf keeps the most recent lines of a stream in a fixed amount
+// of memory, so long-running commands such as followed logs can't grow
+// without bound.
+package ringbuf
+
+import "strings"
+
+// Lines is a ring buffer of text lines. The zero value is not usable; call
+// New.
+type Lines struct {
+	lines   []string
+	start   int // index of the oldest line
+	count   int
+	dropped int
+	partial string // text after the last newline, not yet a full line
+}
+
+// New creates a buffer that holds at most capacity lines
+func New(capacity int) *Lines {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Lines{lines: make([]string, capacity)}
+}
+
+// Add appends lines, evicting the oldest ones once the buffer is full
+func (b *Lines) Add(lines ...string) {
+	for _, line := range lines {
+		if b.count < len(b.lines) {
+			b.lines[(b.start+b.count)%len(b.lines)] = line
+			b.count++
+			continue
+		}
+		b.lines[b.start] = line
+		b.start = (b.start + 1) % len(b.lines)
+		b.dropped++
+	}
+}
+
+// WriteString appends a chunk of output. Text after the last newline is
+// held back until the rest of its line arrives.
+func (b *Lines) WriteString(s string) {
+	s = b.partial + s
+	b.partial = ""
+	parts := strings.Split(s, "\n")
+	b.partial = parts[len(parts)-1]
+	b.Add(parts[:len(parts)-1]...)
+}
+
+// Flush adds any held back partial line
+func (b *Lines) Flush() {
+	if b.partial != "" {
+		b.Add(b.partial)
+		b.partial = ""
+	}
+}
+
+// Len returns the number of complete lines held
+func (b *Lines) Len() int {
+	return b.count
+}
+
+// Cap returns the most lines the buffer holds
+func (b *Lines) Cap() int {
+	return len(b.lines)
+}
+
+// Dropped returns how many lines have been evicted since the last Reset
+func (b *Lines) Dropped() int {
+	return b.dropped
+}
+
+// Lines returns the held lines, oldest first
+func (b *Lines) Lines() []string {
+	return b.Tail(b.count)
+}
+
+// Tail returns up to the last n lines, oldest first
+func (b *Lines) Tail(n int) []string {
+	if n > b.count {
+		n = b.count
+	}
+	if n < 0 {
+		n = 0
+	}
+	out := make([]string, n)
+	first := b.start + b.count - n
+	for i := range out {
+		out[i] = b.lines[(first+i)%len(b.lines)]
+	}
+	return out
+}
+
+// String returns the held lines joined by newlines, including any
+// partial line
+func (b *Lines) String() string {
+	lines := b.Lines()
+	if b.partial != "" {
+		lines = append(lines, b.partial)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Reset empties the buffer
+func (b *Lines) Reset() {
+	clear(b.lines)
+	b.start, b.count, b.dropped = 0, 0, 0
+	b.partial = ""
+}
@@ -0,0 +1,51 @@
+package ringbuf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLines_Add(t *testing.T) {
+	b := New(3)
+	b.Add("a", "b")
+	if got := b.Lines(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Lines() = %v, want [a b]", got)
+	}
+
+	b.Add("c", "d", "e")
+	if got := b.Lines(); !reflect.DeepEqual(got, []string{"c", "d", "e"}) {
+		t.Errorf("Lines() = %v, want [c d e]", got)
+	}
+	if b.Len() != 3 || b.Dropped() != 2 {
+		t.Errorf("Len() = %d, Dropped() = %d, want 3, 2", b.Len(), b.Dropped())
+	}
+	if got := b.Tail(2); !reflect.DeepEqual(got, []string{"d", "e"}) {
+		t.Errorf("Tail(2) = %v, want [d e]", got)
+	}
+	if got := b.Tail(10); len(got) != 3 {
+		t.Errorf("Tail(10) returned %d lines, want 3", len(got))
+	}
+
+	b.Reset()
+	if b.Len() != 0 || b.Dropped() != 0 || b.String() != "" {
+		t.Errorf("Reset() left %q", b.String())
+	}
+}
+
+func TestLines_WriteString(t *testing.T) {
+	b := New(10)
+	b.WriteString("one\ntw")
+	b.WriteString("o\nthree\n")
+	if got := b.Lines(); !reflect.DeepEqual(got, []string{"one", "two", "three"}) {
+		t.Errorf("Lines() = %v, want [one two three]", got)
+	}
+
+	b.WriteString("four")
+	if b.Len() != 3 || b.String() != "one\ntwo\nthree\nfour" {
+		t.Errorf("partial line: Len() = %d, String() = %q", b.Len(), b.String())
+	}
+	b.Flush()
+	if b.Len() != 4 {
+		t.Errorf("Flush() Len() = %d, want 4", b.Len())
+	}
+}
@@ -0,0 +1,184 @@
+// Package shlex splits command lines the way a POSIX shell does, keeping the
+// byte offset of every token so callers can map a cursor position back to
+// the word it falls in.
+package shlex
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrUnterminatedQuote is returned when a quote is opened but not closed
+	ErrUnterminatedQuote = errors.New("unterminated quote")
+	// ErrTrailingBackslash is returned when the input ends in an escape
+	ErrTrailingBackslash = errors.New("trailing backslash")
+)
+
+// Token is a single shell word
+type Token struct {
+	Value string // the word with quotes and escapes removed
+	Start int    // byte offset of the first character in the input
+	End   int    // byte offset just past the last character
+}
+
+// Raw returns the token as it was typed
+func (t Token) Raw(input string) string {
+	return input[t.Start:t.End]
+}
+
+// Split tokenizes s. Single quotes preserve everything literally, double
+// quotes allow \", \\, \$ and \` escapes, and a backslash outside quotes
+// escapes the next byte. When the input ends inside a quote or after a
+// backslash, the partial last token is still returned along with the error,
+// which is what completion wants while the user is mid-word.
+func Split(s string) ([]Token, error) {
+	var (
+		tokens  []Token
+		buf     strings.Builder
+		inToken bool
+		start   int
+		quote   byte // 0, '\'' or '"'
+	)
+
+	flush := func(end int) {
+		if inToken {
+			tokens = append(tokens, Token{Value: buf.String(), Start: start, End: end})
+			buf.Reset()
+			inToken = false
+		}
+	}
+	begin := func(i int) {
+		if !inToken {
+			inToken = true
+			start = i
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch quote {
+		case '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				buf.WriteByte(c)
+			}
+			continue
+		case '"':
+			switch c {
+			case '"':
+				quote = 0
+			case '\\':
+				if i+1 >= len(s) {
+					flush(len(s))
+					return tokens, ErrUnterminatedQuote
+				}
+				next := s[i+1]
+				if next == '"' || next == '\\' || next == '$' || next == '`' {
+					buf.WriteByte(next)
+				} else if next != '\n' {
+					buf.WriteByte(c)
+					buf.WriteByte(next)
+				}
+				i++
+			default:
+				buf.WriteByte(c)
+			}
+			continue
+		}
+
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			flush(i)
+		case '\'', '"':
+			begin(i)
+			quote = c
+		case '\\':
+			begin(i)
+			if i+1 >= len(s) {
+				flush(len(s))
+				return tokens, ErrTrailingBackslash
+			}
+			// Backslash-newline is a line continuation
+			if s[i+1] != '\n' {
+				buf.WriteByte(s[i+1])
+			}
+			i++
+		default:
+			begin(i)
+			buf.WriteByte(c)
+		}
+	}
+
+	flush(len(s))
+	if quote != 0 {
+		return tokens, ErrUnterminatedQuote
+	}
+	return tokens, nil
+}
+
+// Fields returns just the token values of s
+func Fields(s string) ([]string, error) {
+	tokens, err := Split(s)
+	values := make([]string, len(tokens))
+	for i, t := range tokens {
+		values[i] = t.Value
+	}
+	return values, err
+}
+
+// Values returns the values of tokens
+func Values(tokens []Token) []string {
+	values := make([]string, len(tokens))
+	for i, t := range tokens {
+		values[i] = t.Value
+	}
+	return values
+}
+
+// TokenAt returns the index of the token containing or ending at cursor, or
+// -1 when the cursor sits in whitespace between tokens
+func TokenAt(tokens []Token, cursor int) int {
+	for i, t := range tokens {
+		if cursor >= t.Start && cursor <= t.End {
+			return i
+		}
+	}
+	return -1
+}
+
+// SplitFlag splits "--name=value" into its parts. ok is false for
+// arguments that are not flags or have no "=".
+func SplitFlag(arg string) (name, value string, ok bool) {
+	if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+		return "", "", false
+	}
+	name, value, ok = strings.Cut(arg, "=")
+	if !ok {
+		return "", "", false
+	}
+	return name, value, true
+}
+
+// Quote returns s quoted so that Split yields it back as a single token.
+// Words that need no quoting are returned unchanged.
+func Quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(s, " \t\n\r'\"\\$`|&;<>()*?[]{}#~!") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join quotes each word and joins them with spaces
+func Join(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = Quote(w)
+	}
+	return strings.Join(quoted, " ")
+}
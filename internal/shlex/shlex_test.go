@@ -0,0 +1,130 @@
+package shlex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"get pods", []string{"get", "pods"}},
+		{"  get   pods  ", []string{"get", "pods"}},
+		{`exec pod -- sh -c "echo hi"`, []string{"exec", "pod", "--", "sh", "-c", "echo hi"}},
+		{`get pods -l 'app in (a,b)'`, []string{"get", "pods", "-l", "app in (a,b)"}},
+		{`get pods --template='{{.metadata.name}}'`, []string{"get", "pods", "--template={{.metadata.name}}"}},
+		{`echo "a \"quoted\" word"`, []string{"echo", `a "quoted" word`}},
+		{`echo "keep \n literal"`, []string{"echo", `keep \n literal`}},
+		{`echo 'no \escape'`, []string{"echo", `no \escape`}},
+		{`echo a\ b`, []string{"echo", "a b"}},
+		{`echo '' ""`, []string{"echo", "", ""}},
+		{`echo foo"bar"'baz'`, []string{"echo", "foobarbaz"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Fields(tt.input)
+			if err != nil {
+				t.Fatalf("Fields(%q) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fields(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit_Offsets(t *testing.T) {
+	input := `get pods -l 'app=web' --x="y z"`
+	tokens, err := Split(input)
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+
+	wantRaw := []string{"get", "pods", "-l", "'app=web'", `--x="y z"`}
+	if len(tokens) != len(wantRaw) {
+		t.Fatalf("Split() returned %d tokens, want %d", len(tokens), len(wantRaw))
+	}
+	for i, tok := range tokens {
+		if got := tok.Raw(input); got != wantRaw[i] {
+			t.Errorf("token %d Raw() = %q, want %q", i, got, wantRaw[i])
+		}
+	}
+
+	if got := TokenAt(tokens, 14); got != 3 {
+		t.Errorf("TokenAt(14) = %d, want 3", got)
+	}
+	if got := TokenAt(tokens, len(input)); got != 4 {
+		t.Errorf("TokenAt(end) = %d, want 4", got)
+	}
+}
+
+func TestSplit_Unterminated(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{`get pods -l 'app in (a`, []string{"get", "pods", "-l", "app in (a"}, ErrUnterminatedQuote},
+		{`echo "hi `, []string{"echo", "hi "}, ErrUnterminatedQuote},
+		{`echo hi\`, []string{"echo", "hi"}, ErrTrailingBackslash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, err := Split(tt.input)
+			if err != tt.wantErr {
+				t.Errorf("Split(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+			if got := Values(tokens); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if last := tokens[len(tokens)-1]; last.End != len(tt.input) {
+				t.Errorf("last token End = %d, want %d", last.End, len(tt.input))
+			}
+		})
+	}
+}
+
+func TestSplitFlag(t *testing.T) {
+	tests := []struct {
+		arg       string
+		wantName  string
+		wantValue string
+		wantOK    bool
+	}{
+		{"--output=json", "--output", "json", true},
+		{"-n=default", "-n", "default", true},
+		{"--selector=app=web", "--selector", "app=web", true},
+		{"--output", "", "", false},
+		{"--", "", "", false},
+		{"pods", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			name, value, ok := SplitFlag(tt.arg)
+			if name != tt.wantName || value != tt.wantValue || ok != tt.wantOK {
+				t.Errorf("SplitFlag(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.arg, name, value, ok, tt.wantName, tt.wantValue, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestQuote_RoundTrip(t *testing.T) {
+	words := []string{"plain", "with space", "it's", `back\slash`, "", "{{.metadata.name}}", "$HOME"}
+
+	got, err := Fields(Join(words))
+	if err != nil {
+		t.Fatalf("Fields(Join()) error = %v", err)
+	}
+	if !reflect.DeepEqual(got, words) {
+		t.Errorf("Fields(Join(%q)) = %q", words, got)
+	}
+	if Quote("nginx-app") != "nginx-app" {
+		t.Errorf("Quote(nginx-app) = %q, want unchanged", Quote("nginx-app"))
+	}
+}
@@ -0,0 +1,194 @@
+package specgen
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// help is what `kubectl <command> --help` describes
+type help struct {
+	description string
+	usage       string
+	flags       []flagHelp
+	subcommands []commandHelp
+}
+
+// commandHelp is a command listed in help or completions
+type commandHelp struct {
+	name        string
+	description string
+}
+
+// flagHelp is a flag listed under Options
+type flagHelp struct {
+	short       string // "-n", or ""
+	long        string // "--namespace"
+	value       valueType
+	description string
+}
+
+// valueType is the type of a flag's value, as told by its default
+type valueType string
+
+const (
+	valueBool     valueType = "bool"
+	valueString   valueType = "string"
+	valueSlice    valueType = "slice"
+	valueInt      valueType = "int"
+	valueDuration valueType = "duration"
+)
+
+var (
+	// sectionHeader starts a section of help output, e.g. "Options:" or
+	// "Basic Commands (Beginner):"
+	sectionHeader = regexp.MustCompile(`^[A-Z][\w ()-]*:$`)
+	// commandsHeader starts a section listing commands
+	commandsHeader = regexp.MustCompile(`^[A-Z][\w ()-]*Commands[\w ()-]*:$`)
+	// commandLine is a command in such a section
+	commandLine = regexp.MustCompile(`^  ([a-z][\w-]*)\s+(.*)$`)
+	// flagLine starts a flag under Options: "    -n, --namespace='':"
+	flagLine = regexp.MustCompile(`^\s+(?:(-\w), )?(--[\w-]+)=(.*)$`)
+	// durationValue is a Go duration such as 0s or 1m0s
+	durationValue = regexp.MustCompile(`^\d+(\.\d+)?(ns|us|µs|ms|s|m|h)`)
+	// intValue is an integer
+	intValue = regexp.MustCompile(`^-?\d+$`)
+)
+
+// parseHelp reads the output of `kubectl <command> --help`
+func parseHelp(out string) help {
+	var h help
+	var description []string
+	section := ""
+	lines := strings.Split(out, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \r")
+		if sectionHeader.MatchString(line) {
+			section = line
+			continue
+		}
+
+		switch {
+		case section == "":
+			if text := strings.TrimSpace(line); text != "" {
+				description = append(description, text)
+			}
+		case section == "Usage:":
+			if h.usage == "" && strings.TrimSpace(line) != "" {
+				h.usage = strings.TrimSuffix(strings.TrimSpace(line), " [options]")
+			}
+		case section == "Options:" || section == "Flags:":
+			if f, ok := parseFlagLine(line); ok {
+				i = flagDescription(lines, i+1, &f) - 1
+				h.flags = append(h.flags, f)
+			}
+		case commandsHeader.MatchString(section):
+			if m := commandLine.FindStringSubmatch(line); m != nil {
+				h.subcommands = append(h.subcommands, commandHelp{name: m[1], description: strings.TrimSpace(m[2])})
+			}
+		}
+	}
+	h.description = strings.Join(description, " ")
+	return h
+}
+
+// parseFlags reads the flags in the output of `kubectl options`
+func parseFlags(out string) []flagHelp {
+	var flags []flagHelp
+	lines := strings.Split(out, "\n")
+	for i := 0; i < len(lines); i++ {
+		if f, ok := parseFlagLine(strings.TrimRight(lines[i], " \r")); ok {
+			i = flagDescription(lines, i+1, &f) - 1
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+// parseFlagLine reads the first line of a flag. Newer kubectl ends it with
+// a colon and describes the flag on the lines after it; older kubectl
+// describes it on the same line, after ": ".
+func parseFlagLine(line string) (flagHelp, bool) {
+	m := flagLine.FindStringSubmatch(line)
+	if m == nil {
+		return flagHelp{}, false
+	}
+	f := flagHelp{short: m[1], long: m[2]}
+	rest := m[3]
+	if def, ok := strings.CutSuffix(rest, ":"); ok {
+		f.value = valueTypeOf(def)
+	} else if def, desc, ok := strings.Cut(rest, ": "); ok {
+		f.value = valueTypeOf(def)
+		f.description = strings.TrimSpace(desc)
+	} else {
+		return flagHelp{}, false
+	}
+	return f, true
+}
+
+// flagDescription appends the description lines starting at i to a flag
+// and returns the index of the first line after them
+func flagDescription(lines []string, i int, f *flagHelp) int {
+	var desc []string
+	if f.description != "" {
+		desc = append(desc, f.description)
+	}
+	for ; i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		if text == "" || flagLine.MatchString(lines[i]) || sectionHeader.MatchString(lines[i]) {
+			break
+		}
+		desc = append(desc, text)
+	}
+	f.description = strings.Join(desc, " ")
+	return i
+}
+
+// valueTypeOf tells the type of a flag from its default value
+func valueTypeOf(def string) valueType {
+	switch {
+	case def == "true" || def == "false":
+		return valueBool
+	case strings.HasPrefix(def, "["):
+		return valueSlice
+	case strings.HasPrefix(def, "'"):
+		return valueString
+	case intValue.MatchString(def):
+		return valueInt
+	case durationValue.MatchString(def):
+		return valueDuration
+	default:
+		return valueString
+	}
+}
+
+// Directives cobra ends `__complete` output with, as ":N"
+const (
+	directiveError         = 1
+	directiveFilterFileExt = 8
+	directiveFilterDirs    = 16
+)
+
+// parseCompletions reads the output of `kubectl __complete`. Completions
+// that are file extensions or directories, or come with an error, are not
+// values and are dropped.
+func parseCompletions(out string) []commandHelp {
+	var completions []commandHelp
+	directive := 0
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, ":") {
+			directive, _ = strconv.Atoi(line[1:])
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "_activeHelp_") {
+			continue
+		}
+		name, description, _ := strings.Cut(line, "\t")
+		completions = append(completions, commandHelp{name: name, description: description})
+	}
+	if directive&(directiveError|directiveFilterFileExt|directiveFilterDirs) != 0 {
+		return nil
+	}
+	return completions
+}
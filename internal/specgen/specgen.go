@@ -0,0 +1,475 @@
+// Package specgen generates the kubectl commands specification from the
+// kubectl binary: the command tree and flags from `kubectl <command>
+// --help` and `kubectl options`, plugins and the allowed values of flags
+// from `kubectl __complete`.
+package specgen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tapcraft-io/purr/internal/kubecomplete"
+)
+
+// SpecVersion is the version of the spec format
+const SpecVersion = "1.0"
+
+// Runner runs kubectl with args and returns what it printed on stdout
+type Runner func(ctx context.Context, args ...string) ([]byte, error)
+
+// Kubectl returns a Runner for a kubectl binary. It runs without a
+// kubeconfig, so completions list what kubectl knows and never what a
+// cluster holds.
+func Kubectl(path string) Runner {
+	return func(ctx context.Context, args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, path, args...)
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+os.DevNull)
+		out, err := cmd.Output()
+		if err != nil {
+			return out, fmt.Errorf("kubectl %s: %w", strings.Join(args, " "), err)
+		}
+		return out, nil
+	}
+}
+
+// Options tune a generation
+type Options struct {
+	// Base is a spec whose positionals, roles and value kinds are kept for
+	// the commands and flags kubectl still has; kubectl cannot tell them
+	Base *kubecomplete.RootSpec
+	// Workers is how many kubectl processes run at once; 0 means one per CPU
+	Workers int
+}
+
+// Generate builds the spec of the kubectl that run runs
+func Generate(ctx context.Context, run Runner, opts Options) (*kubecomplete.RootSpec, error) {
+	g := &generator{run: run, workers: opts.Workers, base: make(map[string]*kubecomplete.CommandSpec)}
+	if g.workers <= 0 {
+		g.workers = runtime.NumCPU()
+	}
+	if opts.Base != nil {
+		for i := range opts.Base.Commands {
+			spec := &opts.Base.Commands[i]
+			g.base[strings.Join(spec.Path, " ")] = spec
+		}
+	}
+
+	version, err := Version(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+	out, err := run(ctx, "options")
+	if err != nil {
+		return nil, err
+	}
+	globals := parseFlags(string(out))
+
+	commands, err := g.walk(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plugins := g.plugins(ctx, commands)
+
+	specs := make([]kubecomplete.CommandSpec, len(commands))
+	for i, c := range commands {
+		specs[i] = g.commandSpec(c.path, c.help, globals)
+	}
+	if err := g.allowedValues(ctx, specs); err != nil {
+		return nil, err
+	}
+	specs = append(specs, plugins...)
+	sort.Slice(specs, func(i, j int) bool {
+		return strings.Join(specs[i].Path, " ") < strings.Join(specs[j].Path, " ")
+	})
+
+	return &kubecomplete.RootSpec{
+		Version:        SpecVersion,
+		GeneratedFrom:  "kubectl " + version + " --help and __complete",
+		KubectlVersion: version,
+		Commands:       specs,
+	}, nil
+}
+
+// generator holds what a generation needs along the way
+type generator struct {
+	run     Runner
+	workers int
+	base    map[string]*kubecomplete.CommandSpec // by path key
+}
+
+// command is a command found in the help tree
+type command struct {
+	path []string
+	help help
+}
+
+// Version returns the client version of the kubectl that run runs, e.g.
+// v1.31.0
+func Version(ctx context.Context, run Runner) (string, error) {
+	out, err := run(ctx, "version", "--client", "-o", "json")
+	if err != nil {
+		return "", err
+	}
+	var v struct {
+		ClientVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+	}
+	if err := json.Unmarshal(out, &v); err != nil {
+		return "", fmt.Errorf("kubectl version: %w", err)
+	}
+	if v.ClientVersion.GitVersion == "" {
+		return "", fmt.Errorf("kubectl version: no client version")
+	}
+	return v.ClientVersion.GitVersion, nil
+}
+
+// walk reads the help of every command, level by level from the top
+func (g *generator) walk(ctx context.Context) ([]command, error) {
+	out, err := g.run(ctx, "--help")
+	if err != nil {
+		return nil, err
+	}
+	var level [][]string
+	for _, sub := range parseHelp(string(out)).subcommands {
+		if sub.name != "help" {
+			level = append(level, []string{sub.name})
+		}
+	}
+
+	var commands []command
+	for len(level) > 0 {
+		found := make([]command, len(level))
+		err := g.each(ctx, len(level), func(i int) error {
+			args := append(append([]string{}, level[i]...), "--help")
+			out, err := g.run(ctx, args...)
+			if err != nil {
+				return err
+			}
+			found[i] = command{path: level[i], help: parseHelp(string(out))}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		level = nil
+		for _, c := range found {
+			commands = append(commands, c)
+			for _, sub := range c.help.subcommands {
+				level = append(level, append(append([]string{}, c.path...), sub.name))
+			}
+		}
+	}
+	return commands, nil
+}
+
+// plugins returns the top-level commands kubectl completes that its help
+// does not list: kubectl-* plugins on PATH. Older kubectl does not complete
+// plugins, which leaves none.
+func (g *generator) plugins(ctx context.Context, commands []command) []kubecomplete.CommandSpec {
+	out, err := g.run(ctx, "__complete", "")
+	if err != nil {
+		return nil
+	}
+	builtin := make(map[string]bool)
+	for _, c := range commands {
+		builtin[c.path[0]] = true
+	}
+
+	var plugins []kubecomplete.CommandSpec
+	for _, c := range parseCompletions(string(out)) {
+		if builtin[c.name] || c.name == "help" || strings.HasPrefix(c.name, "-") {
+			continue
+		}
+		description := c.description
+		if description == "" {
+			description = "plugin"
+		}
+		plugins = append(plugins, kubecomplete.CommandSpec{
+			Path:        []string{c.name},
+			Synopsis:    "kubectl " + c.name + " [flags]",
+			Description: description,
+			Flags:       map[string]kubecomplete.FlagDescriptor{},
+			Plugin:      true,
+		})
+	}
+	return plugins
+}
+
+// commandSpec builds the spec of a command from its help and the global
+// flags, keeping what the base spec knows that kubectl cannot tell
+func (g *generator) commandSpec(path []string, h help, globals []flagHelp) kubecomplete.CommandSpec {
+	key := strings.Join(path, " ")
+	base := g.base[key]
+	spec := kubecomplete.CommandSpec{
+		Path:        path,
+		Synopsis:    h.usage,
+		Description: h.description,
+		Flags:       make(map[string]kubecomplete.FlagDescriptor),
+	}
+	if base != nil {
+		spec.Positionals = base.Positionals
+		spec.Interactive = base.Interactive
+	} else if len(h.subcommands) == 0 {
+		spec.Positionals = positionalsFromUsage(h.usage)
+	}
+	if spec.Positionals == nil {
+		spec.Positionals = []kubecomplete.TokenDescriptor{}
+	}
+
+	for _, flags := range [][]flagHelp{h.flags, globals} {
+		for _, f := range flags {
+			desc := flagSpec(f)
+			if _, ok := spec.Flags[desc.Primary]; ok {
+				continue
+			}
+			if base != nil {
+				if old, ok := base.Flags[desc.Primary]; ok {
+					desc = mergeFlag(desc, old)
+				}
+			}
+			spec.Flags[desc.Primary] = desc
+		}
+	}
+	return spec
+}
+
+// flagRoles are the roles and value kinds of flags the completer treats
+// specially, by long name
+var flagRoles = map[string]struct {
+	role string
+	kind kubecomplete.TokenKind
+}{
+	"--namespace":       {"namespace-scope", kubecomplete.TokenNamespace},
+	"--all-namespaces":  {"namespace-scope", ""},
+	"--selector":        {"label-selector", kubecomplete.TokenSelector},
+	"--field-selector":  {"field-selector", kubecomplete.TokenSelector},
+	"--output":          {"output-format", kubecomplete.TokenOutput},
+	"--container":       {"container-selector", kubecomplete.TokenContainerName},
+	"--context":         {"context-selector", "context-name"},
+	"--cluster":         {"cluster-selector", "cluster-name"},
+	"--user":            {"user-selector", "user-name"},
+	"--kubeconfig":      {"kubeconfig", "filepath"},
+	"--filename":        {"file-input", "filepath"},
+	"--kustomize":       {"kustomize-input", "dirpath"},
+	"--timeout":         {"timeout", kubecomplete.TokenDuration},
+	"--request-timeout": {"timeout", kubecomplete.TokenDuration},
+}
+
+// flagSpec describes a flag from its help alone
+func flagSpec(f flagHelp) kubecomplete.FlagDescriptor {
+	desc := kubecomplete.FlagDescriptor{
+		Kind:        "flag",
+		Primary:     f.long,
+		Aliases:     []string{},
+		Role:        "other",
+		Description: f.description,
+	}
+	if f.short != "" {
+		desc.Primary = f.short
+		desc.Aliases = []string{f.long}
+	}
+	known, isKnown := flagRoles[f.long]
+	if isKnown {
+		desc.Role = known.role
+	}
+	if f.value == valueBool {
+		return desc
+	}
+
+	after := &kubecomplete.TokenDescriptor{Kind: kubecomplete.TokenOther, Role: desc.Role, Required: true, Allowed: []string{}}
+	switch {
+	case isKnown && known.kind != "":
+		after.Kind = known.kind
+	case f.value == valueDuration:
+		after.Kind = kubecomplete.TokenDuration
+	case f.value == valueInt:
+		after.Kind = "integer"
+	}
+	desc.After = after
+	return desc
+}
+
+// mergeFlag keeps the role and value kind of a flag from the base spec,
+// and takes everything kubectl tells: whether the flag takes a value at
+// all, its aliases and its description
+func mergeFlag(desc, old kubecomplete.FlagDescriptor) kubecomplete.FlagDescriptor {
+	desc.Role = old.Role
+	desc.Required = old.Required
+	if desc.After != nil && old.After != nil {
+		after := *old.After
+		after.Value = ""
+		if after.Allowed == nil {
+			after.Allowed = []string{}
+		}
+		desc.After = &after
+	}
+	return desc
+}
+
+// positionalsFromUsage guesses the positionals of a command the base spec
+// does not know from its usage line
+func positionalsFromUsage(usage string) []kubecomplete.TokenDescriptor {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(usage, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r == '_' || r == '/')
+	}) {
+		words[w] = true
+	}
+	switch {
+	case words["TYPE/NAME"] || words["POD"]:
+		return []kubecomplete.TokenDescriptor{
+			{Kind: kubecomplete.TokenResourceTarget, Role: "target", Required: true, Allowed: []string{}},
+		}
+	case words["TYPE"]:
+		return []kubecomplete.TokenDescriptor{
+			{Kind: kubecomplete.TokenResourceType, Role: "type", Required: true, Allowed: []string{}},
+			{Kind: kubecomplete.TokenResourceName, Role: "name", Allowed: []string{}},
+		}
+	case words["NAME"]:
+		return []kubecomplete.TokenDescriptor{
+			{Kind: kubecomplete.TokenResourceName, Role: "name", Required: true, Allowed: []string{}},
+		}
+	case words["NODE"]:
+		return []kubecomplete.TokenDescriptor{
+			{Kind: "node-name", Role: "node", Required: true, Allowed: []string{}},
+		}
+	}
+	return nil
+}
+
+// allowedValues asks kubectl to complete the value of every flag that
+// takes one, and records the values it offers. The global flags complete
+// the same for every command, so they are asked about once.
+func (g *generator) allowedValues(ctx context.Context, specs []kubecomplete.CommandSpec) error {
+	type query struct {
+		path []string
+		flag string // long form
+	}
+	var queries []query
+	queried := make(map[string]bool)
+	for _, spec := range specs {
+		for _, f := range spec.Flags {
+			if f.After == nil {
+				continue
+			}
+			long := longName(f)
+			path := spec.Path
+			if !isLocalFlag(specs, spec, f.Primary) {
+				path = nil
+			}
+			key := strings.Join(path, " ") + " " + long
+			if !queried[key] {
+				queried[key] = true
+				queries = append(queries, query{path, long})
+			}
+		}
+	}
+
+	values := make([][]string, len(queries))
+	err := g.each(ctx, len(queries), func(i int) error {
+		args := append(append([]string{"__complete"}, queries[i].path...), queries[i].flag, "")
+		out, err := g.run(ctx, args...)
+		if err != nil {
+			// A flag kubectl cannot complete has no fixed values
+			return nil
+		}
+		for _, c := range parseCompletions(string(out)) {
+			values[i] = append(values[i], c.name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	byKey := make(map[string][]string)
+	for i, q := range queries {
+		if len(values[i]) > 0 {
+			byKey[strings.Join(q.path, " ")+" "+q.flag] = values[i]
+		}
+	}
+	for _, spec := range specs {
+		for primary, f := range spec.Flags {
+			if f.After == nil {
+				continue
+			}
+			allowed, ok := byKey[strings.Join(spec.Path, " ")+" "+longName(f)]
+			if !ok {
+				allowed, ok = byKey[" "+longName(f)]
+			}
+			if ok {
+				after := *f.After
+				after.Allowed = allowed
+				f.After = &after
+				spec.Flags[primary] = f
+			}
+		}
+	}
+	return nil
+}
+
+// isLocalFlag reports whether a flag belongs to the command rather than
+// to every command
+func isLocalFlag(specs []kubecomplete.CommandSpec, spec kubecomplete.CommandSpec, primary string) bool {
+	for _, other := range specs {
+		if _, ok := other.Flags[primary]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// longName returns the long form of a flag
+func longName(f kubecomplete.FlagDescriptor) string {
+	if strings.HasPrefix(f.Primary, "--") {
+		return f.Primary
+	}
+	for _, alias := range f.Aliases {
+		if strings.HasPrefix(alias, "--") {
+			return alias
+		}
+	}
+	return f.Primary
+}
+
+// each calls fn for 0 to n-1 on at most g.workers goroutines at once, and
+// returns the first error
+func (g *generator) each(ctx context.Context, n int, fn func(i int) error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, g.workers)
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(i); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
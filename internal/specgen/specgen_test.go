@@ -0,0 +1,210 @@
+package specgen
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tapcraft-io/purr/internal/kubecomplete"
+)
+
+// fakeKubectl answers with the output real kubectl gives, trimmed
+var fakeKubectl = map[string]string{
+	"version --client -o json": `{"clientVersion":{"major":"1","minor":"31","gitVersion":"v1.31.0"}}`,
+	"options": `The following options can be passed to any command:
+
+    --context='':
+	The name of the kubeconfig context to use
+
+    -n, --namespace='':
+	If present, the namespace scope for this CLI request
+
+    --request-timeout='0':
+	The length of time to wait before giving up on a single server request.
+`,
+	"--help": `kubectl controls the Kubernetes cluster manager.
+
+ Find more information at: https://kubernetes.io/docs/reference/kubectl/
+
+Basic Commands (Beginner):
+  get             Display one or many resources
+
+Deploy Commands:
+  rollout         Manage the rollout of a resource
+
+Other Commands:
+  frobnicate      Frobnicate resources
+  help            Help about any command
+
+Usage:
+  kubectl [flags] [options]
+`,
+	"get --help": `Display one or many resources.
+
+ Prints a table of the most important information about the specified resources.
+
+Examples:
+  # List all pods in ps output format
+  kubectl get pods
+
+Options:
+    -A, --all-namespaces=false:
+	If present, list the requested object(s) across all namespaces.
+
+    --allow-missing-template-keys=true:
+	If true, ignore any errors in templates when a field or map key is missing in the template.
+
+    -o, --output='':
+	Output format. One of: (json, yaml, name, wide).
+
+    -w, --watch=false:
+	After listing/getting the requested object, watch for changes.
+
+Usage:
+  kubectl get [(-o|--output=)json|yaml|name|wide] (TYPE[.VERSION][.GROUP] [NAME | -l label] | TYPE[.VERSION][.GROUP]/NAME ...) [flags] [options]
+
+Use "kubectl options" for a list of global command-line options (applies to all commands).
+`,
+	"rollout --help": `Manage the rollout of one or many resources.
+
+Available Commands:
+  restart       Restart a resource
+
+Usage:
+  kubectl rollout SUBCOMMAND [options]
+`,
+	"rollout restart --help": `Restart a resource.
+
+Options:
+    --field-manager='kubectl-rollout':
+	Name of the manager used to track field ownership.
+
+    --timeout=0s: The length of time to wait.
+
+Usage:
+  kubectl rollout restart RESOURCE [options]
+`,
+	"frobnicate --help": `Frobnicate resources.
+
+Options:
+    --level=3:
+	How hard to frobnicate.
+
+Usage:
+  kubectl frobnicate (TYPE NAME | TYPE/NAME) [options]
+`,
+	"__complete ":              "get\tDisplay one or many resources\nrollout\tManage the rollout of a resource\nfrobnicate\tFrobnicate resources\ncnpg\tThe command kubectl-cnpg is a plugin installed by the user\nhelp\tHelp about any command\n:4\n",
+	"__complete get --output ": "json\nyaml\nname\nwide\n:4\n",
+	"__complete --namespace ":  ":4\n",
+	"__complete --context ":    "_activeHelp_ no contexts\n:36\n",
+}
+
+func fakeRunner(ctx context.Context, args ...string) ([]byte, error) {
+	out, ok := fakeKubectl[strings.Join(args, " ")]
+	if !ok {
+		return nil, fmt.Errorf("kubectl %s: exit status 1", strings.Join(args, " "))
+	}
+	return []byte(out), nil
+}
+
+func TestGenerate(t *testing.T) {
+	base := &kubecomplete.RootSpec{Commands: []kubecomplete.CommandSpec{{
+		Path: []string{"get"},
+		Positionals: []kubecomplete.TokenDescriptor{
+			{Kind: kubecomplete.TokenResourceType, Role: "type", Required: true},
+		},
+		Flags: map[string]kubecomplete.FlagDescriptor{
+			"--allow-missing-template-keys": {Primary: "--allow-missing-template-keys", Role: "template",
+				After: &kubecomplete.TokenDescriptor{Kind: "template-string"}},
+			"-o": {Primary: "-o", Role: "output-format",
+				After: &kubecomplete.TokenDescriptor{Kind: kubecomplete.TokenOutput, Allowed: []string{"json"}}},
+		},
+	}}}
+
+	root, err := Generate(context.Background(), fakeRunner, Options{Base: base, Workers: 2})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if root.KubectlVersion != "v1.31.0" {
+		t.Errorf("KubectlVersion = %q, want v1.31.0", root.KubectlVersion)
+	}
+
+	commands := make(map[string]kubecomplete.CommandSpec)
+	var keys []string
+	for _, c := range root.Commands {
+		key := strings.Join(c.Path, " ")
+		commands[key] = c
+		keys = append(keys, key)
+	}
+	if want := []string{"cnpg", "frobnicate", "get", "rollout", "rollout restart"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("commands = %v, want %v", keys, want)
+	}
+
+	get := commands["get"]
+	if len(get.Positionals) != 1 || get.Positionals[0].Kind != kubecomplete.TokenResourceType {
+		t.Errorf("get positionals = %v, want the base ones", get.Positionals)
+	}
+	if !strings.HasPrefix(get.Description, "Display one or many resources. Prints a table") {
+		t.Errorf("get description = %q", get.Description)
+	}
+	if strings.HasSuffix(get.Synopsis, "[options]") || !strings.HasPrefix(get.Synopsis, "kubectl get ") {
+		t.Errorf("get synopsis = %q", get.Synopsis)
+	}
+
+	tests := []struct {
+		command, flag string
+		aliases       []string
+		kind          kubecomplete.TokenKind // "" for a flag without a value
+		allowed       []string
+	}{
+		{"get", "-A", []string{"--all-namespaces"}, "", nil},
+		{"get", "--allow-missing-template-keys", []string{}, "", nil}, // a bool, whatever the base says
+		{"get", "-o", []string{"--output"}, kubecomplete.TokenOutput, []string{"json", "yaml", "name", "wide"}},
+		{"get", "-n", []string{"--namespace"}, kubecomplete.TokenNamespace, []string{}},
+		{"get", "--context", []string{}, "context-name", []string{}},
+		{"get", "--request-timeout", []string{}, kubecomplete.TokenDuration, []string{}},
+		{"rollout restart", "--timeout", []string{}, kubecomplete.TokenDuration, []string{}},
+		{"rollout restart", "--field-manager", []string{}, kubecomplete.TokenOther, []string{}},
+		{"frobnicate", "--level", []string{}, "integer", []string{}},
+	}
+	for _, tt := range tests {
+		f, ok := commands[tt.command].Flags[tt.flag]
+		if !ok {
+			t.Errorf("%s has no flag %s", tt.command, tt.flag)
+			continue
+		}
+		if !reflect.DeepEqual(f.Aliases, tt.aliases) {
+			t.Errorf("%s %s aliases = %v, want %v", tt.command, tt.flag, f.Aliases, tt.aliases)
+		}
+		switch {
+		case tt.kind == "" && f.After != nil:
+			t.Errorf("%s %s takes a %s value, want none", tt.command, tt.flag, f.After.Kind)
+		case tt.kind != "" && f.After == nil:
+			t.Errorf("%s %s takes no value, want a %s", tt.command, tt.flag, tt.kind)
+		case tt.kind != "" && (f.After.Kind != tt.kind || !reflect.DeepEqual(f.After.Allowed, tt.allowed)):
+			t.Errorf("%s %s value = %s %v, want %s %v", tt.command, tt.flag, f.After.Kind, f.After.Allowed, tt.kind, tt.allowed)
+		}
+	}
+
+	frob := commands["frobnicate"]
+	if len(frob.Positionals) != 1 || frob.Positionals[0].Kind != kubecomplete.TokenResourceTarget {
+		t.Errorf("frobnicate positionals = %v, want a resource target from its usage", frob.Positionals)
+	}
+	if cnpg := commands["cnpg"]; !cnpg.Plugin || len(cnpg.Flags) != 0 {
+		t.Errorf("cnpg = %+v, want a plugin without flags", cnpg)
+	}
+	if len(commands["rollout"].Positionals) != 0 {
+		t.Errorf("rollout positionals = %v, want none for a parent command", commands["rollout"].Positionals)
+	}
+}
+
+func TestGenerate_KubectlFails(t *testing.T) {
+	failing := func(ctx context.Context, args ...string) ([]byte, error) {
+		return nil, fmt.Errorf("kubectl: not found")
+	}
+	if _, err := Generate(context.Background(), failing, Options{}); err == nil {
+		t.Error("Generate() with a failing kubectl error = nil, want an error")
+	}
+}
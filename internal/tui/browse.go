@@ -0,0 +1,298 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/internal/k8s"
+	"github.com/tapcraft-io/purr/internal/shlex"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// defaultBrowseResource is shown when no resource type is given
+const defaultBrowseResource = "pods"
+
+// browser is the state of browse mode, kept while the user runs commands
+// so returning to it lands on the same row
+type browser struct {
+	resource      string // resource type as the user named it
+	allNamespaces bool
+	table         k8s.Table
+	rows          []k8s.TableRow // table rows after filtering and sorting
+	cursor        int
+	sortCol       int // -1 keeps the cache's name order
+	sortDesc      bool
+	filter        textinput.Model
+	filtering     bool
+}
+
+// newBrowser creates an empty browser
+func newBrowser() browser {
+	fi := textinput.New()
+	fi.Prompt = "/"
+	fi.Placeholder = "filter"
+	return browser{sortCol: -1, filter: fi}
+}
+
+// showBrowser opens browse mode on a resource type. An empty type
+// reopens the last one browsed.
+func (m Model) showBrowser(resource string) (tea.Model, tea.Cmd) {
+	if m.cache == nil || !m.cache.IsReady() {
+		m.statusMsg = "Resources are not loaded yet"
+		return m, nil
+	}
+
+	if resource == "" {
+		resource = m.browse.resource
+	}
+	if resource == "" {
+		resource = defaultBrowseResource
+	}
+	if _, ok := m.cache.LookupResource(resource); !ok {
+		m.statusMsg = fmt.Sprintf("Unknown resource type %s", resource)
+		return m, nil
+	}
+
+	if resource != m.browse.resource {
+		m.browse.resource = resource
+		m.browse.cursor = 0
+		m.browse.sortCol = -1
+		m.browse.sortDesc = false
+		m.browse.filter.SetValue("")
+	}
+	m.mode = types.ModeBrowsing
+	m.statusMsg = ""
+	m.commandInput.Blur()
+	m = m.refreshBrowser()
+	return m.watchCache()
+}
+
+// browseNamespace is the namespace the browser lists, "" for all
+func (m Model) browseNamespace() string {
+	if m.browse.allNamespaces {
+		return ""
+	}
+	return m.namespace
+}
+
+// refreshBrowser reloads the table from the cache, keeping the cursor on
+// the same object when it still exists
+func (m Model) refreshBrowser() Model {
+	if m.cache == nil {
+		return m
+	}
+
+	selected, hasSelected := m.selectedRow()
+	table, ok := m.cache.Table(m.browse.resource, m.browseNamespace())
+	if !ok {
+		m.statusMsg = fmt.Sprintf("Unknown resource type %s", m.browse.resource)
+		table = k8s.Table{}
+	}
+	m.browse.table = table
+	m.browse.rows = filterRows(table.Sorted(m.browse.sortCol, m.browse.sortDesc), m.browse.filter.Value())
+
+	if hasSelected {
+		for i, row := range m.browse.rows {
+			if row.Name == selected.Name && row.Namespace == selected.Namespace {
+				m.browse.cursor = i
+				break
+			}
+		}
+	}
+	m.browse.cursor = clamp(m.browse.cursor, 0, len(m.browse.rows)-1)
+	return m
+}
+
+// filterRows keeps the rows where any cell contains the query,
+// ignoring case
+func filterRows(rows []k8s.TableRow, query string) []k8s.TableRow {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return rows
+	}
+	var out []k8s.TableRow
+	for _, row := range rows {
+		if strings.Contains(strings.ToLower(strings.Join(row.Cells, " ")), query) {
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+// selectedRow returns the row under the cursor
+func (m Model) selectedRow() (k8s.TableRow, bool) {
+	if m.browse.cursor < 0 || m.browse.cursor >= len(m.browse.rows) {
+		return k8s.TableRow{}, false
+	}
+	return m.browse.rows[m.browse.cursor], true
+}
+
+// browseRowsVisible is how many table rows fit on screen
+func (m Model) browseRowsVisible() int {
+	return max(m.height-9, 1)
+}
+
+// handleBrowsingMode handles key presses in browse mode
+func (m Model) handleBrowsingMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.browse.filtering {
+		switch msg.String() {
+		case "enter":
+			m.browse.filtering = false
+			m.browse.filter.Blur()
+			return m, nil
+		case "esc":
+			m.browse.filtering = false
+			m.browse.filter.Blur()
+			m.browse.filter.SetValue("")
+			return m.refreshBrowser(), nil
+		}
+		var cmd tea.Cmd
+		m.browse.filter, cmd = m.browse.filter.Update(msg)
+		m.browse.cursor = 0
+		return m.refreshBrowser(), cmd
+	}
+
+	page := m.browseRowsVisible()
+	switch key := msg.String(); key {
+	case "q":
+		m.mode = types.ModeTyping
+		m.commandInput.Focus()
+		return m, nil
+
+	case "up", "k":
+		m.browse.cursor = clamp(m.browse.cursor-1, 0, len(m.browse.rows)-1)
+	case "down", "j":
+		m.browse.cursor = clamp(m.browse.cursor+1, 0, len(m.browse.rows)-1)
+	case "pgup", "ctrl+u":
+		m.browse.cursor = clamp(m.browse.cursor-page, 0, len(m.browse.rows)-1)
+	case "pgdown", "ctrl+f":
+		m.browse.cursor = clamp(m.browse.cursor+page, 0, len(m.browse.rows)-1)
+	case "home", "g":
+		m.browse.cursor = 0
+	case "end", "G":
+		m.browse.cursor = max(len(m.browse.rows)-1, 0)
+
+	case "/":
+		m.browse.filtering = true
+		m.browse.filter.Focus()
+		return m, textinput.Blink
+
+	case "a":
+		m.browse.allNamespaces = !m.browse.allNamespaces
+		return m.refreshBrowser(), nil
+
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		col := int(key[0] - '1')
+		if col >= len(m.browse.table.Columns) {
+			return m, nil
+		}
+		if col == m.browse.sortCol {
+			m.browse.sortDesc = !m.browse.sortDesc
+		} else {
+			m.browse.sortCol, m.browse.sortDesc = col, false
+		}
+		return m.refreshBrowser(), nil
+
+	case "L":
+		row, ok := m.selectedRow()
+		if !ok {
+			return m, nil
+		}
+		args := []string{"logs", m.browse.table.Resource.CommandName() + "/" + row.Name}
+		if row.Namespace != "" {
+			args = append(args, "-n", row.Namespace)
+		}
+		return m.openMultiLog(shlex.Join(args))
+
+	case "enter", "d", "l", "e", "s", "ctrl+d":
+		args, err := m.browseAction(key)
+		if err != nil {
+			m.statusMsg = err.Error()
+			return m, nil
+		}
+		m.mode = types.ModeTyping
+		m.commandInput.Focus()
+		return m.submitCommand(shlex.Join(args))
+	}
+	return m, nil
+}
+
+// browseAction builds the kubectl command a row action key runs
+func (m Model) browseAction(key string) ([]string, error) {
+	row, ok := m.selectedRow()
+	if !ok {
+		return nil, fmt.Errorf("no %s selected", m.browse.resource)
+	}
+	info := m.browse.table.Resource
+	return rowCommand(key, info, row)
+}
+
+// rowCommand builds the kubectl command for an action on a table row
+func rowCommand(key string, info k8s.ResourceInfo, row k8s.TableRow) ([]string, error) {
+	kind := info.CommandName()
+
+	var args []string
+	switch key {
+	case "enter", "d":
+		args = []string{"describe", kind, row.Name}
+	case "e":
+		args = []string{"edit", kind, row.Name}
+	case "ctrl+d":
+		args = []string{"delete", kind, row.Name}
+	case "l":
+		if !hasLogs(info) {
+			return nil, fmt.Errorf("%s have no logs", info.Name)
+		}
+		args = []string{"logs", "-f", podTarget(info, row.Name)}
+		if info.Name != "pods" {
+			args = append(args, "--all-containers")
+		}
+	case "s":
+		if !hasLogs(info) {
+			return nil, fmt.Errorf("cannot exec into %s", info.Name)
+		}
+		args = []string{"exec", "-it", podTarget(info, row.Name)}
+	default:
+		return nil, fmt.Errorf("unknown action %s", key)
+	}
+
+	if info.Namespaced && row.Namespace != "" {
+		args = append(args, "-n", row.Namespace)
+	}
+	if key == "s" {
+		args = append(args, "--", "sh")
+	}
+	return args, nil
+}
+
+// hasLogs checks if kubectl logs and exec accept a resource: pods, and
+// the controllers kubectl resolves to one of their pods
+func hasLogs(info k8s.ResourceInfo) bool {
+	switch info.QualifiedName() {
+	case "pods", "deployments.apps", "statefulsets.apps", "daemonsets.apps", "replicasets.apps", "jobs.batch":
+		return true
+	}
+	return false
+}
+
+// podTarget names a pod-backed object the way logs and exec expect:
+// a bare pod name, or kind/name for controllers
+func podTarget(info k8s.ResourceInfo, name string) string {
+	if info.Name == "pods" {
+		return name
+	}
+	return info.Name + "/" + name
+}
+
+// clamp limits v to [lo, hi], preferring lo when the range is empty
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
@@ -0,0 +1,209 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/internal/exec"
+	"github.com/tapcraft-io/purr/internal/k8s"
+	"github.com/tapcraft-io/purr/internal/policy"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// previewTimeout bounds the server-side dry run behind a blast-radius
+// preview
+const previewTimeout = 15 * time.Second
+
+// confirmation is a command waiting for the user's go-ahead
+type confirmation struct {
+	command  string
+	isShell  bool
+	decision policy.Decision
+	input    textinput.Model // for typed confirmations
+	review   *diffReview     // an accepted diff waiting on the confirmation
+
+	// What the command would touch, filled in by previewBlastRadius
+	radius        blastRadius
+	cancelPreview context.CancelFunc
+}
+
+// blastRadius is what a command would touch if confirmed
+type blastRadius struct {
+	loading bool
+	done    bool
+	objects []string // kind/name, from a server-side dry run
+	drains  []k8s.DrainPlan
+	err     error
+}
+
+// blastRadiusMsg delivers the preview of a command awaiting confirmation
+type blastRadiusMsg struct {
+	command string
+	radius  blastRadius
+}
+
+// WithPolicy sets the rules deciding which commands need confirmation and
+// which are refused
+func (m Model) WithPolicy(p *policy.Policy) Model {
+	m.policy = p
+	return m
+}
+
+// checkPolicy decides what a prepared command needs before it runs.
+// Shell commands and plugins get no session flags, so they are judged by
+// where kubectl takes them: the kubeconfig's current context.
+func (m Model) checkPolicy(command string) policy.Decision {
+	session := policy.Session{Context: m.context, Namespace: m.namespace}
+	sessionFlags := !strings.HasPrefix(strings.TrimSpace(command), "!") &&
+		(m.executor == nil || !m.executor.RunsPlugin(command))
+	if !sessionFlags {
+		session.CurrentContext, _ = k8s.GetCurrentContext(m.kubeconfig)
+		session.CurrentNamespace, _ = k8s.ContextNamespace(m.kubeconfig, "")
+	}
+	_, d := m.policy.Check(session, exec.Assess(command), sessionFlags, m.confirmDestructive)
+	return d
+}
+
+// askConfirmation shows the confirmation prompt for a command, and starts
+// working out what it would touch
+func (m Model) askConfirmation(command string, isShell bool, d policy.Decision) (tea.Model, tea.Cmd) {
+	m.stopPreview()
+	m.confirm = confirmation{command: command, isShell: isShell, decision: d}
+	m.mode = types.ModeConfirming
+	m.commandInput.Blur()
+
+	var cmds []tea.Cmd
+	if preview := m.previewBlastRadius(); preview != nil {
+		cmds = append(cmds, preview)
+	}
+	if d.Action == policy.ActionTypeNamespace {
+		ti := textinput.New()
+		ti.Prompt = "> "
+		ti.Placeholder = d.Expect
+		ti.Focus()
+		m.confirm.input = ti
+		cmds = append(cmds, textinput.Blink)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// previewBlastRadius starts a server-side dry run of the pending command,
+// plus a drain plan from the cache for drains. It returns nil for commands
+// that cannot be previewed.
+func (m *Model) previewBlastRadius() tea.Cmd {
+	command := m.confirm.command
+	if m.confirm.isShell || m.executor == nil {
+		return nil
+	}
+	if _, ok := exec.PreviewArgs(command); !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), previewTimeout)
+	m.confirm.cancelPreview = cancel
+	m.confirm.radius.loading = true
+	executor, cache := m.executor, m.cache
+	return func() tea.Msg {
+		defer cancel()
+		r := blastRadius{done: true}
+		r.objects, r.err = executor.Preview(ctx, command)
+		if exec.Assess(command).Verb == "drain" && cache != nil {
+			for _, node := range drainedNodes(command, r.objects) {
+				if plan, err := cache.PlanDrain(node); err == nil {
+					r.drains = append(r.drains, plan)
+				}
+			}
+		}
+		return blastRadiusMsg{command: command, radius: r}
+	}
+}
+
+// drainedNodes returns the nodes a drain acts on: those its dry run
+// reported, or else the ones it names
+func drainedNodes(command string, objects []string) []string {
+	var nodes []string
+	for _, obj := range objects {
+		if name, ok := strings.CutPrefix(obj, "node/"); ok {
+			nodes = append(nodes, name)
+		}
+	}
+	if len(nodes) == 0 {
+		nodes = exec.Operands(command)
+	}
+	return nodes
+}
+
+// applyBlastRadius shows a finished preview if its command is still
+// waiting for confirmation
+func (m Model) applyBlastRadius(msg blastRadiusMsg) Model {
+	if m.mode != types.ModeConfirming || m.confirm.command != msg.command {
+		return m
+	}
+	m.confirm.radius = msg.radius
+	return m
+}
+
+// stopPreview abandons a preview still running
+func (m Model) stopPreview() {
+	if m.confirm.cancelPreview != nil {
+		m.confirm.cancelPreview()
+	}
+}
+
+// handleConfirmingMode handles the answer to a confirmation prompt
+func (m Model) handleConfirmingMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.confirm.decision.Action == policy.ActionTypeNamespace {
+		switch msg.String() {
+		case "enter":
+			if strings.TrimSpace(m.confirm.input.Value()) != m.confirm.decision.Expect {
+				m.statusMsg = "Type " + m.confirm.decision.Expect + " to confirm, or Esc to cancel"
+				return m, nil
+			}
+			return m.confirmed()
+		case "esc":
+			return m.cancelConfirmation(), nil
+		}
+		var cmd tea.Cmd
+		m.confirm.input, cmd = m.confirm.input.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "y", "Y":
+		return m.confirmed()
+	case "n", "N", "esc":
+		return m.cancelConfirmation(), nil
+	}
+	return m, nil
+}
+
+// confirmed runs the confirmed command
+func (m Model) confirmed() (tea.Model, tea.Cmd) {
+	pending := m.confirm
+	m.stopPreview()
+	m.confirm = confirmation{}
+	if pending.review != nil {
+		return m.applyReview(*pending.review)
+	}
+	m.mode = types.ModeTyping
+	m.commandInput.Focus()
+	m.statusMsg = "Executing command..."
+	return m.runCommand(pending.command, pending.isShell)
+}
+
+// cancelConfirmation drops the pending command
+func (m Model) cancelConfirmation() Model {
+	m.stopPreview()
+	if r := m.confirm.review; r != nil && r.file != "" {
+		_ = os.Remove(r.file)
+	}
+	m.confirm = confirmation{}
+	m.mode = types.ModeTyping
+	m.commandInput.Focus()
+	m.statusMsg = "Cancelled"
+	return m
+}
@@ -0,0 +1,208 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/internal/k8s"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// ConnectFunc builds a started cache for a kubeconfig context
+type ConnectFunc func(contextName string) (k8s.Cache, error)
+
+// pickerKind says what a selection in the resource list is for
+type pickerKind int
+
+const (
+	pickerInsert    pickerKind = iota // append the selection to the command
+	pickerContext                     // switch kubeconfig context
+	pickerNamespace                   // switch session namespace
+)
+
+// contextSwitchedMsg is sent once a new context's cache is ready
+type contextSwitchedMsg struct {
+	seq   int
+	name  string
+	cache k8s.Cache
+	err   error
+}
+
+// WithConnector enables context switching. Executed kubectl commands are
+// pinned to the model's context from then on.
+func (m Model) WithConnector(connect ConnectFunc) Model {
+	m.connect = connect
+	if m.executor != nil && m.context != "" && m.context != "unknown" {
+		m.executor.SetContext(m.context)
+	}
+	return m
+}
+
+// Cache returns the cache currently in use, which changes when the user
+// switches context
+func (m Model) Cache() k8s.Cache {
+	return m.cache
+}
+
+// showContextPicker lists the kubeconfig contexts
+func (m Model) showContextPicker() (tea.Model, tea.Cmd) {
+	if m.connect == nil {
+		m.statusMsg = "Context switching is not available"
+		return m, nil
+	}
+
+	contexts, err := k8s.ListContexts(m.kubeconfig)
+	if err != nil {
+		m.statusMsg = fmt.Sprintf("Could not read kubeconfig: %v", err)
+		return m, nil
+	}
+
+	items := make([]types.ListItem, len(contexts))
+	for i, c := range contexts {
+		ns := c.Namespace
+		if ns == "" {
+			ns = m.defaultNamespace
+		}
+		desc := fmt.Sprintf("Cluster: %s | User: %s | NS: %s", c.Cluster, c.User, ns)
+		if c.Name == m.context {
+			desc += " | active"
+		}
+		items[i] = types.ListItem{
+			Title:       c.Name,
+			Description: desc,
+			Metadata: map[string]string{
+				"cluster":   c.Cluster,
+				"user":      c.User,
+				"namespace": c.Namespace,
+			},
+		}
+	}
+
+	m.resourceList.Title = "Select Context"
+	m.resourceList.SetItems(convertToListItems(items))
+	m.picker = pickerContext
+	m.mode = types.ModeSelectingResource
+	return m, nil
+}
+
+// switchContext connects to a context in the background. The old cache
+// keeps serving completions until the new one is ready. A switch still
+// connecting is superseded.
+func (m Model) switchContext(name string) (tea.Model, tea.Cmd) {
+	m.mode = types.ModeTyping
+	m.commandInput.Focus()
+
+	if m.connect == nil {
+		m.statusMsg = "Context switching is not available"
+		return m, nil
+	}
+	m.switchSeq++
+	if name == m.context {
+		m.statusMsg = "Already using context " + name
+		return m, nil
+	}
+
+	m.statusMsg = fmt.Sprintf("Connecting to %s...", name)
+	connect, seq := m.connect, m.switchSeq
+	return m, func() tea.Msg {
+		cache, err := connect(name)
+		return contextSwitchedMsg{seq: seq, name: name, cache: cache, err: err}
+	}
+}
+
+// applyContextSwitch swaps in the new context's cache, unless a later
+// switch superseded it
+func (m Model) applyContextSwitch(msg contextSwitchedMsg) Model {
+	if msg.seq != m.switchSeq {
+		if msg.cache != nil {
+			go msg.cache.Stop()
+		}
+		return m
+	}
+	if msg.err != nil {
+		m.statusMsg = fmt.Sprintf("Could not switch to %s: %v", msg.name, msg.err)
+		return m
+	}
+
+	old := m.cache
+	m.cache = msg.cache
+	if m.completer != nil {
+		m.completer.Cache = msg.cache
+		m.completer.Schema = msg.cache
+	}
+	if m.executor != nil {
+		m.executor.SetContext(msg.name)
+	}
+	m.context = msg.name
+	// Forwards of the context left keep their pods and stop following it
+	m.resolveForwards()
+	m.watching = false
+	// Each context brings its own default namespace
+	m.setNamespace(m.contextNamespace(msg.name))
+	m.ready = msg.cache.IsReady()
+	m.statusMsg = "Switched to context " + msg.name
+
+	// Stopping waits for informer goroutines; don't block the UI on it
+	if old != nil {
+		go old.Stop()
+	}
+	return m
+}
+
+// contextNamespace returns the namespace a context selects, or the
+// configured default when it names none
+func (m Model) contextNamespace(name string) string {
+	contexts, err := k8s.ListContexts(m.kubeconfig)
+	if err != nil {
+		return m.defaultNamespace
+	}
+	for _, c := range contexts {
+		if c.Name == name && c.Namespace != "" {
+			return c.Namespace
+		}
+	}
+	return m.defaultNamespace
+}
+
+// runBuiltin handles ":" commands
+func (m Model) runBuiltin(input string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(strings.TrimPrefix(input, ":"))
+	if len(fields) == 0 {
+		m.statusMsg = "Empty built-in command"
+		return m, nil
+	}
+
+	m.commandInput.SetValue("")
+	switch fields[0] {
+	case "ctx", "context":
+		if len(fields) > 1 {
+			return m.switchContext(fields[1])
+		}
+		return m.showContextPicker()
+	case "ns", "namespace":
+		if len(fields) > 1 {
+			return m.switchNamespace(fields[1])
+		}
+		return m.showSessionNamespacePicker()
+	case "browse", "b":
+		resource := ""
+		if len(fields) > 1 {
+			resource = fields[1]
+		}
+		return m.showBrowser(resource)
+	case "logs":
+		return m.openMultiLog(input)
+	case "forwards", "pf":
+		return m.showForwards()
+	case "top":
+		kind := ""
+		if len(fields) > 1 {
+			kind = fields[1]
+		}
+		return m.showTop(kind)
+	default:
+		m.statusMsg = fmt.Sprintf("Unknown built-in command :%s", fields[0])
+		return m, nil
+	}
+}
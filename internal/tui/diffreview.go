@@ -0,0 +1,238 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/internal/diff"
+	"github.com/tapcraft-io/purr/internal/exec"
+	"github.com/tapcraft-io/purr/internal/policy"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// diffReview is a mutating command whose changes are shown before it runs
+type diffReview struct {
+	command  string
+	plan     exec.DiffPlan
+	decision policy.Decision // what the policy still requires once the diff is accepted
+	diff     string
+
+	// cancel abandons the diff or live fetch in flight, nil once it is
+	// done
+	cancel context.CancelFunc
+
+	// For edits: the live objects and the edited copy of them
+	live string
+	file string
+}
+
+// Messages of the diff review, each tagged with the command it is for
+type (
+	diffReadyMsg struct {
+		command string
+		diff    string
+		err     error
+	}
+	liveFetchedMsg struct {
+		command string
+		live    string
+		err     error
+	}
+	editorClosedMsg struct {
+		command string
+		err     error
+	}
+)
+
+// startDiffReview works out what a command would change before offering
+// to run it. Edits first fetch the live objects for the user to edit.
+// Either gives up after previewTimeout, or on Esc.
+func (m Model) startDiffReview(command string, plan exec.DiffPlan, d policy.Decision) (tea.Model, tea.Cmd) {
+	ctx, cancel := context.WithTimeout(context.Background(), previewTimeout)
+	m.review = diffReview{command: command, plan: plan, decision: d, cancel: cancel}
+	executor := m.executor
+
+	if plan.Verb == "edit" {
+		m.statusMsg = "Fetching " + plan.Target + "... [Esc] to abort"
+		return m, func() tea.Msg {
+			defer cancel()
+			live, err := executor.Live(ctx, plan)
+			return liveFetchedMsg{command: command, live: live, err: timedOut(ctx, err)}
+		}
+	}
+
+	m.statusMsg = "Working out what would change... [Esc] to abort"
+	return m, func() tea.Msg {
+		defer cancel()
+		d, err := executor.Diff(ctx, plan)
+		return diffReadyMsg{command: command, diff: d, err: timedOut(ctx, err)}
+	}
+}
+
+// timedOut explains an error caused by ctx running out
+func timedOut(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("no answer from the cluster within %s", previewTimeout)
+	}
+	return err
+}
+
+// editLive hands the terminal to the user's editor on a copy of the live
+// objects
+func (m Model) editLive(msg liveFetchedMsg) (tea.Model, tea.Cmd) {
+	if msg.command != m.review.command {
+		return m, nil
+	}
+	m.review.cancel = nil
+	if msg.err != nil {
+		return m.abortReview("Edit failed: " + msg.err.Error()), nil
+	}
+
+	f, err := os.CreateTemp("", "purr-edit-*.yaml")
+	if err != nil {
+		return m.abortReview("Edit failed: " + err.Error()), nil
+	}
+	_, err = f.WriteString(msg.live)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	m.review.live = msg.live
+	m.review.file = f.Name()
+	if err != nil {
+		return m.abortReview("Edit failed: " + err.Error()), nil
+	}
+
+	editor, err := exec.EditorCommand(f.Name())
+	if err != nil {
+		return m.abortReview("Edit failed: " + err.Error()), nil
+	}
+	m.statusMsg = "Editing " + m.review.plan.Target + "..."
+	command := msg.command
+	return m, tea.ExecProcess(editor, func(err error) tea.Msg {
+		return editorClosedMsg{command: command, err: err}
+	})
+}
+
+// diffEdit compares the edited copy with the live objects
+func (m Model) diffEdit(msg editorClosedMsg) (tea.Model, tea.Cmd) {
+	if msg.command != m.review.command {
+		return m, nil
+	}
+	if msg.err != nil {
+		return m.abortReview("Editor failed: " + msg.err.Error()), nil
+	}
+	edited, err := os.ReadFile(m.review.file)
+	if err != nil {
+		return m.abortReview("Edit failed: " + err.Error()), nil
+	}
+	target := m.review.plan.Target
+	d := diff.Unified(target+" (live)", target+" (edited)", m.review.live, string(edited))
+	return m.showDiff(diffReadyMsg{command: msg.command, diff: d})
+}
+
+// showDiff opens the review of a finished diff. A command that would
+// change nothing is not offered.
+func (m Model) showDiff(msg diffReadyMsg) (tea.Model, tea.Cmd) {
+	if msg.command != m.review.command {
+		return m, nil
+	}
+	m.review.cancel = nil
+	if msg.err != nil {
+		return m.abortReview("Diff failed: " + msg.err.Error()), nil
+	}
+	if strings.TrimSpace(msg.diff) == "" {
+		return m.abortReview("No changes, nothing to apply"), nil
+	}
+
+	m.review.diff = msg.diff
+	m.mode = types.ModeReviewingDiff
+	m.commandInput.Blur()
+	m.viewport.SetContent(colorizeDiff(m.shown(msg.diff)))
+	m.viewport.GotoTop()
+	m.statusMsg = ""
+	return m, nil
+}
+
+// handleReviewingDiffMode handles key presses while a diff is reviewed
+func (m Model) handleReviewingDiffMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "a", "y":
+		return m.acceptReview()
+	case "q", "n", "esc":
+		return m.abortReview("Aborted, nothing applied"), nil
+	}
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// acceptReview runs a reviewed command. Accepting the diff answers a plain
+// confirmation, but a policy demanding the namespace still asks for it.
+func (m Model) acceptReview() (tea.Model, tea.Cmd) {
+	r := m.review
+	m.review = diffReview{}
+	if r.decision.Action == policy.ActionTypeNamespace {
+		mm, cmd := m.askConfirmation(r.command, false, r.decision)
+		m = mm.(Model)
+		m.confirm.review = &r
+		return m, cmd
+	}
+	return m.applyReview(r)
+}
+
+// applyReview runs the command behind an accepted diff. An edit is applied
+// by replacing the objects with the edited copy.
+func (m Model) applyReview(r diffReview) (tea.Model, tea.Cmd) {
+	m.mode = types.ModeTyping
+	m.commandInput.Focus()
+	m.statusMsg = "Executing command..."
+	if m.executor == nil {
+		return m, nil
+	}
+
+	executor := m.executor
+	return m, func() tea.Msg {
+		var result *exec.ExecuteResult
+		if r.plan.Verb == "edit" {
+			result = executor.Execute(context.Background(), r.plan.ReplaceArgs(r.file))
+			_ = os.Remove(r.file)
+		} else {
+			result = executor.ExecuteString(context.Background(), r.command)
+		}
+		return commandResultMsg{result: result, cmd: r.command, diff: r.diff}
+	}
+}
+
+// abortReview drops the command under review
+func (m Model) abortReview(status string) Model {
+	if m.review.file != "" {
+		_ = os.Remove(m.review.file)
+	}
+	m.review = diffReview{}
+	m.mode = types.ModeTyping
+	m.commandInput.Focus()
+	m.statusMsg = status
+	return m
+}
+
+// colorizeDiff colours the lines of a unified diff
+func colorizeDiff(d string) string {
+	lines := strings.Split(strings.TrimSuffix(d, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "diff "):
+			lines[i] = highlightStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = infoStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = successStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = errorStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tapcraft-io/purr/internal/kubecomplete"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+const (
+	// explainMinWidth is the narrowest terminal the explain panel shows in,
+	// so the input keeps room to type
+	explainMinWidth = 100
+
+	// explainMaxLines bounds the text of the explain panel
+	explainMaxLines = 16
+)
+
+// explainWidth returns how wide the explain panel is, 0 when it is hidden
+func (m Model) explainWidth() int {
+	if !m.explain || m.width < explainMinWidth {
+		return 0
+	}
+	return min(64, m.width*2/5)
+}
+
+// renderExplainPanel documents what the word at the cursor is about, the
+// way kubectl explain does, or "" when there is nothing to tell. It is
+// worked out on every render, so a schema that finishes loading shows at
+// once.
+func (m Model) renderExplainPanel(width int) string {
+	if width == 0 || m.completer == nil {
+		return ""
+	}
+	input := []rune(m.commandInput.Value())
+	cursor := min(m.commandInput.Position(), len(input))
+	topic := m.completer.TopicAt(string(input), len(string(input[:cursor])))
+
+	var lines []string
+	var exp *types.Explanation
+	if topic.Resource != "" && m.cache != nil {
+		exp = m.cache.Explain(topic.Resource, topic.Field)
+		if exp == nil && len(topic.Field) > 0 {
+			// The last name is still being typed
+			exp = m.cache.Explain(topic.Resource, topic.Field[:len(topic.Field)-1])
+		}
+	}
+	switch {
+	case exp != nil && (len(exp.Path) > 0 || topic.Flag == nil):
+		lines = explainResource(exp)
+	case topic.Flag != nil:
+		lines = explainFlag(topic.Flag)
+	case topic.Command != nil:
+		lines = explainCommand(topic.Command)
+	default:
+		return ""
+	}
+
+	inner := width - 4 // border and padding
+	text := lipgloss.NewStyle().Width(inner).Render(strings.Join(lines, "\n"))
+	if wrapped := strings.Split(text, "\n"); len(wrapped) > explainMaxLines {
+		text = strings.Join(wrapped[:explainMaxLines-1], "\n") + "\n" + dimStyle.Render("…")
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorder).
+		Padding(0, 1).
+		Width(width - 2).
+		Render(text)
+}
+
+// explainLabel renders a heading of the explain panel
+func explainLabel(label string) string {
+	return highlightStyle.Render(label)
+}
+
+// explainResource documents a resource type or one of its fields
+func explainResource(exp *types.Explanation) []string {
+	version := exp.Version
+	if exp.Group != "" {
+		version = exp.Group + "/" + exp.Version
+	}
+	lines := []string{
+		explainLabel("KIND:    ") + exp.Kind,
+		explainLabel("VERSION: ") + version,
+	}
+	if len(exp.Path) > 0 {
+		lines = append(lines, explainLabel("FIELD:   ")+strings.Join(exp.Path, ".")+" "+dimStyle.Render("<"+exp.Type+">"))
+	}
+	if exp.Description != "" {
+		lines = append(lines, "", explainLabel("DESCRIPTION:"), exp.Description)
+	}
+	if len(exp.Fields) > 0 {
+		lines = append(lines, "", explainLabel("FIELDS:"))
+		for _, f := range exp.Fields {
+			lines = append(lines, "  "+f.Name+" "+dimStyle.Render("<"+f.Type+">"))
+		}
+	}
+	return lines
+}
+
+// explainFlag documents a flag of the command typed
+func explainFlag(f *kubecomplete.FlagDescriptor) []string {
+	names := append([]string{f.Primary}, f.Aliases...)
+	flag := strings.Join(names, ", ")
+	if f.After != nil {
+		value := f.After.Role
+		if value == "" {
+			value = string(f.After.Kind)
+		}
+		flag += " " + dimStyle.Render("<"+value+">")
+	}
+	lines := []string{explainLabel("FLAG: ") + flag}
+	if f.Description != "" {
+		lines = append(lines, "", f.Description)
+	}
+	if f.After != nil && len(f.After.Allowed) > 0 {
+		lines = append(lines, "", explainLabel("VALUES:"), strings.Join(f.After.Allowed, ", "))
+	}
+	return lines
+}
+
+// explainCommand documents the command typed
+func explainCommand(spec *kubecomplete.CommandSpec) []string {
+	name := "kubectl " + strings.Join(spec.Path, " ")
+	if spec.Plugin {
+		name += dimStyle.Render(" (plugin)")
+	}
+	lines := []string{explainLabel("COMMAND: ") + name}
+	if spec.Description != "" {
+		lines = append(lines, "", spec.Description)
+	}
+	if spec.Synopsis != "" {
+		lines = append(lines, "", explainLabel("USAGE:"), spec.Synopsis)
+	}
+	return lines
+}
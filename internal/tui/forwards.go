@@ -0,0 +1,270 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tapcraft-io/purr/internal/exec"
+	"github.com/tapcraft-io/purr/internal/k8s"
+	"github.com/tapcraft-io/purr/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// forwardView is the list of port-forwards running in the background
+type forwardView struct {
+	manager *exec.ForwardManager // nil without kubectl
+	file    string               // where the forwards are saved for the next start, "" to not save them
+	restore []exec.ForwardSpec   // saved forwards to start once the cache is ready
+	list    []exec.Forward       // as last reported by the manager
+	cursor  int
+}
+
+// forwardResolver pins the forwards of a context to pods from its cache.
+// Forwards of other contexts, and any started before the cache is ready,
+// leave the pod to kubectl.
+func forwardResolver(cache k8s.Cache, kubeContext string) exec.ForwardResolver {
+	return func(spec exec.ForwardSpec, current string) (string, []string, error) {
+		if cache == nil || !cache.IsReady() || spec.Context != kubeContext {
+			return "", spec.Ports, nil
+		}
+		return cache.ForwardTarget(spec.Namespace, spec.Target, spec.Ports, current)
+	}
+}
+
+// resolveForwards points the forwards at the current cache
+func (m Model) resolveForwards() {
+	if m.forwards.manager != nil && m.executor != nil {
+		m.forwards.manager.SetResolver(forwardResolver(m.cache, m.context))
+	}
+}
+
+// inSession fills in the session's context and namespace where a forward
+// names none
+func (m Model) inSession(spec exec.ForwardSpec) exec.ForwardSpec {
+	if spec.Context == "" {
+		spec.Context = m.context
+	}
+	if spec.Namespace == "" {
+		spec.Namespace = m.namespace
+	}
+	return spec
+}
+
+// startForward runs a port-forward in the background rather than in a
+// pane, where it would block or die with its pod
+func (m Model) startForward(spec exec.ForwardSpec) (tea.Model, tea.Cmd) {
+	if spec.Target == "" || len(spec.Ports) == 0 {
+		m.statusMsg = "Usage: port-forward TYPE/NAME [LOCAL_PORT:]REMOTE_PORT..."
+		return m, nil
+	}
+	m.resolveForwards()
+	m.forwards.manager.Start(m.inSession(spec))
+	m = m.refreshForwards().saveForwards()
+	m.commandInput.SetValue("")
+	m.suggestions = []string{"get", "describe", "logs", "apply", "delete", "exec", "create", "rollout", "scale"}
+	m.suggestionIndex = 0
+	m.commandInput.SetSuggestions(m.suggestions)
+	m.statusMsg = fmt.Sprintf("Forwarding %s in the background, [Alt+F] to list", spec.Target)
+	m, cmd := m.watchCache()
+	return m, cmd
+}
+
+// restoreForwards starts the forwards saved last time
+func (m Model) restoreForwards() (Model, tea.Cmd) {
+	if m.forwards.manager == nil || len(m.forwards.restore) == 0 {
+		return m, nil
+	}
+	m.resolveForwards()
+	for _, spec := range m.forwards.restore {
+		m.forwards.manager.Start(m.inSession(spec))
+	}
+	m.statusMsg = fmt.Sprintf("Restored %d port-forwards", len(m.forwards.restore))
+	m.forwards.restore = nil
+	m = m.refreshForwards()
+	return m.watchCache()
+}
+
+// saveForwards writes the forwards out to restore them next time
+func (m Model) saveForwards() Model {
+	if m.forwards.file == "" {
+		return m
+	}
+	specs := make([]exec.ForwardSpec, len(m.forwards.list))
+	for i, f := range m.forwards.list {
+		specs[i] = f.Spec
+	}
+	if err := exec.SaveForwards(m.forwards.file, specs); err != nil {
+		m.statusMsg = "Could not save port-forwards: " + err.Error()
+	}
+	return m
+}
+
+// refreshForwards takes in the forwards' latest state
+func (m Model) refreshForwards() Model {
+	if m.forwards.manager == nil {
+		return m
+	}
+	m.forwards.list = m.forwards.manager.List()
+	m.forwards.cursor = clamp(m.forwards.cursor, 0, len(m.forwards.list)-1)
+	return m
+}
+
+// syncForwards moves forwards whose pod the cache no longer has
+func (m Model) syncForwards() {
+	if m.forwards.manager != nil && len(m.forwards.list) > 0 {
+		m.forwards.manager.Sync()
+	}
+}
+
+// hasForwards checks if any forward needs cache events
+func (m Model) hasForwards() bool {
+	return len(m.forwards.list) > 0
+}
+
+// StopForwards ends the forwards running in the background and waits for
+// their kubectl processes to exit
+func (m Model) StopForwards() {
+	if m.forwards.manager != nil {
+		m.forwards.manager.Close()
+	}
+}
+
+// showForwards lists the port-forwards
+func (m Model) showForwards() (tea.Model, tea.Cmd) {
+	if m.forwards.manager == nil {
+		m.statusMsg = "kubectl is not available"
+		return m, nil
+	}
+	m = m.refreshForwards()
+	m.commandInput.Blur()
+	m.statusMsg = ""
+	m.mode = types.ModeViewingForwards
+	return m, nil
+}
+
+// handleViewingForwardsMode handles key presses in the forwards list
+func (m Model) handleViewingForwardsMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	last := len(m.forwards.list) - 1
+	switch msg.String() {
+	case "q":
+		m.mode = types.ModeTyping
+		m.commandInput.Focus()
+	case "up", "k":
+		m.forwards.cursor = clamp(m.forwards.cursor-1, 0, last)
+	case "down", "j":
+		m.forwards.cursor = clamp(m.forwards.cursor+1, 0, last)
+	case "x", "delete":
+		if m.forwards.cursor <= last {
+			f := m.forwards.list[m.forwards.cursor]
+			m.forwards.manager.Stop(f.ID)
+			m.statusMsg = "Stopped forwarding " + f.Spec.Target
+			m = m.refreshForwards().saveForwards()
+		}
+	case "X":
+		for _, f := range m.forwards.list {
+			m.forwards.manager.Stop(f.ID)
+		}
+		m.statusMsg = "Stopped every port-forward"
+		m = m.refreshForwards().saveForwards()
+	}
+	return m, nil
+}
+
+// renderViewingForwardsMode renders the forwards list
+func (m Model) renderViewingForwardsMode() string {
+	var b strings.Builder
+
+	// Title bar
+	title := RenderTitle("Purr", m.context, m.namespace)
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	b.WriteString(highlightStyle.Render(fmt.Sprintf("Port-forwards %d", len(m.forwards.list))))
+	b.WriteString("\n\n")
+
+	columns := []string{"STATE", "PORTS", "TARGET", "POD", "NAMESPACE", "RESTARTS", "AGE"}
+	rows := make([][]string, len(m.forwards.list))
+	for i, f := range m.forwards.list {
+		ports := strings.Join(f.Spec.Ports, ", ")
+		if len(f.Listening) > 0 {
+			ports = strings.Join(f.Listening, ", ")
+		}
+		pod := f.Pod
+		if pod == "" {
+			pod = "-"
+		}
+		target := f.Spec.Target
+		if f.Spec.Context != "" && f.Spec.Context != m.executor.Context() {
+			target = f.Spec.Context + ": " + target
+		}
+		rows[i] = []string{
+			string(f.State), ports, target, pod, f.Spec.Namespace,
+			strconv.Itoa(f.Restarts), duration.HumanDuration(time.Since(f.Since)),
+		}
+	}
+
+	const maxColWidth = 40
+	widths := make([]int, len(columns))
+	for i, col := range columns {
+		widths[i] = len(col)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = min(max(widths[i], len(cell)), maxColWidth)
+		}
+	}
+	lineWidth := max(m.width-4, 20)
+	formatRow := func(cells []string) string {
+		parts := make([]string, len(cells))
+		for i, cell := range cells {
+			parts[i] = padRight(truncate(cell, widths[i]), widths[i])
+		}
+		return truncate(strings.Join(parts, "  "), lineWidth)
+	}
+
+	headerStyle := lipgloss.NewStyle().Foreground(colorTextDim).Bold(true).Padding(0, 1)
+	b.WriteString(headerStyle.Render(formatRow(columns)))
+	b.WriteString("\n")
+	for i, row := range rows {
+		line := padRight(formatRow(row), lineWidth)
+		switch {
+		case i == m.forwards.cursor:
+			b.WriteString(selectedStyle.Render(line))
+		case m.forwards.list[i].State == exec.ForwardActive:
+			b.WriteString(normalStyle.Render(line))
+		default: // not listening
+			b.WriteString(normalStyle.Foreground(colorWarning).Render(line))
+		}
+		b.WriteString("\n")
+	}
+	if len(rows) == 0 {
+		b.WriteString(dimStyle.Render("  No port-forwards. Run kubectl port-forward to start one."))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	// Why the selected forward last went down
+	if m.forwards.cursor < len(m.forwards.list) {
+		f := m.forwards.list[m.forwards.cursor]
+		b.WriteString(promptStyle.Render("$ "))
+		b.WriteString(dimStyle.Render(f.Spec.String()))
+		b.WriteString("\n")
+		if f.Err != "" && f.State != exec.ForwardActive {
+			b.WriteString(errorStyle.Render(f.Err))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.statusMsg != "" {
+		b.WriteString(RenderInfo(m.statusMsg))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(RenderHelp("[↑↓] move  [x] stop  [X] stop all  [Esc] back"))
+
+	return b.String()
+}
@@ -2,85 +2,386 @@ package tui
 
 import (
 	"context"
-	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/internal/debuglog"
+	"github.com/tapcraft-io/purr/internal/exec"
+	"github.com/tapcraft-io/purr/internal/kubecomplete"
+	"github.com/tapcraft-io/purr/internal/shlex"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+const (
+	// kubectlCompleteDelay is how long typing has to pause before kubectl
+	// is asked, so a burst of keystrokes starts one process at most
+	kubectlCompleteDelay = 150 * time.Millisecond
+	// kubectlCompleteTimeout bounds one kubectl __complete run, which may
+	// ask the cluster
+	kubectlCompleteTimeout = 2 * time.Second
+	// kubectlCompleteTTL is how long completions are reused
+	kubectlCompleteTTL = 30 * time.Second
+	// kubectlCompleteCacheSize bounds how many prefixes are remembered
+	kubectlCompleteCacheSize = 200
+)
+
+// Cobra's completion directives, the :N that ends __complete output
+const (
+	directiveError = 1 << iota
+	directiveNoSpace
+	directiveNoFileComp
+	directiveFilterFileExt
+	directiveFilterDirs
 )
 
-// KubectlCompleter uses kubectl's native __complete command for suggestions
+// KubectlCompleter uses kubectl's native __complete command for what the
+// spec does not know: plugins and flags it lacks. Completions are cached
+// per prefix of whole words and filtered by the word being typed, so
+// typing on within a word does not run kubectl again. Plugins that answer
+// __complete themselves are asked directly. Both run through the
+// executor, with its kubectl and the session's kubeconfig.
 type KubectlCompleter struct {
-	timeout time.Duration
+	executor *exec.Executor
+
+	mu       sync.Mutex
+	cache    map[string]kubectlCompletions
+	supports map[string]bool // whether a plugin executable answers __complete
 }
 
-// NewKubectlCompleter creates a new completer that delegates to kubectl
-func NewKubectlCompleter() *KubectlCompleter {
+// NewKubectlCompleter creates a new completer that delegates to the
+// executor's kubectl
+func NewKubectlCompleter(executor *exec.Executor) *KubectlCompleter {
 	return &KubectlCompleter{
-		timeout: 500 * time.Millisecond, // Fast timeout for responsive UX
+		executor: executor,
+		cache:    make(map[string]kubectlCompletions),
+		supports: make(map[string]bool),
 	}
 }
 
-// Complete gets completions from kubectl's native completion system
-func (k *KubectlCompleter) Complete(input string) []string {
-	// kubectl __complete expects the command and an empty string for word to complete
-	ctx, cancel := context.WithTimeout(context.Background(), k.timeout)
-	defer cancel()
+// kubectlQuery is what to ask kubectl to complete for an input
+type kubectlQuery struct {
+	key     string   // cache key: the words before the one typed, and its flag part
+	args    []string // the arguments to __complete, with the session's context and namespace
+	words   []string // the whole words typed, without "kubectl"
+	partial string   // the word being typed, completions are filtered by it
+
+	plugin     string   // the executable of the plugin being typed, if any
+	pluginArgs []string // the __complete arguments for the plugin itself
+}
+
+// kubectlCompletions is what kubectl offered for a query
+type kubectlCompletions struct {
+	values  []string
+	noSpace bool // accepting a value should not add a space (e.g. deployment/)
+	fetched time.Time
+}
 
-	cmd := exec.CommandContext(ctx, "kubectl", "__complete", input, "")
-	output, err := cmd.Output()
+// newKubectlQuery builds the query for an input in a kubeconfig context
+// and namespace. It returns false for input kubectl cannot complete.
+func newKubectlQuery(input, kubeContext, namespace string) (kubectlQuery, bool) {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" || strings.HasPrefix(trimmed, "!") || strings.HasPrefix(trimmed, ":") {
+		return kubectlQuery{}, false
+	}
+	toks, err := shlex.Split(input)
 	if err != nil {
-		// Fallback to our basic heuristics if kubectl fails
-		return nil
+		return kubectlQuery{}, false
+	}
+	words := shlex.Values(toks)
+	if len(words) > 0 && words[0] == "kubectl" {
+		words = words[1:]
 	}
 
-	lines := strings.Split(string(output), "\n")
-	var suggestions []string
+	partial := ""
+	if n := len(toks); n > 0 && toks[n-1].End == len(input) && len(words) > 0 {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, ":") {
-			// Skip empty lines and completion directives (e.g., ":4")
-			continue
+	// Cobra lists flags only for a word starting with -, and the values of
+	// --flag= only for that exact prefix; anything else it filters itself
+	toComplete := ""
+	if strings.HasPrefix(partial, "-") {
+		toComplete = "-"
+		if name, _, ok := strings.Cut(partial, "="); ok {
+			toComplete = name + "="
 		}
-		suggestions = append(suggestions, line)
 	}
 
-	return suggestions
+	// Words typed later win over the session's, as with any kubectl flag
+	var args []string
+	if kubeContext != "" {
+		args = append(args, "--context", kubeContext)
+	}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	args = append(args, words...)
+	args = append(args, toComplete)
+	return kubectlQuery{
+		key:     kubeContext + "\x00" + namespace + "\x00" + strings.Join(words, "\x00") + "\x00" + toComplete,
+		args:    args,
+		words:   words,
+		partial: partial,
+	}, true
 }
 
-// GetFullSuggestions returns full command suggestions for textinput
-func (k *KubectlCompleter) GetFullSuggestions(input string) []string {
-	completions := k.Complete(input)
-	if len(completions) == 0 {
-		return nil
-	}
+// forPlugin directs a query to a plugin executable, which takes the words
+// after its own command
+func (q kubectlQuery) forPlugin(file string, pathLen int) kubectlQuery {
+	q.plugin = file
+	q.key = file + "\x00" + q.key
+	q.pluginArgs = append([]string{"__complete"}, q.words[pathLen:]...)
+	q.pluginArgs = append(q.pluginArgs, q.args[len(q.args)-1])
+	return q
+}
 
-	var fullSuggestions []string
-	trimmed := strings.TrimSpace(input)
+// Cached returns the completions for a query if they are fresh
+func (k *KubectlCompleter) Cached(q kubectlQuery) (kubectlCompletions, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	c, ok := k.cache[q.key]
+	if !ok || time.Since(c.fetched) > kubectlCompleteTTL {
+		return kubectlCompletions{}, false
+	}
+	return c, true
+}
 
-	// Determine if we're completing the current token or adding a new one
-	hasTrailingSpace := len(input) > 0 && input[len(input)-1] == ' '
+// Complete runs __complete for a query, on the plugin if it answers it and
+// otherwise on kubectl, and caches what it offers
+func (k *KubectlCompleter) Complete(ctx context.Context, q kubectlQuery) (kubectlCompletions, error) {
+	var out []byte
+	var err error
+	if q.plugin != "" && k.supportsComplete(q.plugin) {
+		out, err = k.executor.PluginCommand(ctx, q.plugin, q.pluginArgs).Output()
+	}
+	if out == nil || err != nil {
+		out, err = k.executor.CompleteCommand(ctx, q.args).Output()
+	}
+	if err != nil {
+		return kubectlCompletions{}, err
+	}
+	c := parseKubectlCompletions(string(out))
+	c.fetched = time.Now()
 
-	if hasTrailingSpace {
-		// Append suggestions as new tokens
-		for _, comp := range completions {
-			fullSuggestions = append(fullSuggestions, trimmed+" "+comp)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.cache) >= kubectlCompleteCacheSize {
+		for key, old := range k.cache {
+			if time.Since(old.fetched) > kubectlCompleteTTL {
+				delete(k.cache, key)
+			}
 		}
-	} else {
-		// Replace last token with suggestion
-		parts := strings.Fields(trimmed)
-		if len(parts) == 0 {
-			return completions
+		if len(k.cache) >= kubectlCompleteCacheSize {
+			k.cache = make(map[string]kubectlCompletions)
 		}
+	}
+	k.cache[q.key] = c
+	return c, nil
+}
 
-		prefix := ""
-		if len(parts) > 1 {
-			prefix = strings.Join(parts[:len(parts)-1], " ") + " "
+// supportsComplete reports whether a plugin answers __complete, reading
+// each executable once
+func (k *KubectlCompleter) supportsComplete(file string) bool {
+	k.mu.Lock()
+	ok, known := k.supports[file]
+	k.mu.Unlock()
+	if known {
+		return ok
+	}
+	ok = kubecomplete.SupportsComplete(file)
+	k.mu.Lock()
+	k.supports[file] = ok
+	k.mu.Unlock()
+	return ok
+}
+
+// parseKubectlCompletions reads __complete output: one value per line,
+// with an optional tab and description, then the directive. File
+// extensions and directories to filter by are not values, nor is active
+// help, and an error directive leaves nothing.
+func parseKubectlCompletions(out string) kubectlCompletions {
+	var c kubectlCompletions
+	directive := 0
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, ":") {
+			directive, _ = strconv.Atoi(line[1:])
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "_activeHelp_") {
+			continue
 		}
+		value, _, _ := strings.Cut(line, "\t")
+		c.values = append(c.values, value)
+	}
+	if directive&(directiveError|directiveFilterFileExt|directiveFilterDirs) != 0 {
+		c.values = nil
+	}
+	c.noSpace = directive&directiveNoSpace != 0
+	return c
+}
 
-		for _, comp := range completions {
-			fullSuggestions = append(fullSuggestions, prefix+comp)
+// Matching returns the values that continue the word being typed
+func (c kubectlCompletions) Matching(q kubectlQuery) []string {
+	var out []string
+	for _, v := range c.values {
+		if strings.HasPrefix(v, q.partial) {
+			out = append(out, v)
 		}
 	}
+	return out
+}
+
+// nativeCompletion tracks the kubectl __complete run behind the input
+type nativeCompletion struct {
+	completer *KubectlCompleter
+	seq       int                // bumped on every change of input
+	input     string             // of the run in flight
+	cancel    context.CancelFunc // of the run in flight
+}
+
+// Messages of kubectl completion
+type (
+	// kubectlCompleteTickMsg fires once typing paused
+	kubectlCompleteTickMsg struct{ seq int }
+	// kubectlCompleteMsg delivers what kubectl completed for an input
+	kubectlCompleteMsg struct {
+		seq         int
+		input       string
+		completions kubectlCompletions
+		err         error
+	}
+)
+
+// kubectlQuery builds the query for an input, directed to the plugin
+// being typed if there is one
+func (m *Model) kubectlQuery(input string) (kubectlQuery, bool) {
+	q, ok := newKubectlQuery(input, m.context, m.namespace)
+	if !ok || m.completer == nil {
+		return q, ok
+	}
+	if cmd, n := m.completer.Registry.MatchCommand(q.words); cmd != nil && cmd.PluginFile != "" {
+		q = q.forPlugin(cmd.PluginFile, n)
+	}
+	return q, true
+}
+
+// wantsKubectlCompletion reports whether the spec falls short for the
+// input: it suggested nothing, does not know the command or only that it
+// is a plugin, or a flag is being typed that it may lack
+func (m *Model) wantsKubectlCompletion(q kubectlQuery) bool {
+	if m.native.completer == nil {
+		return false
+	}
+	if len(m.suggestions) == 0 || strings.HasPrefix(q.partial, "-") || q.plugin != "" {
+		return true
+	}
+	if len(q.words) == 0 || m.completer == nil {
+		return false
+	}
+	cmd, _ := m.completer.Registry.MatchCommand(q.words)
+	return cmd == nil || cmd.Spec.Plugin
+}
+
+// completeWithKubectl adds kubectl's completions of the input to the
+// suggestions: at once when they are cached, otherwise once typing
+// pauses. A run for older input is cancelled; one for the same input is
+// left to finish.
+func (m Model) completeWithKubectl() (Model, tea.Cmd) {
+	input := m.commandInput.Value()
+	q, ok := m.kubectlQuery(input)
+	wanted := ok && m.wantsKubectlCompletion(q)
+	if wanted && m.native.cancel != nil && m.native.input == input {
+		return m, nil
+	}
+
+	m.native.seq++
+	if m.native.cancel != nil {
+		m.native.cancel()
+		m.native.cancel = nil
+	}
+	if !wanted {
+		return m, nil
+	}
+	if c, ok := m.native.completer.Cached(q); ok {
+		m.mergeKubectlCompletions(q, c)
+		return m, nil
+	}
+	seq := m.native.seq
+	return m, tea.Tick(kubectlCompleteDelay, func(time.Time) tea.Msg {
+		return kubectlCompleteTickMsg{seq: seq}
+	})
+}
+
+// startKubectlComplete runs kubectl __complete once typing paused on the
+// same input
+func (m Model) startKubectlComplete(msg kubectlCompleteTickMsg) (Model, tea.Cmd) {
+	if msg.seq != m.native.seq || m.mode != types.ModeTyping {
+		return m, nil
+	}
+	input := m.commandInput.Value()
+	q, ok := m.kubectlQuery(input)
+	if !ok {
+		return m, nil
+	}
 
-	return fullSuggestions
+	ctx, cancel := context.WithTimeout(context.Background(), kubectlCompleteTimeout)
+	m.native.input = input
+	m.native.cancel = cancel
+	completer := m.native.completer
+	return m, func() tea.Msg {
+		defer cancel()
+		c, err := completer.Complete(ctx, q)
+		return kubectlCompleteMsg{seq: msg.seq, input: input, completions: c, err: err}
+	}
+}
+
+// applyKubectlComplete merges kubectl's completions if the input is still
+// the one they were asked for
+func (m Model) applyKubectlComplete(msg kubectlCompleteMsg) Model {
+	if msg.seq != m.native.seq || msg.input != m.commandInput.Value() {
+		return m
+	}
+	m.native.cancel = nil
+	if msg.err != nil {
+		debuglog.Printf("kubectl __complete: %v", msg.err)
+		return m
+	}
+	q, ok := m.kubectlQuery(msg.input)
+	if !ok {
+		return m
+	}
+	m.mergeKubectlCompletions(q, msg.completions)
+	return m
+}
+
+// mergeKubectlCompletions appends the completions the spec did not
+// suggest after the spec's own
+func (m *Model) mergeKubectlCompletions(q kubectlQuery, c kubectlCompletions) {
+	seen := make(map[string]bool, len(m.suggestions))
+	for _, s := range m.suggestions {
+		seen[s] = true
+	}
+	added := false
+	for _, v := range c.Matching(q) {
+		if seen[v] || len(m.suggestions) >= maxSuggestions {
+			continue
+		}
+		seen[v] = true
+		m.suggestions = append(m.suggestions, v)
+		if c.noSpace {
+			if m.noSpace == nil {
+				m.noSpace = make(map[string]bool)
+			}
+			m.noSpace[v] = true
+		}
+		added = true
+	}
+	if added {
+		m.setInputSuggestions()
+	}
 }
@@ -0,0 +1,419 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tapcraft-io/purr/internal/exec"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// logInputKind says what the log viewer's input line is editing
+type logInputKind int
+
+const (
+	logInputNone    logInputKind = iota
+	logInputSearch               // regex to highlight
+	logInputInclude              // regex lines must match
+	logInputExclude              // regex lines must not match
+)
+
+// logViewer is the state of the log viewer. The stream itself runs in a
+// pane, so it keeps going after the viewer is closed.
+type logViewer struct {
+	paneID   int
+	source   exec.LogsCommand
+	viewport viewport.Model
+
+	search  *regexp.Regexp
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+
+	paused  bool
+	pending int // lines received while paused
+	wrap    bool
+
+	input     textinput.Model
+	inputKind logInputKind
+
+	matches  []int // viewport lines holding a search match
+	matchIdx int
+}
+
+// newLogViewer creates an idle log viewer
+func newLogViewer() logViewer {
+	ti := textinput.New()
+	return logViewer{
+		paneID:   -1,
+		viewport: viewport.New(80, 20),
+		input:    ti,
+	}
+}
+
+// openLogViewer starts streaming a logs command into a new pane and shows
+// it in the log viewer
+func (m Model) openLogViewer(source exec.LogsCommand) (tea.Model, tea.Cmd) {
+	if source.Namespace == "" && source.Target != "" {
+		source.Namespace = m.namespace
+	}
+	source.Follow = !source.Previous
+
+	viewer := newLogViewer()
+	viewer.source = source
+	viewer.wrap = m.logs.wrap
+	m.logs = viewer
+
+	m.commandInput.SetValue("")
+	m.commandInput.Blur()
+	m.statusMsg = ""
+	m.mode = types.ModeViewingLogs
+	m = m.resizeLogViewer()
+	return m.startLogStream()
+}
+
+// showPaneLogs opens the log viewer on a pane that is already streaming
+func (m Model) showPaneLogs(index int) (tea.Model, tea.Cmd) {
+	pane := m.panes[index]
+	source, _ := exec.ParseLogsCommand(pane.Command)
+
+	if m.logs.paneID != pane.ID {
+		viewer := newLogViewer()
+		viewer.source = source
+		viewer.paneID = pane.ID
+		viewer.wrap = m.logs.wrap
+		m.logs = viewer
+	}
+
+	m.commandInput.Blur()
+	m.mode = types.ModeViewingLogs
+	m = m.resizeLogViewer()
+	m = m.refreshLogViewer()
+	m.logs.viewport.GotoBottom()
+	return m, nil
+}
+
+// startLogStream replaces the viewer's stream with one for its current
+// source. Changing container, --previous or --timestamps needs a new
+// kubectl process.
+func (m Model) startLogStream() (tea.Model, tea.Cmd) {
+	if m.executor == nil {
+		m.statusMsg = "kubectl is not available"
+		return m, nil
+	}
+	if idx := m.findPaneByID(m.logs.paneID); idx >= 0 {
+		m.removePane(idx)
+	}
+
+	command := m.logs.source.String()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.logs.paneID = m.createPane(command, cancel)
+	m.logs.paused, m.logs.pending = false, 0
+	m.lastCmd = command
+	m = m.refreshLogViewer()
+	m.logs.viewport.GotoBottom()
+	return m, m.executor.ExecuteStreaming(ctx, command, m.logs.paneID)
+}
+
+// resizeLogViewer fits the viewport to the window
+func (m Model) resizeLogViewer() Model {
+	m.logs.viewport.Width = max(m.width-2, 20)
+	m.logs.viewport.Height = max(m.height-8, 3)
+	return m
+}
+
+// logPane returns the pane backing the viewer
+func (m Model) logPane() (PaneData, bool) {
+	idx := m.findPaneByID(m.logs.paneID)
+	if idx < 0 {
+		return PaneData{}, false
+	}
+	return m.panes[idx], true
+}
+
+// refreshLogViewer re-renders the buffered lines through the filters,
+// staying at the bottom if the user was following there
+func (m Model) refreshLogViewer() Model {
+	pane, ok := m.logPane()
+	if !ok {
+		m.logs.viewport.SetContent("")
+		return m
+	}
+
+	follow := m.logs.viewport.AtBottom()
+	content, matches := renderLogLines(pane.Output.Lines(), m.logs, m.logs.viewport.Width)
+	m.logs.viewport.SetContent(content)
+	m.logs.matches = matches
+	if m.logs.matchIdx >= len(matches) {
+		m.logs.matchIdx = 0
+	}
+	if follow {
+		m.logs.viewport.GotoBottom()
+	}
+	return m
+}
+
+// renderLogLines filters, highlights and wraps log lines. It returns the
+// viewport content and the viewport lines holding search matches.
+func renderLogLines(lines []string, lv logViewer, width int) (string, []int) {
+	var (
+		b       strings.Builder
+		matches []int
+		row     int
+	)
+	wrapStyle := lipgloss.NewStyle().Width(width)
+
+	for _, line := range lines {
+		if lv.include != nil && !lv.include.MatchString(line) {
+			continue
+		}
+		if lv.exclude != nil && lv.exclude.MatchString(line) {
+			continue
+		}
+
+		if !lv.wrap {
+			line = truncate(line, width)
+		}
+		if lv.search != nil && lv.search.MatchString(line) {
+			matches = append(matches, row)
+			line = lv.search.ReplaceAllStringFunc(line, func(s string) string {
+				return highlightStyle.Reverse(true).Render(s)
+			})
+		}
+		if lv.wrap {
+			line = wrapStyle.Render(line)
+		}
+
+		if row > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line)
+		row += strings.Count(line, "\n") + 1
+	}
+	return b.String(), matches
+}
+
+// handleViewingLogsMode handles key presses in the log viewer
+func (m Model) handleViewingLogsMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.logs.inputKind != logInputNone {
+		return m.handleLogInput(msg)
+	}
+
+	switch msg.String() {
+	case "q":
+		m.mode = types.ModeTyping
+		m.commandInput.Focus()
+		return m, nil
+
+	case "/":
+		return m.startLogInput(logInputSearch, m.logs.search)
+	case "i":
+		return m.startLogInput(logInputInclude, m.logs.include)
+	case "x":
+		return m.startLogInput(logInputExclude, m.logs.exclude)
+
+	case "n", "N":
+		if len(m.logs.matches) == 0 {
+			return m, nil
+		}
+		if msg.String() == "n" {
+			m.logs.matchIdx = (m.logs.matchIdx + 1) % len(m.logs.matches)
+		} else {
+			m.logs.matchIdx = (m.logs.matchIdx - 1 + len(m.logs.matches)) % len(m.logs.matches)
+		}
+		m.logs.paused = true
+		m.logs.viewport.SetYOffset(m.logs.matches[m.logs.matchIdx])
+		return m, nil
+
+	case " ", "f":
+		m.logs.paused = !m.logs.paused
+		if !m.logs.paused {
+			m.logs.pending = 0
+			m = m.refreshLogViewer()
+			m.logs.viewport.GotoBottom()
+		}
+		return m, nil
+
+	case "w":
+		m.logs.wrap = !m.logs.wrap
+		return m.refreshLogViewer(), nil
+
+	case "t", "p", "c":
+		if pane, ok := m.logPane(); ok && pane.Tail != nil {
+			m.statusMsg = "Not available for multi-pod logs"
+			return m, nil
+		}
+		switch msg.String() {
+		case "t":
+			m.logs.source.Timestamps = !m.logs.source.Timestamps
+		case "p":
+			m.logs.source.Previous = !m.logs.source.Previous
+			m.logs.source.Follow = !m.logs.source.Previous
+		case "c":
+			return m.nextLogContainer()
+		}
+		return m.startLogStream()
+
+	case "G", "end":
+		m.logs.viewport.GotoBottom()
+		return m, nil
+
+	case "g", "home":
+		m.logs.paused = true
+		m.logs.viewport.GotoTop()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.logs.viewport, cmd = m.logs.viewport.Update(msg)
+	return m, cmd
+}
+
+// startLogInput opens the input line to edit a search or filter
+func (m Model) startLogInput(kind logInputKind, current *regexp.Regexp) (tea.Model, tea.Cmd) {
+	m.logs.inputKind = kind
+	switch kind {
+	case logInputSearch:
+		m.logs.input.Prompt = "search: "
+	case logInputInclude:
+		m.logs.input.Prompt = "include: "
+	case logInputExclude:
+		m.logs.input.Prompt = "exclude: "
+	}
+	m.logs.input.SetValue("")
+	if current != nil {
+		m.logs.input.SetValue(current.String())
+	}
+	m.logs.input.CursorEnd()
+	m.logs.input.Focus()
+	return m, textinput.Blink
+}
+
+// handleLogInput handles keys while the input line is open. An empty
+// pattern clears the search or filter.
+func (m Model) handleLogInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.logs.inputKind = logInputNone
+		m.logs.input.Blur()
+		return m, nil
+
+	case "enter":
+		var re *regexp.Regexp
+		if pattern := m.logs.input.Value(); pattern != "" {
+			var err error
+			if re, err = regexp.Compile(pattern); err != nil {
+				m.statusMsg = fmt.Sprintf("Invalid pattern: %v", err)
+				return m, nil
+			}
+		}
+
+		kind := m.logs.inputKind
+		switch kind {
+		case logInputSearch:
+			m.logs.search = re
+			m.logs.matchIdx = 0
+		case logInputInclude:
+			m.logs.include = re
+		case logInputExclude:
+			m.logs.exclude = re
+		}
+		m.logs.inputKind = logInputNone
+		m.logs.input.Blur()
+		m.statusMsg = ""
+		m = m.refreshLogViewer()
+
+		// Jump to the first match at or below the top of the screen
+		if kind == logInputSearch && len(m.logs.matches) > 0 {
+			for i, row := range m.logs.matches {
+				if row >= m.logs.viewport.YOffset {
+					m.logs.matchIdx = i
+					break
+				}
+			}
+			m.logs.paused = true
+			m.logs.viewport.SetYOffset(m.logs.matches[m.logs.matchIdx])
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.logs.input, cmd = m.logs.input.Update(msg)
+	return m, cmd
+}
+
+// nextLogContainer switches the stream to the target's next container
+func (m Model) nextLogContainer() (tea.Model, tea.Cmd) {
+	if m.cache == nil || m.logs.source.Target == "" {
+		m.statusMsg = "No container list for this stream"
+		return m, nil
+	}
+
+	kind, name := m.logs.source.TargetKind()
+	containers := m.cache.Containers(m.logs.source.Namespace, kind, name)
+	if len(containers) < 2 {
+		m.statusMsg = "Only one container"
+		return m, nil
+	}
+
+	next := 0
+	for i, c := range containers {
+		if c == m.logs.source.Container {
+			next = (i + 1) % len(containers)
+			break
+		}
+	}
+	m.logs.source.Container = containers[next]
+	m.statusMsg = "Container " + containers[next]
+	return m.startLogStream()
+}
+
+// logStreamOutput updates the viewer when its pane receives output
+func (m Model) logStreamOutput(paneID int) Model {
+	if m.mode != types.ModeViewingLogs || paneID != m.logs.paneID {
+		return m
+	}
+	if m.logs.paused {
+		m.logs.pending++
+		return m
+	}
+	return m.refreshLogViewer()
+}
+
+// logTitle is the command shown above the log viewer
+func (m Model) logTitle() string {
+	if pane, ok := m.logPane(); ok && pane.Tail != nil {
+		return pane.Command
+	}
+	return m.logs.source.String()
+}
+
+// logStatus describes the stream for the viewer's header
+func (m Model) logStatus() string {
+	pane, ok := m.logPane()
+	switch {
+	case !ok:
+		return "closed"
+	case pane.Status == types.PaneStatusError:
+		return fmt.Sprintf("ended with code %d", pane.ExitCode)
+	case pane.Status == types.PaneStatusCompleted:
+		return "ended"
+	case m.logs.paused && m.logs.pending > 0:
+		return fmt.Sprintf("paused (+%d updates)", m.logs.pending)
+	case m.logs.paused:
+		return "paused"
+	case pane.Tail != nil:
+		if n := len(pane.Tail.stream.Sources()); n != 1 {
+			return fmt.Sprintf("following %d containers", n)
+		}
+		return "following 1 container"
+	case m.logs.source.Follow:
+		return "following"
+	}
+	return "loading"
+}
@@ -13,18 +13,31 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/internal/config"
+	"github.com/tapcraft-io/purr/internal/debuglog"
 	"github.com/tapcraft-io/purr/internal/exec"
 	"github.com/tapcraft-io/purr/internal/history"
 	"github.com/tapcraft-io/purr/internal/k8s"
 	"github.com/tapcraft-io/purr/internal/kubecomplete"
+	"github.com/tapcraft-io/purr/internal/policy"
+	"github.com/tapcraft-io/purr/internal/redact"
+	"github.com/tapcraft-io/purr/internal/ringbuf"
+	"github.com/tapcraft-io/purr/internal/shlex"
 	"github.com/tapcraft-io/purr/pkg/types"
 )
 
+// paneBufferLines bounds how much output a pane keeps
+const paneBufferLines = 10000
+
+// maxSuggestions bounds the suggestions listed under the input
+const maxSuggestions = 20
+
 // PaneData holds the runtime data for a command pane
 type PaneData struct {
 	types.CommandPane
-	Output   *strings.Builder // Pointer to avoid copy issues with BubbleTea
+	Output   *ringbuf.Lines // Pointer to avoid copy issues with BubbleTea
 	Viewport viewport.Model
+	Tail     *multiLog // set for multi-pod log panes
 }
 
 // Model represents the application state
@@ -47,6 +60,15 @@ type Model struct {
 	context    string
 	namespace  string
 	kubeconfig string
+	connect    ConnectFunc
+	watching   bool // a watchChanges command is waiting on the cache
+
+	// defaultNamespace is the configured namespace for contexts that
+	// name none
+	defaultNamespace string
+
+	// switchSeq numbers context switches, so only the latest one lands
+	switchSeq int
 
 	// Command State
 	currentCmd *types.ParsedCommand
@@ -65,9 +87,41 @@ type Model struct {
 	parser    *exec.Parser
 	completer *kubecomplete.Completer
 
+	// Safety
+	policy   *policy.Policy
+	confirm  confirmation
+	review   diffReview
+	redactor *redact.Redactor
+
+	// Preferences
+	confirmDestructive bool
+	diffBeforeApply    bool
+	redactOutput       bool
+	showHelp           bool
+	compact            bool
+	explain            bool // the explain panel beside the input
+
+	// Picker state
+	picker pickerKind
+
+	// Browse state
+	browse browser
+
+	// Log viewer state
+	logs logViewer
+
+	// Port-forwards running in the background
+	forwards forwardView
+
+	// Pod and node usage from metrics-server
+	top topView
+
 	// Autocomplete state
 	suggestions     []string
-	suggestionIndex int // Currently selected suggestion (0 = first)
+	suggestionIndex int               // Currently selected suggestion (0 = first)
+	noSpace         map[string]bool   // Suggestions accepted without a space, as more is typed after them
+	descriptions    map[string]string // What each suggestion is, shown beside it
+	native          nativeCompletion  // kubectl __complete for what the spec lacks
 
 	// Flags
 	ready        bool
@@ -79,7 +133,9 @@ type Model struct {
 }
 
 // NewModel creates a new application model
-func NewModel(cache k8s.Cache, hist *history.History, ctx, kubeconfig string, completer *kubecomplete.Completer) Model {
+func NewModel(cfg *config.Config, cache k8s.Cache, hist *history.History, ctx string, completer *kubecomplete.Completer) Model {
+	ApplyTheme(cfg.Theme)
+
 	// Initialize text input with suggestion support
 	ti := textinput.New()
 	ti.Placeholder = "get pods"
@@ -103,6 +159,21 @@ func NewModel(cache k8s.Cache, hist *history.History, ctx, kubeconfig string, co
 
 	parser := exec.NewParser()
 
+	// Start in the context's namespace unless the config names one
+	namespace := cfg.DefaultNamespace
+	if cfg.Source("default_namespace") == config.SourceDefault {
+		if ns, err := k8s.ContextNamespace(cfg.KubeconfigPath, ctx); err == nil {
+			namespace = ns
+		}
+	}
+	if executor != nil {
+		executor.SetKubeconfig(cfg.KubeconfigPath)
+		executor.SetNamespace(namespace)
+		if completer != nil {
+			executor.SetPlugins(completer.Registry.PluginNames())
+		}
+	}
+
 	// Initialize viewport
 	vp := viewport.New(80, 20)
 	vp.Style = viewportStyle
@@ -128,6 +199,20 @@ func NewModel(cache k8s.Cache, hist *history.History, ctx, kubeconfig string, co
 	fp.ShowSize = true
 	fp.Height = 15
 
+	var native nativeCompletion
+	var forwards forwardView
+	var status string
+	if executor != nil {
+		native.completer = NewKubectlCompleter(executor)
+		forwards.manager = executor.NewForwardManager()
+		if cfg.RestoreForwards {
+			forwards.file = cfg.ForwardsFile
+			if forwards.restore, err = exec.LoadForwards(cfg.ForwardsFile); err != nil {
+				status = "Could not restore port-forwards: " + err.Error()
+			}
+		}
+	}
+
 	return Model{
 		commandInput: ti,
 		resourceList: rl,
@@ -141,21 +226,54 @@ func NewModel(cache k8s.Cache, hist *history.History, ctx, kubeconfig string, co
 		cache:        cache,
 		history:      hist,
 		context:      ctx,
-		kubeconfig:   kubeconfig,
+		kubeconfig:   cfg.KubeconfigPath,
 		executor:     executor,
 		parser:       parser,
 		completer:    completer,
-		namespace:    "default",
+		namespace:    namespace,
+		browse:       newBrowser(),
+		logs:         newLogViewer(),
+		native:       native,
+		forwards:     forwards,
+		top:          newTopView(),
+		statusMsg:    status,
+
+		defaultNamespace:   cfg.DefaultNamespace,
+		confirmDestructive: cfg.ConfirmDestructive,
+		diffBeforeApply:    cfg.DiffBeforeApply,
+		redactOutput:       cfg.RedactOutput,
+		showHelp:           cfg.ShowHelp,
+		compact:            cfg.CompactMode,
+		explain:            cfg.ExplainPanel,
 	}
 }
 
+// WithRedactor sets how secrets are masked in the output shown
+func (m Model) WithRedactor(r *redact.Redactor) Model {
+	m.redactor = r
+	return m
+}
+
+// shown masks the secrets in output about to be shown, unless the user
+// turned that off
+func (m Model) shown(output string) string {
+	if !m.redactOutput {
+		return output
+	}
+	return m.redactor.String(output)
+}
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
+	cmds := []tea.Cmd{
 		textinput.Blink,
 		spinner.Tick,
 		checkCacheReady(m.cache),
-	)
+	}
+	if m.forwards.manager != nil {
+		cmds = append(cmds, m.forwards.manager.Wait())
+	}
+	return tea.Batch(cmds...)
 }
 
 // Messages for async operations
@@ -164,10 +282,18 @@ type (
 	commandResultMsg struct {
 		result *exec.ExecuteResult
 		cmd    string
+		diff   string // the reviewed diff it was run with
 	}
 	errMsg struct{ err error }
+
+	// cacheChangedMsg is sent when cached objects changed
+	cacheChangedMsg struct{ cache k8s.Cache }
 )
 
+// cacheSettleDelay lets a burst of cache events settle before the UI
+// reacts, so a rollout doesn't repaint for every pod update
+const cacheSettleDelay = 250 * time.Millisecond
+
 // checkCacheReady checks if the cache is ready
 func checkCacheReady(cache k8s.Cache) tea.Cmd {
 	return func() tea.Msg {
@@ -191,6 +317,27 @@ func checkCacheReady(cache k8s.Cache) tea.Cmd {
 	}
 }
 
+// watchChanges waits for the cache to change
+func watchChanges(c k8s.Cache) tea.Cmd {
+	changes := c.Changes()
+	return func() tea.Msg {
+		if _, ok := <-changes; !ok {
+			return nil
+		}
+		time.Sleep(cacheSettleDelay)
+		return cacheChangedMsg{cache: c}
+	}
+}
+
+// watchCache makes sure a watchChanges command is running
+func (m Model) watchCache() (Model, tea.Cmd) {
+	if m.watching || m.cache == nil {
+		return m, nil
+	}
+	m.watching = true
+	return m, watchChanges(m.cache)
+}
+
 // executeCommand executes a command asynchronously
 func executeCommand(executor *exec.Executor, command string) tea.Cmd {
 	return func() tea.Msg {
@@ -228,45 +375,33 @@ func convertToListItems(items []types.ListItem) []list.Item {
 	return result
 }
 
-// debugLog writes debug messages to a file
-func debugLog(msg string) {
-	f, err := os.OpenFile("/tmp/purr-debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return
-	}
-	defer f.Close()
-	fmt.Fprintf(f, "%s\n", msg)
-}
-
 // getAutocompleteSuggestions generates autocomplete suggestions based on current input
 // Returns just the next token(s) to suggest, not full commands
 func (m *Model) getAutocompleteSuggestions(input string) []string {
-	debugLog(fmt.Sprintf("=== getAutocompleteSuggestions input=%q ===", input))
+	debuglog.Printf("=== getAutocompleteSuggestions input=%q ===", input)
+	m.noSpace = nil
+	m.descriptions = nil
 
 	// Don't suggest for shell commands
 	if strings.HasPrefix(strings.TrimSpace(input), "!") {
-		debugLog("skipping: shell command")
+		debuglog.Printf("skipping: shell command")
 		return nil
 	}
 
 	// Don't suggest if no completer
 	if m.completer == nil {
-		debugLog("skipping: no completer")
+		debuglog.Printf("skipping: no completer")
 		return nil
 	}
 
 	// Use the new kubecomplete engine
-	ctx := kubecomplete.CompletionContext{
-		Line:             input,
-		Cursor:           len(input),
-		CurrentNamespace: m.namespace,
-	}
+	ctx := m.completionContext(input)
 
 	suggestions := m.completer.Complete(input, len(input), ctx)
-	debugLog(fmt.Sprintf("completer returned %d suggestions", len(suggestions)))
+	debuglog.Printf("completer returned %d suggestions", len(suggestions))
 	if len(suggestions) > 0 {
 		first := min(5, len(suggestions))
-		debugLog(fmt.Sprintf("first few: %+v", suggestions[:first]))
+		debuglog.Printf("first few: %+v", suggestions[:first])
 	}
 
 	if len(suggestions) == 0 {
@@ -274,10 +409,8 @@ func (m *Model) getAutocompleteSuggestions(input string) []string {
 	}
 
 	// Check if user is typing a partial token (no trailing space)
-	hasTrailingSpace := len(input) > 0 && input[len(input)-1] == ' '
-	trimmed := strings.TrimSpace(input)
-	tokens := strings.Fields(trimmed)
-	debugLog(fmt.Sprintf("hasTrailingSpace=%v, tokens=%v", hasTrailingSpace, tokens))
+	tokens, hasTrailingSpace := kubecomplete.SplitAtCursor(input, len(input))
+	debuglog.Printf("hasTrailingSpace=%v, tokens=%v", hasTrailingSpace, tokens)
 
 	// Determine if we should filter by current partial token
 	var currentPartial string
@@ -285,15 +418,15 @@ func (m *Model) getAutocompleteSuggestions(input string) []string {
 		// Check if the current tokens match a complete command
 		// If so, we're suggesting the next token, not completing the command
 		cmd, pathLen := m.completer.Registry.MatchCommand(tokens)
-		debugLog(fmt.Sprintf("cmd=%v, pathLen=%d, len(tokens)=%d", cmd != nil, pathLen, len(tokens)))
+		debuglog.Printf("cmd=%v, pathLen=%d, len(tokens)=%d", cmd != nil, pathLen, len(tokens))
 		if cmd != nil && pathLen == len(tokens) {
 			// Tokens match a complete command - don't filter
 			currentPartial = ""
-			debugLog("complete command match - no filtering")
+			debuglog.Printf("complete command match - no filtering")
 		} else {
 			// Either no command match or partial match - filter by last token
 			currentPartial = tokens[len(tokens)-1]
-			debugLog(fmt.Sprintf("partial/no match - filter by %q", currentPartial))
+			debuglog.Printf("partial/no match - filter by %q", currentPartial)
 		}
 	}
 
@@ -305,16 +438,58 @@ func (m *Model) getAutocompleteSuggestions(input string) []string {
 		}
 
 		result = append(result, sug.Value)
+		if sug.NoSpace {
+			if m.noSpace == nil {
+				m.noSpace = make(map[string]bool)
+			}
+			m.noSpace[sug.Value] = true
+		}
+		if sug.Description != "" {
+			if m.descriptions == nil {
+				m.descriptions = make(map[string]string)
+			}
+			m.descriptions[sug.Value] = sug.Description
+		}
 
-		if len(result) >= 20 { // Limit to 20 suggestions
+		if len(result) >= maxSuggestions {
 			break
 		}
 	}
 
-	debugLog(fmt.Sprintf("returning %d results: %v", len(result), result))
+	debuglog.Printf("returning %d results: %v", len(result), result)
 	return result
 }
 
+// completionContext describes where the input is completed
+func (m *Model) completionContext(input string) kubecomplete.CompletionContext {
+	return kubecomplete.CompletionContext{
+		Line:             input,
+		Cursor:           len(input),
+		CurrentContext:   m.context,
+		CurrentNamespace: m.namespace,
+	}
+}
+
+// predictCommand returns the full command from history the input most
+// likely continues into, or "" if there is none
+func (m *Model) predictCommand(input string) string {
+	if m.completer == nil {
+		return ""
+	}
+	return m.completer.Predict(input, m.completionContext(input))
+}
+
+// setInputSuggestions hands the textinput what to show as ghost text: the
+// rest of the command predicted from history, or else of the first
+// suggestion
+func (m *Model) setInputSuggestions() {
+	if prediction := m.predictCommand(m.commandInput.Value()); prediction != "" {
+		m.commandInput.SetSuggestions([]string{prediction})
+		return
+	}
+	m.commandInput.SetSuggestions(m.suggestions)
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -340,7 +515,7 @@ func (m *Model) createPane(command string, cancel context.CancelFunc) int {
 			Status:    types.PaneStatusRunning,
 			Cancel:    cancel,
 		},
-		Output:   &strings.Builder{}, // Use pointer to avoid copy issues
+		Output:   ringbuf.New(paneBufferLines),
 		Viewport: vp,
 	}
 
@@ -401,6 +576,22 @@ func (m *Model) cyclePaneBackward() {
 	}
 }
 
+// isInteractive checks if a command needs the real terminal (exec -it,
+// edit, debug -it, !vim, ...)
+func (m Model) isInteractive(command string, isShell bool) bool {
+	if isShell {
+		return exec.IsInteractiveShell(command)
+	}
+	if m.completer == nil || m.completer.Registry == nil {
+		return false
+	}
+	args, _ := shlex.Fields(command)
+	if len(args) > 0 && args[0] == "kubectl" {
+		args = args[1:]
+	}
+	return m.completer.Registry.IsInteractive(args)
+}
+
 // isLongRunningCommand checks if a command is likely to be long-running
 func isLongRunningCommand(command string) bool {
 	trimmed := strings.TrimSpace(command)
@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/internal/exec"
+	"github.com/tapcraft-io/purr/internal/k8s"
+	"github.com/tapcraft-io/purr/internal/shlex"
+)
+
+// multiLogTail is how many earlier lines each container starts with
+const multiLogTail = 20
+
+// multiLog is a pane following the logs of every pod a workload or label
+// selector covers. The pods are resolved from the cache, and re-resolved
+// whenever it changes.
+type multiLog struct {
+	stream    *exec.MultiLog
+	cache     k8s.Cache // cache of the context the pane was opened in
+	namespace string
+	target    string // workload such as deploy/api, or ""
+	selector  string // label selector, or ""
+	container string // only this container, or "" for all
+}
+
+// openMultiLog starts a multi-pod log pane from a ":logs" command, which
+// takes the same target, -l, -n, -c and --timestamps as kubectl logs, and
+// shows it in the log viewer
+func (m Model) openMultiLog(input string) (tea.Model, tea.Cmd) {
+	if m.executor == nil {
+		m.statusMsg = "kubectl is not available"
+		return m, nil
+	}
+	if m.cache == nil || !m.cache.IsReady() {
+		m.statusMsg = "Resources are not loaded yet"
+		return m, nil
+	}
+
+	lc, _ := exec.ParseLogsCommand(strings.TrimPrefix(strings.TrimSpace(input), ":"))
+	if len(lc.Extra) > 0 {
+		m.statusMsg = fmt.Sprintf("Unsupported flag %s for :logs", lc.Extra[0])
+		return m, nil
+	}
+	if (lc.Target == "") == (lc.Selector == "") {
+		m.statusMsg = "Usage: :logs <deploy/name|sts/name|ds/name|svc/name> or :logs -l <selector> [-n ns] [-c container]"
+		return m, nil
+	}
+	if lc.Namespace == "" {
+		lc.Namespace = m.namespace
+	}
+	if _, err := m.cache.LogTargets(lc.Namespace, lc.Target, lc.Selector); err != nil {
+		m.statusMsg = err.Error()
+		return m, nil
+	}
+
+	ml := &multiLog{
+		stream:    m.executor.NewMultiLog(multiLogTail, lc.Timestamps),
+		cache:     m.cache,
+		namespace: lc.Namespace,
+		target:    lc.Target,
+		selector:  lc.Selector,
+		container: lc.Container,
+	}
+	lc.Follow = false
+	command := ":" + shlex.Join(lc.Args())
+	paneID := m.createPane(command, ml.stream.Stop)
+	idx := m.findPaneByID(paneID)
+	m.panes[idx].Tail = ml
+	m.lastCmd = command
+	m.commandInput.SetValue("")
+
+	m = m.syncMultiLog(idx)
+	if len(ml.stream.Sources()) == 0 {
+		m = m.appendPaneOutput(paneID, dimStyle.Render("waiting for running pods...")+"\n")
+	}
+
+	model, cmd := m.showPaneLogs(idx)
+	m = model.(Model)
+	m, watch := m.watchCache()
+	return m, tea.Batch(cmd, watch, ml.stream.Wait(paneID))
+}
+
+// syncMultiLog re-resolves a multi-pod log pane's pods and attaches or
+// detaches their streams, noting each change in the pane
+func (m Model) syncMultiLog(idx int) Model {
+	ml := m.panes[idx].Tail
+	if ml.cache != m.cache {
+		// Opened in a context we have since left
+		return m
+	}
+
+	// A deleted workload selects nothing, which detaches every stream
+	targets, _ := m.cache.LogTargets(ml.namespace, ml.target, ml.selector)
+	var sources []exec.LogSource
+	for _, t := range targets {
+		if ml.container != "" && t.Container != ml.container {
+			continue
+		}
+		sources = append(sources, exec.LogSource{Namespace: t.Namespace, Pod: t.Pod, Container: t.Container})
+	}
+
+	attached, detached := ml.stream.Sync(sources)
+	var b strings.Builder
+	for _, src := range attached {
+		b.WriteString(successStyle.Render("+ ") + RenderLogTag(src.Tag()) + "\n")
+	}
+	for _, src := range detached {
+		b.WriteString(errorStyle.Render("- ") + RenderLogTag(src.Tag()) + "\n")
+	}
+	return m.appendPaneOutput(m.panes[idx].ID, b.String())
+}
+
+// syncMultiLogs re-resolves every multi-pod log pane
+func (m Model) syncMultiLogs() Model {
+	for i := range m.panes {
+		if m.panes[i].Tail != nil {
+			m = m.syncMultiLog(i)
+		}
+	}
+	return m
+}
+
+// hasMultiLogs checks if any multi-pod log pane needs cache events
+func (m Model) hasMultiLogs() bool {
+	for _, pane := range m.panes {
+		if pane.Tail != nil && pane.Tail.cache == m.cache {
+			return true
+		}
+	}
+	return false
+}
+
+// formatLogLines prefixes each line with its pod/container tag
+func formatLogLines(lines []exec.LogLine) string {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(RenderLogTag(line.Source.Tag()))
+		b.WriteString(" ")
+		b.WriteString(line.Text)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
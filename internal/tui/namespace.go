@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// setNamespace changes the session namespace, which completions and
+// pickers use and which is passed as -n to commands that omit it
+func (m *Model) setNamespace(ns string) {
+	m.namespace = ns
+	if m.executor != nil {
+		m.executor.SetNamespace(ns)
+	}
+}
+
+// showSessionNamespacePicker lists namespaces to switch the session to
+func (m Model) showSessionNamespacePicker() (tea.Model, tea.Cmd) {
+	if m.cache == nil || !m.cache.IsReady() {
+		m.statusMsg = "Namespaces are not loaded yet"
+		return m, nil
+	}
+
+	namespaces := m.cache.GetNamespaces()
+	items := make([]types.ListItem, len(namespaces))
+	for i, ns := range namespaces {
+		desc := "Namespace"
+		if ns == m.namespace {
+			desc += " | active"
+		}
+		items[i] = types.ListItem{
+			Title:       ns,
+			Description: desc,
+		}
+	}
+
+	m.resourceList.Title = "Switch Namespace"
+	m.resourceList.SetItems(convertToListItems(items))
+	m.picker = pickerNamespace
+	m.mode = types.ModeSelectingResource
+	return m, nil
+}
+
+// switchNamespace makes ns the session namespace
+func (m Model) switchNamespace(ns string) (tea.Model, tea.Cmd) {
+	m.mode = types.ModeTyping
+	m.commandInput.Focus()
+
+	if m.cache != nil && m.cache.IsReady() && !containsString(m.cache.GetNamespaces(), ns) {
+		m.statusMsg = fmt.Sprintf("Namespace %s not found in this cluster", ns)
+		return m, nil
+	}
+
+	m.setNamespace(ns)
+	m.statusMsg = "Switched to namespace " + ns
+	return m, nil
+}
+
+// commandNamespace returns the namespace a command runs in: its own -n,
+// or the session namespace
+func (m Model) commandNamespace(command string) string {
+	if m.parser != nil {
+		if parsed := m.parser.Parse(command); parsed.Namespace != "" {
+			return parsed.Namespace
+		}
+	}
+	return m.namespace
+}
+
+// containsString checks if a slice contains a string
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,141 +1,233 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"hash/fnv"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 // Color palette
 var (
 	// Primary colors
-	colorPrimary   = lipgloss.Color("#7D56F4") // Purple
-	colorSecondary = lipgloss.Color("#FF6B9D") // Pink
-	colorAccent    = lipgloss.Color("#00D9FF") // Cyan
+	colorPrimary   lipgloss.Color
+	colorSecondary lipgloss.Color
+	colorAccent    lipgloss.Color
 
 	// Status colors
-	colorSuccess = lipgloss.Color("#00D787") // Green
-	colorWarning = lipgloss.Color("#FFB86C") // Orange
-	colorError   = lipgloss.Color("#FF5555") // Red
-	colorInfo    = lipgloss.Color("#8BE9FD") // Cyan
+	colorSuccess lipgloss.Color
+	colorWarning lipgloss.Color
+	colorError   lipgloss.Color
+	colorInfo    lipgloss.Color
 
 	// UI colors
-	colorText    = lipgloss.Color("#F8F8F2") // White
-	colorTextDim = lipgloss.Color("#6272A4") // Gray
-	colorBorder  = lipgloss.Color("#44475A") // Dark gray
-	colorBg      = lipgloss.Color("#282A36") // Background
-	colorBgAlt   = lipgloss.Color("#21222C") // Alt background
+	colorText    lipgloss.Color
+	colorTextDim lipgloss.Color
+	colorBorder  lipgloss.Color
+	colorBg      lipgloss.Color
+	colorBgAlt   lipgloss.Color
 )
 
+// palette is a set of colors for a theme
+type palette struct {
+	primary, secondary, accent       lipgloss.Color
+	success, warning, error, info    lipgloss.Color
+	text, textDim, border, bg, bgAlt lipgloss.Color
+}
+
+// palettes holds the available themes, keyed by config.Config.Theme
+var palettes = map[string]palette{
+	"dark": {
+		primary:   "#7D56F4", // Purple
+		secondary: "#FF6B9D", // Pink
+		accent:    "#00D9FF", // Cyan
+		success:   "#00D787", // Green
+		warning:   "#FFB86C", // Orange
+		error:     "#FF5555", // Red
+		info:      "#8BE9FD", // Cyan
+		text:      "#F8F8F2", // White
+		textDim:   "#6272A4", // Gray
+		border:    "#44475A", // Dark gray
+		bg:        "#282A36", // Background
+		bgAlt:     "#21222C", // Alt background
+	},
+	"light": {
+		primary:   "#5A3FC0", // Purple
+		secondary: "#D6336C", // Pink
+		accent:    "#0B7A99", // Teal
+		success:   "#1A7F37", // Green
+		warning:   "#B35900", // Orange
+		error:     "#CF222E", // Red
+		info:      "#0969DA", // Blue
+		text:      "#24292F", // Near black
+		textDim:   "#6E7781", // Gray
+		border:    "#D0D7DE", // Light gray
+		bg:        "#FFFFFF", // Background
+		bgAlt:     "#F6F8FA", // Alt background
+	},
+}
+
+// logTagColors color the pod/container tags of multi-pod logs. The basic
+// ANSI colors follow the terminal's own theme, so they suit both palettes.
+var logTagColors = []lipgloss.Color{"2", "3", "4", "5", "6", "10", "11", "12", "13", "14"}
+
 // Style definitions
 var (
+	titleStyle         lipgloss.Style
+	contextStyle       lipgloss.Style
+	inputStyle         lipgloss.Style
+	promptStyle        lipgloss.Style
+	selectedStyle      lipgloss.Style
+	normalStyle        lipgloss.Style
+	successStyle       lipgloss.Style
+	errorStyle         lipgloss.Style
+	warningStyle       lipgloss.Style
+	infoStyle          lipgloss.Style
+	helpStyle          lipgloss.Style
+	borderStyle        lipgloss.Style
+	boxStyle           lipgloss.Style
+	viewportStyle      lipgloss.Style
+	descriptionStyle   lipgloss.Style
+	highlightStyle     lipgloss.Style
+	dimStyle           lipgloss.Style
+	spinnerStyle       lipgloss.Style
+	statusReadyStyle   lipgloss.Style
+	statusPendingStyle lipgloss.Style
+	statusFailedStyle  lipgloss.Style
+)
+
+func init() {
+	ApplyTheme("dark")
+}
+
+// ApplyTheme switches the color palette and rebuilds all styles. Unknown
+// theme names fall back to the dark theme.
+func ApplyTheme(name string) {
+	p, ok := palettes[name]
+	if !ok {
+		p = palettes["dark"]
+	}
+
+	colorPrimary, colorSecondary, colorAccent = p.primary, p.secondary, p.accent
+	colorSuccess, colorWarning, colorError, colorInfo = p.success, p.warning, p.error, p.info
+	colorText, colorTextDim, colorBorder = p.text, p.textDim, p.border
+	colorBg, colorBgAlt = p.bg, p.bgAlt
+
+	buildStyles()
+}
+
+// buildStyles derives every style from the current palette
+func buildStyles() {
 	// Title bar
 	titleStyle = lipgloss.NewStyle().
-			Foreground(colorPrimary).
-			Bold(true).
-			Padding(0, 1)
+		Foreground(colorPrimary).
+		Bold(true).
+		Padding(0, 1)
 
 	contextStyle = lipgloss.NewStyle().
-			Foreground(colorInfo).
-			Padding(0, 1)
+		Foreground(colorInfo).
+		Padding(0, 1)
 
 	// Command input
 	inputStyle = lipgloss.NewStyle().
-			Foreground(colorText).
-			Padding(0, 1)
+		Foreground(colorText).
+		Padding(0, 1)
 
 	// Prompt
 	promptStyle = lipgloss.NewStyle().
-			Foreground(colorPrimary).
-			Bold(true)
+		Foreground(colorPrimary).
+		Bold(true)
 
 	// Selected item in list
 	selectedStyle = lipgloss.NewStyle().
-			Foreground(colorBgAlt).
-			Background(colorPrimary).
-			Bold(true).
-			Padding(0, 1)
+		Foreground(colorBgAlt).
+		Background(colorPrimary).
+		Bold(true).
+		Padding(0, 1)
 
 	// Normal list item
 	normalStyle = lipgloss.NewStyle().
-			Foreground(colorText).
-			Padding(0, 1)
+		Foreground(colorText).
+		Padding(0, 1)
 
 	// Success message
 	successStyle = lipgloss.NewStyle().
-			Foreground(colorSuccess).
-			Bold(true)
+		Foreground(colorSuccess).
+		Bold(true)
 
 	// Error message
 	errorStyle = lipgloss.NewStyle().
-			Foreground(colorError).
-			Bold(true)
+		Foreground(colorError).
+		Bold(true)
 
 	// Warning message
 	warningStyle = lipgloss.NewStyle().
-			Foreground(colorWarning).
-			Bold(true)
+		Foreground(colorWarning).
+		Bold(true)
 
 	// Info message
 	infoStyle = lipgloss.NewStyle().
-			Foreground(colorInfo)
+		Foreground(colorInfo)
 
 	// Help text
 	helpStyle = lipgloss.NewStyle().
-			Foreground(colorTextDim)
+		Foreground(colorTextDim)
 
 	// Border style
 	borderStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorBorder).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorder).
+		Padding(1, 2)
 
 	// Box style for pickers/dialogs
 	boxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorPrimary).
-			Padding(1, 2).
-			Width(60)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2).
+		Width(60)
 
 	// Output viewport style
 	viewportStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorBorder).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorder).
+		Padding(1, 2)
 
 	// Description style (for list items)
 	descriptionStyle = lipgloss.NewStyle().
-				Foreground(colorTextDim)
+		Foreground(colorTextDim)
 
 	// Highlighted text
 	highlightStyle = lipgloss.NewStyle().
-			Foreground(colorAccent).
-			Bold(true)
+		Foreground(colorAccent).
+		Bold(true)
 
 	// Dimmed text (for autocomplete suggestions)
 	dimStyle = lipgloss.NewStyle().
-			Foreground(colorTextDim)
+		Foreground(colorTextDim)
 
 	// Spinner style
 	spinnerStyle = lipgloss.NewStyle().
-			Foreground(colorPrimary)
+		Foreground(colorPrimary)
 
 	// Status indicator styles
 	statusReadyStyle = lipgloss.NewStyle().
-				Foreground(colorSuccess).
-				Bold(true)
+		Foreground(colorSuccess).
+		Bold(true)
 
 	statusPendingStyle = lipgloss.NewStyle().
-				Foreground(colorWarning).
-				Bold(true)
+		Foreground(colorWarning).
+		Bold(true)
 
 	statusFailedStyle = lipgloss.NewStyle().
-				Foreground(colorError).
-				Bold(true)
-)
+		Foreground(colorError).
+		Bold(true)
+}
 
 // Helper functions for styling
 
 // RenderTitle renders the title bar
-func RenderTitle(title string, context string) string {
+func RenderTitle(title, context, namespace string) string {
 	left := titleStyle.Render("Purr")
-	right := contextStyle.Render("[context: " + context + "]")
+	right := contextStyle.Render("[context: " + context + " | ns: " + namespace + "]")
 	return lipgloss.JoinHorizontal(lipgloss.Left, left, right)
 }
 
@@ -188,6 +280,15 @@ func RenderListItem(title, description string, selected bool) string {
 	return titleRendered + "\n" + descRendered
 }
 
+// RenderLogTag renders a log source tag in a color picked from the tag
+// itself, so each container keeps its color for the whole stream
+func RenderLogTag(tag string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(tag))
+	color := logTagColors[h.Sum32()%uint32(len(logTagColors))]
+	return lipgloss.NewStyle().Foreground(color).Render(tag)
+}
+
 // RenderStatus renders a status indicator
 func RenderStatus(status string) string {
 	switch status {
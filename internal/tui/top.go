@@ -0,0 +1,358 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tapcraft-io/purr/internal/k8s"
+	"github.com/tapcraft-io/purr/internal/shlex"
+	"github.com/tapcraft-io/purr/pkg/types"
+)
+
+// topSparkWidth is how many recent samples a sparkline shows
+const topSparkWidth = 20
+
+// sparkBlocks are the levels sparklines are drawn with
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// topView is the live table of what pods or nodes use, kept while the
+// user runs commands so returning to it lands on the same row
+type topView struct {
+	nodes         bool // nodes rather than pods
+	allNamespaces bool
+	rows          []k8s.UsageSeries
+	sortBy        string // "cpu", "memory" or "name"
+	sortDesc      bool
+	cursor        int
+}
+
+// newTopView creates a top view of pods, busiest first
+func newTopView() topView {
+	return topView{sortBy: "cpu", sortDesc: true}
+}
+
+// showTop opens the top view on pods or nodes. An empty kind reopens the
+// last one shown.
+func (m Model) showTop(kind string) (tea.Model, tea.Cmd) {
+	if m.cache == nil || !m.cache.IsReady() {
+		m.statusMsg = "Resources are not loaded yet"
+		return m, nil
+	}
+
+	switch kind {
+	case "":
+	case "pods", "pod", "po":
+		m.top.nodes = false
+	case "nodes", "node", "no":
+		m.top.nodes = true
+	default:
+		m.statusMsg = "Usage: :top [pods|nodes]"
+		return m, nil
+	}
+	m.mode = types.ModeViewingTop
+	m.statusMsg = ""
+	m.commandInput.Blur()
+	m = m.refreshTop()
+	return m.watchCache()
+}
+
+// topNamespace is the namespace the top view shows pods of, "" for all
+func (m Model) topNamespace() string {
+	if m.top.allNamespaces {
+		return ""
+	}
+	return m.namespace
+}
+
+// refreshTop reloads the usage from the cache, keeping the cursor on the
+// same pod or node
+func (m Model) refreshTop() Model {
+	if m.cache == nil {
+		return m
+	}
+
+	selected, hasSelected := m.selectedUsage()
+	if m.top.nodes {
+		m.top.rows = m.cache.NodeUsage()
+	} else {
+		m.top.rows = m.cache.PodUsage(m.topNamespace())
+	}
+	sortUsage(m.top.rows, m.top.sortBy, m.top.sortDesc)
+
+	if hasSelected {
+		for i, row := range m.top.rows {
+			if row.Name == selected.Name && row.Namespace == selected.Namespace {
+				m.top.cursor = i
+				break
+			}
+		}
+	}
+	m.top.cursor = clamp(m.top.cursor, 0, len(m.top.rows)-1)
+	return m
+}
+
+// selectedUsage returns the row under the cursor
+func (m Model) selectedUsage() (k8s.UsageSeries, bool) {
+	if m.top.cursor < 0 || m.top.cursor >= len(m.top.rows) {
+		return k8s.UsageSeries{}, false
+	}
+	return m.top.rows[m.top.cursor], true
+}
+
+// sortUsage orders rows by CPU, memory or name. Ties keep name order.
+func sortUsage(rows []k8s.UsageSeries, by string, desc bool) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if desc {
+			a, b = b, a
+		}
+		switch by {
+		case "cpu":
+			return a.CPU < b.CPU
+		case "memory":
+			return a.Memory < b.Memory
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
+
+// topRowsVisible is how many rows fit on screen
+func (m Model) topRowsVisible() int {
+	return max(m.height-9, 1)
+}
+
+// handleViewingTopMode handles key presses in the top view
+func (m Model) handleViewingTopMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	last := len(m.top.rows) - 1
+	page := m.topRowsVisible()
+	switch key := msg.String(); key {
+	case "q":
+		m.mode = types.ModeTyping
+		m.commandInput.Focus()
+		return m, nil
+
+	case "up", "k":
+		m.top.cursor = clamp(m.top.cursor-1, 0, last)
+	case "down", "j":
+		m.top.cursor = clamp(m.top.cursor+1, 0, last)
+	case "pgup", "ctrl+u":
+		m.top.cursor = clamp(m.top.cursor-page, 0, last)
+	case "pgdown", "ctrl+f":
+		m.top.cursor = clamp(m.top.cursor+page, 0, last)
+	case "home", "g":
+		m.top.cursor = 0
+	case "end", "G":
+		m.top.cursor = max(last, 0)
+
+	case "tab":
+		m.top.nodes = !m.top.nodes
+		m.top.cursor = 0
+		return m.refreshTop(), nil
+
+	case "a":
+		m.top.allNamespaces = !m.top.allNamespaces
+		return m.refreshTop(), nil
+
+	case "c", "m", "n":
+		by := map[string]string{"c": "cpu", "m": "memory", "n": "name"}[key]
+		if by == m.top.sortBy {
+			m.top.sortDesc = !m.top.sortDesc
+		} else {
+			// Usage reads best busiest first, names from A
+			m.top.sortBy, m.top.sortDesc = by, by != "name"
+		}
+		return m.refreshTop(), nil
+
+	case "enter", "d", "l", "s":
+		row, ok := m.selectedUsage()
+		if !ok {
+			return m, nil
+		}
+		resource := "pods"
+		if m.top.nodes {
+			resource = "nodes"
+		}
+		info, _ := m.cache.LookupResource(resource)
+		args, err := rowCommand(key, info, k8s.TableRow{Name: row.Name, Namespace: row.Namespace})
+		if err != nil {
+			m.statusMsg = err.Error()
+			return m, nil
+		}
+		m.mode = types.ModeTyping
+		m.commandInput.Focus()
+		return m.submitCommand(shlex.Join(args))
+	}
+	return m, nil
+}
+
+// renderViewingTopMode renders the top view
+func (m Model) renderViewingTopMode() string {
+	var b strings.Builder
+
+	// Title bar
+	title := RenderTitle("Purr", m.context, m.namespace)
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	kind, scope := "pods", m.namespace
+	if m.top.nodes {
+		kind, scope = "nodes", "cluster"
+	} else if m.top.allNamespaces {
+		scope = "all namespaces"
+	}
+	dir := "↑"
+	if m.top.sortDesc {
+		dir = "↓"
+	}
+	summary := fmt.Sprintf("top %s (%s) %d  sort: %s %s", kind, scope, len(m.top.rows), m.top.sortBy, dir)
+	b.WriteString(highlightStyle.Render(summary))
+	b.WriteString("\n\n")
+
+	if err := m.cache.MetricsError(); err != nil {
+		b.WriteString(RenderWarning(err.Error()))
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  CPU and memory come from metrics-server: https://github.com/kubernetes-sigs/metrics-server"))
+		b.WriteString("\n\n")
+		b.WriteString(RenderHelp("[Tab] pods/nodes  [Esc] back"))
+		return b.String()
+	}
+
+	// Columns, with a sparkline of recent samples after CPU and MEMORY
+	columns := []string{"NAME", "CPU", "", "MEMORY", ""}
+	if m.top.nodes {
+		columns = []string{"NAME", "CPU", "CPU%", "", "MEMORY", "MEMORY%", ""}
+	} else if m.top.allNamespaces {
+		columns = append([]string{"NAMESPACE"}, columns...)
+	}
+	rows := make([][]string, len(m.top.rows))
+	for i, row := range m.top.rows {
+		cpu, memory := make([]int64, len(row.History)), make([]int64, len(row.History))
+		for j, u := range row.History {
+			cpu[j], memory[j] = u.CPU, u.Memory
+		}
+		if m.top.nodes {
+			rows[i] = []string{
+				row.Name,
+				k8s.FormatCPU(row.CPU), percent(row.CPU, row.Capacity.CPU), sparkline(cpu, topSparkWidth),
+				k8s.FormatMemory(row.Memory), percent(row.Memory, row.Capacity.Memory), sparkline(memory, topSparkWidth),
+			}
+			continue
+		}
+		rows[i] = []string{
+			row.Name,
+			k8s.FormatCPU(row.CPU), sparkline(cpu, topSparkWidth),
+			k8s.FormatMemory(row.Memory), sparkline(memory, topSparkWidth),
+		}
+		if m.top.allNamespaces {
+			rows[i] = append([]string{row.Namespace}, rows[i]...)
+		}
+	}
+
+	// Column widths fit the widest cell, counting sparklines by rune
+	const maxColWidth = 48
+	widths := make([]int, len(columns))
+	for i, col := range columns {
+		widths[i] = len(col)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = min(max(widths[i], lipgloss.Width(cell)), maxColWidth)
+		}
+	}
+	lineWidth := max(m.width-4, 20)
+	clip := lipgloss.NewStyle().MaxWidth(lineWidth)
+	formatRow := func(cells []string) string {
+		parts := make([]string, len(cells))
+		for i, cell := range cells {
+			if lipgloss.Width(cell) > widths[i] {
+				cell = truncate(cell, widths[i])
+			}
+			parts[i] = cell + strings.Repeat(" ", widths[i]-lipgloss.Width(cell))
+		}
+		line := clip.Render(strings.Join(parts, "  "))
+		return line + strings.Repeat(" ", max(lineWidth-lipgloss.Width(line), 0))
+	}
+
+	headerStyle := lipgloss.NewStyle().Foreground(colorTextDim).Bold(true).Padding(0, 1)
+	b.WriteString(headerStyle.Render(formatRow(columns)))
+	b.WriteString("\n")
+
+	// Rows, scrolled to keep the cursor visible
+	visible := m.topRowsVisible()
+	start := 0
+	if m.top.cursor >= visible {
+		start = m.top.cursor - visible + 1
+	}
+	end := min(start+visible, len(rows))
+	for i := start; i < end; i++ {
+		line := formatRow(rows[i])
+		if i == m.top.cursor {
+			b.WriteString(selectedStyle.Render(line))
+		} else {
+			b.WriteString(normalStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+	if len(rows) == 0 {
+		b.WriteString(dimStyle.Render("  No " + kind + " reported yet"))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if m.statusMsg != "" {
+		b.WriteString(RenderInfo(m.statusMsg))
+		b.WriteString("\n")
+	}
+
+	help := "[↑↓] move  [c/m/n] sort by cpu/memory/name  [Tab] pods/nodes  [Enter] describe  [Esc] back"
+	if !m.top.nodes {
+		help = "[↑↓] move  [c/m/n] sort by cpu/memory/name  [Tab] pods/nodes  [a] all namespaces  [Enter/l/s] describe/logs/shell  [Esc] back"
+	}
+	b.WriteString(RenderHelp(help))
+
+	return b.String()
+}
+
+// percent formats used as a share of capacity, the way kubectl top nodes
+// does
+func percent(used, capacity int64) string {
+	if capacity <= 0 {
+		return "<unknown>"
+	}
+	return fmt.Sprintf("%d%%", used*100/capacity)
+}
+
+// sparkline draws the last width values as block characters, scaled
+// between the lowest and highest of them, padded on the left to width
+func sparkline(values []int64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	if len(values) == 0 {
+		return strings.Repeat(" ", width)
+	}
+	low, high := values[0], values[0]
+	for _, v := range values {
+		if v < low {
+			low = v
+		}
+		high = max(high, v)
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", width-len(values)))
+	for _, v := range values {
+		level := 0
+		if high > low {
+			level = int((v - low) * int64(len(sparkBlocks)-1) / (high - low))
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
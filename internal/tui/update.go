@@ -10,6 +10,9 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/tapcraft-io/purr/internal/exec"
+	"github.com/tapcraft-io/purr/internal/policy"
+	"github.com/tapcraft-io/purr/internal/redact"
+	"github.com/tapcraft-io/purr/internal/shlex"
 	"github.com/tapcraft-io/purr/pkg/types"
 )
 
@@ -29,6 +32,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.historyList.SetWidth(msg.Width - 4)
 		m.historyList.SetHeight(msg.Height - 6)
 		m.commandInput.Width = msg.Width - 6
+		m = m.resizeLogViewer()
+		if m.mode == types.ModeViewingLogs {
+			m = m.refreshLogViewer()
+		}
 
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
@@ -36,27 +43,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case cacheReadyMsg:
 		m.ready = true
 		m.statusMsg = "Cache ready"
+		m, cmd = m.restoreForwards()
+		cmds = append(cmds, cmd)
 
 	case commandResultMsg:
-		m.cmdOutput = msg.result.Stdout
+		m.cmdOutput = m.shown(msg.result.Stdout)
 		if msg.result.Error != nil {
 			m.cmdError = msg.result.Error
-			m.cmdOutput += "\n" + msg.result.Stderr
-			if m.history != nil {
-				m.history.Add(msg.cmd, false, m.context, m.namespace)
-			}
+			m.cmdOutput += "\n" + m.shown(msg.result.Stderr)
 		} else {
 			m.cmdError = nil
-			if m.history != nil {
-				m.history.Add(msg.cmd, true, m.context, m.namespace)
-			}
 		}
-		m.viewport.SetContent(m.cmdOutput)
-		m.viewport.GotoTop()
-		// Save history after command execution
 		if m.history != nil {
-			_ = m.history.Save()
+			m.history.AddEntry(types.HistoryEntry{
+				Command:   msg.cmd,
+				Success:   msg.result.Error == nil,
+				ExitCode:  msg.result.ExitCode,
+				Duration:  msg.result.Duration,
+				Context:   m.context,
+				Namespace: m.commandNamespace(msg.cmd),
+				Output:    msg.result.Stdout + msg.result.Stderr,
+				Diff:      msg.diff,
+			})
 		}
+		m.viewport.SetContent(m.cmdOutput)
+		m.viewport.GotoTop()
 		// Return to typing mode with cleared input and suggestions - output remains visible
 		m.mode = types.ModeTyping
 		m.commandInput.SetValue("")
@@ -66,20 +77,103 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.suggestionIndex = 0
 		m.commandInput.SetSuggestions(m.suggestions)
 
+	case contextSwitchedMsg:
+		m = m.applyContextSwitch(msg)
+		if m.mode == types.ModeBrowsing {
+			m = m.refreshBrowser()
+		}
+		if m.mode == types.ModeViewingTop {
+			m = m.refreshTop()
+		}
+		if m.mode == types.ModeBrowsing || m.mode == types.ModeViewingTop || m.hasForwards() {
+			m, cmd = m.watchCache()
+			cmds = append(cmds, cmd)
+		}
+
+	case cacheChangedMsg:
+		if msg.cache != m.cache {
+			// From the cache of a context we have since left
+			break
+		}
+		m.watching = false
+		if m.mode == types.ModeBrowsing {
+			m = m.refreshBrowser()
+		}
+		if m.mode == types.ModeViewingTop {
+			m = m.refreshTop()
+		}
+		m = m.syncMultiLogs()
+		m.syncForwards()
+		if m.mode == types.ModeTyping && m.commandInput.Value() != "" {
+			m, cmd = m.updateSuggestions()
+			cmds = append(cmds, cmd)
+		}
+		if m.mode == types.ModeBrowsing || m.mode == types.ModeViewingTop || m.hasMultiLogs() || m.hasForwards() {
+			m, cmd = m.watchCache()
+			cmds = append(cmds, cmd)
+		}
+
+	case blastRadiusMsg:
+		m = m.applyBlastRadius(msg)
+
+	case kubectlCompleteTickMsg:
+		return m.startKubectlComplete(msg)
+
+	case kubectlCompleteMsg:
+		m = m.applyKubectlComplete(msg)
+
+	case diffReadyMsg:
+		return m.showDiff(msg)
+
+	case liveFetchedMsg:
+		return m.editLive(msg)
+
+	case editorClosedMsg:
+		return m.diffEdit(msg)
+
+	case exec.InteractiveCompleteMsg:
+		// The terminal is ours again; there is no captured output to show
+		success := msg.Error == nil
+		if success {
+			m.statusMsg = "Interactive command finished"
+		} else {
+			m.statusMsg = fmt.Sprintf("Interactive command exited with code %d", msg.ExitCode)
+		}
+		if m.history != nil {
+			m.history.AddEntry(types.HistoryEntry{
+				Command:   msg.Command,
+				Success:   success,
+				ExitCode:  msg.ExitCode,
+				Duration:  msg.Duration,
+				Context:   m.context,
+				Namespace: m.commandNamespace(msg.Command),
+			})
+		}
+		m.mode = types.ModeTyping
+		m.commandInput.SetValue("")
+		m.commandInput.Focus()
+		m.suggestions = []string{"get", "describe", "logs", "apply", "delete", "exec", "create", "rollout", "scale"}
+		m.suggestionIndex = 0
+		m.commandInput.SetSuggestions(m.suggestions)
+
 	case exec.PaneOutputMsg:
 		// Handle output from a pane
-		paneIdx := m.findPaneByID(msg.PaneID)
-		if paneIdx >= 0 && msg.Output != "" {
-			m.panes[paneIdx].Output.WriteString(msg.Output)
-			content := m.panes[paneIdx].Output.String()
-			m.panes[paneIdx].Viewport.SetContent(content)
-			m.panes[paneIdx].Viewport.GotoBottom()
-		}
+		m = m.appendPaneOutput(msg.PaneID, msg.Output)
 		// Continue streaming if there's a next command
 		if msg.NextCmd != nil {
 			cmds = append(cmds, msg.NextCmd)
 		}
 
+	case exec.MultiLogOutputMsg:
+		m = m.appendPaneOutput(msg.PaneID, formatLogLines(msg.Lines))
+		if m.findPaneByID(msg.PaneID) >= 0 {
+			cmds = append(cmds, msg.NextCmd)
+		}
+
+	case exec.ForwardsChangedMsg:
+		m = m.refreshForwards()
+		cmds = append(cmds, msg.NextCmd)
+
 	case exec.PaneCompleteMsg:
 		// Handle pane completion
 		paneIdx := m.findPaneByID(msg.PaneID)
@@ -90,6 +184,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.panes[paneIdx].Status = types.PaneStatusCompleted
 			}
 			m.panes[paneIdx].ExitCode = msg.ExitCode
+			m.panes[paneIdx].Output.Flush()
+			m = m.logStreamOutput(msg.PaneID)
 		}
 
 	case errMsg:
@@ -122,6 +218,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case types.ModeViewingOutput:
 		m.viewport, cmd = m.viewport.Update(msg)
 		cmds = append(cmds, cmd)
+
+	case types.ModeBrowsing:
+		if m.browse.filtering {
+			m.browse.filter, cmd = m.browse.filter.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+
+	case types.ModeViewingLogs:
+		if m.logs.inputKind != logInputNone {
+			m.logs.input, cmd = m.logs.input.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 	}
 
 	return m, tea.Batch(cmds...)
@@ -154,9 +262,28 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "esc":
+		// The browser's filter takes esc for itself
+		if m.mode == types.ModeBrowsing && m.browse.filtering {
+			break
+		}
+		// So does the log viewer's input line
+		if m.mode == types.ModeViewingLogs && m.logs.inputKind != logInputNone {
+			break
+		}
+		// and the confirmation prompt and diff review, which have a
+		// command to drop
+		if m.mode == types.ModeConfirming || m.mode == types.ModeReviewingDiff {
+			break
+		}
+		// A diff still being worked out is abandoned
+		if m.review.cancel != nil {
+			m.review.cancel()
+			return m.abortReview("Aborted, nothing applied"), nil
+		}
 		// Cancel current operation and return to typing
 		if m.mode != types.ModeTyping {
 			m.mode = types.ModeTyping
+			m.picker = pickerInsert
 			m.commandInput.Focus()
 			return m, nil
 		}
@@ -178,6 +305,24 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case types.ModeViewingOutput:
 		return m.handleViewingOutputMode(msg)
+
+	case types.ModeConfirming:
+		return m.handleConfirmingMode(msg)
+
+	case types.ModeReviewingDiff:
+		return m.handleReviewingDiffMode(msg)
+
+	case types.ModeBrowsing:
+		return m.handleBrowsingMode(msg)
+
+	case types.ModeViewingLogs:
+		return m.handleViewingLogsMode(msg)
+
+	case types.ModeViewingForwards:
+		return m.handleViewingForwardsMode(msg)
+
+	case types.ModeViewingTop:
+		return m.handleViewingTopMode(msg)
 	}
 
 	return m, tea.Batch(cmds...)
@@ -193,31 +338,49 @@ func (m Model) handleTypingMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg.String() {
-	case "tab", "right":
+	case "right":
+		// Accept the command predicted from history, as in fish
+		value := m.commandInput.Value()
+		if prediction := m.predictCommand(value); prediction != "" && m.commandInput.Position() == len([]rune(value)) {
+			m.commandInput.SetValue(prediction)
+			m.commandInput.CursorEnd()
+			m.suggestions = m.getAutocompleteSuggestions(prediction)
+			m.suggestionIndex = 0
+			m.setInputSuggestions()
+			return m.completeWithKubectl()
+		}
+		fallthrough
+
+	case "tab":
 		// Accept the currently selected suggestion
 		if len(m.suggestions) > 0 && m.suggestionIndex < len(m.suggestions) {
 			currentInput := m.commandInput.Value()
 			suggestion := m.suggestions[m.suggestionIndex]
 
+			// Some suggestions are a prefix to type on from, like
+			// deployment/ or app=
+			space := " "
+			if m.noSpace[suggestion] {
+				space = ""
+			}
+
 			// Determine how to append the suggestion
-			if len(currentInput) > 0 && currentInput[len(currentInput)-1] != ' ' {
-				// Replace the last partial token with the suggestion
-				tokens := strings.Fields(strings.TrimSpace(currentInput))
-				if len(tokens) > 0 {
-					// Remove last partial token and add suggestion
-					prefix := strings.TrimSuffix(currentInput, tokens[len(tokens)-1])
-					m.commandInput.SetValue(prefix + suggestion + " ")
-				}
+			tokens, _ := shlex.Split(currentInput)
+			if n := len(tokens); n > 0 && tokens[n-1].End == len(currentInput) {
+				// Replace the last partial token, quotes included, with the suggestion
+				prefix := currentInput[:tokens[n-1].Start]
+				m.commandInput.SetValue(prefix + shlex.Quote(suggestion) + space)
 			} else {
 				// Append suggestion after the space
-				m.commandInput.SetValue(currentInput + suggestion + " ")
+				m.commandInput.SetValue(currentInput + shlex.Quote(suggestion) + space)
 			}
 			m.commandInput.CursorEnd()
 
 			// Update suggestions for new input and reset index
 			m.suggestions = m.getAutocompleteSuggestions(m.commandInput.Value())
 			m.suggestionIndex = 0
-			m.commandInput.SetSuggestions(m.suggestions)
+			m.setInputSuggestions()
+			return m.completeWithKubectl()
 		}
 		return m, nil
 
@@ -237,52 +400,52 @@ func (m Model) handleTypingMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if strings.HasPrefix(inputValue, ":") {
+			return m.runBuiltin(inputValue)
+		}
+
 		if inputValue == "exit" || inputValue == "quit" {
 			m.quitting = true
 			return m, tea.Quit
 		}
 
-		command, isShell, err := m.prepareCommand(m.commandInput.Value())
-		if err != nil {
-			m.statusMsg = err.Error()
-			return m, nil
-		}
+		return m.submitCommand(m.commandInput.Value())
 
-		m.lastCmd = command
-		m.statusMsg = "Executing command..."
+	case "alt+c":
+		// Switch kubeconfig context
+		return m.showContextPicker()
 
-		// Check if destructive
-		if !isShell && m.parser != nil && exec.IsDestructive(command) {
-			m.mode = types.ModeConfirming
-			return m, nil
-		}
+	case "alt+s":
+		// Switch session namespace
+		return m.showSessionNamespacePicker()
 
-		// Execute the command
-		if m.executor != nil {
-			// Check if this is a long-running command that should run in a pane
-			if isLongRunningCommand(command) {
-				// Create a context with cancellation for this pane
-				ctx, cancel := context.WithCancel(context.Background())
-				paneID := m.createPane(command, cancel)
+	case "alt+e":
+		// Show or hide the explain panel
+		m.explain = !m.explain
+		return m, nil
 
-				// Clear the input for the next command
-				m.commandInput.SetValue("")
-				m.suggestions = []string{"get", "describe", "logs", "apply", "delete", "exec", "create", "rollout", "scale"}
-				m.suggestionIndex = 0
-				m.commandInput.SetSuggestions(m.suggestions)
+	case "alt+r":
+		// Browse resources, starting from the kind in the input if any
+		resource := ""
+		if m.parser != nil {
+			resource = m.parser.Parse(m.commandInput.Value()).Resource
+		}
+		return m.showBrowser(resource)
 
-				// Start streaming execution
-				return m, m.executor.ExecuteStreaming(ctx, command, paneID)
-			}
+	case "alt+f":
+		// List the port-forwards running in the background
+		return m.showForwards()
 
-			// Regular command - use traditional execution
-			return m, executeCommand(m.executor, command)
-		}
+	case "alt+t":
+		// Show what pods and nodes use
+		return m.showTop("")
 
 	case "ctrl+r":
 		// Open history
 		if m.history != nil {
 			m.mode = types.ModeViewingHistory
+			// Pick up commands run in other sessions
+			_ = m.history.Load()
 			entries := m.history.GetAll()
 			items := convertToListItems(m.history.ToListItems(entries))
 			m.historyList.SetItems(items)
@@ -292,6 +455,10 @@ func (m Model) handleTypingMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "ctrl+o":
 		// View full output - prioritize active pane if there are panes
 		if len(m.panes) > 0 && m.activePaneIndex >= 0 && m.activePaneIndex < len(m.panes) {
+			// Log streams get the log viewer
+			if _, ok := exec.ParseLogsCommand(m.panes[m.activePaneIndex].Command); ok || m.panes[m.activePaneIndex].Tail != nil {
+				return m.showPaneLogs(m.activePaneIndex)
+			}
 			// Show active pane's output in the viewport
 			paneOutput := m.panes[m.activePaneIndex].Output.String()
 			if paneOutput != "" {
@@ -387,6 +554,15 @@ func (m Model) handleTypingMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	cmds = append(cmds, cmd)
 
 	// Update autocomplete suggestions after every keystroke
+	m, cmd = m.updateSuggestions()
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+// updateSuggestions completes the input again. The cache is watched while
+// typing, as kinds completed for the first time are still being fetched.
+func (m Model) updateSuggestions() (Model, tea.Cmd) {
 	newSuggestions := m.getAutocompleteSuggestions(m.commandInput.Value())
 	// Reset index if suggestions changed
 	if len(newSuggestions) != len(m.suggestions) || (len(newSuggestions) > 0 && len(m.suggestions) > 0 && newSuggestions[0] != m.suggestions[0]) {
@@ -394,9 +570,97 @@ func (m Model) handleTypingMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 	m.suggestions = newSuggestions
 	// Still set them on the textinput for its built-in ghost text
-	m.commandInput.SetSuggestions(m.suggestions)
+	m.setInputSuggestions()
+	// Then ask kubectl for what the spec doesn't know, without waiting
+	m, complete := m.completeWithKubectl()
+	m, watch := m.watchCache()
+	return m, tea.Batch(complete, watch)
+}
 
-	return m, tea.Batch(cmds...)
+// submitCommand prepares raw input and runs it, unless the policy blocks
+// it or wants it confirmed first
+func (m Model) submitCommand(raw string) (tea.Model, tea.Cmd) {
+	command, isShell, err := m.prepareCommand(raw)
+	if err != nil {
+		m.statusMsg = err.Error()
+		return m, nil
+	}
+
+	m.lastCmd = command
+	m.statusMsg = "Executing command..."
+
+	d := m.checkPolicy(command)
+	if d.Action == policy.ActionDeny {
+		m.statusMsg = "Blocked: " + d.Reason
+		if d.Rule != "" {
+			m.statusMsg += " (" + d.Rule + ")"
+		}
+		return m, nil
+	}
+	if plan, ok := exec.PlanDiff(command); ok && m.diffBeforeApply && !isShell && m.executor != nil {
+		return m.startDiffReview(command, plan, d)
+	}
+	if d.Action == policy.ActionConfirm || d.Action == policy.ActionTypeNamespace {
+		return m.askConfirmation(command, isShell, d)
+	}
+
+	return m.runCommand(command, isShell)
+}
+
+// appendPaneOutput adds streamed output to a pane
+func (m Model) appendPaneOutput(paneID int, output string) Model {
+	paneIdx := m.findPaneByID(paneID)
+	if paneIdx < 0 || output == "" {
+		return m
+	}
+	m.panes[paneIdx].Output.WriteString(output)
+	m.panes[paneIdx].Viewport.SetContent(m.panes[paneIdx].Output.String())
+	m.panes[paneIdx].Viewport.GotoBottom()
+	return m.logStreamOutput(paneID)
+}
+
+// runCommand executes a prepared command, picking how to run it: handing
+// over the terminal, streaming into a pane, or capturing its output
+func (m Model) runCommand(command string, isShell bool) (tea.Model, tea.Cmd) {
+	if m.executor == nil {
+		return m, nil
+	}
+
+	// Interactive commands get the real terminal while purr is suspended
+	if m.isInteractive(command, isShell) {
+		m.statusMsg = "Running interactively..."
+		m.commandInput.SetValue("")
+		return m, m.executor.ExecuteInteractive(command)
+	}
+
+	// Followed logs open the log viewer
+	if logs, ok := exec.ParseLogsCommand(command); ok && !isShell && logs.Follow {
+		return m.openLogViewer(logs)
+	}
+
+	// Port-forwards run in the background, reconnecting as pods come and go
+	if spec, ok := exec.ParsePortForwardCommand(command); ok && !isShell && m.forwards.manager != nil {
+		return m.startForward(spec)
+	}
+
+	// Check if this is a long-running command that should run in a pane
+	if isLongRunningCommand(command) {
+		// Create a context with cancellation for this pane
+		ctx, cancel := context.WithCancel(context.Background())
+		paneID := m.createPane(command, cancel)
+
+		// Clear the input for the next command
+		m.commandInput.SetValue("")
+		m.suggestions = []string{"get", "describe", "logs", "apply", "delete", "exec", "create", "rollout", "scale"}
+		m.suggestionIndex = 0
+		m.commandInput.SetSuggestions(m.suggestions)
+
+		// Start streaming execution
+		return m, m.executor.ExecuteStreaming(ctx, command, paneID)
+	}
+
+	// Regular command - use traditional execution
+	return m, executeCommand(m.executor, command)
 }
 
 // handleSelectingResourceMode handles key presses in resource selection mode
@@ -405,6 +669,15 @@ func (m Model) handleSelectingResourceMode(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 	case "enter":
 		// Get selected item
 		if selected, ok := m.resourceList.SelectedItem().(listItem); ok {
+			kind := m.picker
+			m.picker = pickerInsert
+			switch kind {
+			case pickerContext:
+				return m.switchContext(selected.item.Title)
+			case pickerNamespace:
+				return m.switchNamespace(selected.item.Title)
+			}
+
 			// Append to command
 			currentCmd := m.commandInput.Value()
 			currentCmd = strings.TrimRight(currentCmd, " ")
@@ -418,6 +691,7 @@ func (m Model) handleSelectingResourceMode(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 
 	case "esc":
 		m.mode = types.ModeTyping
+		m.picker = pickerInsert
 		m.commandInput.Focus()
 		return m, nil
 	}
@@ -427,6 +701,10 @@ func (m Model) handleSelectingResourceMode(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 	return m, cmd
 }
 
+// maskedCommandNotice is shown instead of running a command from history
+// whose secrets were redacted, which would send the mask to the cluster
+const maskedCommandNotice = "Secrets are masked in history: replace " + redact.Mask + " before running it"
+
 // handleViewingHistoryMode handles key presses in history viewing mode
 func (m Model) handleViewingHistoryMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
@@ -437,12 +715,11 @@ func (m Model) handleViewingHistoryMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.commandInput.SetValue(command)
 			m.mode = types.ModeTyping
 			m.commandInput.Focus()
-
-			preparedCmd, _, err := m.prepareCommand(command)
-			if err == nil && m.executor != nil {
-				m.lastCmd = preparedCmd
-				return m, executeCommand(m.executor, preparedCmd)
+			if strings.Contains(command, redact.Mask) {
+				m.statusMsg = maskedCommandNotice
+				return m, nil
 			}
+			return m.submitCommand(command)
 		}
 		return m, nil
 
@@ -456,6 +733,40 @@ func (m Model) handleViewingHistoryMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case "d":
+		// Show the diff a reviewed command was run with
+		if selected, ok := m.historyList.SelectedItem().(listItem); ok {
+			d := selected.item.Metadata["diff"]
+			if d == "" {
+				m.statusMsg = "No diff was reviewed for this command"
+				return m, nil
+			}
+			m.lastCmd = selected.item.Title
+			m.cmdOutput = colorizeDiff(d)
+			m.cmdError = nil
+			m.viewport.SetContent(m.cmdOutput)
+			m.viewport.GotoTop()
+			m.mode = types.ModeViewingOutput
+		}
+		return m, nil
+
+	case "o":
+		// Show the start of what the command printed
+		if selected, ok := m.historyList.SelectedItem().(listItem); ok {
+			output := selected.item.Metadata["output"]
+			if output == "" {
+				m.statusMsg = "No output was recorded for this command"
+				return m, nil
+			}
+			m.lastCmd = selected.item.Title
+			m.cmdOutput = output
+			m.cmdError = nil
+			m.viewport.SetContent(m.cmdOutput)
+			m.viewport.GotoTop()
+			m.mode = types.ModeViewingOutput
+		}
+		return m, nil
+
 	case "esc":
 		m.mode = types.ModeTyping
 		m.commandInput.Focus()
@@ -480,9 +791,19 @@ func (m Model) handleViewingOutputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "r":
-		// Re-run last command
-		if m.lastCmd != "" && m.executor != nil {
-			return m, executeCommand(m.executor, m.lastCmd)
+		// Re-run last command, which may come from history with its
+		// secrets masked
+		if strings.Contains(m.lastCmd, redact.Mask) {
+			m.cmdOutput = ""
+			m.viewport.SetContent("")
+			m.commandInput.SetValue(m.lastCmd)
+			m.mode = types.ModeTyping
+			m.commandInput.Focus()
+			m.statusMsg = maskedCommandNotice
+			return m, nil
+		}
+		if m.lastCmd != "" {
+			return m.submitCommand(m.lastCmd)
 		}
 		return m, nil
 
@@ -581,6 +902,7 @@ func (m Model) showNamespacePicker() (tea.Model, tea.Cmd) {
 
 	m.resourceList.Title = "Select Namespace"
 	m.resourceList.SetItems(convertToListItems(items))
+	m.picker = pickerInsert
 	m.mode = types.ModeSelectingResource
 	return m, nil
 }
@@ -602,6 +924,7 @@ func (m Model) showResourcePicker(resourceType, namespace string) (tea.Model, te
 
 	m.resourceList.Title = "Select " + resourceType
 	m.resourceList.SetItems(convertToListItems(items))
+	m.picker = pickerInsert
 	m.mode = types.ModeSelectingResource
 	return m, nil
 }
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/tapcraft-io/purr/internal/policy"
+	"github.com/tapcraft-io/purr/internal/shlex"
 	"github.com/tapcraft-io/purr/pkg/types"
 )
 
@@ -38,6 +40,16 @@ func (m Model) View() string {
 		return m.renderViewingOutputMode()
 	case types.ModeConfirming:
 		return m.renderConfirmingMode()
+	case types.ModeBrowsing:
+		return m.renderBrowsingMode()
+	case types.ModeViewingLogs:
+		return m.renderViewingLogsMode()
+	case types.ModeReviewingDiff:
+		return m.renderReviewingDiffMode()
+	case types.ModeViewingForwards:
+		return m.renderViewingForwardsMode()
+	case types.ModeViewingTop:
+		return m.renderViewingTopMode()
 	case types.ModeError:
 		return m.renderError()
 	default:
@@ -50,7 +62,7 @@ func (m Model) renderLoading() string {
 	var b strings.Builder
 
 	// Title
-	title := RenderTitle("Purr", m.context)
+	title := RenderTitle("Purr", m.context, m.namespace)
 	b.WriteString(title)
 	b.WriteString("\n\n")
 
@@ -67,7 +79,7 @@ func (m Model) renderLoading() string {
 func (m Model) renderError() string {
 	var b strings.Builder
 
-	title := RenderTitle("Purr", m.context)
+	title := RenderTitle("Purr", m.context, m.namespace)
 	b.WriteString(title)
 	b.WriteString("\n\n")
 
@@ -90,14 +102,74 @@ func (m Model) renderTypingMode() string {
 	var b strings.Builder
 
 	// Title bar
-	title := RenderTitle("Purr", m.context)
+	title := RenderTitle("Purr", m.context, m.namespace)
 	b.WriteString(title)
-	b.WriteString("\n\n")
+	b.WriteString(m.sectionGap())
+
+	// Command input and suggestions, with the explain panel beside them
+	// when there is room
+	inputWidth := m.width
+	panelWidth := m.explainWidth()
+	panel := m.renderExplainPanel(panelWidth)
+	if panel != "" {
+		inputWidth -= panelWidth + 1
+	}
+	input := m.renderInput(inputWidth)
+	if panel != "" {
+		input = lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(inputWidth+1).Render(input), panel) + "\n"
+	}
+	b.WriteString(input)
+
+	if !m.compact {
+		b.WriteString("\n")
+	}
+
+	// Show status message if present
+	if m.statusMsg != "" {
+		b.WriteString(RenderInfo(m.statusMsg))
+		b.WriteString(m.sectionGap())
+	}
+
+	// Show output section - this includes both panes (streaming) and last output (non-blocking)
+	// Both can be shown at the same time
+	hasOutput := false
+
+	// First show streaming panes if any
+	if len(m.panes) > 0 {
+		b.WriteString(m.renderPanes())
+		hasOutput = true
+	}
+
+	// Then show last command output (non-blocking) if available and different from pane content
+	if m.cmdOutput != "" {
+		b.WriteString(m.renderLastOutput())
+		hasOutput = true
+	}
+
+	// Add some spacing if we had output
+	if hasOutput && !m.compact {
+		b.WriteString("\n")
+	}
+
+	// Help bar
+	if m.showHelp {
+		help := m.renderHelpBar()
+		b.WriteString(help)
+	}
+
+	return b.String()
+}
+
+// renderInput renders the command input and the suggestions below it,
+// fitting them into width
+func (m Model) renderInput(width int) string {
+	var b strings.Builder
 
 	// Command input with custom ghost text
 	b.WriteString(RenderPrompt())
 
 	// Render the input field
+	m.commandInput.Width = width - 6
 	inputView := m.commandInput.View()
 	b.WriteString(inputView)
 
@@ -142,6 +214,13 @@ func (m Model) renderTypingMode() string {
 			b.WriteString("\n")
 		}
 
+		// Descriptions line up after the longest visible suggestion
+		descriptionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+		column := 0
+		for _, sug := range m.suggestions[startIdx:endIdx] {
+			column = max(column, len(sug))
+		}
+
 		for i := startIdx; i < endIdx; i++ {
 			sug := m.suggestions[i]
 			if i == m.suggestionIndex {
@@ -149,6 +228,12 @@ func (m Model) renderTypingMode() string {
 			} else {
 				b.WriteString(suggestionStyle.Render("  " + sug))
 			}
+			if description := m.descriptions[sug]; description != "" {
+				if room := width - column - 6; room > 10 {
+					b.WriteString(strings.Repeat(" ", column-len(sug)+2))
+					b.WriteString(descriptionStyle.Render(truncate(description, room)))
+				}
+			}
 			b.WriteString("\n")
 		}
 
@@ -159,40 +244,16 @@ func (m Model) renderTypingMode() string {
 		}
 	}
 
-	b.WriteString("\n")
-
-	// Show status message if present
-	if m.statusMsg != "" {
-		b.WriteString(RenderInfo(m.statusMsg))
-		b.WriteString("\n\n")
-	}
-
-	// Show output section - this includes both panes (streaming) and last output (non-blocking)
-	// Both can be shown at the same time
-	hasOutput := false
-
-	// First show streaming panes if any
-	if len(m.panes) > 0 {
-		b.WriteString(m.renderPanes())
-		hasOutput = true
-	}
-
-	// Then show last command output (non-blocking) if available and different from pane content
-	if m.cmdOutput != "" {
-		b.WriteString(m.renderLastOutput())
-		hasOutput = true
-	}
+	return b.String()
+}
 
-	// Add some spacing if we had output
-	if hasOutput {
-		b.WriteString("\n")
+// sectionGap returns the spacing between sections of the typing view,
+// which compact mode tightens to a single line break
+func (m Model) sectionGap() string {
+	if m.compact {
+		return "\n"
 	}
-
-	// Help bar
-	help := m.renderHelpBar()
-	b.WriteString(help)
-
-	return b.String()
+	return "\n\n"
 }
 
 // renderSelectingResourceMode renders the resource selection mode
@@ -200,7 +261,7 @@ func (m Model) renderSelectingResourceMode() string {
 	var b strings.Builder
 
 	// Title bar
-	title := RenderTitle("Purr", m.context)
+	title := RenderTitle("Purr", m.context, m.namespace)
 	b.WriteString(title)
 	b.WriteString("\n\n")
 
@@ -219,7 +280,7 @@ func (m Model) renderSelectingFileMode() string {
 	var b strings.Builder
 
 	// Title bar
-	title := RenderTitle("Purr", m.context)
+	title := RenderTitle("Purr", m.context, m.namespace)
 	b.WriteString(title)
 	b.WriteString("\n\n")
 
@@ -243,7 +304,7 @@ func (m Model) renderViewingHistoryMode() string {
 	var b strings.Builder
 
 	// Title bar
-	title := RenderTitle("Purr", m.context)
+	title := RenderTitle("Purr", m.context, m.namespace)
 	b.WriteString(title)
 	b.WriteString("\n\n")
 
@@ -252,7 +313,7 @@ func (m Model) renderViewingHistoryMode() string {
 	b.WriteString("\n\n")
 
 	// Help
-	b.WriteString(RenderHelp("[↑↓] navigate  [Enter] execute  [e] edit  [Esc] cancel  [/] search"))
+	b.WriteString(RenderHelp("[↑↓] navigate  [Enter] execute  [e] edit  [d] reviewed diff  [o] output  [Esc] cancel  [/] search"))
 
 	return b.String()
 }
@@ -262,7 +323,7 @@ func (m Model) renderViewingOutputMode() string {
 	var b strings.Builder
 
 	// Title bar
-	title := RenderTitle("Purr", m.context)
+	title := RenderTitle("Purr", m.context, m.namespace)
 	b.WriteString(title)
 	b.WriteString("\n\n")
 
@@ -294,30 +355,332 @@ func (m Model) renderViewingOutputMode() string {
 // renderConfirmingMode renders the confirmation dialog
 func (m Model) renderConfirmingMode() string {
 	var b strings.Builder
+	d := m.confirm.decision
 
 	// Title bar
-	title := RenderTitle("Purr", m.context)
+	title := RenderTitle("Purr", m.context, m.namespace)
 	b.WriteString(title)
 	b.WriteString("\n\n")
 
 	// Warning
-	b.WriteString(RenderWarning("⚠ Destructive Operation"))
+	if d.Action == policy.ActionTypeNamespace {
+		b.WriteString(RenderWarning("Protected Operation"))
+	} else {
+		b.WriteString(RenderWarning("Destructive Operation"))
+	}
 	b.WriteString("\n\n")
 
 	// Show command
 	b.WriteString("Command: ")
-	b.WriteString(highlightStyle.Render(m.lastCmd))
+	b.WriteString(highlightStyle.Render(m.confirm.command))
 	b.WriteString("\n\n")
 
+	// Why it needs confirming
+	reason := d.Reason
+	if reason == "" {
+		reason = "This command may delete or modify resources."
+	}
+	b.WriteString(reason)
+	b.WriteString("\n")
+	if d.Rule != "" {
+		b.WriteString(dimStyle.Render("Policy rule: " + d.Rule))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	if radius := renderBlastRadius(m.confirm.radius); radius != "" {
+		b.WriteString(radius)
+		b.WriteString("\n")
+	}
+
 	// Confirmation prompt
-	b.WriteString("This command may delete or modify resources.\n")
-	b.WriteString("Are you sure you want to continue?\n\n")
+	if d.Action == policy.ActionTypeNamespace {
+		b.WriteString("Type " + highlightStyle.Render(d.Expect) + " to continue:\n")
+		b.WriteString(m.confirm.input.View())
+		b.WriteString("\n\n")
+		if m.statusMsg != "" {
+			b.WriteString(RenderInfo(m.statusMsg))
+			b.WriteString("\n")
+		}
+		b.WriteString(RenderHelp("[Enter] confirm  [Esc] cancel"))
+		return b.String()
+	}
 
+	b.WriteString("Are you sure you want to continue?\n\n")
 	b.WriteString(RenderHelp("[y] yes  [n] no"))
 
 	return b.String()
 }
 
+// previewListLimit caps the objects listed in a blast-radius preview
+const previewListLimit = 8
+
+// renderBlastRadius renders what a command awaiting confirmation would
+// touch
+func renderBlastRadius(r blastRadius) string {
+	var b strings.Builder
+	if r.loading {
+		b.WriteString(dimStyle.Render("Checking what this would affect (server dry run)..."))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	if r.err != nil {
+		b.WriteString(RenderError("Dry run failed: " + r.err.Error()))
+		b.WriteString("\n")
+	}
+	if len(r.objects) > 0 {
+		b.WriteString(fmt.Sprintf("Affects %s (%s):\n", plural(len(r.objects), "object"), countKinds(r.objects)))
+		writeLimited(&b, r.objects)
+	} else if r.done && r.err == nil {
+		b.WriteString(RenderWarning("Matches no objects"))
+		b.WriteString("\n")
+	}
+
+	for _, plan := range r.drains {
+		b.WriteString(fmt.Sprintf("\nDraining %s evicts %s", plan.Node, plural(len(plan.Evict), "pod")))
+		if len(plan.Skip) > 0 {
+			b.WriteString(fmt.Sprintf(", leaves %d", len(plan.Skip)))
+		}
+		if len(plan.Evict) == 0 {
+			b.WriteString("\n")
+		} else {
+			b.WriteString(":\n")
+		}
+		pods := make([]string, len(plan.Evict))
+		for i, p := range plan.Evict {
+			pods[i] = p.Namespace + "/" + p.Name
+			if p.Note != "" {
+				pods[i] += dimStyle.Render(" (" + p.Note + ")")
+			}
+		}
+		writeLimited(&b, pods)
+		for _, pdb := range plan.Budgets {
+			b.WriteString(RenderWarning(fmt.Sprintf("PodDisruptionBudget %s/%s allows %s, would block evicting %s",
+				pdb.Namespace, pdb.Name, plural(int(pdb.Allowed), "disruption"), strings.Join(pdb.Pods, ", "))))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// writeLimited writes a bulleted list, cut off at previewListLimit
+func writeLimited(b *strings.Builder, items []string) {
+	for i, item := range items {
+		if i == previewListLimit {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  ... and %d more", len(items)-i)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString("  • " + item + "\n")
+	}
+}
+
+// countKinds summarises kind/name objects as "3 pod, 1 service"
+func countKinds(objects []string) string {
+	counts := make(map[string]int)
+	var kinds []string
+	for _, obj := range objects {
+		kind, _, _ := strings.Cut(obj, "/")
+		if counts[kind] == 0 {
+			kinds = append(kinds, kind)
+		}
+		counts[kind]++
+	}
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", counts[kind], kind)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// plural formats a count with a noun, adding "s" unless it is one
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// renderReviewingDiffMode renders the changes a command would make
+func (m Model) renderReviewingDiffMode() string {
+	var b strings.Builder
+
+	// Title bar
+	title := RenderTitle("Purr", m.context, m.namespace)
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	// Command under review
+	b.WriteString(promptStyle.Render("$ "))
+	b.WriteString(m.review.command)
+	b.WriteString("\n\n")
+
+	// Diff
+	b.WriteString(viewportStyle.Render(m.viewport.View()))
+	b.WriteString("\n\n")
+
+	b.WriteString(RenderWarning("Review the changes before they are applied"))
+	b.WriteString("\n")
+	b.WriteString(RenderHelp("[a] apply  [q] abort  [↑↓/PgUp/PgDn] scroll"))
+
+	return b.String()
+}
+
+// renderBrowsingMode renders the resource browser
+func (m Model) renderBrowsingMode() string {
+	var b strings.Builder
+
+	// Title bar
+	title := RenderTitle("Purr", m.context, m.namespace)
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	// Summary line
+	t := m.browse.table
+	scope := m.namespace
+	if m.browse.allNamespaces {
+		scope = "all namespaces"
+	}
+	if t.Resource.Name != "" && !t.Resource.Namespaced {
+		scope = "cluster"
+	}
+	summary := fmt.Sprintf("%s (%s) %d", m.browse.resource, scope, len(m.browse.rows))
+	if len(m.browse.rows) != len(t.Rows) {
+		summary += fmt.Sprintf(" of %d", len(t.Rows))
+	}
+	if m.browse.sortCol >= 0 && m.browse.sortCol < len(t.Columns) {
+		dir := "↑"
+		if m.browse.sortDesc {
+			dir = "↓"
+		}
+		summary += fmt.Sprintf("  sort: %s %s", t.Columns[m.browse.sortCol], dir)
+	}
+	b.WriteString(highlightStyle.Render(summary))
+	b.WriteString("\n\n")
+
+	// Column widths fit the widest cell, within reason
+	const maxColWidth = 48
+	widths := make([]int, len(t.Columns))
+	for i, col := range t.Columns {
+		widths[i] = len(col)
+	}
+	for _, row := range m.browse.rows {
+		for i, cell := range row.Cells {
+			widths[i] = min(max(widths[i], len(cell)), maxColWidth)
+		}
+	}
+	lineWidth := max(m.width-4, 20)
+	formatRow := func(cells []string) string {
+		parts := make([]string, len(cells))
+		for i, cell := range cells {
+			parts[i] = padRight(truncate(cell, widths[i]), widths[i])
+		}
+		return truncate(strings.Join(parts, "  "), lineWidth)
+	}
+
+	headerStyle := lipgloss.NewStyle().Foreground(colorTextDim).Bold(true).Padding(0, 1)
+	b.WriteString(headerStyle.Render(formatRow(t.Columns)))
+	b.WriteString("\n")
+
+	// Rows, scrolled to keep the cursor visible
+	visible := m.browseRowsVisible()
+	start := 0
+	if m.browse.cursor >= visible {
+		start = m.browse.cursor - visible + 1
+	}
+	end := min(start+visible, len(m.browse.rows))
+	for i := start; i < end; i++ {
+		line := padRight(formatRow(m.browse.rows[i].Cells), lineWidth)
+		if i == m.browse.cursor {
+			b.WriteString(selectedStyle.Render(line))
+		} else {
+			b.WriteString(normalStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.browse.rows) == 0 {
+		b.WriteString(dimStyle.Render("  No resources found"))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	// The command Enter would run
+	if args, err := m.browseAction("enter"); err == nil {
+		b.WriteString(promptStyle.Render("$ "))
+		b.WriteString(dimStyle.Render("kubectl " + shlex.Join(args)))
+		b.WriteString("\n")
+	}
+
+	if m.browse.filtering || m.browse.filter.Value() != "" {
+		b.WriteString(m.browse.filter.View())
+		b.WriteString("\n")
+	}
+
+	if m.statusMsg != "" {
+		b.WriteString(RenderInfo(m.statusMsg))
+		b.WriteString("\n")
+	}
+
+	// Help
+	b.WriteString(RenderHelp("[↑↓] move  [/] filter  [1-9] sort  [a] all ns  [Enter/d] describe  [l] logs  [L] all pods' logs  [e] edit  [s] shell  [Ctrl+D] delete  [Esc] back"))
+
+	return b.String()
+}
+
+// renderViewingLogsMode renders the log viewer
+func (m Model) renderViewingLogsMode() string {
+	var b strings.Builder
+
+	// Title bar
+	title := RenderTitle("Purr", m.context, m.namespace)
+	b.WriteString(title)
+	b.WriteString("\n")
+
+	// Stream and its state
+	b.WriteString(promptStyle.Render("$ "))
+	b.WriteString(m.logTitle())
+	b.WriteString("  ")
+	b.WriteString(highlightStyle.Render(m.logStatus()))
+	b.WriteString("\n")
+
+	// Active filters
+	var filters []string
+	if m.logs.include != nil {
+		filters = append(filters, "include: "+m.logs.include.String())
+	}
+	if m.logs.exclude != nil {
+		filters = append(filters, "exclude: "+m.logs.exclude.String())
+	}
+	if m.logs.search != nil {
+		filters = append(filters, fmt.Sprintf("search: %s (%d)", m.logs.search.String(), len(m.logs.matches)))
+	}
+	if pane, ok := m.logPane(); ok && pane.Output.Dropped() > 0 {
+		filters = append(filters, fmt.Sprintf("%d older lines dropped", pane.Output.Dropped()))
+	}
+	b.WriteString(dimStyle.Render(strings.Join(filters, "  |  ")))
+	b.WriteString("\n")
+
+	b.WriteString(m.logs.viewport.View())
+	b.WriteString("\n")
+
+	if m.logs.inputKind != logInputNone {
+		b.WriteString(m.logs.input.View())
+		b.WriteString("\n")
+	} else if m.statusMsg != "" {
+		b.WriteString(RenderInfo(m.statusMsg))
+		b.WriteString("\n")
+	}
+
+	// Help
+	if pane, ok := m.logPane(); ok && pane.Tail != nil {
+		b.WriteString(RenderHelp("[/] search  [n/N] next/prev  [i] include  [x] exclude  [Space] pause  [w] wrap  [Esc] back"))
+	} else {
+		b.WriteString(RenderHelp("[/] search  [n/N] next/prev  [i] include  [x] exclude  [Space] pause  [t] timestamps  [w] wrap  [c] container  [p] previous  [Esc] back"))
+	}
+
+	return b.String()
+}
+
 // renderLastOutput renders the last command output in a pane-like style
 func (m Model) renderLastOutput() string {
 	if m.cmdOutput == "" {
@@ -338,6 +701,9 @@ func (m Model) renderLastOutput() string {
 	if len(m.panes) > 0 {
 		maxOutputHeight = 5 // Smaller when panes are shown
 	}
+	if m.compact {
+		maxOutputHeight -= 2
+	}
 
 	// Get content
 	lines := strings.Split(m.cmdOutput, "\n")
@@ -417,6 +783,9 @@ func (m Model) renderPanes() string {
 	if m.cmdOutput != "" {
 		maxPaneHeight = 6
 	}
+	if m.compact {
+		maxPaneHeight -= 2
+	}
 
 	// Each pane gets equal width
 	paneWidth := availableWidth / len(m.panes)
@@ -523,6 +892,18 @@ func (m Model) renderHelpBar() string {
 		"[↑↓] cycle",
 		"[@] file",
 		"[Ctrl+R] history",
+		"[Alt+C] context",
+		"[Alt+S] namespace",
+		"[Alt+R] browse",
+		"[Alt+E] explain",
+		"[Alt+T] top",
+	}
+
+	// List the port-forwards, counting the running ones
+	if n := len(m.forwards.list); n > 0 {
+		items = append(items, fmt.Sprintf("[Alt+F] forwards (%d)", n))
+	} else if m.forwards.manager != nil {
+		items = append(items, "[Alt+F] forwards")
 	}
 
 	// Add pane-specific help if there are panes
@@ -24,6 +24,11 @@ const (
 	ModeViewingHistory
 	ModeViewingOutput
 	ModeConfirming
+	ModeBrowsing
+	ModeViewingLogs
+	ModeReviewingDiff
+	ModeViewingForwards
+	ModeViewingTop
 	ModeError
 )
 
@@ -57,6 +62,7 @@ type ParsedCommand struct {
 	Flags        map[string]string
 	BoolFlags    map[string]bool
 	Files        []string
+	Args         []string // arguments after "--"
 	IsComplete   bool
 	NeedsInput   []CompletionNeeded
 	IsValid      bool
@@ -65,11 +71,17 @@ type ParsedCommand struct {
 
 // HistoryEntry represents a command in the history
 type HistoryEntry struct {
-	Command   string
-	Timestamp time.Time
-	Success   bool
-	Context   string
-	Namespace string
+	Command      string        `json:"command"`
+	Timestamp    time.Time     `json:"timestamp"`
+	Success      bool          `json:"success"`
+	ExitCode     int           `json:"exit_code"`
+	Duration     time.Duration `json:"duration,omitempty"` // nanoseconds
+	Cwd          string        `json:"cwd,omitempty"`      // working directory purr ran in
+	Context      string        `json:"context,omitempty"`
+	Namespace    string        `json:"namespace,omitempty"`
+	Output       string        `json:"output,omitempty"`        // start of stdout and stderr
+	OutputDigest string        `json:"output_digest,omitempty"` // sha256 of the full, redacted output
+	Diff         string        `json:"diff,omitempty"`          // the reviewed diff the command was run with
 }
 
 // ListItem represents an item that can be selected from a list
@@ -92,3 +104,32 @@ type CommandPane struct {
 	ExitCode  int
 	Cancel    context.CancelFunc
 }
+
+// SchemaField describes a field of a resource type, as the cluster's
+// OpenAPI schema does
+type SchemaField struct {
+	Name        string
+	Type        string // e.g. "string", "[]Container" or "map[string]string"
+	Description string
+}
+
+// Explanation documents a resource type or one of its fields, as kubectl
+// explain does
+type Explanation struct {
+	Group       string
+	Version     string
+	Kind        string
+	Path        []string // the field's path in the kind, empty for the kind itself
+	Type        string
+	Description string
+	Fields      []SchemaField // the fields of an object, sorted by name
+}
+
+// Port is a port a port-forward can reach: one of a service's ports or a
+// container's
+type Port struct {
+	Number    int32
+	Name      string // may be empty
+	Protocol  string
+	Container string // the container exposing it, empty for a service port
+}